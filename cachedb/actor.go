@@ -0,0 +1,90 @@
+package cachedb
+
+import (
+	"fmt"
+	"sync"
+)
+
+// mailbox 是一个 key 的待处理消息，running 表示已有协程在处理
+type mailbox struct {
+	msgs    []func()
+	running bool
+}
+
+// actors 为每个有消息的 key 运行一个处理协程，消息处理完后协程退出
+type actors struct {
+	mu    sync.Mutex
+	boxes map[interface{}]*mailbox
+	wg    sync.WaitGroup
+}
+
+// send 把消息放入 key 的信箱，必要时启动处理协程
+func (a *actors) send(key interface{}, msg func(), run func(func())) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.boxes == nil {
+		a.boxes = make(map[interface{}]*mailbox)
+	}
+	box := a.boxes[key]
+	if box == nil {
+		box = &mailbox{}
+		a.boxes[key] = box
+	}
+	box.msgs = append(box.msgs, msg)
+	if box.running {
+		return
+	}
+	box.running = true
+	a.wg.Add(1)
+	go a.drain(key, box, run)
+}
+
+// drain 按顺序处理信箱中的消息，信箱清空后移除并退出
+func (a *actors) drain(key interface{}, box *mailbox, run func(func())) {
+	defer a.wg.Done()
+	for {
+		a.mu.Lock()
+		if len(box.msgs) == 0 {
+			delete(a.boxes, key)
+			a.mu.Unlock()
+			return
+		}
+		msg := box.msgs[0]
+		box.msgs = box.msgs[1:]
+		a.mu.Unlock()
+		run(msg)
+	}
+}
+
+// Tell 把对 key 的修改投递到它的信箱后立即返回。同一个 key 的消息由一个协程按投递顺序依次执行，
+// 不同 key 之间并行，调用方无需加锁。fn 的执行方式与 Update 相同，不能调用该缓存的方法；
+// key 加载失败时记录日志并丢弃该消息
+func (c *CacheDB[T]) Tell(key interface{}, fn func(*T)) {
+	key = c.canonicalKey(key)
+	c.actors.send(key, func() {
+		if err := c.Update(key, fn); err != nil {
+			c.log(LogError, "Actor message failed", "key", key, "err", err)
+		}
+	}, c.runActorMessage)
+}
+
+// Ask 与 Tell 相同，但等待消息执行完毕并返回 fn 或加载的错误
+func (c *CacheDB[T]) Ask(key interface{}, fn func(*T) error) error {
+	key = c.canonicalKey(key)
+	done := make(chan error, 1)
+	c.actors.send(key, func() {
+		var fnErr error
+		err := fmt.Errorf("panic in actor message for key %v", key)
+		defer func() { done <- err }()
+		if err = c.Update(key, func(v *T) { fnErr = fn(v) }); err == nil {
+			err = fnErr
+		}
+	}, c.runActorMessage)
+	return <-done
+}
+
+// runActorMessage 执行一条消息，panic 不会终止该 key 的处理协程
+func (c *CacheDB[T]) runActorMessage(msg func()) {
+	defer c.recoverPanic("actor", nil, nil)
+	msg()
+}
@@ -0,0 +1,61 @@
+package cachedb
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestActors(t *testing.T) {
+	type Hero struct {
+		ID   uint
+		Log  []int `gorm:"serializer:json"`
+		Gold int
+	}
+	db := openTestDB(t, &Hero{})
+	db.Create(&Hero{ID: 1})
+	db.Create(&Hero{ID: 2})
+	c := newTestCache[Hero](t, db, 10)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Tell(1, func(h *Hero) { h.Gold++ })
+			c.Tell(2, func(h *Hero) { h.Gold += 2 })
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		c.Tell(1, func(h *Hero) { h.Log = append(h.Log, i) })
+	}
+	wg.Wait()
+
+	err := c.Ask(1, func(h *Hero) error {
+		if h.Gold != 100 {
+			t.Errorf("expected all earlier messages to run first, gold %d", h.Gold)
+		}
+		for i, v := range h.Log {
+			if v != i {
+				t.Errorf("expected messages in send order, got %v", h.Log)
+				break
+			}
+		}
+		return errors.New("refused")
+	})
+	if err == nil || err.Error() != "refused" {
+		t.Errorf("expected Ask to return fn's error, got %v", err)
+	}
+	if err := c.Ask(3, func(*Hero) error { return nil }); err == nil {
+		t.Errorf("expected Ask on a missing key to fail")
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	var h Hero
+	db.First(&h, 2)
+	if h.Gold != 200 {
+		t.Errorf("expected actor mutations to be flushed, got %+v", h)
+	}
+}
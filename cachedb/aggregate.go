@@ -0,0 +1,166 @@
+package cachedb
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AggregateSpec 定义一个聚合值，例如公会人数或流通货币总量。
+// GroupColumn/Expr 用于从数据库校准，Group/Value 是与之对应的 Go 计算方式
+type AggregateSpec[T any] struct {
+	Name        string
+	GroupColumn string           // 分组列，为空表示全表聚合
+	Expr        string           // SQL 聚合表达式，例如 "COUNT(*)"、"SUM(gold)"
+	Group       func(*T) string  // 对象所属的分组，GroupColumn 为空时可为 nil
+	Value       func(*T) float64 // 单行对聚合值的贡献，计数时返回 1
+}
+
+// AggregateCache 在实体落库、通过缓存 Create 和 Delete 时增量维护聚合值，并定期与数据库校准。
+// Set 一个未加载过的 key 时落库前的行未知，这次落库不做增量更新，由 Reconcile 修正
+type AggregateCache[T any] struct {
+	cache *CacheDB[T]
+
+	mu     sync.RWMutex
+	specs  map[string]AggregateSpec[T]
+	totals map[string]map[string]float64 // name → group → value
+}
+
+// NewAggregateCache 创建聚合缓存并挂接到 cache 的落库、插入和删除回调上
+func NewAggregateCache[T any](cache *CacheDB[T]) *AggregateCache[T] {
+	a := &AggregateCache[T]{
+		cache:  cache,
+		specs:  make(map[string]AggregateSpec[T]),
+		totals: make(map[string]map[string]float64),
+	}
+	cache.OnFlush(func(_ interface{}, old, new T) {
+		var unknown T
+		if cache.equal(old, unknown) {
+			return // 旧值未知，增量会重复计入已存在的行
+		}
+		a.apply(&old, &new)
+	})
+	cache.OnCreateRow(func(_ interface{}, row T) { a.apply(nil, &row) })
+	cache.OnDeleteRow(func(_ interface{}, row T) { a.apply(&row, nil) })
+	return a
+}
+
+// Define 注册聚合值并立即从数据库加载初始值
+func (a *AggregateCache[T]) Define(spec AggregateSpec[T]) error {
+	totals, err := a.query(spec)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.specs[spec.Name] = spec
+	a.totals[spec.Name] = totals
+	return nil
+}
+
+// Get 返回聚合值，全表聚合时 group 传空字符串
+func (a *AggregateCache[T]) Get(name, group string) float64 {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.totals[name][group]
+}
+
+// apply 根据一行修改前后的值增量更新所有聚合值，old 为 nil 表示插入，new 为 nil 表示删除
+func (a *AggregateCache[T]) apply(old, new *T) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for name, spec := range a.specs {
+		totals := a.totals[name]
+		if old != nil {
+			totals[spec.group(old)] -= spec.Value(old)
+		}
+		if new != nil {
+			totals[spec.group(new)] += spec.Value(new)
+		}
+	}
+}
+
+func (spec AggregateSpec[T]) group(v *T) string {
+	if spec.Group == nil {
+		return ""
+	}
+	return spec.Group(v)
+}
+
+// Reconcile 从数据库重新计算所有聚合值并整体替换，修正增量维护中的漂移
+func (a *AggregateCache[T]) Reconcile() error {
+	a.mu.RLock()
+	specs := make([]AggregateSpec[T], 0, len(a.specs))
+	for _, spec := range a.specs {
+		specs = append(specs, spec)
+	}
+	a.mu.RUnlock()
+
+	for _, spec := range specs {
+		totals, err := a.query(spec)
+		if err != nil {
+			return err
+		}
+		a.mu.Lock()
+		a.totals[spec.Name] = totals
+		a.mu.Unlock()
+	}
+	return nil
+}
+
+// ReconcileEvery 按固定间隔校准，返回的函数用于停止
+func (a *AggregateCache[T]) ReconcileEvery(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := a.Reconcile(); err != nil {
+					a.cache.log(LogError, "Aggregate reconcile failed", "err", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// query 在数据库中执行聚合查询
+func (a *AggregateCache[T]) query(spec AggregateSpec[T]) (map[string]float64, error) {
+	totals := make(map[string]float64)
+	q, err := a.cache.wholeTable()
+	if err != nil {
+		return nil, err
+	}
+
+	if spec.GroupColumn == "" {
+		var value float64
+		if err := q.Select("COALESCE(" + spec.Expr + ", 0)").Row().Scan(&value); err != nil {
+			return nil, fmt.Errorf("failed to query aggregate %s: %w", spec.Name, err)
+		}
+		totals[""] = value
+		return totals, nil
+	}
+
+	rows, err := q.Select(spec.GroupColumn + ", COALESCE(" + spec.Expr + ", 0)").Group(spec.GroupColumn).Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query aggregate %s: %w", spec.Name, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var group interface{}
+		var value float64
+		if err := rows.Scan(&group, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan aggregate %s: %w", spec.Name, err)
+		}
+		if b, ok := group.([]byte); ok {
+			group = string(b)
+		}
+		totals[fmt.Sprint(group)] = value
+	}
+	return totals, rows.Err()
+}
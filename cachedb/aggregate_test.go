@@ -0,0 +1,101 @@
+package cachedb
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAggregateCache(t *testing.T) {
+	type Member struct {
+		ID      uint
+		GuildID uint
+		Gold    int
+	}
+	db := openTestDB(t, &Member{})
+	db.Create(&Member{ID: 1, GuildID: 1, Gold: 100})
+	db.Create(&Member{ID: 2, GuildID: 1, Gold: 50})
+	db.Create(&Member{ID: 3, GuildID: 2, Gold: 10})
+
+	c := newTestCache[Member](t, db, 10)
+	agg := NewAggregateCache(c)
+	if err := agg.Define(AggregateSpec[Member]{
+		Name:  "gold",
+		Expr:  "SUM(gold)",
+		Value: func(m *Member) float64 { return float64(m.Gold) },
+	}); err != nil {
+		t.Fatalf("define failed: %v", err)
+	}
+	if err := agg.Define(AggregateSpec[Member]{
+		Name:        "members",
+		GroupColumn: "guild_id",
+		Expr:        "COUNT(*)",
+		Group:       func(m *Member) string { return fmt.Sprint(m.GuildID) },
+		Value:       func(*Member) float64 { return 1 },
+	}); err != nil {
+		t.Fatalf("define failed: %v", err)
+	}
+
+	if got := agg.Get("gold", ""); got != 160 {
+		t.Fatalf("expected total gold 160, got %v", got)
+	}
+	if got := agg.Get("members", "1"); got != 2 {
+		t.Fatalf("expected 2 members in guild 1, got %v", got)
+	}
+
+	m, _ := c.Get(uint(2))
+	m.Gold = 80
+	m.GuildID = 2
+	if err := c.Flush(uint(2)); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+
+	if got := agg.Get("gold", ""); got != 190 {
+		t.Errorf("expected total gold 190 after flush, got %v", got)
+	}
+	if agg.Get("members", "1") != 1 || agg.Get("members", "2") != 2 {
+		t.Errorf("expected member moved between guilds, got %v/%v", agg.Get("members", "1"), agg.Get("members", "2"))
+	}
+
+	db.Create(&Member{ID: 4, GuildID: 2, Gold: 5}) // 绕过缓存的写入
+	if err := agg.Reconcile(); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if got := agg.Get("gold", ""); got != 195 {
+		t.Errorf("expected total gold 195 after reconcile, got %v", got)
+	}
+
+	// 通过缓存插入和删除，无需校准
+	if _, err := c.Create(Member{ID: 5, GuildID: 1, Gold: 20}); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	c.Get(uint(3))
+	if err := c.Delete(uint(3)); err != nil { // 已缓存，取副本
+		t.Fatalf("delete failed: %v", err)
+	}
+	if err := c.Delete(uint(4)); err != nil { // 未缓存，删除前读取
+		t.Fatalf("delete failed: %v", err)
+	}
+	if got := agg.Get("gold", ""); got != 200 {
+		t.Errorf("expected total gold 200 after create and deletes, got %v", got)
+	}
+	if agg.Get("members", "1") != 2 || agg.Get("members", "2") != 1 {
+		t.Errorf("expected 2/1 members after create and deletes, got %v/%v", agg.Get("members", "1"), agg.Get("members", "2"))
+	}
+
+	// Set 未加载过的 key 时旧值未知，不做增量，以免重复计入
+	if err := c.Set(uint(1), Member{ID: 1, GuildID: 1, Gold: 70}); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if err := c.Flush(uint(1)); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	if got := agg.Get("gold", ""); got != 200 || agg.Get("members", "1") != 2 {
+		t.Errorf("expected the unknown baseline to be skipped, got gold %v and %v members", got, agg.Get("members", "1"))
+	}
+	if err := agg.Reconcile(); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if got := agg.Get("gold", ""); got != 170 {
+		t.Errorf("expected total gold 170 after reconcile, got %v", got)
+	}
+}
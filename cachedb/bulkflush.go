@@ -0,0 +1,122 @@
+package cachedb
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BatchSaver 由支持批量回写的 Store 实现，FlushAll 优先使用它代替逐行 Save
+type BatchSaver[T any] interface {
+	SaveBatch(keys []interface{}, values []*T) error // 把 values 整行写回，keys 与 values 一一对应
+}
+
+// WithBulkFlush 开启 FlushAll 的批量写回并设置每条语句包含的行数（例如 500），小于 2 时逐行回写。
+// 默认不开启，FlushAll 与 Flush 一样逐行只更新变化的列。
+// 批量写入使用 Dialect 的 Upsert 生成与方言对应的 upsert（MySQL 为 ON DUPLICATE KEY UPDATE，
+// SQLite/PostgreSQL 为 ON CONFLICT DO UPDATE），会写入所有列（包括零值），
+// 覆盖其他进程对未修改列的更新，并且会重新插入已在数据库中被直接删除的行
+func WithBulkFlush[T any](size int) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.flushBatch = size
+	}
+}
+
+// SaveBatch 按 key 所在的表分组，每组用一条 upsert 语句写回
+func (s gormStore[T]) SaveBatch(keys []interface{}, values []*T) error {
+	order, groups := s.c.groupByShard(keys)
+	for _, shard := range order {
+		rows := make([]T, 0, len(groups[shard]))
+		for _, i := range groups[shard] {
+			rows = append(rows, *values[i])
+		}
+		db, done := withTimeout(shard.DB.Table(shard.Table), s.c.flushTimeout, ErrFlushTimeout)
+		if err := done(s.c.upsert(db, rows)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upsert 用一条语句把 rows 整行写入 db 指定的表
+func (c *CacheDB[T]) upsert(db *gorm.DB, rows []T) error {
+	upsert := dialectOf(db).Upsert
+	if upsert == nil {
+		upsert = defaultDialect.Upsert
+	}
+	start := time.Now()
+	result := db.Clauses(upsert(c.keyField.DBName)).Create(&rows)
+	c.observeFlush(fmt.Sprintf("%d rows", len(rows)), start, result.RowsAffected)
+	if err := result.Error; err != nil {
+		return fmt.Errorf("failed to bulk save %d rows to %s: %w", len(rows), db.Statement.Table, err)
+	}
+	return nil
+}
+
+// groupByShard 按所在的表对 keys 分组，返回各组在 keys 中的下标
+func (c *CacheDB[T]) groupByShard(keys []interface{}) ([]Shard, map[Shard][]int) {
+	groups := make(map[Shard][]int)
+	var order []Shard
+	for i, key := range keys {
+		shard := c.shardFor(key)
+		if _, ok := groups[shard]; !ok {
+			order = append(order, shard)
+		}
+		groups[shard] = append(groups[shard], i)
+	}
+	return order, groups
+}
+
+// chunkSaver 在持有 c.mu 时写回一组脏数据，olds 为各 key 的副本
+type chunkSaver[T any] func(keys []interface{}, olds []T, values []*T) error
+
+// flushChunk 在持有 c.mu 时写回 keys 中仍为脏的部分。整组写入失败时逐个 Flush，
+// 一行失败不影响同组的其他行
+func (c *CacheDB[T]) flushChunk(save chunkSaver[T], keys []interface{}, report *FlushReport) {
+	c.mu.Lock()
+	var dirty []interface{}
+	var olds []T
+	var values []*T
+	for _, key := range keys {
+		value, ok := c.values[key]
+		if !ok || !c.modified(key, value) {
+			continue
+		}
+		if err := c.checkPersist(key, value); err != nil {
+			report.record(key, err, false)
+			continue
+		}
+		c.setState(key, StateFlushing)
+		dirty = append(dirty, key)
+		olds = append(olds, c.copies[key])
+		values = append(values, value)
+	}
+	if len(dirty) == 0 {
+		c.mu.Unlock()
+		return
+	}
+
+	release := c.acquireDB()
+	err := save(dirty, olds, values)
+	release()
+	if err != nil {
+		c.mu.Unlock()
+		c.log(LogWarn, "Batch flush failed, retrying keys one by one", "keys", len(dirty), "err", err)
+		for _, key := range dirty {
+			report.record(key, c.flush(key, report.reason), true)
+		}
+		return
+	}
+	defer c.mu.Unlock()
+
+	c.logEvent(EventSave, LogInfo, "Saved changes in one batch", "keys", len(dirty), "reason", report.reason)
+	for i, key := range dirty {
+		c.runFlushHooks(key, report.reason, olds[i], *values[i])
+		err := c.rebase(key, values[i])
+		if err == nil {
+			c.settle(key)
+		}
+		report.record(key, err, false)
+	}
+}
@@ -0,0 +1,75 @@
+package cachedb
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestFlushAllBulk(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Name string
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	for i := 1; i <= 10; i++ {
+		db.Create(&Player{ID: uint(i), Name: "p", Gold: 100})
+	}
+
+	var statements []string
+	db.Callback().Create().After("gorm:create").Register("record_sql", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+	defer db.Callback().Create().Remove("record_sql")
+
+	c := newTestCache[Player](t, db, 100, WithBulkFlush[Player](4))
+	flushed := 0
+	c.OnFlush(func(interface{}, Player, Player) { flushed++ })
+	for i := 1; i <= 10; i++ {
+		p, _ := c.Get(i)
+		p.Gold = 0 // 零值也要写回
+	}
+
+	if err := c.FlushAll(); err != nil {
+		t.Fatalf("FlushAll failed: %v", err)
+	}
+	if len(statements) != 3 {
+		t.Fatalf("expected 3 bulk statements for 10 rows, got %d", len(statements))
+	}
+	if !strings.Contains(statements[0], "ON CONFLICT") {
+		t.Errorf("expected an upsert statement, got %s", statements[0])
+	}
+	if flushed != 10 || len(c.DirtyKeys()) != 0 {
+		t.Errorf("expected 10 flushed keys and none dirty, got %d and %v", flushed, c.DirtyKeys())
+	}
+
+	var total int64
+	db.Model(&Player{}).Where("gold = 0 AND name = ?", "p").Count(&total)
+	if total != 10 {
+		t.Errorf("expected 10 rows written back, got %d", total)
+	}
+}
+
+func TestFlushAllDefaultsToUpdate(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1})
+	db.Create(&Player{ID: 2})
+
+	c := newTestCache[Player](t, db, 10)
+	for id := 1; id <= 2; id++ {
+		c.Update(id, func(p *Player) { p.Gold = 9 })
+	}
+	db.Delete(&Player{}, 2) // 绕过缓存删除
+	c.FlushAll()
+	var rows []Player
+	db.Find(&rows)
+	if len(rows) != 1 || rows[0].ID != 1 || rows[0].Gold != 9 {
+		t.Errorf("expected FlushAll to update without resurrecting deleted rows, got %+v", rows)
+	}
+}
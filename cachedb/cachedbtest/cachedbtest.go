@@ -0,0 +1,214 @@
+// Package cachedbtest 提供测试 cachedb 使用方代码的工具：内存存储、可控时钟、进程内消息流与 Kafka 和断言函数，
+// 无需 sqlite 即可对游戏逻辑做单元测试
+package cachedbtest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/beijian128/cachedb"
+	"gorm.io/gorm"
+)
+
+// MemStore 是 cachedb.Store 的内存实现，按 fmt.Sprint(key) 存储行。
+// Save 整体覆盖行（不同于 gorm 的 Updates 会跳过零值字段）
+type MemStore[T any] struct {
+	keyOf func(*T) interface{}
+
+	mu    sync.Mutex
+	rows  map[string]T
+	saves map[string]int
+}
+
+// NewMemStore 创建内存存储，keyOf 从行中取出 key，rows 为初始数据
+func NewMemStore[T any](keyOf func(*T) interface{}, rows ...T) *MemStore[T] {
+	s := &MemStore[T]{
+		keyOf: keyOf,
+		rows:  make(map[string]T),
+		saves: make(map[string]int),
+	}
+	for i := range rows {
+		s.rows[fmt.Sprint(keyOf(&rows[i]))] = rows[i]
+	}
+	return s
+}
+
+func (s *MemStore[T]) Load(key interface{}) (T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	row, ok := s.rows[fmt.Sprint(key)]
+	if !ok {
+		var zero T
+		return zero, gorm.ErrRecordNotFound
+	}
+	return cachedb.DeepCopy(row)
+}
+
+func (s *MemStore[T]) Save(key interface{}, _, new *T) error {
+	row, err := cachedb.DeepCopy(*new)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := fmt.Sprint(key)
+	s.rows[k] = row
+	s.saves[k]++
+	return nil
+}
+
+func (s *MemStore[T]) Insert(value *T) error {
+	row, err := cachedb.DeepCopy(*value)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := fmt.Sprint(s.keyOf(value))
+	if _, exists := s.rows[k]; exists {
+		return fmt.Errorf("duplicate key %s", k)
+	}
+	s.rows[k] = row
+	return nil
+}
+
+func (s *MemStore[T]) Delete(key interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.rows, fmt.Sprint(key))
+	return nil
+}
+
+// Row 返回存储中的行
+func (s *MemStore[T]) Row(key interface{}) (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	row, ok := s.rows[fmt.Sprint(key)]
+	return row, ok
+}
+
+// Saves 返回 key 被写回的次数
+func (s *MemStore[T]) Saves(key interface{}) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saves[fmt.Sprint(key)]
+}
+
+// New 创建以 store 为存储、不连接数据库的 CacheDB。
+// 只支持按 key 的读写、固定与刷盘，CachedPage、LoadAll 等需要数据库的功能不可用
+func New[T any](t testing.TB, store cachedb.Store[T], size int, opts ...cachedb.Option[T]) *cachedb.CacheDB[T] {
+	t.Helper()
+	opts = append(opts, cachedb.WithStore(func(cachedb.Store[T]) cachedb.Store[T] { return store }))
+	c, err := cachedb.NewWithCache[T](nil, size, opts...)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	return c
+}
+
+// Clock 是手动推进的时钟，实现了 cachedb.Clock
+type Clock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+}
+
+// waiter 是一个等待中的 After
+type waiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewClock 创建停在 start 的时钟
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now 返回当前时间
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After 返回的 channel 在时钟被推进 d 之后收到时间
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, waiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance 把时钟向前推进 d，并唤醒到期的 After
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setLocked(c.now.Add(d))
+}
+
+// Set 把时钟设置到 t，并唤醒到期的 After
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setLocked(t)
+}
+
+func (c *Clock) setLocked(t time.Time) {
+	c.now = t
+	remaining := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(t) {
+			remaining = append(remaining, w)
+			continue
+		}
+		w.ch <- t
+	}
+	c.waiters = remaining
+}
+
+// Waiters 返回等待中的 After 数量，可用于确认后台协程已进入等待
+func (c *Clock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// BlockUntil 阻塞到至少有 n 个等待中的 After
+func (c *Clock) BlockUntil(n int) {
+	for c.Waiters() < n {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// AssertDirty 断言 key 有未落库的修改，key 按 fmt.Sprint 比较
+func AssertDirty(t testing.TB, f cachedb.Flusher, key interface{}) {
+	t.Helper()
+	if !isDirty(f, key) {
+		t.Errorf("expected key %v to be dirty", key)
+	}
+}
+
+// AssertFlushed 断言 key 没有未落库的修改
+func AssertFlushed(t testing.TB, f cachedb.Flusher, key interface{}) {
+	t.Helper()
+	if isDirty(f, key) {
+		t.Errorf("expected key %v to be flushed", key)
+	}
+}
+
+func isDirty(f cachedb.Flusher, key interface{}) bool {
+	k := fmt.Sprint(key)
+	for _, dirty := range f.DirtyKeys() {
+		if fmt.Sprint(dirty) == k {
+			return true
+		}
+	}
+	return false
+}
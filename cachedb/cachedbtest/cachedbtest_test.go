@@ -0,0 +1,192 @@
+package cachedbtest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beijian128/cachedb"
+)
+
+type player struct {
+	ID   uint
+	Gold int
+	Bag  []string `gorm:"serializer:json"`
+}
+
+func TestMemStore(t *testing.T) {
+	store := NewMemStore(func(p *player) interface{} { return p.ID },
+		player{ID: 1, Gold: 10})
+	c := New[player](t, store, 10)
+
+	p, err := c.Get(1)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	AssertFlushed(t, c, 1)
+
+	p.Gold = 20
+	p.Bag = append(p.Bag, "sword")
+	AssertDirty(t, c, 1)
+	if row, _ := store.Row(1); row.Gold != 10 {
+		t.Errorf("store should not see unflushed changes, got %+v", row)
+	}
+
+	if err := c.Flush(1); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	AssertFlushed(t, c, 1)
+	row, _ := store.Row(1)
+	if row.Gold != 20 || len(row.Bag) != 1 || store.Saves(1) != 1 {
+		t.Errorf("unexpected stored row %+v saves=%d", row, store.Saves(1))
+	}
+
+	if _, err := c.Get(2); err == nil {
+		t.Errorf("expected error for missing key")
+	}
+	if _, err := c.Create(player{ID: 2, Gold: 5}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := c.Delete(1); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, ok := store.Row(1); ok {
+		t.Errorf("expected key 1 to be deleted")
+	}
+}
+
+func TestClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+	clock.Advance(time.Hour)
+	if got := clock.Now(); !got.Equal(start.Add(time.Hour)) {
+		t.Errorf("expected %v, got %v", start.Add(time.Hour), got)
+	}
+}
+
+func TestClockDrivesCache(t *testing.T) {
+	clock := NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := NewMemStore(func(p *player) interface{} { return p.ID },
+		player{ID: 1, Gold: 10})
+	c := New[player](t, store, 10, cachedb.WithClock[player](clock))
+
+	p, _ := c.Get(1)
+	p.Gold = 20
+	clock.Advance(5 * time.Minute)
+	pending := c.PendingWrites()
+	if len(pending) != 1 || pending[0].Age != 5*time.Minute {
+		t.Fatalf("expected one write pending for 5m, got %+v", pending)
+	}
+
+	// 超过缓存的 2 秒有效期后条目被淘汰并回写
+	clock.Advance(3 * time.Second)
+	if c.Cache.Has(uint(1)) {
+		t.Errorf("expected entry to expire")
+	}
+	c.Cache.Get(uint(1)) // 过期条目在访问时才会被移除
+	if row, _ := store.Row(1); row.Gold != 20 {
+		t.Errorf("expected expired entry to be written back, got %+v", row)
+	}
+}
+
+func TestClockDrivesCheckpoint(t *testing.T) {
+	clock := NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := NewMemStore(func(p *player) interface{} { return p.ID },
+		player{ID: 1})
+	c := New[player](t, store, 10)
+	p, _ := c.Get(1)
+	p.Gold = 7
+
+	cp := cachedb.NewCheckpointer(cachedb.CheckpointConfig{
+		Schedule: cachedb.Every(time.Minute),
+		Clock:    clock,
+	}, c)
+	cp.Start()
+	defer cp.Stop()
+
+	clock.BlockUntil(1)
+	if store.Saves(1) != 0 {
+		t.Fatalf("checkpoint ran before the clock advanced")
+	}
+	clock.Advance(time.Minute)
+	clock.BlockUntil(1) // 下一次检查点开始等待，说明本次已完成
+	if store.Saves(1) != 1 {
+		t.Errorf("expected one checkpoint flush, got %d", store.Saves(1))
+	}
+	AssertFlushed(t, c, 1)
+}
+
+func TestMemJetStream(t *testing.T) {
+	key := func(p *player) interface{} { return p.ID }
+	js := NewMemJetStream()
+	owner := New[player](t, NewMemStore(key, player{ID: 1, Gold: 10}), 10,
+		cachedb.WithFlushPublisher[player](cachedb.NewJetStreamBus[player](js, "players", "")))
+	follower := New[player](t, NewMemStore(key, player{ID: 1, Gold: 10}), 10, cachedb.WithReadOnly[player]())
+	follower.Get(1)
+	if _, err := follower.Follow(cachedb.NewJetStreamBus[player](js, "players", "")); err != nil {
+		t.Fatalf("Follow failed: %v", err)
+	}
+
+	// 同一消费者组中的两个消费者分摊事件，处理失败的事件会被重投
+	var seen []int
+	failOnce := true
+	analytics := cachedb.NewJetStreamBus[player](js, "players", "analytics")
+	for i := 0; i < 2; i++ {
+		analytics.SubscribeFlush(func(e cachedb.FlushEvent[player]) {
+			if failOnce {
+				failOnce = false
+				panic("pipeline down")
+			}
+			seen = append(seen, e.Value.Gold)
+		})
+	}
+	other := cachedb.NewJetStreamBus[player](js, "other", "")
+	cancel, _ := other.SubscribeFlush(func(cachedb.FlushEvent[player]) { t.Error("unexpected event on another subject") })
+	cancel()
+
+	owner.Update(1, func(p *player) { p.Gold = 20 })
+	if err := owner.Flush(1); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if p, _ := follower.Get(1); p.Gold != 20 {
+		t.Errorf("expected the follower to apply the streamed event, got %+v", p)
+	}
+	if len(seen) != 1 || seen[0] != 20 {
+		t.Errorf("expected the consumer group to process the event once, got %v", seen)
+	}
+	if js.Delivered() != 3 || js.Dropped() != 0 {
+		t.Errorf("expected 3 deliveries including one redelivery, got %d (dropped %d)", js.Delivered(), js.Dropped())
+	}
+}
+
+func TestMemKafka(t *testing.T) {
+	store := NewMemStore(func(p *player) interface{} { return p.ID }, player{ID: 1, Gold: 10})
+	kafka := NewMemKafka()
+	c := New[player](t, store, 10, cachedb.WithCDC[player](kafka, "player-changes"))
+
+	c.Update(1, func(p *player) { p.Gold = 30 })
+	if err := c.Flush(1); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	kafka.Err = errors.New("broker down") // 发送失败不影响落库
+	c.Update(1, func(p *player) { p.Gold = 40 })
+	if err := c.Flush(1); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	msgs := kafka.Messages("player-changes")
+	if len(msgs) != 1 || !strings.HasSuffix(string(msgs[0].Key), ":1") {
+		t.Fatalf("expected one change for key 1, got %+v", msgs)
+	}
+	records, err := kafka.Changes("player-changes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records[0].Changes) != 1 || records[0].Changes[0].New != float64(30) {
+		t.Errorf("unexpected change record %+v", records[0])
+	}
+	if row, _ := store.Row(1); row.Gold != 40 {
+		t.Errorf("expected the second flush to succeed, got %+v", row)
+	}
+}
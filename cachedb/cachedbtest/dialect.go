@@ -0,0 +1,116 @@
+package cachedbtest
+
+import (
+	"testing"
+
+	"github.com/beijian128/cachedb"
+	"gorm.io/gorm"
+)
+
+// suiteStats 是 RunDialectSuite 中的 JSON 列
+type suiteStats struct {
+	Level int            `json:"level"`
+	Bag   map[string]int `json:"bag"`
+}
+
+// suitePlayer 是 RunDialectSuite 使用的模型，表名为 cachedb_suite_players
+type suitePlayer struct {
+	ID    uint `gorm:"primaryKey;autoIncrement:false"`
+	Name  string
+	Gold  int64
+	Stats suiteStats `gorm:"serializer:json"`
+}
+
+func (suitePlayer) TableName() string { return "cachedb_suite_players" }
+
+// RunDialectSuite 在 db 上验证写回语义：淘汰写回、批量 upsert（包括零值）、JSON 局部更新和悲观锁转账。
+// sqlite 的结果不能代表生产使用的 MySQL、PostgreSQL，集成测试应对每种数据库各调用一次，
+// 例如用 testcontainers 启动容器后传入连接。本模块的集成测试只覆盖 MySQL，见 TestDialectSuiteMySQL。
+// 会创建并清空 cachedb_suite_players 表
+func RunDialectSuite(t *testing.T, db *gorm.DB) {
+	if err := db.AutoMigrate(&suitePlayer{}, &cachedb.TransferAudit{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	// reset 清空表并写入 n 个玩家，返回新的缓存
+	reset := func(t *testing.T, n, size int, opts ...cachedb.Option[suitePlayer]) *cachedb.CacheDB[suitePlayer] {
+		t.Helper()
+		if err := db.Where("1 = 1").Delete(&suitePlayer{}).Error; err != nil {
+			t.Fatalf("failed to clear table: %v", err)
+		}
+		for i := 1; i <= n; i++ {
+			row := suitePlayer{ID: uint(i), Name: "p", Gold: 100, Stats: suiteStats{Level: 1, Bag: map[string]int{"potion": 1}}}
+			if err := db.Create(&row).Error; err != nil {
+				t.Fatalf("failed to seed: %v", err)
+			}
+		}
+		c, err := cachedb.NewWithCache[suitePlayer](db, size, opts...)
+		if err != nil {
+			t.Fatalf("failed to create cache: %v", err)
+		}
+		return c
+	}
+	load := func(t *testing.T, id uint) suitePlayer {
+		t.Helper()
+		var row suitePlayer
+		if err := db.First(&row, id).Error; err != nil {
+			t.Fatalf("failed to read row %d: %v", id, err)
+		}
+		return row
+	}
+
+	t.Run("WriteBackOnEvict", func(t *testing.T) {
+		c := reset(t, 2, 1)
+		p, _ := c.Get(1)
+		p.Name = "evicted"
+		c.Get(2)
+		if row := load(t, 1); row.Name != "evicted" {
+			t.Errorf("expected eviction to write back, got %q", row.Name)
+		}
+	})
+
+	t.Run("BulkUpsert", func(t *testing.T) {
+		c := reset(t, 5, 10, cachedb.WithBulkFlush[suitePlayer](2))
+		for i := 1; i <= 5; i++ {
+			c.Update(i, func(p *suitePlayer) { p.Gold = int64(i - 1) }) // 第一行写入零值
+		}
+		if err := c.FlushAll(); err != nil {
+			t.Fatalf("FlushAll failed: %v", err)
+		}
+		for i := 1; i <= 5; i++ {
+			if row := load(t, uint(i)); row.Gold != int64(i-1) || row.Name != "p" {
+				t.Errorf("unexpected row %+v", row)
+			}
+		}
+	})
+
+	t.Run("JSONPatch", func(t *testing.T) {
+		c := reset(t, 1, 10)
+		c.Update(1, func(p *suitePlayer) {
+			p.Stats.Level = 2
+			p.Stats.Bag["sword"] = 1
+		})
+		if err := c.Flush(1); err != nil {
+			t.Fatalf("Flush failed: %v", err)
+		}
+		row := load(t, 1)
+		if row.Stats.Level != 2 || row.Stats.Bag["sword"] != 1 || row.Stats.Bag["potion"] != 1 {
+			t.Errorf("unexpected JSON column %+v", row.Stats)
+		}
+	})
+
+	t.Run("PessimisticTransfer", func(t *testing.T) {
+		c := reset(t, 2, 10)
+		spec := cachedb.TransferSpec[suitePlayer]{
+			Column:  "gold",
+			Balance: func(p *suitePlayer) int64 { return p.Gold },
+			Set:     func(p *suitePlayer, v int64) { p.Gold = v },
+			Mode:    cachedb.LockPessimistic,
+		}
+		if err := cachedb.Transfer(c, 1, 2, 30, spec); err != nil {
+			t.Fatalf("Transfer failed: %v", err)
+		}
+		if a, b := load(t, 1), load(t, 2); a.Gold != 70 || b.Gold != 130 {
+			t.Errorf("expected balances 70 and 130, got %d and %d", a.Gold, b.Gold)
+		}
+	})
+}
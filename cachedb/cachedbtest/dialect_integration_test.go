@@ -0,0 +1,31 @@
+//go:build integration
+
+package cachedbtest
+
+import (
+	"os"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// 集成测试针对真实的 MySQL 运行写回语义，例如：
+//
+//	docker run -d -p 3306:3306 -e MYSQL_ROOT_PASSWORD=root -e MYSQL_DATABASE=cachedb mysql:8
+//	CACHEDB_MYSQL_DSN='root:root@tcp(127.0.0.1:3306)/cachedb?parseTime=true' go test -tags integration ./cachedbtest
+//
+// 这里只自动验证 MySQL：本模块不依赖 PostgreSQL 驱动，内置的 postgres 方言（ON CONFLICT 与 JSON 路径）
+// 没有在真实的 PostgreSQL 上经过本套件验证。使用 PostgreSQL 的项目应在自己的集成测试中
+// 以 gorm.io/driver/postgres 打开连接后调用 RunDialectSuite
+func TestDialectSuiteMySQL(t *testing.T) {
+	dsn := os.Getenv("CACHEDB_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("CACHEDB_MYSQL_DSN not set")
+	}
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	RunDialectSuite(t, db)
+}
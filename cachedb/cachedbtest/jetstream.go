@@ -0,0 +1,133 @@
+package cachedbtest
+
+import (
+	"sync"
+
+	"github.com/beijian128/cachedb"
+)
+
+// MemJetStream 是 cachedb.JetStream 的进程内实现，用于在测试中代替 NATS JetStream。
+// subject 按字符串精确匹配，不支持通配符。Publish 同步投递：空 group 的订阅者都会收到消息，
+// 同一 group 的订阅者轮流分配。处理完未 Ack、调用了 Nak 或 panic 的消息会立即重投给同一订阅者，
+// 一条消息最多投递 MaxDeliver 次
+type MemJetStream struct {
+	MaxDeliver int // 每条消息的最大投递次数，为 0 时为 3
+
+	mu        sync.Mutex
+	nextID    int
+	subs      map[string]map[string][]*memSub // subject -> group -> 订阅者
+	next      map[string]int                  // subject+group -> 下一个订阅者
+	delivered int
+	dropped   int
+}
+
+var _ cachedb.JetStream = (*MemJetStream)(nil)
+
+// memSub 是一个订阅者
+type memSub struct {
+	id      int
+	handler func(cachedb.StreamMsg)
+}
+
+// memMsg 是投递给订阅者的一条消息
+type memMsg struct {
+	data       []byte
+	acked, nak bool
+}
+
+func (m *memMsg) Data() []byte { return m.data }
+func (m *memMsg) Ack() error   { m.acked = true; return nil }
+func (m *memMsg) Nak() error   { m.nak = true; return nil }
+
+// NewMemJetStream 创建进程内消息流
+func NewMemJetStream() *MemJetStream {
+	return &MemJetStream{
+		subs: make(map[string]map[string][]*memSub),
+		next: make(map[string]int),
+	}
+}
+
+// Publish 把 data 同步投递给 subject 的订阅者
+func (js *MemJetStream) Publish(subject string, data []byte) error {
+	js.mu.Lock()
+	var targets []*memSub
+	for group, subs := range js.subs[subject] {
+		if len(subs) == 0 {
+			continue
+		}
+		if group == "" {
+			targets = append(targets, subs...)
+			continue
+		}
+		i := js.next[subject+"\x00"+group]
+		js.next[subject+"\x00"+group] = i + 1
+		targets = append(targets, subs[i%len(subs)])
+	}
+	js.mu.Unlock()
+
+	for _, sub := range targets {
+		js.deliver(sub, data)
+	}
+	return nil
+}
+
+// deliver 投递一条消息，直到被 Ack 或达到 MaxDeliver
+func (js *MemJetStream) deliver(sub *memSub, data []byte) {
+	max := js.MaxDeliver
+	if max <= 0 {
+		max = 3
+	}
+	for attempt := 0; attempt < max; attempt++ {
+		msg := &memMsg{data: append([]byte(nil), data...)}
+		func() {
+			defer func() { recover() }() // 与真实消费者崩溃一样，视为未确认
+			sub.handler(msg)
+		}()
+		js.mu.Lock()
+		js.delivered++
+		js.mu.Unlock()
+		if msg.acked && !msg.nak {
+			return
+		}
+	}
+	js.mu.Lock()
+	js.dropped++
+	js.mu.Unlock()
+}
+
+// Subscribe 订阅 subject，group 为空时每个订阅者都收到全部消息。cancel 取消订阅
+func (js *MemJetStream) Subscribe(subject, group string, handler func(cachedb.StreamMsg)) (func(), error) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	js.nextID++
+	sub := &memSub{id: js.nextID, handler: handler}
+	if js.subs[subject] == nil {
+		js.subs[subject] = make(map[string][]*memSub)
+	}
+	js.subs[subject][group] = append(js.subs[subject][group], sub)
+	return func() {
+		js.mu.Lock()
+		defer js.mu.Unlock()
+		subs := js.subs[subject][group]
+		for i, s := range subs {
+			if s.id == sub.id {
+				js.subs[subject][group] = append(subs[:i:i], subs[i+1:]...)
+				break
+			}
+		}
+	}, nil
+}
+
+// Delivered 返回累计的投递次数，包括重投
+func (js *MemJetStream) Delivered() int {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	return js.delivered
+}
+
+// Dropped 返回达到 MaxDeliver 仍未确认而被放弃的消息数
+func (js *MemJetStream) Dropped() int {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	return js.dropped
+}
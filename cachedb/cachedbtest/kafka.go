@@ -0,0 +1,62 @@
+package cachedbtest
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/beijian128/cachedb"
+)
+
+// KafkaMessage 是 MemKafka 收到的一条消息
+type KafkaMessage struct {
+	Key   []byte
+	Value []byte
+}
+
+// MemKafka 是 cachedb.KafkaProducer 的内存实现，按 topic 依次记录收到的消息，
+// 用于在测试中检查 WithCDC 发送的变更记录。Err 非空时 Produce 返回它且不记录消息
+type MemKafka struct {
+	mu     sync.Mutex
+	Err    error
+	topics map[string][]KafkaMessage
+}
+
+var _ cachedb.KafkaProducer = (*MemKafka)(nil)
+
+// NewMemKafka 创建内存 Kafka
+func NewMemKafka() *MemKafka {
+	return &MemKafka{topics: make(map[string][]KafkaMessage)}
+}
+
+// Produce 记录一条消息
+func (k *MemKafka) Produce(topic string, key, value []byte) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.Err != nil {
+		return k.Err
+	}
+	k.topics[topic] = append(k.topics[topic], KafkaMessage{
+		Key:   append([]byte(nil), key...),
+		Value: append([]byte(nil), value...),
+	})
+	return nil
+}
+
+// Messages 返回 topic 收到的消息，按发送顺序排列
+func (k *MemKafka) Messages(topic string) []KafkaMessage {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return append([]KafkaMessage(nil), k.topics[topic]...)
+}
+
+// Changes 把 topic 收到的消息解码为 cachedb.ChangeRecord
+func (k *MemKafka) Changes(topic string) ([]cachedb.ChangeRecord, error) {
+	msgs := k.Messages(topic)
+	records := make([]cachedb.ChangeRecord, len(msgs))
+	for i, m := range msgs {
+		if err := json.Unmarshal(m.Value, &records[i]); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
@@ -1,52 +1,260 @@
 package cachedb
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bluele/gcache"
 	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 )
 
 // CacheDB 是一个带缓存的泛型数据库包装器
 type CacheDB[T any] struct {
 	db     *gorm.DB
 	Cache  gcache.Cache
-	copies map[interface{}]T // 保存深拷贝副本
+	size   int
+	mu     sync.Mutex          // 保护 copies、values、pins 和 meta
+	copies map[interface{}]T   // 保存深拷贝副本
+	values map[interface{}]*T  // 缓存中对象的引用，供主动刷盘使用
+	pins   map[interface{}]int // 被固定的 key 及其引用计数
+	meta   map[interface{}]*entryMeta
+
+	fullTable bool // 全表常驻模式，见 LoadAll
+
+	copier           func(T) (T, error)                      // 生成副本的拷贝函数
+	customCopier     bool                                    // 是否通过 WithCopier 指定了拷贝函数
+	equal            func(a, b T) bool                       // 比较对象与副本，见 chooseCopyPlan
+	keyName          string                                  // WithKeyField 指定的 key 字段
+	schema           *schema.Schema                          // 构造时解析的模型 schema
+	redacted         []*schema.Field                         // 需要在输出中遮蔽的字段，见 Redact
+	updatedAt        *schema.Field                           // gorm 自动维护的更新时间字段，用于条件重载
+	keyField         *schema.Field                           // 作为缓存 key 的字段
+	hasJSON          bool                                    // 模型是否包含 JSON 列，见 jsonAssignments
+	collectionFields []string                                // WithCollections 指定的关联字段
+	collections      []*schema.Relationship                  // 按元素增删写入的关联，见 WithCollections
+	safeReads        bool                                    // Get 是否返回深拷贝，见 WithSafeReads
+	flushWorkers     int                                     // 异步回写的协程数，见 WithFlushWorkers
+	pool             *FlushPool                              // 异步回写协程池，未开启时为 nil
+	dbLocal          *DBLimiter                              // 该缓存自身的数据库并发限制，未设置时为 nil
+	dbShared         *DBLimiter                              // 多个缓存共享的数据库并发限制，未设置时为 nil
+	ownsPool         bool                                    // 协程池是否由该缓存创建
+	queueSize        int                                     // 每个刷盘协程的队列长度
+	overflow         OverflowPolicy                          // 队列满时的处理方式
+	wal              WriteAheadLog[T]                        // OverflowSpill 使用的预写日志
+	deadLetters      WriteAheadLog[T]                        // 回写失败的对象，见 WithDeadLetter
+	store            Store[T]                                // 单行加载与回写使用的存储
+	wrapStore        func(Store[T]) Store[T]                 // WithStore 指定的存储
+	clock            Clock                                   // 时间来源，见 WithClock
+	tableName        string                                  // WithTable 指定的表名
+	sharding         ShardStrategy                           // WithSharding 指定的分片策略
+	batchSize        int                                     // MGet/Warm 每条 IN 查询的 key 数
+	batchConcurrency int                                     // MGet/Warm 同时执行的查询数
+	flushBatch       int                                     // FlushAll 每条批量写入语句的行数
+	flushTx          int                                     // FlushAll 每个事务包含的行数
+	flushTxOpts      FlushTxOptions                          // 落库事务的隔离级别与语句超时，见 WithFlushTxOptions
+	loadTimeout      time.Duration                           // 每次加载的超时，见 WithTimeouts
+	flushTimeout     time.Duration                           // 每次写回的超时，见 WithTimeouts
+	maxSize          int                                     // 对象序列化后的最大字节数，见 WithMaxEntitySize
+	sizePolicy       SizePolicy                              // 超过 maxSize 时的处理方式
+	schemaCheck      bool                                    // 构造时是否检查表结构，见 WithSchemaCheck
+	schemaStrict     bool                                    // 表结构不一致时是否构造失败
+	logger           Logger                                  // 结构化日志输出，见 WithLogger
+	entity           string                                  // 日志与统计使用的实体标签，见 Entity
+	logLevel         LogLevel                                // 最低日志级别，见 WithLogLevel
+	logPolicies      map[LogEvent]*eventPolicy               // 各类事件日志的策略，见 WithLogPolicy
+	slowLoad         time.Duration                           // 慢加载阈值
+	slowFlush        time.Duration                           // 慢回写阈值
+	counters         cacheCounters                           // 运行统计，见 Stats
+	shed             *shedState                              // 连接池降载，见 WithLoadShedding
+	loop             *gameLoop                               // 单线程模式的任务队列，见 WithGameLoop
+	actors           actors                                  // 按 key 串行执行的信箱，见 Tell
+	diffEncoder      DiffEncoder                             // 字段修改的编码器，见 WithDiffEncoder
+	namespace        *Namespace                              // 共享外部缓存与事件通道时的键前缀，见 WithNamespace
+	l2               L2                                      // 进程外的二级缓存，见 WithL2
+	l2TTL            time.Duration                           // 写入 L2 的过期时间
+	l2w              *l2Writer                               // L2 的后台写入队列
+	cdc              *cdcSink                                // 变更数据捕获，见 WithCDC
+	replicator       Replicator[T]                           // 可选的写复制器
+	publisher        FlushPublisher[T]                       // 落库事件的发布者，见 WithFlushPublisher
+	readOnly         bool                                    // 只读的跟随模式，见 WithReadOnly
+	onFlush          []func(key interface{}, old, new T)     // 落库成功后的回调
+	onDelete         []func(key interface{})                 // 删除成功后的回调
+	onCreateRow      []func(key interface{}, row T)          // 插入成功后的回调，见 OnCreateRow
+	onDeleteRow      []func(key interface{}, row T)          // 删除成功后带被删除行的回调，见 OnDeleteRow
+	onEvict          []func(key interface{})                 // 对象离开缓存后的回调，见 OnEvict
+	beforeEvict      []func(key interface{}, value *T) bool  // 淘汰前的否决检查，见 OnBeforeEvict
+	secondChance     bool                                    // 脏对象第一次被淘汰时放回，见 WithDirtySecondChance
+	partitions       *partitionSpec                          // 容量分区，见 WithPartitions
+	replicas         *replicaIndex[T]                        // 热点 key 的只读副本，见 WithReadReplicas
+	coalesce         *coalescer                              // 合并连续修改的自动落库，见 WithCoalesce
+	ready            *readyGate                              // 预热完成前的门闩，见 WithReadyGate
+	verifier         *copyVerifier[T]                        // 别名 bug 调试模式，见 WithCopyVerify
+	flushReason      FlushReason                             // 正在执行 OnFlush 回调的落库原因，由 c.mu 保护
+	flushCounts      map[FlushReason]uint64                  // 各原因落库的对象数，由 c.mu 保护，见 Stats
+	savepoints       map[interface{}]*savepoint[T]           // 进行中的保存点，由 c.mu 保护，见 Begin
+	openSavepoints   atomic.Int64                            // 进行中的保存点数，淘汰时据此跳过检查
+	validators       []func(key interface{}, value *T) error // 落库前的校验，见 WithValidator
+	quarantine       map[interface{}]*quarantined[T]         // 隔离区，未开启 WithQuarantine 时为 nil
+	tags             *tagIndex[T]                            // 标签索引，见 WithTags
+	ttl              ttlFunc[T]                              // 按对象计算的有效期，见 WithTTLFunc
+	gen              uint64                                  // 最近分配的代数，见 Generation
+	closed           atomic.Bool                             // 是否已经 Close
+	presence         *presenceIndex                          // 已存在 key 的过滤器，见 WithPresenceIndex
+	presenceRebuild  time.Duration                           // 过滤器定期重建的间隔，见 WithPresenceRebuild
+	loading          map[interface{}]*loadTicket             // 正在从数据库加载的 key
+	deleted          map[interface{}]struct{}                // 最近删除的 key，见 EntryInfo
+	owner            ownerFunc[T]                            // 对象的归属者，见 WithOwner
+	ownerBatch       *ownerBatch[T]                          // FlushOwner 进行中的脏数据，由 c.mu 保护
+	queries          *queryCache                             // 分页等查询结果缓存
 }
 
-// NewWithCache 创建一个新的带缓存的泛型DB实例
-func NewWithCache[T any](db *gorm.DB, size int) *CacheDB[T] {
+// NewWithCache 创建一个新的带缓存的泛型DB实例。
+// T 必须是带主键的 gorm 模型，且不能包含 chan、func 等无法深拷贝的字段（除非提供了 WithCopier）
+func NewWithCache[T any](db *gorm.DB, size int, opts ...Option[T]) (*CacheDB[T], error) {
 	c := &CacheDB[T]{
-		db:     db,
-		copies: make(map[interface{}]T),
+		db:          db,
+		size:        size,
+		copies:      make(map[interface{}]T),
+		values:      make(map[interface{}]*T),
+		pins:        make(map[interface{}]int),
+		meta:        make(map[interface{}]*entryMeta),
+		loading:     make(map[interface{}]*loadTicket),
+		deleted:     make(map[interface{}]struct{}),
+		flushCounts: make(map[FlushReason]uint64),
+		queries:     newQueryCache(30 * time.Second),
+		copier:      deepCopy[T],
+		clock:       realClock{},
+		logger:      stdLogger{},
+
+		queueSize:        1024,
+		batchSize:        500,
+		batchConcurrency: 4,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	c.chooseCopyPlan()
+	c.queries.clock = c.clock
+	c.entity = c.defaultTable()
+	if err := c.checkNamespace(); err != nil {
+		return nil, err
+	}
+	if c.schemaCheck {
+		if err := c.checkSchema(); err != nil {
+			return nil, err
+		}
+	}
+	c.store = gormStore[T]{c: c}
+	if c.wrapStore != nil {
+		c.store = c.wrapStore(c.store)
+	}
+	c.OnFlush(func(interface{}, T, T) { c.queries.invalidateAll() })
+	if c.wal != nil {
+		c.OnFlush(func(key interface{}, _, _ T) {
+			if err := c.wal.Commit(key); err != nil {
+				c.log(LogError, "WAL commit failed", "key", key, "err", err)
+			}
+		})
+	}
+	if c.publisher != nil {
+		c.OnFlush(c.publishFlush)
+	}
+	if c.l2 != nil {
+		c.startL2()
+		c.OnFlush(c.storeL2)
+	}
+	if c.cdc != nil {
+		c.cdc.schema = c.changeSchema()
+		c.OnFlush(c.publishChange)
 	}
+	if c.deadLetters != nil {
+		c.OnFlush(func(key interface{}, _, _ T) {
+			if err := c.deadLetters.Commit(key); err != nil {
+				c.log(LogError, "Dead letter commit failed", "key", key, "err", err)
+			}
+		})
+	}
+	if c.pool == nil && c.flushWorkers > 0 {
+		c.pool = NewFlushPool(c.flushWorkers, c.queueSize)
+		c.ownsPool = true
+	}
+
+	if c.partitions != nil {
+		c.Cache = c.buildPartitions(size)
+	} else {
+		c.Cache = c.newLRU(size).Build()
+	}
+
+	return c, nil
+}
 
-	c.Cache = gcache.New(size).
+// newLRU 返回容量为 size、配置好加载与回写回调的构建器
+func (c *CacheDB[T]) newLRU(size int) *gcache.CacheBuilder {
+	builder := gcache.New(size).
 		LRU().
 		Expiration(time.Second * 2).
+		Clock(c.clock).
 		LoaderFunc(c.loadFromDB()).      // 缓存未命中时从数据库加载
 		EvictedFunc(c.evictToDB()).      // 缓存淘汰时回写
 		PurgeVisitorFunc(c.purgeToDB()). // 清空缓存时回写
-		AddedFunc(c.logCacheAdd()).      // 可选的添加日志
-		Build()
-
-	return c
+		AddedFunc(c.logCacheAdd())       // 可选的添加日志
+	if c.ttl != nil {
+		builder.LoaderExpireFunc(c.loadWithTTL()) // 按对象状态设置有效期
+	}
+	return builder
 }
 
 // loadFromDB 从数据库加载数据并保存副本
 func (c *CacheDB[T]) loadFromDB() gcache.LoaderFunc {
-	return func(key interface{}) (interface{}, error) {
-		var entity T
-		if err := c.db.First(&entity, key).Error; err != nil {
-			return nil, fmt.Errorf("failed to load from DB: %w", err)
+	return func(key interface{}) (v interface{}, err error) {
+		defer c.recoverPanic("load", key, &err)
+
+		// 被固定的对象淘汰后仍在追踪，直接放回缓存以保持同一个指针
+		// 回写失败而滞留的对象同样直接放回，未落库的修改不会丢失
+		c.mu.Lock()
+		if value, ok := c.values[key]; ok && (c.shed == nil || c.reuseShed(key)) {
+			c.meta[key].detached = false
+			c.mu.Unlock()
+			return value, nil
 		}
+		c.mu.Unlock()
 
-		// 保存深拷贝副本
-		copy := deepCopy(entity)
-		c.copies[key] = copy
+		if c.absent(key) {
+			return nil, fmt.Errorf("failed to load from DB: %w: key %v", ErrNotFound, key)
+		}
+		ticket := c.startLoad(key)
+		entity, err := c.loadRow(key)
+		if errors.Is(err, ErrNotFound) {
+			c.missedPresence()
+		}
 
+		// 加载期间 key 可能已被 Set，此时以内存中的新值为准，不能用数据库中的旧行覆盖
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.finishLoad(key, ticket) && err == nil {
+			// 加载期间被删除，读到的行已经过时
+			return nil, fmt.Errorf("failed to load from DB: %w: key %v deleted during load", ErrNotFound, key)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if value, ok := c.values[key]; ok {
+			c.meta[key].detached = false
+			return value, nil
+		}
+		// 保存深拷贝副本
+		if err := c.rebase(key, &entity); err != nil {
+			return nil, err
+		}
+		c.values[key] = &entity
 		return &entity, nil
 	}
 }
@@ -54,29 +262,162 @@ func (c *CacheDB[T]) loadFromDB() gcache.LoaderFunc {
 // evictToDB 缓存淘汰时的回写逻辑
 func (c *CacheDB[T]) evictToDB() gcache.EvictedFunc {
 	return func(key, value interface{}) {
-		if err := c.saveIfModified(key, value); err != nil {
-			fmt.Printf("Evict save failed: %v\n", err)
+		if _, ok := key.(capacityProbe); ok {
+			return // SimulateCapacityPressure 的占位，不是缓存的对象
+		}
+		defer c.recoverPanic("evict", key, nil)
+		if c.vetoEvict(key, value) {
+			return // 被 OnBeforeEvict 否决
+		}
+		if c.shed != nil && c.shedEvict(key, value) {
+			return // 降载期间推迟回写
+		}
+		if len(c.onEvict) > 0 {
+			c.mu.Lock()
+			resident := c.resident(key)
+			c.mu.Unlock()
+			if !resident {
+				defer c.runEvictHooks(key)
+			}
+		}
+		if c.pool != nil || c.loop != nil {
+			c.writeBehind(key, value, FlushEvict)
+			return
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if !c.current(key, value) {
+			return // 已被移交、丢弃或被 Set 替换，无需回写
+		}
+		if err := c.saveIfModified(key, value, FlushEvict); err != nil {
+			// 保留副本和修改，等待 Flush 重试或下次访问时放回缓存
+			c.log(LogError, "Evict save failed", "key", key, "err", err)
+			c.detach(key)
+			c.deadLetter(key)
+			return
+		}
+		if c.resident(key) {
+			// 被固定的对象只回写不丢弃，下次访问时重新放回缓存
+			if err := c.rebase(key, c.values[key]); err != nil {
+				c.log(LogError, "Evict rebase failed", "key", key, "err", err)
+			}
+			return
 		}
-		delete(c.copies, key) // 清理副本
+		c.untrack(key) // 清理副本
 		// 记录日志
-		fmt.Printf("Evicted from cache: key=%v\n", key)
+		c.logEvent(EventEvict, LogDebug, "Evicted from cache", "key", key)
 	}
 }
 
 // purgeToDB 清空缓存时的回写逻辑
 func (c *CacheDB[T]) purgeToDB() gcache.PurgeVisitorFunc {
 	return func(key, value interface{}) {
-		if err := c.saveIfModified(key, value); err != nil {
-			fmt.Printf("Purge save failed: %v\n", err)
+		defer c.recoverPanic("purge", key, nil)
+		if c.pool != nil || c.loop != nil {
+			c.writeBehind(key, value, FlushPurge)
+			return
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if !c.current(key, value) {
+			return // 已被移交、丢弃或被 Set 替换，无需回写
 		}
-		delete(c.copies, key) // 清理副本
+		if err := c.saveIfModified(key, value, FlushPurge); err != nil {
+			c.log(LogError, "Purge save failed", "key", key, "err", err)
+			c.detach(key)
+			c.deadLetter(key)
+			return
+		}
+		c.untrack(key) // 清理副本
 		// 记录日志
-		fmt.Printf("Purged from cache: key=%v\n", key)
+		c.logEvent(EventPurge, LogDebug, "Purged from cache", "key", key)
+	}
+}
+
+// track 记录缓存对象及其深拷贝副本，调用方无需持有 c.mu
+func (c *CacheDB[T]) track(key interface{}, value *T) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.rebase(key, value); err != nil {
+		return err
+	}
+	c.values[key] = value
+	c.meta[key].detached = false
+	c.bump(key)
+	return nil
+}
+
+// adopt 把批量查询得到的行纳入缓存并返回缓存中的对象，
+// 已缓存的 key 以内存中的版本为准（可能包含未落库的修改）
+func (c *CacheDB[T]) adopt(key interface{}, row *T) (*T, error) {
+	c.mu.Lock()
+	if value, ok := c.values[key]; ok {
+		c.mu.Unlock()
+		return value, nil
+	}
+	if err := c.rebase(key, row); err != nil {
+		c.mu.Unlock()
+		return nil, err
 	}
+	c.values[key] = row
+	c.mu.Unlock()
+
+	if err := c.cacheSet(key, row); err != nil {
+		return nil, fmt.Errorf("failed to cache key %v: %w", key, err)
+	}
+	return row, nil
+}
+
+// rebase 以 value 的当前状态作为新的副本，即认为它已与数据库一致，调用方需持有 c.mu
+func (c *CacheDB[T]) rebase(key interface{}, value *T) error {
+	cpy, err := c.copier(*value)
+	if err != nil {
+		return fmt.Errorf("failed to copy key %v: %w", key, err)
+	}
+	c.copies[key] = cpy
+	old := c.meta[key]
+	c.meta[key] = &entryMeta{since: c.clock.Now(), detached: old != nil && old.detached}
+	c.unshed(key) // 已与数据库一致，不再是推迟回写的对象
+	if old != nil {
+		c.meta[key].gen = old.gen             // 落库不改变对象，代数保持不变
+		c.meta[key].mutations = old.mutations // 修改来源跨越落库保留
+	} else {
+		c.bump(key)
+		c.markPresent(key)
+		delete(c.deleted, key)
+	}
+	c.retag(key, value)
+	return c.refreshReplicas(key, value)
+}
+
+// detach 标记回写失败后滞留在内存中的对象，调用方需持有 c.mu
+func (c *CacheDB[T]) detach(key interface{}) {
+	if m := c.meta[key]; m != nil {
+		m.detached = true
+	}
+}
+
+// settle 在滞留对象成功落库后停止追踪，常驻对象除外，调用方需持有 c.mu
+func (c *CacheDB[T]) settle(key interface{}) {
+	if m := c.meta[key]; m != nil && m.detached && !c.resident(key) {
+		c.untrack(key)
+	}
+}
+
+// untrack 清理副本，调用方需持有 c.mu
+func (c *CacheDB[T]) untrack(key interface{}) {
+	delete(c.copies, key)
+	delete(c.values, key)
+	delete(c.meta, key)
+	c.untag(key)
+	c.dropReplicas(key)
+	c.unshed(key)
 }
 
-// saveIfModified 比较新旧值并保存修改
-func (c *CacheDB[T]) saveIfModified(key, newValue interface{}) error {
+// saveIfModified 比较新旧值并以 reason 保存修改，调用方需持有 c.mu
+func (c *CacheDB[T]) saveIfModified(key, newValue interface{}, reason FlushReason) error {
 	// 获取保存的副本
 	oldCopy, exists := c.copies[key]
 	if !exists {
@@ -90,108 +431,609 @@ func (c *CacheDB[T]) saveIfModified(key, newValue interface{}) error {
 	}
 
 	// 比较当前值与副本
-	if !reflect.DeepEqual(oldCopy, *newVal) {
-		if err := c.db.Model(&oldCopy).Updates(newVal).Error; err != nil {
-			return fmt.Errorf("failed to update: %w", err)
+	if !c.modified(key, newVal) {
+		return nil
+	}
+	if c.readOnly {
+		c.log(LogWarn, "Discarded local change on read-only cache", "key", key)
+		return nil
+	}
+	err := c.checkPersist(key, newVal)
+	if err == nil {
+		c.setState(key, StateFlushing)
+		release := c.acquireDB()
+		err = c.store.Save(key, &oldCopy, newVal)
+		release()
+	}
+	if err != nil {
+		werr := &WriteError{Key: key, Attempts: 1, Err: err}
+		if m := c.meta[key]; m != nil {
+			m.attempts++
+			m.lastErr = werr
+			m.state = StateFailed
+			werr.Attempts = m.attempts
 		}
-		fmt.Printf("Saved changes for key %v\n", key)
+		return werr
 	}
+	c.logEvent(EventSave, LogInfo, "Saved changes", "key", key, "reason", reason)
+	c.runFlushHooks(key, reason, oldCopy, *newVal)
 	return nil
 }
 
 // logCacheAdd 可选的缓存添加日志
 func (c *CacheDB[T]) logCacheAdd() func(key, value interface{}) {
 	return func(key, value interface{}) {
-		fmt.Printf("New cache added: key=%v\n", key)
+		if _, ok := key.(capacityProbe); ok {
+			return
+		}
+		defer c.recoverPanic("add", key, nil)
+		c.logEvent(EventAdd, LogDebug, "New cache added", "key", key)
 	}
 }
 
-// deepCopy 创建深拷贝
-func deepCopy[T any](src T) T {
+// DeepCopy 返回 src 的深拷贝，与生成副本使用的默认实现相同
+func DeepCopy[T any](src T) (T, error) {
+	return deepCopy(src)
+}
+
+// deepCopy 创建深拷贝，遇到无法拷贝的类型（chan、func、unsafe.Pointer）时返回错误
+func deepCopy[T any](src T) (T, error) {
 	// 使用反射创建深拷贝
 	original := reflect.ValueOf(src)
 	cpy := reflect.New(original.Type()).Elem()
 
 	// 递归拷贝
-	copyRecursive(original, cpy)
+	if err := copyRecursive(original, cpy); err != nil {
+		var zero T
+		return zero, err
+	}
 
-	return cpy.Interface().(T)
+	return cpy.Interface().(T), nil
 }
 
 // copyRecursive 递归拷贝结构体
-func copyRecursive(original, cpy reflect.Value) {
+var timeType = reflect.TypeOf(time.Time{})
+
+func copyRecursive(original, cpy reflect.Value) error {
 	switch original.Kind() {
 	case reflect.Ptr:
 		// 解引用指针
 		originalValue := original.Elem()
 		if !originalValue.IsValid() {
-			return
+			return nil
 		}
 		cpy.Set(reflect.New(originalValue.Type()))
-		copyRecursive(originalValue, cpy.Elem())
+		return copyRecursive(originalValue, cpy.Elem())
 
 	case reflect.Interface:
 		// 解引用接口
 		if original.IsNil() {
-			return
+			return nil
 		}
 		originalValue := original.Elem()
 		copyValue := reflect.New(originalValue.Type()).Elem()
-		copyRecursive(originalValue, copyValue)
+		if err := copyRecursive(originalValue, copyValue); err != nil {
+			return err
+		}
 		cpy.Set(copyValue)
 
 	case reflect.Struct:
+		if original.Type() == timeType {
+			cpy.Set(original) // time.Time 的字段均未导出，按值整体拷贝
+			return nil
+		}
 		// 拷贝结构体字段
 		for i := 0; i < original.NumField(); i++ {
-			if original.Type().Field(i).PkgPath != "" {
+			field := original.Type().Field(i)
+			if field.PkgPath != "" {
 				continue // 跳过未导出字段
 			}
-			copyRecursive(original.Field(i), cpy.Field(i))
+			if err := copyRecursive(original.Field(i), cpy.Field(i)); err != nil {
+				return fmt.Errorf("%s.%w", field.Name, err)
+			}
 		}
 
 	case reflect.Slice:
 		// 拷贝切片
 		if original.IsNil() {
-			return
+			return nil
 		}
 		cpy.Set(reflect.MakeSlice(original.Type(), original.Len(), original.Cap()))
 		for i := 0; i < original.Len(); i++ {
-			copyRecursive(original.Index(i), cpy.Index(i))
+			if err := copyRecursive(original.Index(i), cpy.Index(i)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Array:
+		// 拷贝数组，元素可能包含引用类型
+		for i := 0; i < original.Len(); i++ {
+			if err := copyRecursive(original.Index(i), cpy.Index(i)); err != nil {
+				return err
+			}
 		}
 
 	case reflect.Map:
 		// 拷贝map
 		if original.IsNil() {
-			return
+			return nil
 		}
 		cpy.Set(reflect.MakeMap(original.Type()))
 		for _, key := range original.MapKeys() {
 			originalValue := original.MapIndex(key)
 			copyValue := reflect.New(originalValue.Type()).Elem()
-			copyRecursive(originalValue, copyValue)
+			if err := copyRecursive(originalValue, copyValue); err != nil {
+				return err
+			}
 			cpy.SetMapIndex(key, copyValue)
 		}
 
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return fmt.Errorf("%w: %s", ErrUncopyable, original.Type())
+
 	default:
 		// 直接设置基础类型
 		cpy.Set(original)
 	}
+	return nil
 }
 
-// Get 从缓存或数据库获取值
+// Get 从缓存或数据库获取值。开启 WithSafeReads 时返回深拷贝，修改需通过 Update 或 Set；
+// 热点 key 返回只读副本，见 WithReadReplicas；预热完成前的行为见 WithReadyGate
 func (c *CacheDB[T]) Get(key interface{}) (*T, error) {
+	if err := c.awaitReady(); err != nil {
+		return nil, err
+	}
+	if cpy := c.readReplica(key); cpy != nil {
+		return cpy, nil
+	}
+	value, err := c.get(key)
+	if err != nil {
+		return nil, err
+	}
+	if cpy, err := c.replicate(c.canonicalKey(key), value); cpy != nil || err != nil {
+		return cpy, err
+	}
+	c.recordHandoff(key)
+	return c.view(value)
+}
+
+// Update 在内部锁保护下修改缓存中的对象，修改会在之后的刷盘中写回数据库。
+// fn 中不能调用该缓存的方法；配置了复制器时复制失败会撤销修改并返回错误
+func (c *CacheDB[T]) Update(key interface{}, fn func(*T)) error {
+	key = c.canonicalKey(key)
+	for {
+		value, err := c.get(key)
+		if err != nil {
+			return err
+		}
+		c.mu.Lock()
+		// 与 Pin 相同：get 返回后对象可能已被淘汰并停止追踪，在它上面的修改会丢失，需要重新获取
+		if !c.current(key, value) {
+			c.mu.Unlock()
+			continue
+		}
+		err = c.updateLocked(key, value, fn)
+		c.mu.Unlock()
+		return err
+	}
+}
+
+// updateLocked 在 value 上执行 fn 并标记为脏，调用方需持有 c.mu 且 value 是 key 当前追踪的对象
+func (c *CacheDB[T]) updateLocked(key interface{}, value *T, fn func(*T)) error {
+	before, err := c.snapshotForReplication(key, value)
+	if err != nil {
+		return err
+	}
+	fn(value)
+	if err := c.replicateLocked(key, value, before); err != nil {
+		return err
+	}
+	c.meta[key].marked = true
+	c.bump(key)
+	c.recordMutation(key, "Update", 2)
+	c.touchCoalesce(key)
+	return nil
+}
+
+// MarkDirty 声明已经通过 Get 返回的指针直接修改了 key，之后的刷盘无需比较副本即可确定它需要写回。
+// 修改同样会递增代数；未缓存的 key 无需处理。配置了复制器时提交当前值，
+// 复制失败时返回错误，修改已在对象上，仍会保留并落库
+func (c *CacheDB[T]) MarkDirty(key interface{}) error {
+	key = c.canonicalKey(key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m := c.meta[key]
+	if m == nil {
+		return nil
+	}
+	m.marked = true
+	c.bump(key)
+	c.recordMutation(key, "MarkDirty", 1)
+	c.touchCoalesce(key)
+	return c.replicateLocked(key, c.values[key], nil)
+}
+
+// get 返回缓存中的对象本身
+func (c *CacheDB[T]) get(key interface{}) (*T, error) {
+	if err := c.checkOpen(); err != nil {
+		return nil, err
+	}
+	key, err := c.normalizeKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkQuarantine(key); err != nil {
+		return nil, err
+	}
 	val, err := c.Cache.Get(key)
 	if err != nil {
 		return nil, err
 	}
-	return val.(*T), nil
+	// 追踪的对象是权威版本：与 Set 并发的加载可能把旧对象放回 gcache
+	value := val.(*T)
+	c.mu.Lock()
+	tracked, ok := c.values[key]
+	c.mu.Unlock()
+	if ok && tracked != value {
+		if err := c.cacheSet(key, tracked); err != nil {
+			return nil, err
+		}
+		value = tracked
+	}
+	return value, nil
 }
 
-// Set 设置缓存值
+// Set 设置缓存值，新值被视为未落库的修改，会在之后的刷盘或淘汰时写回数据库。
+// Set 返回后的 Get 总是得到新值：并发的加载不会用数据库中的旧行覆盖它，淘汰时也会先回写再丢弃。
+// key 此前不在缓存中时不知道数据库中的旧值，刷盘时按整个对象写回。配置了复制器时需复制成功后才会生效
 func (c *CacheDB[T]) Set(key interface{}, value T) error {
+	if c.readOnly {
+		return ErrReadOnly
+	}
+	if err := c.checkOpen(); err != nil {
+		return err
+	}
+	key, err := c.normalizeKey(key)
+	if err != nil {
+		return err
+	}
+	if err := c.checkSize(key, &value); err != nil {
+		return err
+	}
+	if err := c.checkQuarantine(key); err != nil {
+		return err
+	}
+	return c.put(key, value, "Set", true)
+}
+
+// put 把 value 作为 key 的新值放入缓存并标记为脏，保留已有的副本作为数据库中的旧值。
+// replicate 为真时先在持有 c.mu 时经复制器提交，使跟随节点与本地写入的顺序一致，失败时不修改缓存
+func (c *CacheDB[T]) put(key interface{}, value T, op string, replicate bool) error {
+	c.mu.Lock()
+	if replicate {
+		if err := c.replicateLocked(key, &value, nil); err != nil {
+			c.mu.Unlock()
+			return err
+		}
+	}
+	if _, ok := c.copies[key]; !ok {
+		var unknown T // 旧值未知，与任何非零值都不相等
+		c.copies[key] = unknown
+		c.meta[key] = &entryMeta{since: c.clock.Now()}
+	}
+	c.values[key] = &value
+	c.meta[key].detached = false
+	c.meta[key].marked = true
+	c.bump(key)
+	c.recordMutation(key, op, 2)
+	c.touchCoalesce(key)
+	c.retag(key, &value)
+	c.markPresent(key)
+	delete(c.deleted, key)
+	c.mu.Unlock()
+
+	return c.cacheSet(key, &value)
+}
+
+// current 判断 value 是否仍是 key 当前追踪的对象，调用方需持有 c.mu
+func (c *CacheDB[T]) current(key, value interface{}) bool {
+	tracked, ok := c.values[key]
+	return ok && interface{}(tracked) == value
+}
+
+// setLocal 仅修改本地缓存，value 被视为与数据库一致
+func (c *CacheDB[T]) setLocal(key interface{}, value T) error {
 	// 保存深拷贝副本
-	copy := deepCopy(value)
-	c.copies[key] = copy
+	if err := c.track(key, &value); err != nil {
+		return err
+	}
 
-	return c.Cache.Set(key, &value)
+	return c.cacheSet(key, &value)
+}
+
+// Create 立即向数据库插入一行并放入缓存，返回缓存中的对象。
+// 由于行集合发生了变化，所有缓存的查询结果都会失效
+func (c *CacheDB[T]) Create(value T) (*T, error) {
+	if c.readOnly {
+		return nil, ErrReadOnly
+	}
+	if err := c.checkOpen(); err != nil {
+		return nil, err
+	}
+	if err := c.checkSize(c.keyOf(&value), &value); err != nil {
+		return nil, err
+	}
+	if err := c.checkValid(c.keyOf(&value), &value); err != nil {
+		return nil, err
+	}
+	release := c.acquireDB()
+	err := c.store.Insert(&value)
+	release()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create: %w", err)
+	}
+	c.queries.invalidateAll()
+	key := c.keyOf(&value)
+	for _, fn := range c.onCreateRow {
+		fn(key, value)
+	}
+
+	return c.adopt(key, &value)
+}
+
+// Delete 立即从数据库删除 key 对应的行并移出缓存，未落库的修改一并丢弃。
+// 所有缓存的查询结果都会失效
+func (c *CacheDB[T]) Delete(key interface{}) error {
+	if c.readOnly {
+		return ErrReadOnly
+	}
+	if err := c.checkOpen(); err != nil {
+		return err
+	}
+	key, err := c.normalizeKey(key)
+	if err != nil {
+		return err
+	}
+	row, found, err := c.deletedRow(key)
+	if err != nil {
+		return err
+	}
+	release := c.acquireDB()
+	err = c.store.Delete(key)
+	release()
+	if err != nil {
+		return fmt.Errorf("failed to delete key %v: %w", key, err)
+	}
+	c.queries.invalidateAll()
+	c.deleteL2(key)
+	for _, fn := range c.onDelete {
+		fn(key)
+	}
+	if found {
+		for _, fn := range c.onDeleteRow {
+			fn(key, row)
+		}
+	}
+
+	c.mu.Lock()
+	c.untrack(key)
+	delete(c.pins, key)
+	c.tombstone(key)
+	c.mu.Unlock()
+	c.Cache.Remove(key)
+	return nil
+}
+
+// Pin 获取并固定 key，被固定的对象即使被淘汰也只回写而不丢弃，
+// 保证所有持有者看到的是同一个指针。每次 Pin 需对应一次 Unpin
+func (c *CacheDB[T]) Pin(key interface{}) (*T, error) {
+	key = c.canonicalKey(key)
+	for {
+		value, err := c.get(key)
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		// get 返回后对象可能已被淘汰并停止追踪，此时固定它会让之后的修改丢失，需要重新获取
+		if c.current(key, value) {
+			c.pins[key]++
+			c.mu.Unlock()
+			return value, nil
+		}
+		c.mu.Unlock()
+	}
+}
+
+// resident 判断 key 是否常驻内存（被固定或处于全表模式），调用方需持有 c.mu
+func (c *CacheDB[T]) resident(key interface{}) bool {
+	return c.fullTable || c.pins[key] > 0
+}
+
+// Unpin 释放一次固定，引用计数归零后恢复正常淘汰。
+// 若对象在固定期间已被淘汰，则回写后停止追踪
+func (c *CacheDB[T]) Unpin(key interface{}) {
+	key = c.canonicalKey(key)
+	c.mu.Lock()
+	if c.pins[key] > 1 {
+		c.pins[key]--
+		c.mu.Unlock()
+		return
+	}
+	delete(c.pins, key)
+	value := c.values[key]
+	c.mu.Unlock()
+
+	if value == nil || c.Cache.Has(key) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.values[key] != value || c.resident(key) {
+		return // 期间已被重新加载或固定
+	}
+	if err := c.saveIfModified(key, value, FlushEvict); err != nil {
+		c.log(LogError, "Unpin save failed", "key", key, "err", err)
+		c.detach(key)
+		c.deadLetter(key)
+		return
+	}
+	c.untrack(key)
+}
+
+// Invalidate 丢弃 key 在本地的缓存（包括未落库的修改），用于数据库被外部修改的场景。
+// 常驻的对象无法丢弃，会改为原地刷新
+func (c *CacheDB[T]) Invalidate(key interface{}) {
+	key = c.canonicalKey(key)
+	c.mu.Lock()
+	if c.resident(key) {
+		c.mu.Unlock()
+		if err := c.Refresh(key); err != nil {
+			c.log(LogError, "Invalidate refresh failed", "key", key, "err", err)
+		}
+		return
+	}
+	c.untrack(key) // 先解除追踪，淘汰回调便不会回写
+	c.mu.Unlock()
+
+	c.Cache.Remove(key)
+	c.deleteL2(key)
+}
+
+// Refresh 丢弃未落库的修改并从数据库重新加载 key，未缓存的 key 无需处理。
+// 已缓存的对象会被原地覆盖，持有该指针的调用方能看到新值。模型带有更新时间字段时只在行被修改过时传输整行，见 reload
+func (c *CacheDB[T]) Refresh(key interface{}) error {
+	_, err := c.refresh(c.canonicalKey(key), nil)
+	return err
+}
+
+// refresh 从数据库重新加载已缓存的 key。cleanGen 非空时只在对象仍未修改且代数仍为 *cleanGen 时
+// 替换为新值，否则放弃本次结果；返回是否替换了对象
+func (c *CacheDB[T]) refresh(key interface{}, cleanGen *uint64) (bool, error) {
+	c.deleteL2(key) // 数据库可能已被外部修改，L2 中的值不再可信
+	c.mu.Lock()
+	_, tracked := c.values[key]
+	base := c.copies[key]
+	c.mu.Unlock()
+	if !tracked {
+		return false, nil
+	}
+
+	fresh, err := c.reload(key, base)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[key]
+	if !ok {
+		return false, nil // 期间已被淘汰，下次访问时会重新加载
+	}
+	if cleanGen != nil && (c.meta[key].gen != *cleanGen || c.modified(key, value)) {
+		return false, nil // 加载期间被修改，保留修改
+	}
+	if c.modified(key, value) {
+		c.log(LogWarn, "Discarded local changes on refresh", "key", key)
+	}
+	*value = fresh
+	if err := c.rebase(key, value); err != nil {
+		return false, err
+	}
+	c.bump(key)
+	c.settle(key)
+	return true, nil
+}
+
+// OnFlush 注册落库成功后的回调，old 为落库前的副本，new 为写入的值。
+// 回调在持有内部锁时同步执行，不能再调用该缓存的方法，需在使用缓存前注册
+func (c *CacheDB[T]) OnFlush(fn func(key interface{}, old, new T)) {
+	c.onFlush = append(c.onFlush, fn)
+}
+
+// OnDelete 注册通过 Delete 删除成功后的回调，需在使用缓存前注册
+func (c *CacheDB[T]) OnDelete(fn func(key interface{})) {
+	c.onDelete = append(c.onDelete, fn)
+}
+
+// OnCreateRow 注册通过 Create 插入成功后的回调，row 为插入的值（自增主键已回填）。
+// 回调不持有内部锁，需在使用缓存前注册
+func (c *CacheDB[T]) OnCreateRow(fn func(key interface{}, row T)) {
+	c.onCreateRow = append(c.onCreateRow, fn)
+}
+
+// OnDeleteRow 注册通过 Delete 删除成功后的回调，row 为删除前数据库中的行：已缓存时取副本，
+// 否则在删除前从数据库读取一次。行原本不存在时不调用。回调不持有内部锁，需在使用缓存前注册
+func (c *CacheDB[T]) OnDeleteRow(fn func(key interface{}, row T)) {
+	c.onDeleteRow = append(c.onDeleteRow, fn)
+}
+
+// deletedRow 返回 Delete 即将删除的行，供 OnDeleteRow 使用；没有注册回调时不读取
+func (c *CacheDB[T]) deletedRow(key interface{}) (T, bool, error) {
+	var row T
+	if len(c.onDeleteRow) == 0 {
+		return row, false, nil
+	}
+	c.mu.Lock()
+	cpy, ok := c.copies[key]
+	c.mu.Unlock()
+	var zero T
+	if ok && !c.equal(cpy, zero) { // 旧值未知（Set 一个未加载过的 key）时仍需读取
+		return cpy, true, nil
+	}
+	release := c.acquireDB()
+	row, err := c.store.Load(key)
+	release()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return row, false, nil
+	}
+	if err != nil {
+		return row, false, fmt.Errorf("failed to load key %v before delete: %w", key, err)
+	}
+	return row, true, nil
+}
+
+// Flush 将指定 key 的修改写回数据库，成功后以当前值作为新的副本。
+// 淘汰时回写失败而滞留在内存中的对象，落库成功后会停止追踪
+func (c *CacheDB[T]) Flush(key interface{}) error {
+	return c.flush(c.canonicalKey(key), FlushManual)
+}
+
+// flush 以 reason 写回 key 的修改，见 Flush
+func (c *CacheDB[T]) flush(key interface{}, reason FlushReason) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, ok := c.values[key]
+	if !ok {
+		return nil // 不在缓存中，无需刷盘
+	}
+	if err := c.saveIfModified(key, value, reason); err != nil {
+		return err
+	}
+	if err := c.rebase(key, value); err != nil {
+		return err
+	}
+	c.settle(key)
+	return nil
+}
+
+// FlushAll 将所有脏数据写回数据库，返回遇到的第一个错误。
+// 开启 WithBulkFlush 且存储支持 BatchSaver 时按其批大小批量写回，需要每个 key 的结果时使用 FlushAllReport
+func (c *CacheDB[T]) FlushAll() error {
+	return c.FlushAllReport().first
+}
+
+// DirtyKeys 返回当前与副本不一致的 key
+func (c *CacheDB[T]) DirtyKeys() []interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var keys []interface{}
+	for key, value := range c.values {
+		if c.modified(key, value) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
 }
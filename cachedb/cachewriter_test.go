@@ -1,7 +1,9 @@
 package cachedb
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -33,7 +35,10 @@ func TestNewWithCache(t *testing.T) {
 		t.Fatalf("failed to create user: %v", result.Error)
 	}
 
-	userCache := NewWithCache[User](db, 10)
+	userCache, err := NewWithCache[User](db, 10)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
 
 	// 从缓存获取用户
 	u, err := userCache.Cache.Get(user.ID)
@@ -63,3 +68,119 @@ func TestNewWithCache(t *testing.T) {
 	}
 
 }
+
+// openTestDB 为每个测试打开独立的内存数据库并迁移模型
+func openTestDB(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db
+}
+
+// newTestCache 创建缓存，失败时终止测试
+func newTestCache[T any](t *testing.T, db *gorm.DB, size int, opts ...Option[T]) *CacheDB[T] {
+	t.Helper()
+	c, err := NewWithCache[T](db, size, opts...)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	return c
+}
+
+func TestFlushAll(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, Gold: 10})
+	db.Create(&Player{ID: 2, Gold: 20})
+
+	c := newTestCache[Player](t, db, 10)
+	p1, _ := c.Get(uint(1))
+	if _, err := c.Get(uint(2)); err != nil {
+		t.Fatalf("failed to get: %v", err)
+	}
+	p1.Gold = 11
+
+	if keys := c.DirtyKeys(); len(keys) != 1 || keys[0] != uint(1) {
+		t.Fatalf("expected dirty key 1, got %v", keys)
+	}
+	if err := c.FlushAll(); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	if keys := c.DirtyKeys(); len(keys) != 0 {
+		t.Errorf("expected no dirty keys after flush, got %v", keys)
+	}
+
+	var got Player
+	db.First(&got, 1)
+	if got.Gold != 11 {
+		t.Errorf("expected gold 11 in db, got %d", got.Gold)
+	}
+}
+
+func TestEvictFailureKeepsChanges(t *testing.T) {
+	type Pet struct {
+		ID   uint
+		Name string `gorm:"unique"`
+	}
+	db := openTestDB(t, &Pet{})
+	db.Create(&Pet{ID: 1, Name: "rex"})
+	db.Create(&Pet{ID: 2, Name: "tom"})
+
+	c := newTestCache[Pet](t, db, 10)
+	pet, _ := c.Get(uint(1))
+	pet.Name = "tom" // 违反唯一约束，淘汰时回写会失败
+	c.Cache.Remove(uint(1))
+
+	if keys := c.DirtyKeys(); len(keys) != 1 || keys[0] != uint(1) {
+		t.Fatalf("expected key 1 to stay dirty, got %v", keys)
+	}
+	again, err := c.Get(uint(1))
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if again != pet || again.Name != "tom" {
+		t.Errorf("expected the unsaved object to be returned, got %+v", again)
+	}
+
+	// 再次滞留后修正冲突，Flush 成功即停止追踪
+	c.Cache.Remove(uint(1))
+	pet.Name = "max"
+	if err := c.Flush(uint(1)); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	c.mu.Lock()
+	_, tracked := c.values[uint(1)]
+	c.mu.Unlock()
+	if tracked {
+		t.Errorf("expected detached key to be untracked after a successful flush")
+	}
+	var stored Pet
+	db.First(&stored, 1)
+	if stored.Name != "max" {
+		t.Errorf("expected name max, got %q", stored.Name)
+	}
+}
+
+func TestDeepCopyTime(t *testing.T) {
+	type Session struct {
+		Start time.Time
+		End   *time.Time
+	}
+	now := time.Now()
+	src := Session{Start: now, End: &now}
+	cpy, err := DeepCopy(src)
+	if err != nil {
+		t.Fatalf("DeepCopy failed: %v", err)
+	}
+	if !reflect.DeepEqual(src, cpy) || cpy.End == src.End {
+		t.Errorf("expected an equal copy with a distinct pointer, got %+v", cpy)
+	}
+}
@@ -0,0 +1,92 @@
+package cachedb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// OnEvict 注册对象离开缓存（容量淘汰、过期或 EvictNow）后的回调，被固定或全表常驻的对象不会触发。
+// 回调在 gcache 的内部锁中执行，可以操作其他缓存，但不能再调用该缓存的方法；需在使用缓存前注册
+func (c *CacheDB[T]) OnEvict(fn func(key interface{})) {
+	c.onEvict = append(c.onEvict, fn)
+}
+
+// runEvictHooks 在淘汰回调结束后通知 OnEvict 的订阅者
+func (c *CacheDB[T]) runEvictHooks(key interface{}) {
+	for _, fn := range c.onEvict {
+		fn(key)
+	}
+}
+
+// cascadeParent 是级联失效中的父缓存，*CacheDB[T] 实现了该接口
+type cascadeParent interface {
+	OnDelete(fn func(key interface{}))
+	OnEvict(fn func(key interface{}))
+}
+
+// cascadeChild 是级联失效中的子缓存，*CacheDB[T] 实现了该接口
+type cascadeChild interface {
+	childKeys(column string, parent interface{}) []interface{}
+	hasColumn(column string) bool
+	Invalidate(key interface{})
+	EvictNow(key interface{}) bool
+	Flush(key interface{}) error
+}
+
+func (c *CacheDB[T]) hasColumn(column string) bool {
+	f := c.schema.LookUpField(column)
+	return f != nil && f.DBName != ""
+}
+
+// childKeys 返回 column 列等于 parent 的已缓存 key
+func (c *CacheDB[T]) childKeys(column string, parent interface{}) []interface{} {
+	f := c.schema.LookUpField(column)
+	want := fmt.Sprint(parent)
+	ctx := context.Background()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var keys []interface{}
+	for key, value := range c.values {
+		v, _ := f.ValueOf(ctx, reflect.ValueOf(value).Elem())
+		if fmt.Sprint(v) == want {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Cascade 声明 child 缓存中 column 列引用 parent 的 key（例如背包、邮件、任务的 player_id）。
+// 通过缓存删除父对象时，子缓存中引用它的对象被丢弃（未落库的修改一并丢弃，数据库中的子行由外键或业务自行清理）；
+// 父对象被淘汰时，子对象先落库再淘汰。只处理子缓存中已缓存的对象，需在缓存开始使用前声明，且不能形成环
+func (r *Registry) Cascade(parent, child, column string) error {
+	r.mu.RLock()
+	p, pok := r.caches[parent].(cascadeParent)
+	ch, cok := r.caches[child].(cascadeChild)
+	r.mu.RUnlock()
+	switch {
+	case parent == child:
+		return fmt.Errorf("cache %q cannot cascade to itself", parent)
+	case !pok:
+		return fmt.Errorf("cache %q not registered or does not support cascading", parent)
+	case !cok:
+		return fmt.Errorf("cache %q not registered or does not support cascading", child)
+	case !ch.hasColumn(column):
+		return fmt.Errorf("cache %q has no column %q", child, column)
+	}
+
+	p.OnDelete(func(key interface{}) {
+		for _, k := range ch.childKeys(column, key) {
+			ch.Invalidate(k)
+		}
+	})
+	p.OnEvict(func(key interface{}) {
+		for _, k := range ch.childKeys(column, key) {
+			if err := ch.Flush(k); err != nil {
+				continue // 写回失败的对象留在缓存中，由之后的刷盘重试
+			}
+			ch.EvictNow(k)
+		}
+	})
+	return nil
+}
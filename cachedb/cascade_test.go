@@ -0,0 +1,60 @@
+package cachedb
+
+import "testing"
+
+func TestCascade(t *testing.T) {
+	type Player struct {
+		ID uint
+	}
+	type Mail struct {
+		ID       uint
+		PlayerID uint
+		Read     bool
+	}
+	db := openTestDB(t, &Player{}, &Mail{})
+	db.Create(&Player{ID: 1})
+	db.Create(&Player{ID: 2})
+	db.Create(&Mail{ID: 10, PlayerID: 1})
+	db.Create(&Mail{ID: 11, PlayerID: 1})
+	db.Create(&Mail{ID: 20, PlayerID: 2})
+
+	r := NewRegistry()
+	r.AddDB("game", db)
+	players, _ := RegisterCache[Player](r, "players", "game", 10)
+	mails, _ := RegisterCache[Mail](r, "mails", "game", 10)
+	if err := r.Cascade("players", "mails", "nope"); err == nil {
+		t.Error("expected an unknown column to be rejected")
+	}
+	if err := r.Cascade("players", "mails", "player_id"); err != nil {
+		t.Fatal(err)
+	}
+
+	players.Get(1)
+	players.Get(2)
+	for _, id := range []int{10, 11, 20} {
+		mails.Update(id, func(m *Mail) { m.Read = true })
+	}
+
+	// 淘汰父对象：子对象先落库再淘汰
+	players.EvictNow(1)
+	if n := mails.Stats().Tracked; n != 1 {
+		t.Errorf("expected player 1's mails to be evicted, %d still tracked", n)
+	}
+	var read int64
+	db.Model(&Mail{}).Where("player_id = ? AND read = ?", 1, true).Count(&read)
+	if read != 2 {
+		t.Errorf("expected evicted mails to be written back, got %d", read)
+	}
+
+	// 删除父对象：子对象被丢弃
+	if err := players.Delete(2); err != nil {
+		t.Fatal(err)
+	}
+	if n := mails.Stats().Tracked; n != 0 {
+		t.Errorf("expected player 2's mails to be dropped, %d still tracked", n)
+	}
+	db.Model(&Mail{}).Where("player_id = ? AND read = ?", 2, true).Count(&read)
+	if read != 0 {
+		t.Errorf("expected dropped mail changes to be discarded, got %d", read)
+	}
+}
@@ -0,0 +1,96 @@
+package cachedb
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"strings"
+	"time"
+)
+
+// KafkaProducer 是 CDC 写入 Kafka 所需的最小接口，使用方用 sarama、franz-go 等客户端实现，
+// 测试中可以使用记录消息的 cachedbtest.MemKafka。
+// Produce 在持有缓存内部锁时调用，应只把消息放入客户端的异步发送队列
+type KafkaProducer interface {
+	Produce(topic string, key, value []byte) error
+}
+
+// ChangeSchema 描述变更记录中字段的结构，Version 随模型字段变化而改变，消费方据此发现模型升级
+type ChangeSchema struct {
+	Name    string        `json:"name"`
+	Version string        `json:"version"`
+	Fields  []SchemaField `json:"fields"`
+}
+
+// SchemaField 是模型中一个持久化字段
+type SchemaField struct {
+	Name   string `json:"name"`
+	Column string `json:"column"`
+	Type   string `json:"type"`
+}
+
+// ChangeRecord 是发送到 Kafka 的一条变更，每次落库对应一条，只包含发生变化的字段
+type ChangeRecord struct {
+	Schema  ChangeSchema  `json:"schema"`
+	Entity  string        `json:"entity"`
+	Key     string        `json:"key"`
+	Time    time.Time     `json:"ts"`
+	Reason  FlushReason   `json:"reason"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// WithCDC 在每次落库成功后把字段修改作为 ChangeRecord 以 JSON 写入 topic，消息 key 为 NamespacedKey，
+// 同一个 key 的变更进入同一分区并保持顺序。被 `cache:"redact"` 标记的字段值会被遮蔽。
+// 发送失败只记录日志，不影响落库
+func WithCDC[T any](producer KafkaProducer, topic string) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.cdc = &cdcSink{producer: producer, topic: topic}
+	}
+}
+
+// cdcSink 保存 CDC 的目标和构造时生成的 schema
+type cdcSink struct {
+	producer KafkaProducer
+	topic    string
+	schema   ChangeSchema
+}
+
+// changeSchema 根据模型的持久化字段生成 schema
+func (c *CacheDB[T]) changeSchema() ChangeSchema {
+	s := ChangeSchema{Name: c.schema.Name}
+	var sig []string
+	for _, f := range c.schema.Fields {
+		if f.DBName == "" {
+			continue
+		}
+		sf := SchemaField{Name: f.Name, Column: f.DBName, Type: f.FieldType.String()}
+		s.Fields = append(s.Fields, sf)
+		sig = append(sig, sf.Column+" "+sf.Type)
+	}
+	s.Version = fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(strings.Join(sig, ","))))
+	return s
+}
+
+// publishChange 是发送变更记录的 OnFlush 回调
+func (c *CacheDB[T]) publishChange(key interface{}, old, new T) {
+	changes := c.diff(&old, &new)
+	if len(changes) == 0 {
+		return
+	}
+	c.redactChanges(changes)
+	record := ChangeRecord{
+		Schema:  c.cdc.schema,
+		Entity:  c.qualifiedEntity(),
+		Key:     fmt.Sprint(key),
+		Time:    c.clock.Now(),
+		Reason:  c.flushReason,
+		Changes: changes,
+	}
+	data, err := json.Marshal(record)
+	if err == nil {
+		err = c.cdc.producer.Produce(c.cdc.topic, []byte(c.NamespacedKey(key)), data)
+	}
+	if err != nil {
+		c.log(LogError, "CDC publish failed", "key", key, "err", err)
+	}
+}
@@ -0,0 +1,52 @@
+package cachedb
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type recordProducer struct {
+	topics []string
+	keys   []string
+	values [][]byte
+}
+
+func (p *recordProducer) Produce(topic string, key, value []byte) error {
+	p.topics = append(p.topics, topic)
+	p.keys = append(p.keys, string(key))
+	p.values = append(p.values, value)
+	return nil
+}
+
+func TestCDC(t *testing.T) {
+	type Hero struct {
+		ID    uint
+		Level int
+		Token string `cache:"redact"`
+	}
+	db := openTestDB(t, &Hero{})
+	db.Create(&Hero{ID: 1, Level: 1, Token: "a"})
+	p := &recordProducer{}
+	c := newTestCache[Hero](t, db, 10, WithCDC[Hero](p, "player-changes"))
+
+	c.Update(1, func(h *Hero) { h.Level = 3; h.Token = "b" })
+	if err := c.Flush(1); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	c.Update(1, func(*Hero) {}) // 没有字段变化时不发送
+	c.Flush(1)
+
+	if len(p.values) != 1 || p.topics[0] != "player-changes" || p.keys[0] != "heros:1" {
+		t.Fatalf("unexpected produced messages %v %v", p.topics, p.keys)
+	}
+	var rec ChangeRecord
+	if err := json.Unmarshal(p.values[0], &rec); err != nil {
+		t.Fatalf("invalid record: %v", err)
+	}
+	if rec.Entity != "heros" || rec.Key != "1" || len(rec.Schema.Fields) != 3 || rec.Schema.Version == "" {
+		t.Errorf("unexpected record header %+v", rec)
+	}
+	if len(rec.Changes) != 2 || rec.Changes[0].Column != "level" || rec.Changes[0].New != float64(3) || rec.Changes[1].New != RedactMask {
+		t.Errorf("unexpected changes %+v", rec.Changes)
+	}
+}
@@ -0,0 +1,123 @@
+package cachedb
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ErrInjected 是 ChaosStore 注入的错误
+var ErrInjected = errors.New("injected store failure")
+
+// ChaosConfig 描述故障注入的概率，取值范围 [0, 1]
+type ChaosConfig struct {
+	Latency     time.Duration // 每次调用前的固定延迟
+	Jitter      time.Duration // 在 Latency 基础上增加的随机延迟上限
+	ErrorRate   float64       // 调用直接失败、不触达底层存储的概率
+	PartialRate float64       // 写入已成功但仍返回错误的概率，模拟提交后连接断开
+	Seed        int64         // 随机数种子，相同的种子得到相同的故障序列
+}
+
+// ChaosStore 包装另一个 Store 并按配置注入延迟和故障，用于在模拟的数据库故障下
+// 验证回写不丢数据。通过 WithStore 接入：
+//
+//	WithStore(func(base Store[T]) Store[T] { return NewChaosStore(base, cfg) })
+type ChaosStore[T any] struct {
+	inner Store[T]
+
+	mu    sync.Mutex // 保护 cfg、rng 和统计
+	cfg   ChaosConfig
+	rng   *rand.Rand
+	stats ChaosStats
+}
+
+// ChaosStats 是已注入的故障次数
+type ChaosStats struct {
+	Calls    int // 总调用次数
+	Errors   int // 直接失败的次数
+	Partials int // 写入成功但返回错误的次数
+}
+
+// NewChaosStore 创建故障注入存储
+func NewChaosStore[T any](inner Store[T], cfg ChaosConfig) *ChaosStore[T] {
+	return &ChaosStore[T]{
+		inner: inner,
+		cfg:   cfg,
+		rng:   rand.New(rand.NewSource(cfg.Seed)),
+	}
+}
+
+// SetConfig 修改故障配置，例如模拟数据库恢复
+func (s *ChaosStore[T]) SetConfig(cfg ChaosConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
+}
+
+// Stats 返回已注入的故障次数
+func (s *ChaosStore[T]) Stats() ChaosStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// roll 决定本次调用的延迟与故障类型
+func (s *ChaosStore[T]) roll() (delay time.Duration, fail, partial bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.Calls++
+	delay = s.cfg.Latency
+	if s.cfg.Jitter > 0 {
+		delay += time.Duration(s.rng.Int63n(int64(s.cfg.Jitter)))
+	}
+	switch r := s.rng.Float64(); {
+	case r < s.cfg.ErrorRate:
+		s.stats.Errors++
+		fail = true
+	case r < s.cfg.ErrorRate+s.cfg.PartialRate:
+		s.stats.Partials++
+		partial = true
+	}
+	return delay, fail, partial
+}
+
+// inject 按配置执行 fn，读操作不会出现部分失败
+func (s *ChaosStore[T]) inject(write bool, fn func() error) error {
+	delay, fail, partial := s.roll()
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	if fail {
+		return ErrInjected
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	if partial && write {
+		return ErrInjected
+	}
+	return nil
+}
+
+func (s *ChaosStore[T]) Load(key interface{}) (T, error) {
+	var entity T
+	err := s.inject(false, func() error {
+		var err error
+		entity, err = s.inner.Load(key)
+		return err
+	})
+	return entity, err
+}
+
+func (s *ChaosStore[T]) Save(key interface{}, old, new *T) error {
+	return s.inject(true, func() error { return s.inner.Save(key, old, new) })
+}
+
+func (s *ChaosStore[T]) Insert(value *T) error {
+	return s.inject(true, func() error { return s.inner.Insert(value) })
+}
+
+func (s *ChaosStore[T]) Delete(key interface{}) error {
+	return s.inject(true, func() error { return s.inner.Delete(key) })
+}
@@ -0,0 +1,53 @@
+package cachedb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestChaosWriteBackDurability(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	for i := 1; i <= 10; i++ {
+		db.Create(&Player{ID: uint(i)})
+	}
+
+	var chaos *ChaosStore[Player]
+	c := newTestCache[Player](t, db, 4, WithStore(func(base Store[Player]) Store[Player] {
+		chaos = NewChaosStore(base, ChaosConfig{ErrorRate: 0.4, PartialRate: 0.2, Seed: 42})
+		return chaos
+	}))
+
+	want := make(map[uint]int)
+	for round := 1; round <= 5; round++ {
+		for i := uint(1); i <= 10; i++ {
+			err := c.Update(i, func(p *Player) { p.Gold += round })
+			if errors.Is(err, ErrInjected) {
+				continue // 加载失败，本轮跳过
+			}
+			if err != nil {
+				t.Fatalf("Update failed: %v", err)
+			}
+			want[i] += round
+		}
+	}
+	if s := chaos.Stats(); s.Errors == 0 || s.Partials == 0 {
+		t.Fatalf("expected injected failures, got %+v", s)
+	}
+
+	// 数据库恢复后，所有修改都应能写回
+	chaos.SetConfig(ChaosConfig{})
+	if err := c.FlushAll(); err != nil {
+		t.Fatalf("FlushAll failed: %v", err)
+	}
+	var players []Player
+	db.Find(&players)
+	for _, p := range players {
+		if p.Gold != want[p.ID] {
+			t.Errorf("player %d: expected gold %d, got %d", p.ID, want[p.ID], p.Gold)
+		}
+	}
+}
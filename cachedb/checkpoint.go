@@ -0,0 +1,364 @@
+package cachedb
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Flusher 是检查点调度所需的最小接口，*CacheDB[T] 实现了该接口
+type Flusher interface {
+	DirtyKeys() []interface{}
+	Flush(key interface{}) error
+}
+
+// flushAutosave 以 FlushAutosave 写回 key，f 不支持 FlushWithReason 时使用 Flush
+func flushAutosave(f Flusher, key interface{}) error {
+	if r, ok := f.(interface {
+		FlushWithReason(key interface{}, reason FlushReason) error
+	}); ok {
+		return r.FlushWithReason(key, FlushAutosave)
+	}
+	return f.Flush(key)
+}
+
+// Schedule 计算下一次检查点的触发时间
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// Every 返回按固定间隔触发的调度
+func Every(d time.Duration) Schedule {
+	return everySchedule(d)
+}
+
+type everySchedule time.Duration
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
+
+// cronSchedule 用位图表示每个字段允许的取值
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// cronField 描述 cron 表达式中一个字段的取值范围
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// ParseCron 解析 5 段式 cron 表达式（分 时 日 月 周），
+// 每段支持 *、*/n、a-b、a-b/n 以及逗号分隔的列表
+func ParseCron(spec string) (Schedule, error) {
+	parts := strings.Fields(spec)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("invalid cron spec %q: expected %d fields", spec, len(cronFields))
+	}
+
+	var bits [5]uint64
+	for i, part := range parts {
+		b, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron spec %q: %w", spec, err)
+		}
+		bits[i] = b
+	}
+
+	return &cronSchedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: parts[2] == "*",
+		dowAny: parts[4] == "*",
+	}, nil
+}
+
+// parseCronField 解析单个字段为位图
+func parseCronField(expr string, f cronField) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(expr, ",") {
+		rangePart, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("bad step in %s field: %q", f.name, item)
+			}
+			rangePart, step = item[:i], n
+		}
+
+		lo, hi := f.min, f.max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("bad value in %s field: %q", f.name, item)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("bad value in %s field: %q", f.name, item)
+				}
+			} else if step > 1 {
+				hi = f.max // "a/n" 表示从 a 开始每 n 个取一次
+			}
+		}
+		if lo < f.min || hi > f.max || lo > hi {
+			return 0, fmt.Errorf("%s field out of range: %q", f.name, item)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next 返回 t 之后第一个满足表达式的整分钟时刻，五年内无匹配时返回零值
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches 遵循标准 cron 语义：日和周都被限制时满足其一即可
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Window 表示每天的一个时间段（距零点的偏移），End 小于 Start 时表示跨越午夜
+type Window struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// contains 判断 t 是否落在时间段内
+func (w Window) contains(t time.Time) bool {
+	offset := sinceMidnight(t)
+	if w.Start <= w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// endAfter 返回包含 t 的时间段的结束时刻
+func (w Window) endAfter(t time.Time) time.Time {
+	midnight := t.Add(-sinceMidnight(t))
+	end := midnight.Add(w.End)
+	if !end.After(t) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	y, m, d := t.Date()
+	return t.Sub(time.Date(y, m, d, 0, 0, 0, 0, t.Location()))
+}
+
+// CheckpointConfig 检查点调度配置
+type CheckpointConfig struct {
+	Schedule Schedule      // 触发时间
+	Spread   time.Duration // 一次检查点的刷盘分摊到多长时间内完成
+	Batches  int           // 分批数量，默认 10
+	Tick     time.Duration // 大于 0 时改为每个 Tick 刷 Fraction 比例的脏 key，忽略 Spread 和 Batches
+	Fraction float64       // 配合 Tick 使用的每批比例，默认 0.05
+	Peaks    []Window      // 高峰期，期间不执行刷盘
+	Clock    Clock         // 时间来源，默认使用系统时间
+	Logger   Logger        // 刷盘失败的日志输出，默认打印到标准输出
+}
+
+// Checkpointer 按计划对一组缓存执行错峰的全量刷盘
+type Checkpointer struct {
+	cfg      CheckpointConfig
+	flushers []Flusher
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewCheckpointer 创建检查点调度器，调用 Start 后开始运行
+func NewCheckpointer(cfg CheckpointConfig, flushers ...Flusher) *Checkpointer {
+	if cfg.Batches <= 0 {
+		cfg.Batches = 10
+	}
+	if cfg.Tick > 0 && cfg.Fraction <= 0 {
+		cfg.Fraction = 0.05
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = realClock{}
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = stdLogger{}
+	}
+	return &Checkpointer{
+		cfg:      cfg,
+		flushers: flushers,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start 启动后台调度
+func (cp *Checkpointer) Start() {
+	cp.startOnce.Do(func() {
+		go cp.loop()
+	})
+}
+
+// Stop 停止调度并等待正在进行的检查点结束当前批次
+func (cp *Checkpointer) Stop() {
+	cp.stopOnce.Do(func() {
+		close(cp.stop)
+	})
+	cp.startOnce.Do(func() {
+		close(cp.done) // 从未启动
+	})
+	<-cp.done
+}
+
+func (cp *Checkpointer) loop() {
+	defer close(cp.done)
+	for {
+		next := cp.cfg.Schedule.Next(cp.cfg.Clock.Now())
+		if next.IsZero() {
+			return
+		}
+		if !cp.sleepUntil(next) {
+			return
+		}
+		if err := cp.RunOnce(); err != nil {
+			cp.cfg.Logger.Log(LogError, "Checkpoint failed", "err", err)
+		}
+	}
+}
+
+// RunOnce 立即执行一次检查点：收集所有脏 key，分批在 Spread 时间内刷盘，
+// 遇到高峰期时暂停到高峰结束。返回遇到的第一个错误
+func (cp *Checkpointer) RunOnce() error {
+	type dirtyKey struct {
+		f   Flusher
+		key interface{}
+	}
+	var pending []dirtyKey
+	for _, f := range cp.flushers {
+		for _, key := range f.DirtyKeys() {
+			pending = append(pending, dirtyKey{f, key})
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	batches := cp.cfg.Batches
+	if batches > len(pending) {
+		batches = len(pending)
+	}
+	interval := cp.cfg.Spread / time.Duration(batches)
+	batchSize := (len(pending) + batches - 1) / batches
+	if cp.cfg.Tick > 0 {
+		interval = cp.cfg.Tick
+		batchSize = max(int(math.Ceil(float64(len(pending))*cp.cfg.Fraction)), 1)
+	}
+
+	var firstErr error
+	for start := 0; start < len(pending); start += batchSize {
+		if !cp.waitOffPeak() {
+			return firstErr
+		}
+		end := min(start+batchSize, len(pending))
+		for _, p := range pending[start:end] {
+			if err := flushAutosave(p.f, p.key); err != nil {
+				cp.cfg.Logger.Log(LogError, "Checkpoint flush failed", "key", p.key, "err", err)
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+		if end < len(pending) && !cp.sleepUntil(cp.cfg.Clock.Now().Add(interval)) {
+			return firstErr
+		}
+	}
+	return firstErr
+}
+
+// waitOffPeak 处于高峰期时等待其结束，调度器被停止时返回 false
+func (cp *Checkpointer) waitOffPeak() bool {
+	for {
+		now := cp.cfg.Clock.Now()
+		peak := -1
+		for i, w := range cp.cfg.Peaks {
+			if w.contains(now) {
+				peak = i
+				break
+			}
+		}
+		if peak < 0 {
+			return true
+		}
+		if !cp.sleepUntil(cp.cfg.Peaks[peak].endAfter(now)) {
+			return false
+		}
+	}
+}
+
+// sleepUntil 睡眠到指定时刻，调度器被停止时返回 false
+func (cp *Checkpointer) sleepUntil(t time.Time) bool {
+	d := t.Sub(cp.cfg.Clock.Now())
+	if d <= 0 {
+		select {
+		case <-cp.stop:
+			return false
+		default:
+			return true
+		}
+	}
+	select {
+	case <-cp.cfg.Clock.After(d):
+		return true
+	case <-cp.stop:
+		return false
+	}
+}
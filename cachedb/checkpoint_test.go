@@ -0,0 +1,98 @@
+package cachedb
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	base := time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC) // 周一
+
+	cases := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, 1, 1, 10, 45, 0, 0, time.UTC)},
+		{"0 4 * * *", time.Date(2024, 1, 2, 4, 0, 0, 0, time.UTC)},
+		{"0 3 * * 0", time.Date(2024, 1, 7, 3, 0, 0, 0, time.UTC)},
+		{"0 0 1 2 *", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		s, err := ParseCron(tc.spec)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tc.spec, err)
+		}
+		if got := s.Next(base); !got.Equal(tc.want) {
+			t.Errorf("ParseCron(%q).Next = %v, want %v", tc.spec, got, tc.want)
+		}
+	}
+
+	for _, bad := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := ParseCron(bad); err == nil {
+			t.Errorf("ParseCron(%q) expected error", bad)
+		}
+	}
+}
+
+func TestWindow(t *testing.T) {
+	night := Window{Start: 22 * time.Hour, End: 2 * time.Hour}
+	at := time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)
+	if !night.contains(at) {
+		t.Fatalf("expected %v inside window", at)
+	}
+	if end := night.endAfter(at); !end.Equal(time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected window end %v", end)
+	}
+	if night.contains(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("noon should be outside window")
+	}
+}
+
+func TestCheckpointerRunOnce(t *testing.T) {
+	type Hero struct {
+		ID    uint
+		Level int
+	}
+	db := openTestDB(t, &Hero{})
+	for i := 1; i <= 5; i++ {
+		db.Create(&Hero{ID: uint(i), Level: 1})
+	}
+
+	c := newTestCache[Hero](t, db, 10)
+	for i := 1; i <= 5; i++ {
+		h, err := c.Get(uint(i))
+		if err != nil {
+			t.Fatalf("failed to get: %v", err)
+		}
+		h.Level = 2
+	}
+
+	cp := NewCheckpointer(CheckpointConfig{Schedule: Every(time.Hour), Batches: 2}, c)
+	if err := cp.RunOnce(); err != nil {
+		t.Fatalf("checkpoint failed: %v", err)
+	}
+
+	var count int64
+	db.Model(&Hero{}).Where("level = ?", 2).Count(&count)
+	if count != 5 {
+		t.Errorf("expected 5 flushed rows, got %d", count)
+	}
+}
+
+// failingFlusher 的刷盘总是失败
+type failingFlusher struct{}
+
+func (failingFlusher) DirtyKeys() []interface{} { return []interface{}{1} }
+func (failingFlusher) Flush(interface{}) error  { return errors.New("db down") }
+
+func TestCheckpointerLogsFailures(t *testing.T) {
+	logger := &recordLogger{}
+	cp := NewCheckpointer(CheckpointConfig{Schedule: Every(time.Hour), Logger: logger}, failingFlusher{})
+	if err := cp.RunOnce(); err == nil {
+		t.Fatal("expected the checkpoint to fail")
+	}
+	if entries := logger.find("Checkpoint flush failed"); len(entries) != 1 || entries[0].level != LogError {
+		t.Errorf("expected the failure to reach the configured logger, got %+v", logger.entries)
+	}
+}
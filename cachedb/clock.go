@@ -0,0 +1,23 @@
+package cachedb
+
+import "time"
+
+// Clock 抽象当前时间与定时等待，测试中可替换为手动推进的时钟（见 cachedbtest.Clock）。
+// Clock 同时满足 gcache.Clock，缓存条目的过期也使用同一个时钟
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock 使用系统时间
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// WithClock 指定 CacheDB 使用的时钟，影响缓存过期、查询结果的有效期和待落库时长
+func WithClock[T any](clock Clock) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.clock = clock
+	}
+}
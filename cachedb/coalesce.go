@@ -0,0 +1,113 @@
+package cachedb
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// coalescer 按 key 合并短时间内的连续修改，见 WithCoalesce
+type coalescer struct {
+	window   time.Duration
+	maxDelay time.Duration
+
+	mu      sync.Mutex
+	pending map[interface{}]*coalesced
+	stop    chan struct{} // Close 时关闭
+	once    sync.Once
+	merged  atomic.Uint64 // 被合并到同一次写入中的修改次数
+}
+
+// coalesced 是一个等待合并写入的 key
+type coalesced struct {
+	first time.Time // 本轮第一次修改的时间
+	last  time.Time // 最近一次修改的时间
+}
+
+// WithCoalesce 在 key 被 Update、Set 修改后自动落库：window 内没有新的修改时写入一次，
+// 连续修改合并为一次数据库写入；但自本轮第一次修改起最多等待 maxDelay，持续被修改的 key 也会按时落库。
+// 落库失败时记录日志，修改保持为脏，等待下一次修改、Flush 或淘汰时重试。Close 时停止等待并由 FlushAll 写回。
+// 与 WithGameLoop 同时使用时，到期的落库排队由主循环执行
+func WithCoalesce[T any](window, maxDelay time.Duration) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.coalesce = &coalescer{
+			window:   window,
+			maxDelay: maxDelay,
+			pending:  make(map[interface{}]*coalesced),
+			stop:     make(chan struct{}),
+		}
+	}
+}
+
+// check 在构造时检查合并窗口
+func (co *coalescer) check() error {
+	if co.window <= 0 {
+		return fmt.Errorf("WithCoalesce requires a positive window")
+	}
+	if co.maxDelay < co.window {
+		return fmt.Errorf("WithCoalesce: max delay %v is shorter than window %v", co.maxDelay, co.window)
+	}
+	return nil
+}
+
+// due 返回 p 应当落库的时间
+func (co *coalescer) due(p *coalesced) time.Time {
+	at := p.last.Add(co.window)
+	if deadline := p.first.Add(co.maxDelay); deadline.Before(at) {
+		return deadline
+	}
+	return at
+}
+
+// touchCoalesce 记录 key 被修改，本轮的第一次修改启动等待落库的协程。调用方可以持有 c.mu
+func (c *CacheDB[T]) touchCoalesce(key interface{}) {
+	co := c.coalesce
+	if co == nil || c.readOnly {
+		return
+	}
+	now := c.clock.Now()
+	co.mu.Lock()
+	defer co.mu.Unlock()
+	if p, ok := co.pending[key]; ok {
+		p.last = now
+		co.merged.Add(1)
+		return
+	}
+	co.pending[key] = &coalesced{first: now, last: now}
+	go c.awaitCoalesce(key)
+}
+
+// awaitCoalesce 等到 key 的合并窗口结束后落库
+func (c *CacheDB[T]) awaitCoalesce(key interface{}) {
+	co := c.coalesce
+	for {
+		co.mu.Lock()
+		wait := co.due(co.pending[key]).Sub(c.clock.Now())
+		if wait <= 0 {
+			delete(co.pending, key) // 之后的修改开始新的一轮
+		}
+		co.mu.Unlock()
+		if wait <= 0 {
+			break
+		}
+		select {
+		case <-c.clock.After(wait):
+		case <-co.stop:
+			return
+		}
+	}
+	// 单线程模式下等待不访问对象，落库本身交给主循环执行
+	c.Post(func() {
+		if err := c.flush(key, FlushAutosave); err != nil {
+			c.log(LogError, "Coalesced flush failed", "key", key, "err", err)
+		}
+	})
+}
+
+// stopCoalesce 停止所有等待中的合并写入，由 Close 调用
+func (c *CacheDB[T]) stopCoalesce() {
+	if c.coalesce != nil {
+		c.coalesce.once.Do(func() { close(c.coalesce.stop) })
+	}
+}
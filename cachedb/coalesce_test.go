@@ -0,0 +1,69 @@
+package cachedb
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingStore 统计 Save 的次数
+type countingStore[T any] struct {
+	Store[T]
+	saves *atomic.Int64
+}
+
+func (s countingStore[T]) Save(key interface{}, old, new *T) error {
+	s.saves.Add(1)
+	return s.Store.Save(key, old, new)
+}
+
+func TestCoalesce(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1})
+	db.Create(&Player{ID: 2})
+
+	if _, err := NewWithCache[Player](db, 10, WithCoalesce[Player](time.Second, time.Millisecond)); err == nil {
+		t.Error("expected a max delay shorter than the window to be rejected")
+	}
+	var saves atomic.Int64
+	c := newTestCache[Player](t, db, 10,
+		WithCoalesce[Player](30*time.Millisecond, 150*time.Millisecond),
+		WithStore(func(base Store[Player]) Store[Player] {
+			return countingStore[Player]{Store: base, saves: &saves}
+		}))
+
+	// 窗口内的连续修改只写一次
+	for i := 0; i < 5; i++ {
+		c.Update(1, func(p *Player) { p.Gold++ })
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := saves.Load(); n != 1 {
+		t.Errorf("expected 1 coalesced write, got %d", n)
+	}
+	var stored Player
+	db.First(&stored, 1)
+	if stored.Gold != 5 {
+		t.Errorf("expected gold 5 in the database, got %d", stored.Gold)
+	}
+	if n := c.Stats().CoalescedWrites; n != 4 {
+		t.Errorf("expected 4 merged modifications, got %d", n)
+	}
+
+	// 持续修改的 key 不会超过 maxDelay
+	saves.Store(0)
+	start := time.Now()
+	for time.Since(start) < 250*time.Millisecond {
+		c.Set(2, Player{ID: 2, Gold: int(time.Since(start))})
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := saves.Load(); n < 1 {
+		t.Error("expected a continuously modified key to be written within max delay")
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,209 @@
+package cachedb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// WithCollections 让 fields 指定的一对多（has many）和多对多（many2many）关联随对象一起加载，
+// 落库时按元素主键比较新旧集合，只对增加、删除和修改过的元素执行 INSERT、DELETE、UPDATE，
+// 而不是重写整个集合；多对多只增删中间表的行，已有元素本身不写回。
+// 父对象与集合的写入在同一个事务中。JSON 序列化的数组列没有元素主键，仍按整列写入
+func WithCollections[T any](fields ...string) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.collectionFields = append(c.collectionFields, fields...)
+	}
+}
+
+// resolveCollections 在构造时解析 WithCollections 指定的关联
+func (c *CacheDB[T]) resolveCollections() error {
+	for _, name := range c.collectionFields {
+		rel := c.schema.Relationships.Relations[name]
+		if rel == nil || (rel.Type != schema.HasMany && rel.Type != schema.Many2Many) {
+			return fmt.Errorf("WithCollections: %s is not a has many or many2many association", name)
+		}
+		if len(rel.FieldSchema.PrimaryFields) == 0 {
+			return fmt.Errorf("WithCollections: elements of %s have no primary key", name)
+		}
+		c.collections = append(c.collections, rel)
+	}
+	return nil
+}
+
+// preload 让查询一并加载 WithCollections 指定的关联
+func (c *CacheDB[T]) preload(db *gorm.DB) *gorm.DB {
+	for _, rel := range c.collections {
+		db = db.Preload(rel.Name)
+	}
+	return db
+}
+
+// element 是集合中的一个元素，ptr 指向元素本身，id 为主键，新元素的 id 为空
+type element struct {
+	id  string
+	ptr reflect.Value
+}
+
+// collectionElems 列出 parent 中 rel 集合的元素
+func collectionElems(ctx context.Context, rel *schema.Relationship, parent reflect.Value) []element {
+	list := reflect.Indirect(rel.Field.ReflectValueOf(ctx, parent))
+	elems := make([]element, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		ptr := list.Index(i)
+		if ptr.Kind() != reflect.Ptr {
+			ptr = ptr.Addr()
+		} else if ptr.IsNil() {
+			continue
+		}
+		elems = append(elems, element{id: elementID(ctx, rel.FieldSchema, ptr), ptr: ptr})
+	}
+	return elems
+}
+
+// elementID 返回元素主键的字符串形式，主键为零值时返回空串
+func elementID(ctx context.Context, s *schema.Schema, ptr reflect.Value) string {
+	ids := make([]interface{}, len(s.PrimaryFields))
+	for i, f := range s.PrimaryFields {
+		v, zero := f.ValueOf(ctx, ptr)
+		if zero {
+			return ""
+		}
+		ids[i] = v
+	}
+	return fmt.Sprint(ids)
+}
+
+// saveCollections 把 new 中各集合相对 old 的增删写入 db，db 为与父对象同一事务的新会话
+func (c *CacheDB[T]) saveCollections(db *gorm.DB, old, new *T) error {
+	ctx := db.Statement.Context
+	ov, nv := reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem()
+	for _, rel := range c.collections {
+		before := make(map[string]reflect.Value)
+		for _, e := range collectionElems(ctx, rel, ov) {
+			if e.id != "" {
+				before[e.id] = e.ptr
+			}
+		}
+		var err error
+		if rel.Type == schema.Many2Many {
+			err = saveJoinRows(db, rel, nv, before, collectionElems(ctx, rel, nv))
+		} else {
+			err = saveChildren(db, rel, nv, before, collectionElems(ctx, rel, nv))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save collection %s: %w", rel.Name, err)
+		}
+	}
+	return nil
+}
+
+// saveChildren 写入一对多集合：新元素按父对象设置外键后插入，消失的元素按主键删除，
+// 修改过的元素只更新变化的列。before 为旧集合中按主键索引的元素
+func saveChildren(db *gorm.DB, rel *schema.Relationship, parent reflect.Value, before map[string]reflect.Value, after []element) error {
+	ctx := db.Statement.Context
+	for _, e := range after {
+		for _, ref := range rel.References {
+			var err error
+			if ref.OwnPrimaryKey {
+				v, _ := ref.PrimaryKey.ValueOf(ctx, parent)
+				err = ref.ForeignKey.Set(ctx, e.ptr, v)
+			} else if ref.PrimaryValue != "" {
+				err = ref.ForeignKey.Set(ctx, e.ptr, ref.PrimaryValue) // 多态关联的类型列
+			}
+			if err != nil {
+				return err
+			}
+		}
+		old, ok := before[e.id]
+		if !ok {
+			// 旧副本中没有的元素可能已在数据库中（例如 Set 覆盖的对象），按主键 upsert
+			err := db.Omit(clause.Associations).Clauses(clause.OnConflict{UpdateAll: true}).Create(e.ptr.Interface()).Error
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		delete(before, e.id)
+		changed := make(map[string]interface{})
+		for _, f := range rel.FieldSchema.Fields {
+			if f.DBName == "" || !f.Updatable || f.PrimaryKey {
+				continue
+			}
+			o, _ := f.ValueOf(ctx, old)
+			n, _ := f.ValueOf(ctx, e.ptr)
+			if !sameValue(o, n) {
+				changed[f.DBName] = n
+			}
+		}
+		if len(changed) > 0 {
+			if err := db.Model(e.ptr.Interface()).Updates(changed).Error; err != nil {
+				return err
+			}
+		}
+	}
+	for _, removed := range before {
+		if err := db.Delete(removed.Interface()).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveJoinRows 写入多对多集合：新元素插入中间表的行（元素不存在时先插入元素），
+// 消失的元素删除中间表的行，元素本身不受影响
+func saveJoinRows(db *gorm.DB, rel *schema.Relationship, parent reflect.Value, before map[string]reflect.Value, after []element) error {
+	ctx := db.Statement.Context
+	// joinRow 返回 parent 与 elem 之间的中间表行
+	joinRow := func(elem reflect.Value) (reflect.Value, error) {
+		row := reflect.New(rel.JoinTable.ModelType)
+		for _, ref := range rel.References {
+			owner := elem
+			if ref.OwnPrimaryKey {
+				owner = parent
+			}
+			v, _ := ref.PrimaryKey.ValueOf(ctx, owner)
+			if err := ref.ForeignKey.Set(ctx, row, v); err != nil {
+				return row, err
+			}
+		}
+		return row, nil
+	}
+	for _, e := range after {
+		if _, ok := before[e.id]; ok {
+			delete(before, e.id)
+			continue
+		}
+		if e.id == "" {
+			if err := db.Omit(clause.Associations).Create(e.ptr.Interface()).Error; err != nil {
+				return err
+			}
+		}
+		row, err := joinRow(e.ptr)
+		if err != nil {
+			return err
+		}
+		err = db.Table(rel.JoinTable.Table).Clauses(clause.OnConflict{DoNothing: true}).Create(row.Interface()).Error
+		if err != nil {
+			return err
+		}
+	}
+	for _, removed := range before {
+		row, err := joinRow(removed)
+		if err != nil {
+			return err
+		}
+		cond := make(map[string]interface{})
+		for _, ref := range rel.References {
+			cond[ref.ForeignKey.DBName], _ = ref.ForeignKey.ValueOf(ctx, row)
+		}
+		if err := db.Table(rel.JoinTable.Table).Where(cond).Delete(row.Interface()).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package cachedb
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestCollections(t *testing.T) {
+	type Item struct {
+		ID       uint
+		PlayerID uint
+		Name     string
+		Count    int
+	}
+	type Title struct {
+		ID   uint
+		Name string
+	}
+	type Player struct {
+		ID     uint
+		Gold   int
+		Items  []Item
+		Titles []*Title `gorm:"many2many:player_titles"`
+	}
+	db := openTestDB(t, &Player{}, &Item{}, &Title{})
+	db.Create(&Player{
+		ID:     1,
+		Items:  []Item{{ID: 1, Name: "potion", Count: 3}, {ID: 2, Name: "sword", Count: 1}, {ID: 3, Name: "shield", Count: 1}},
+		Titles: []*Title{{ID: 1, Name: "rookie"}, {ID: 2, Name: "hero"}},
+	})
+
+	var statements []string
+	record := func(tx *gorm.DB) { statements = append(statements, tx.Statement.SQL.String()) }
+	for _, p := range []interface {
+		Register(string, func(*gorm.DB)) error
+	}{
+		db.Callback().Create().After("gorm:create"),
+		db.Callback().Update().After("gorm:update"),
+		db.Callback().Delete().After("gorm:delete"),
+	} {
+		p.Register("record_sql", record)
+	}
+
+	c := newTestCache[Player](t, db, 10, WithCollections[Player]("Items", "Titles"))
+	p, err := c.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Items) != 3 || len(p.Titles) != 2 {
+		t.Fatalf("expected collections to be preloaded, got %+v", *p)
+	}
+	c.Update(1, func(p *Player) {
+		p.Gold = 5                                             // 父对象修改
+		p.Items[0].Count = 2                                   // 修改
+		p.Items = append(p.Items[:1], p.Items[2])              // 删除 sword
+		p.Items = append(p.Items, Item{Name: "bow", Count: 1}) // 新增
+		p.Titles = []*Title{p.Titles[1], {Name: "legend"}}     // 移除 rookie，新增 legend
+	})
+	statements = nil
+	if err := c.Flush(1); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	// 父对象 1 条，物品修改、删除、新增各 1 条，称号新增 1 条、中间表增删各 1 条
+	if len(statements) != 7 {
+		t.Errorf("expected 7 targeted statements, got %d:\n%s", len(statements), strings.Join(statements, "\n"))
+	}
+
+	var items []Item
+	db.Order("id").Find(&items)
+	if len(items) != 3 || items[0].Count != 2 || items[1].Name != "shield" || items[2].Name != "bow" || items[2].PlayerID != 1 {
+		t.Errorf("unexpected items %+v", items)
+	}
+	if p.Items[2].ID == 0 {
+		t.Error("expected the new item's ID to be written back to the cached entity")
+	}
+	var titles []Title
+	db.Find(&titles)
+	if len(titles) != 3 {
+		t.Errorf("expected removed titles to be kept, got %+v", titles)
+	}
+	var stored Player
+	db.Preload("Titles").First(&stored, 1)
+	if len(stored.Titles) != 2 || stored.Titles[0].Name != "hero" || stored.Titles[1].Name != "legend" {
+		t.Errorf("unexpected titles %+v", stored.Titles)
+	}
+	if keys := c.DirtyKeys(); len(keys) != 0 {
+		t.Errorf("expected no dirty keys, got %v", keys)
+	}
+}
+
+func TestCollectionsRejectsPlainField(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	if _, err := NewWithCache[Player](db, 10, WithCollections[Player]("Gold")); err == nil {
+		t.Error("expected a non-association field to be rejected")
+	}
+}
@@ -0,0 +1,48 @@
+package cachedb
+
+import (
+	"context"
+	"reflect"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// updatedAtField 返回 gorm 自动维护的更新时间字段（通常是 UpdatedAt），没有时返回 nil
+func updatedAtField(sch *schema.Schema) *schema.Field {
+	for _, f := range sch.Fields {
+		if f.DBName != "" && f.AutoUpdateTime > 0 {
+			return f
+		}
+	}
+	return nil
+}
+
+// reload 为 Refresh 从数据库重新读取 key 对应的行（不经过 L2），base 为当前副本。模型带有更新时间字段且使用默认存储时，
+// 查询附加 updated_at > 副本中的值 的条件：行未被修改时不传输整行，直接以副本作为结果。
+// 条件查询无法区分未修改与已删除，行被删除后要等到下次从数据库加载时才会发现
+func (c *CacheDB[T]) reload(key interface{}, base T) (T, error) {
+	if c.updatedAt == nil || c.wrapStore != nil {
+		return c.loadDB(key)
+	}
+	since, zero := c.updatedAt.ValueOf(context.Background(), reflect.ValueOf(&base).Elem())
+	if zero {
+		return c.loadDB(key)
+	}
+
+	var rows []T
+	newer := clause.Gt{Column: clause.Column{Table: clause.CurrentTable, Name: c.updatedAt.DBName}, Value: since}
+	release := c.acquireDB()
+	db, done := withTimeout(c.preload(c.keyDB(key)), c.loadTimeout, ErrLoadTimeout)
+	err := done(db.Where(c.keyCondition(key)).Where(newer).Limit(1).Find(&rows).Error)
+	release()
+	if err != nil {
+		var empty T
+		return empty, err
+	}
+	if len(rows) == 0 {
+		c.counters.unchangedReloads.Add(1)
+		return c.copier(base)
+	}
+	return rows[0], nil
+}
@@ -0,0 +1,36 @@
+package cachedb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRefreshConditional(t *testing.T) {
+	type Item struct {
+		ID        uint
+		Price     int
+		UpdatedAt time.Time
+	}
+	db := openTestDB(t, &Item{})
+	db.Create(&Item{ID: 1, Price: 10})
+	c := newTestCache[Item](t, db, 10)
+
+	item, _ := c.Get(1)
+	// 不更新 updated_at 的修改被视为未修改，Refresh 只恢复副本
+	db.Exec("UPDATE items SET price = 20 WHERE id = 1")
+	item.Price = 11
+	if err := c.Refresh(1); err != nil || item.Price != 10 {
+		t.Fatalf("expected the unchanged row to be served from the copy, got %v %+v", err, item)
+	}
+	if got := c.Stats().UnchangedReloads; got != 1 {
+		t.Errorf("UnchangedReloads = %d, want 1", got)
+	}
+
+	db.Model(&Item{}).Where("id = ?", 1).Update("price", 30) // gorm 会更新 updated_at
+	if err := c.Refresh(1); err != nil || item.Price != 30 {
+		t.Fatalf("expected the modified row to be reloaded, got %v %+v", err, item)
+	}
+	if got := c.Stats().UnchangedReloads; got != 1 {
+		t.Errorf("UnchangedReloads = %d, want 1", got)
+	}
+}
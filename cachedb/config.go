@@ -0,0 +1,131 @@
+package cachedb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// configSnapshot 是一次完整加载的配置数据，加载后不再修改
+type configSnapshot[T any] struct {
+	rows     map[interface{}]*T
+	list     []*T
+	loadedAt time.Time
+}
+
+// ConfigCache 把整张配置表（道具模板、数值表等参考数据）常驻内存，
+// 重新加载时整体原子替换，读取方不会看到加载到一半的数据。返回的对象是只读的
+type ConfigCache[T any] struct {
+	db     *gorm.DB
+	pk     *schema.Field
+	data   atomic.Pointer[configSnapshot[T]]
+	logger atomic.Pointer[Logger]
+}
+
+// NewConfigCache 创建配置缓存并立即加载
+func NewConfigCache[T any](db *gorm.DB) (*ConfigCache[T], error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, fmt.Errorf("failed to parse model: %w", err)
+	}
+	if stmt.Schema.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("model %s has no primary key", stmt.Schema.Name)
+	}
+
+	cc := &ConfigCache[T]{db: db, pk: stmt.Schema.PrioritizedPrimaryField}
+	cc.SetLogger(stdLogger{})
+	if err := cc.Reload(); err != nil {
+		return nil, err
+	}
+	return cc, nil
+}
+
+// Reload 重新查询整张表并原子替换内容，失败时保留旧数据
+func (cc *ConfigCache[T]) Reload() error {
+	var rows []T
+	if err := cc.db.Find(&rows).Error; err != nil {
+		return fmt.Errorf("failed to reload config: %w", err)
+	}
+
+	snap := &configSnapshot[T]{
+		rows:     make(map[interface{}]*T, len(rows)),
+		list:     make([]*T, len(rows)),
+		loadedAt: time.Now(),
+	}
+	ctx := context.Background()
+	for i := range rows {
+		key, _ := cc.pk.ValueOf(ctx, reflect.ValueOf(&rows[i]).Elem())
+		snap.rows[cc.normalizeKey(key)] = &rows[i]
+		snap.list[i] = &rows[i]
+	}
+	cc.data.Store(snap)
+	return nil
+}
+
+// Get 返回主键为 key 的配置，key 与 CacheDB 一样按主键类型转换，Get(1) 与 Get(uint(1)) 等价
+func (cc *ConfigCache[T]) Get(key interface{}) (*T, bool) {
+	v, ok := cc.data.Load().rows[cc.normalizeKey(key)]
+	return v, ok
+}
+
+// normalizeKey 把 key 转换为主键字段的类型，无法转换时原样返回
+func (cc *ConfigCache[T]) normalizeKey(key interface{}) interface{} {
+	if k, err := normalizeKeyTo(cc.pk.FieldType, key); err == nil {
+		return k
+	}
+	return key
+}
+
+// All 返回全部配置，顺序与数据库查询结果一致
+func (cc *ConfigCache[T]) All() []*T {
+	return cc.data.Load().list
+}
+
+// SetLogger 替换后台重新加载失败时的日志输出，默认打印到标准输出
+func (cc *ConfigCache[T]) SetLogger(logger Logger) {
+	cc.logger.Store(&logger)
+}
+
+// LoadedAt 返回当前数据的加载时间
+func (cc *ConfigCache[T]) LoadedAt() time.Time {
+	return cc.data.Load().loadedAt
+}
+
+// ReloadEvery 按固定间隔重新加载，返回的函数用于停止
+func (cc *ConfigCache[T]) ReloadEvery(interval time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		reloadOnTrigger(ctx, ticker.C, cc)
+	}()
+	return cancel
+}
+
+// ReloadOn 在收到信号（例如 SIGHUP）时重新加载，直到 ctx 结束或 signals 被关闭
+func (cc *ConfigCache[T]) ReloadOn(ctx context.Context, signals <-chan os.Signal) {
+	go reloadOnTrigger(ctx, signals, cc)
+}
+
+// reloadOnTrigger 每次从 ch 收到值时重新加载，ch 被关闭或 ctx 结束时返回
+func reloadOnTrigger[S, T any](ctx context.Context, ch <-chan S, cc *ConfigCache[T]) {
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+			if err := cc.Reload(); err != nil {
+				(*cc.logger.Load()).Log(LogError, "Config reload failed", "err", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package cachedb
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestConfigCacheReload(t *testing.T) {
+	type ItemTemplate struct {
+		ID    uint
+		Name  string
+		Price int
+	}
+	db := openTestDB(t, &ItemTemplate{})
+	db.Create(&ItemTemplate{ID: 1, Name: "potion", Price: 10})
+
+	cc, err := NewConfigCache[ItemTemplate](db)
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if it, ok := cc.Get(uint(1)); !ok || it.Price != 10 {
+		t.Fatalf("unexpected item %+v %v", it, ok)
+	}
+	for _, key := range []interface{}{1, int64(1), "1"} {
+		if _, ok := cc.Get(key); !ok {
+			t.Errorf("expected Get(%T %v) to find the row stored under uint(1)", key, key)
+		}
+	}
+	old, _ := cc.Get(uint(1))
+
+	// 平衡性补丁推送
+	db.Model(&ItemTemplate{}).Where("id = ?", 1).Update("price", 12)
+	db.Create(&ItemTemplate{ID: 2, Name: "elixir", Price: 50})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sig := make(chan os.Signal, 1)
+	cc.ReloadOn(ctx, sig)
+	sig <- syscall.SIGHUP
+
+	deadline := time.Now().Add(time.Second)
+	for len(cc.All()) != 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if len(cc.All()) != 2 {
+		t.Fatalf("expected reload on signal, got %d items", len(cc.All()))
+	}
+	if it, _ := cc.Get(uint(1)); it.Price != 12 {
+		t.Errorf("expected new price 12, got %d", it.Price)
+	}
+	if old.Price != 10 {
+		t.Errorf("old snapshot must not be mutated, got %d", old.Price)
+	}
+
+	stop := cc.ReloadEvery(time.Millisecond)
+	defer stop()
+	db.Create(&ItemTemplate{ID: 3, Name: "scroll", Price: 5})
+	deadline = time.Now().Add(time.Second)
+	for len(cc.All()) != 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if len(cc.All()) != 3 {
+		t.Errorf("expected periodic reload, got %d items", len(cc.All()))
+	}
+}
+
+func TestConfigCacheLogsReloadFailures(t *testing.T) {
+	type ItemTemplate struct {
+		ID   uint
+		Name string
+	}
+	db := openTestDB(t, &ItemTemplate{})
+	cc, err := NewConfigCache[ItemTemplate](db)
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	logger := &recordLogger{}
+	cc.SetLogger(logger)
+	db.Migrator().DropTable(&ItemTemplate{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sig := make(chan os.Signal, 1)
+	cc.ReloadOn(ctx, sig)
+	sig <- syscall.SIGHUP
+	deadline := time.Now().Add(time.Second)
+	for len(logger.find("Config reload failed")) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if len(logger.find("Config reload failed")) == 0 {
+		t.Error("expected the reload failure to reach the configured logger")
+	}
+}
+
+func TestConfigCacheReloadOnClosedChannel(t *testing.T) {
+	type ItemTemplate struct {
+		ID   uint
+		Name string
+	}
+	db := openTestDB(t, &ItemTemplate{})
+	cc, err := NewConfigCache[ItemTemplate](db)
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	sig := make(chan os.Signal)
+	close(sig)
+	done := make(chan struct{})
+	go func() {
+		reloadOnTrigger(context.Background(), sig, cc)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the reload loop to stop when the channel is closed")
+	}
+}
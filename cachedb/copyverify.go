@@ -0,0 +1,162 @@
+package cachedb
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// handoffDepth 是每个 key 保留的最近 Get 调用栈数
+const handoffDepth = 4
+
+// CopyVerifyReport 是一次检查发现的、未经 Update、Set 等缓存方法的修改
+type CopyVerifyReport struct {
+	Key      interface{}
+	Changes  []FieldChange // 相对上次经缓存方法修改、加载或刷新后的快照的修改
+	Handoffs []string      // 最近通过 Get 取得该对象的调用栈，可能修改了它的代码在其中
+}
+
+// copyVerifier 保存调试模式下的快照和 Get 调用栈
+type copyVerifier[T any] struct {
+	mu       sync.Mutex
+	handoffs map[interface{}][][]uintptr // key -> 最近的 Get 调用栈，最旧的在前
+
+	snapshots map[interface{}]verifySnapshot[T] // 由 c.mu 保护
+}
+
+// verifySnapshot 是对象在代数 gen 时的深拷贝，ptr 为当时追踪的对象
+type verifySnapshot[T any] struct {
+	ptr   *T
+	gen   uint64
+	value T
+}
+
+// WithCopyVerify 开启调试模式，用于查找绕过缓存方法、直接修改缓存指针的代码（别名 bug）：
+// 记录每次 Get 的调用栈，由 VerifyCopies 比较对象与上次经 Update、Set、加载或刷新后的深拷贝，
+// 代数未变而内容变化即为未经缓存方法的修改。适用于约定只通过 Update、Set（或修改后 MarkDirty）修改对象的代码，
+// 每次 Get 都会采集调用栈，不应在生产环境开启
+func WithCopyVerify[T any]() Option[T] {
+	return func(c *CacheDB[T]) {
+		c.verifier = &copyVerifier[T]{
+			handoffs:  make(map[interface{}][][]uintptr),
+			snapshots: make(map[interface{}]verifySnapshot[T]),
+		}
+	}
+}
+
+// recordHandoff 记录 Get 把 key 的指针交给调用方时的调用栈
+func (c *CacheDB[T]) recordHandoff(key interface{}) {
+	v := c.verifier
+	if v == nil {
+		return
+	}
+	pcs := make([]uintptr, 16)
+	pcs = pcs[:runtime.Callers(3, pcs)] // 跳过 runtime.Callers、recordHandoff 和 Get
+	key = c.canonicalKey(key)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	stacks := append(v.handoffs[key], pcs)
+	if len(stacks) > handoffDepth {
+		stacks = stacks[len(stacks)-handoffDepth:]
+	}
+	v.handoffs[key] = stacks
+}
+
+// VerifyCopies 检查一遍所有缓存的对象，记录并返回未经缓存方法的修改。
+// 首次见到的对象和经缓存方法修改过的对象只建立快照。未开启 WithCopyVerify 时返回 nil
+func (c *CacheDB[T]) VerifyCopies() []CopyVerifyReport {
+	v := c.verifier
+	if v == nil {
+		return nil
+	}
+	var reports []CopyVerifyReport
+	c.mu.Lock()
+	for key := range v.snapshots {
+		if _, ok := c.values[key]; !ok {
+			delete(v.snapshots, key)
+		}
+	}
+	v.mu.Lock()
+	for key := range v.handoffs {
+		if _, ok := c.values[key]; !ok {
+			delete(v.handoffs, key)
+		}
+	}
+	v.mu.Unlock()
+	for key, value := range c.values {
+		var gen uint64
+		if m := c.meta[key]; m != nil {
+			gen = m.gen
+		}
+		if snap, ok := v.snapshots[key]; ok && snap.ptr == value && snap.gen == gen {
+			if c.equal(snap.value, *value) {
+				continue // 没有变化，保留原快照
+			}
+			reports = append(reports, CopyVerifyReport{Key: key, Changes: c.diff(&snap.value, value)})
+		}
+		cpy, err := c.copier(*value)
+		if err != nil {
+			continue
+		}
+		v.snapshots[key] = verifySnapshot[T]{ptr: value, gen: gen, value: cpy}
+	}
+	c.mu.Unlock()
+
+	for i := range reports {
+		r := &reports[i]
+		r.Handoffs = v.stacks(r.Key)
+		c.counters.strayMutations.Add(1)
+		c.log(LogWarn, "Cached value mutated outside the cache", "key", r.Key,
+			"fields", changedFields(r.Changes), "handoffs", strings.Join(r.Handoffs, "\n---\n"))
+	}
+	return reports
+}
+
+// VerifyCopiesEvery 每隔 interval 调用一次 VerifyCopies，返回的函数用于停止
+func (c *CacheDB[T]) VerifyCopiesEvery(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	var once sync.Once
+	go func() {
+		for {
+			select {
+			case <-c.clock.After(interval):
+				c.VerifyCopies()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// stacks 格式化 key 最近的 Get 调用栈，最近的在前
+func (v *copyVerifier[T]) stacks(key interface{}) []string {
+	v.mu.Lock()
+	recorded := v.handoffs[key]
+	v.mu.Unlock()
+	out := make([]string, 0, len(recorded))
+	for i := len(recorded) - 1; i >= 0; i-- {
+		var b strings.Builder
+		frames := runtime.CallersFrames(recorded[i])
+		for {
+			f, more := frames.Next()
+			fmt.Fprintf(&b, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+			if !more {
+				break
+			}
+		}
+		out = append(out, b.String())
+	}
+	return out
+}
+
+// changedFields 返回修改涉及的字段名
+func changedFields(changes []FieldChange) []string {
+	fields := make([]string, 0, len(changes))
+	for _, ch := range changes {
+		fields = append(fields, ch.Field)
+	}
+	return fields
+}
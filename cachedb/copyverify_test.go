@@ -0,0 +1,49 @@
+package cachedb
+
+import (
+	"strings"
+	"testing"
+)
+
+// leakPointer 模拟把缓存指针保存起来、之后直接修改的业务代码
+func leakPointer[T any](c *CacheDB[T], key interface{}) *T {
+	p, _ := c.Get(key)
+	return p
+}
+
+func TestCopyVerify(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+		HP   int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, Gold: 10, HP: 100})
+	db.Create(&Player{ID: 2, Gold: 20, HP: 100})
+
+	c := newTestCache[Player](t, db, 10, WithCopyVerify[Player]())
+	leaked := leakPointer(c, 1)
+	c.Get(2)
+	if reports := c.VerifyCopies(); len(reports) != 0 {
+		t.Fatalf("expected the first pass to only take snapshots, got %+v", reports)
+	}
+
+	c.Update(2, func(p *Player) { p.Gold = 30 }) // 经缓存方法的修改
+	leaked.HP = 0                                // 绕过缓存的修改
+	reports := c.VerifyCopies()
+	if len(reports) != 1 || reports[0].Key != uint(1) {
+		t.Fatalf("expected one stray mutation on key 1, got %+v", reports)
+	}
+	if ch := reports[0].Changes; len(ch) != 1 || ch[0].Field != "HP" {
+		t.Errorf("expected the HP change to be reported, got %+v", ch)
+	}
+	if h := reports[0].Handoffs; len(h) == 0 || !strings.Contains(h[0], "leakPointer") {
+		t.Errorf("expected the leaking caller in the recorded stacks, got %v", h)
+	}
+	if reports := c.VerifyCopies(); len(reports) != 0 {
+		t.Errorf("expected each mutation to be reported once, got %+v", reports)
+	}
+	if n := c.Stats().StrayMutations; n != 1 {
+		t.Errorf("expected 1 stray mutation in stats, got %d", n)
+	}
+}
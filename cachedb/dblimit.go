@@ -0,0 +1,78 @@
+package cachedb
+
+import "sync/atomic"
+
+// DBLimiter 限制同时执行的数据库操作（加载与回写）数量，可以由多个缓存共享，
+// 避免开服时所有实体同时冷启动耗尽连接池
+type DBLimiter struct {
+	slots   chan struct{}
+	waiting atomic.Int64
+}
+
+// NewDBLimiter 创建最多允许 n 个并发数据库操作的限制器
+func NewDBLimiter(n int) *DBLimiter {
+	if n <= 0 {
+		n = 1
+	}
+	return &DBLimiter{slots: make(chan struct{}, n)}
+}
+
+// acquire 占用一个名额，返回释放函数。l 为 nil 时不做限制
+func (l *DBLimiter) acquire() func() {
+	if l == nil {
+		return func() {}
+	}
+	select {
+	case l.slots <- struct{}{}:
+	default:
+		l.waiting.Add(1)
+		l.slots <- struct{}{}
+		l.waiting.Add(-1)
+	}
+	return func() { <-l.slots }
+}
+
+// InUse 返回正在执行的数据库操作数
+func (l *DBLimiter) InUse() int {
+	return len(l.slots)
+}
+
+// Waiting 返回正在排队等待名额的数据库操作数
+func (l *DBLimiter) Waiting() int {
+	return int(l.waiting.Load())
+}
+
+// WithDBConcurrency 限制该缓存同时执行的数据库操作数
+func WithDBConcurrency[T any](n int) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.dbLocal = NewDBLimiter(n)
+	}
+}
+
+// WithDBLimiter 让缓存的数据库操作受共享限制器约束，通常由 Registry.LimitDBConcurrency 设置
+func WithDBLimiter[T any](l *DBLimiter) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.dbShared = l
+	}
+}
+
+// LimitDBConcurrency 为之后通过 RegisterCache 创建的缓存设置共享的数据库并发上限并返回限制器，
+// 重复调用返回同一个限制器
+func (r *Registry) LimitDBConcurrency(n int) *DBLimiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.limiter == nil {
+		r.limiter = NewDBLimiter(n)
+	}
+	return r.limiter
+}
+
+// acquireDB 依次占用缓存自身和共享限制器的名额，返回释放函数
+func (c *CacheDB[T]) acquireDB() func() {
+	releaseLocal := c.dbLocal.acquire()
+	releaseShared := c.dbShared.acquire()
+	return func() {
+		releaseShared()
+		releaseLocal()
+	}
+}
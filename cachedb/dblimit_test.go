@@ -0,0 +1,94 @@
+package cachedb
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowStore 在每次加载时停顿并记录同时进行的加载数的峰值
+type slowStore[T any] struct {
+	Store[T]
+	active, peak *atomic.Int64
+}
+
+func (s slowStore[T]) Load(key interface{}) (T, error) {
+	n := s.active.Add(1)
+	defer s.active.Add(-1)
+	for {
+		p := s.peak.Load()
+		if n <= p || s.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	return s.Store.Load(key)
+}
+
+func TestRegistryDBConcurrency(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Name string
+	}
+	type Guild struct {
+		ID   uint
+		Name string
+	}
+	db := openTestDB(t, &Player{}, &Guild{})
+	for i := uint(1); i <= 8; i++ {
+		db.Create(&Player{ID: i})
+		db.Create(&Guild{ID: i})
+	}
+
+	var active, peak atomic.Int64
+	reg := NewRegistry()
+	reg.AddDB("game", db)
+	limiter := reg.LimitDBConcurrency(2)
+	players, _ := RegisterCache[Player](reg, "player", "game", 20, WithStore(func(base Store[Player]) Store[Player] {
+		return slowStore[Player]{base, &active, &peak}
+	}))
+	guilds, _ := RegisterCache[Guild](reg, "guild", "game", 20, WithStore(func(base Store[Guild]) Store[Guild] {
+		return slowStore[Guild]{base, &active, &peak}
+	}))
+
+	var wg sync.WaitGroup
+	for i := uint(1); i <= 8; i++ {
+		wg.Add(2)
+		go func(id uint) { defer wg.Done(); players.Get(id) }(i)
+		go func(id uint) { defer wg.Done(); guilds.Get(id) }(i)
+	}
+	wg.Wait()
+
+	if got := peak.Load(); got != 2 {
+		t.Errorf("expected at most 2 concurrent loads across caches, got %d", got)
+	}
+	if limiter.InUse() != 0 || limiter.Waiting() != 0 {
+		t.Errorf("expected limiter to be idle, in use %d waiting %d", limiter.InUse(), limiter.Waiting())
+	}
+}
+
+func TestCacheDBConcurrency(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Name string
+	}
+	db := openTestDB(t, &Player{})
+	for i := uint(1); i <= 6; i++ {
+		db.Create(&Player{ID: i})
+	}
+	var active, peak atomic.Int64
+	c := newTestCache[Player](t, db, 20, WithDBConcurrency[Player](1), WithStore(func(base Store[Player]) Store[Player] {
+		return slowStore[Player]{base, &active, &peak}
+	}))
+
+	var wg sync.WaitGroup
+	for i := uint(1); i <= 6; i++ {
+		wg.Add(1)
+		go func(id uint) { defer wg.Done(); c.Get(id) }(i)
+	}
+	wg.Wait()
+	if got := peak.Load(); got != 1 {
+		t.Errorf("expected loads to be serialized, got peak %d", got)
+	}
+}
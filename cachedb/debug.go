@@ -0,0 +1,73 @@
+package cachedb
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+)
+
+// DebugPath 是 RegisterDebugHandler 挂载调试页面的路径
+const DebugPath = "/debug/gamecache"
+
+// statsProvider 由 *CacheDB[T] 实现，注册表通过它收集统计
+type statsProvider interface {
+	Stats() CacheStats
+}
+
+// Stats 返回所有实现了 Stats 的已注册缓存的统计，按实体名索引
+func (r *Registry) Stats() map[string]CacheStats {
+	r.mu.RLock()
+	providers := make(map[string]statsProvider, len(r.caches))
+	for name, c := range r.caches {
+		if p, ok := c.(statsProvider); ok {
+			providers[name] = p
+		}
+	}
+	r.mu.RUnlock()
+
+	out := make(map[string]CacheStats, len(providers))
+	for name, p := range providers {
+		out[name] = p.Stats()
+	}
+	return out
+}
+
+// PublishExpvar 以 name 把注册表的统计发布到 expvar（/debug/vars），每次读取时重新收集。
+// 与 expvar.Publish 相同，name 重复时会 panic
+func (r *Registry) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any { return r.Stats() }))
+}
+
+// DebugHandler 以 JSON 返回注册表中所有缓存的统计
+type DebugHandler struct {
+	Registry *Registry
+}
+
+// ServeHTTP 处理 GET 请求，?entity=name 时只返回该实体
+func (h *DebugHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	stats := h.Registry.Stats()
+	var body interface{} = stats
+	if name := r.URL.Query().Get("entity"); name != "" {
+		s, ok := stats[name]
+		if !ok {
+			http.Error(w, "unknown entity "+name, http.StatusNotFound)
+			return
+		}
+		body = s
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+// RegisterDebugHandler 把 DebugHandler 挂载到 mux 的 DebugPath，
+// mux 为 nil 时使用 http.DefaultServeMux（与 net/http/pprof 相同）
+func RegisterDebugHandler(mux *http.ServeMux, r *Registry) {
+	if mux == nil {
+		mux = http.DefaultServeMux
+	}
+	mux.Handle(DebugPath, &DebugHandler{Registry: r})
+}
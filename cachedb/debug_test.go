@@ -0,0 +1,60 @@
+package cachedb
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDebugHandler(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1})
+	db.Create(&Player{ID: 2})
+
+	c := newTestCache[Player](t, db, 10)
+	reg := NewRegistry()
+	reg.Register("players", c)
+
+	p, _ := c.Get(1)
+	p.Gold = 5
+	c.Get(1)
+	c.Get(2)
+	c.Pin(2)
+
+	mux := http.NewServeMux()
+	RegisterDebugHandler(mux, reg)
+	get := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		return rec
+	}
+
+	rec := get(DebugPath)
+	var all map[string]CacheStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &all); err != nil {
+		t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+	}
+	s := all["players"]
+	if s.Entity != "players" || s.Tracked != 2 || s.Dirty != 1 || s.Pinned != 1 || s.Hits == 0 || s.Misses != 2 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+
+	if rec := get(DebugPath + "?entity=players"); rec.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rec.Code)
+	}
+	if rec := get(DebugPath + "?entity=guilds"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown entity, got %d", rec.Code)
+	}
+
+	reg.PublishExpvar("gamecache_test")
+	var published map[string]CacheStats
+	if err := json.Unmarshal([]byte(expvar.Get("gamecache_test").String()), &published); err != nil || published["players"].Dirty != 1 {
+		t.Errorf("unexpected expvar output %v: %v", published, err)
+	}
+}
@@ -0,0 +1,94 @@
+package cachedb
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Dialect 描述一种数据库在写回路径上的差异，按 gorm 的 Dialector.Name() 查找。
+// 内置 mysql、postgres、sqlite，其他数据库（例如 sqlserver）可用 RegisterDialect 注册
+type Dialect struct {
+	// Upsert 返回批量写回的冲突处理子句，key 为主键列名
+	Upsert func(key string) clause.Expression
+	// LockRow 返回事务内锁定读取行的子句，nil 表示不加锁（数据库不支持行锁，事务本身已串行）
+	LockRow func() clause.Expression
+	// StatementTimeout 返回在落库事务开始时执行的语句，限制之后每条语句的执行或锁等待时间，nil 表示不支持
+	StatementTimeout func(d time.Duration) string
+}
+
+// forUpdate 是 SELECT ... FOR UPDATE
+func forUpdate() clause.Expression {
+	return clause.Locking{Strength: clause.LockingStrengthUpdate}
+}
+
+// millis 把 d 向上取整为毫秒
+func millis(d time.Duration) int64 {
+	return int64((d + time.Millisecond - 1) / time.Millisecond)
+}
+
+// dialects 是已知数据库的写回差异
+var dialects = struct {
+	sync.RWMutex
+	byName map[string]Dialect
+}{byName: map[string]Dialect{
+	// MySQL 的 ON DUPLICATE KEY UPDATE 按任意唯一键判断冲突，不能指定列。
+	// MySQL 没有事务级的写语句超时，以会话级的行锁等待时间（秒）代替，设置会留在连接池的连接上
+	"mysql": {
+		Upsert:  func(string) clause.Expression { return clause.OnConflict{UpdateAll: true} },
+		LockRow: forUpdate,
+		StatementTimeout: func(d time.Duration) string {
+			return fmt.Sprintf("SET SESSION innodb_lock_wait_timeout = %d", (millis(d)+999)/1000)
+		},
+	},
+	// PostgreSQL 的 ON CONFLICT 必须指定冲突列
+	"postgres": {
+		Upsert: func(key string) clause.Expression {
+			return clause.OnConflict{Columns: []clause.Column{{Name: key}}, UpdateAll: true}
+		},
+		LockRow: forUpdate,
+		StatementTimeout: func(d time.Duration) string {
+			return fmt.Sprintf("SET LOCAL statement_timeout = %d", millis(d))
+		},
+	},
+	// SQLite 没有行锁，写事务对整个库串行，以连接级的 busy_timeout 限制等待其他写事务的时间
+	"sqlite": {
+		Upsert: func(key string) clause.Expression {
+			return clause.OnConflict{Columns: []clause.Column{{Name: key}}, UpdateAll: true}
+		},
+		StatementTimeout: func(d time.Duration) string {
+			return fmt.Sprintf("PRAGMA busy_timeout = %d", millis(d))
+		},
+	},
+}}
+
+// defaultDialect 用于未注册的数据库，使用标准的 ON CONFLICT 与 FOR UPDATE
+var defaultDialect = dialects.byName["postgres"]
+
+// RegisterDialect 注册或替换名为 name 的数据库的写回差异，需在构造缓存前调用
+func RegisterDialect(name string, d Dialect) {
+	dialects.Lock()
+	defer dialects.Unlock()
+	dialects.byName[name] = d
+}
+
+// dialectOf 返回 db 所用数据库的写回差异
+func dialectOf(db *gorm.DB) Dialect {
+	dialects.RLock()
+	defer dialects.RUnlock()
+	if d, ok := dialects.byName[db.Dialector.Name()]; ok {
+		return d
+	}
+	return defaultDialect
+}
+
+// lockRow 在 db 上追加锁定读取的子句
+func lockRow(db *gorm.DB) *gorm.DB {
+	if d := dialectOf(db); d.LockRow != nil {
+		return db.Clauses(d.LockRow())
+	}
+	return db
+}
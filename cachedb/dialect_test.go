@@ -0,0 +1,37 @@
+package cachedb
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func TestDialectLockRow(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	dry := db.Session(&gorm.Session{DryRun: true})
+	builtin := dialectOf(db)
+	if builtin.LockRow != nil {
+		t.Error("expected sqlite to skip row locks")
+	}
+
+	RegisterDialect("sqlite", Dialect{
+		Upsert: builtin.Upsert,
+		LockRow: func() clause.Expression {
+			return clause.Locking{Strength: clause.LockingStrengthUpdate, Options: "NOWAIT"}
+		},
+	})
+	defer RegisterDialect("sqlite", builtin)
+	stmt := lockRow(dry.Model(&Player{})).Where("id = ?", 1).Find(&[]Player{}).Statement
+	if !strings.Contains(stmt.SQL.String(), "WHERE") {
+		t.Fatalf("unexpected SQL %s", stmt.SQL.String())
+	}
+	if _, ok := stmt.Clauses["FOR"]; !ok {
+		t.Error("expected the registered dialect to add a locking clause")
+	}
+}
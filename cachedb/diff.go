@@ -0,0 +1,44 @@
+package cachedb
+
+import (
+	"context"
+	"reflect"
+)
+
+// FieldChange 是一个字段相对副本的修改。JSON 列（`gorm:"serializer:json"`）按对象的字段逐个比较，
+// 每个修改过的路径对应一条，Path 为列内的路径，Old、New 为该路径上解码后的 JSON 值
+type FieldChange struct {
+	Field   string      `json:"field"`             // 模型中的字段名
+	Column  string      `json:"column"`            // 对应的列
+	Path    []string    `json:"path,omitempty"`    // JSON 列内的路径，普通列为空
+	Old     interface{} `json:"old"`               // 副本中的值，即数据库中的值
+	New     interface{} `json:"new"`               // 内存中的当前值
+	Removed bool        `json:"removed,omitempty"` // JSON 对象中的该路径被删除
+}
+
+// diff 逐列比较 old 与 new，返回修改过的字段
+func (c *CacheDB[T]) diff(old, new *T) []FieldChange {
+	ctx := context.Background()
+	ov, nv := reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem()
+	var changes []FieldChange
+	for _, f := range c.schema.Fields {
+		if f.DBName == "" {
+			continue
+		}
+		o := f.ReflectValueOf(ctx, ov).Interface()
+		n := f.ReflectValueOf(ctx, nv).Interface()
+		if sameValue(o, n) {
+			continue
+		}
+		if isJSONField(f) {
+			if nested, ok := diffJSON(o, n); ok {
+				for _, ch := range nested {
+					changes = append(changes, FieldChange{Field: f.Name, Column: f.DBName, Path: ch.path, Old: ch.old, New: ch.new, Removed: ch.removed})
+				}
+				continue
+			}
+		}
+		changes = append(changes, FieldChange{Field: f.Name, Column: f.DBName, Old: o, New: n})
+	}
+	return changes
+}
@@ -0,0 +1,82 @@
+package cachedb
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DiffEncoder 把一次落库的字段修改编码为对外发送的载荷，例如 JSON Patch 或 protobuf 变更集。
+// 同一个编码器被落库事件（FlushEvent.Patch）和 OnFlushDiff 回调（审计、客户端增量同步）共用
+type DiffEncoder interface {
+	EncodeDiff(entity string, key interface{}, changes []FieldChange) ([]byte, error)
+}
+
+// JSONPatchEncoder 把修改编码为 RFC 6902 JSON Patch，每个修改的列对应一条 replace 操作，
+// JSON 列内的修改以列名加路径为目标，被删除的路径对应 remove 操作
+type JSONPatchEncoder struct{}
+
+// jsonPatchOp 是 JSON Patch 中的一条操作
+type jsonPatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// EncodeDiff 实现 DiffEncoder
+func (JSONPatchEncoder) EncodeDiff(_ string, _ interface{}, changes []FieldChange) ([]byte, error) {
+	ops := make([]jsonPatchOp, len(changes))
+	for i, ch := range changes {
+		ops[i] = jsonPatchOp{Op: "replace", Path: "/" + ch.Column, Value: ch.New}
+		for _, seg := range ch.Path {
+			ops[i].Path += "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(seg)
+		}
+		if ch.Removed {
+			ops[i].Op, ops[i].Value = "remove", nil
+		}
+	}
+	return json.Marshal(ops)
+}
+
+// WithDiffEncoder 设置字段修改的编码器。设置后 FlushEvent 携带 Patch，OnFlushDiff 回调被启用
+func WithDiffEncoder[T any](enc DiffEncoder) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.diffEncoder = enc
+	}
+}
+
+// OnFlushDiff 注册落库成功后的回调，参数为 WithDiffEncoder 编码后的修改，没有字段变化时不调用。
+// 未设置编码器时回调不会被执行。回调在持有内部锁时同步执行，不能调用该缓存的方法
+func (c *CacheDB[T]) OnFlushDiff(fn func(key interface{}, patch []byte)) {
+	c.OnFlush(func(key interface{}, old, new T) {
+		patch, ok := c.encodeDiff(key, &old, &new)
+		if ok {
+			fn(key, patch)
+		}
+	})
+}
+
+// EncodeDiff 用 WithDiffEncoder 设置的编码器编码 old 到 new 的修改
+func (c *CacheDB[T]) EncodeDiff(key interface{}, old, new *T) ([]byte, error) {
+	if c.diffEncoder == nil {
+		return nil, fmt.Errorf("no diff encoder configured")
+	}
+	return c.diffEncoder.EncodeDiff(c.entity, key, c.diff(old, new))
+}
+
+// encodeDiff 编码修改，未设置编码器、没有变化或编码失败时返回 false
+func (c *CacheDB[T]) encodeDiff(key interface{}, old, new *T) ([]byte, bool) {
+	if c.diffEncoder == nil {
+		return nil, false
+	}
+	changes := c.diff(old, new)
+	if len(changes) == 0 {
+		return nil, false
+	}
+	patch, err := c.diffEncoder.EncodeDiff(c.entity, key, changes)
+	if err != nil {
+		c.log(LogError, "Diff encode failed", "key", key, "err", err)
+		return nil, false
+	}
+	return patch, true
+}
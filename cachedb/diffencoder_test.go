@@ -0,0 +1,43 @@
+package cachedb
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDiffEncoder(t *testing.T) {
+	type Hero struct {
+		ID    uint
+		Name  string
+		Level int
+	}
+	db := openTestDB(t, &Hero{})
+	db.Create(&Hero{ID: 1, Name: "a", Level: 1})
+
+	bus := NewMemoryBus[Hero]()
+	var events []FlushEvent[Hero]
+	bus.SubscribeFlush(func(e FlushEvent[Hero]) { events = append(events, e) })
+	c := newTestCache[Hero](t, db, 10, WithDiffEncoder[Hero](JSONPatchEncoder{}), WithFlushPublisher[Hero](bus))
+	var audit [][]byte
+	c.OnFlushDiff(func(_ interface{}, patch []byte) { audit = append(audit, patch) })
+
+	c.Update(1, func(h *Hero) { h.Level = 5 })
+	if err := c.Flush(1); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	want := `[{"op":"replace","path":"/level","value":5}]`
+	if len(audit) != 1 || string(audit[0]) != want {
+		t.Errorf("expected audit patch %s, got %q", want, audit)
+	}
+	if len(events) != 1 || string(events[0].Patch) != want {
+		t.Errorf("expected event patch %s, got %+v", want, events)
+	}
+
+	old, new := Hero{ID: 1, Name: "a"}, Hero{ID: 1, Name: "b"}
+	patch, err := c.EncodeDiff(1, &old, &new)
+	var ops []map[string]interface{}
+	if err != nil || json.Unmarshal(patch, &ops) != nil || len(ops) != 1 || ops[0]["path"] != "/name" {
+		t.Errorf("unexpected patch %s %v", patch, err)
+	}
+}
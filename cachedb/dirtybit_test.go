@@ -0,0 +1,40 @@
+package cachedb
+
+import "testing"
+
+func TestDirtyBit(t *testing.T) {
+	type Hero struct {
+		ID    uint
+		Level int
+	}
+	db := openTestDB(t, &Hero{})
+	db.Create(&Hero{ID: 1, Level: 1})
+	db.Create(&Hero{ID: 2, Level: 1})
+	c := newTestCache[Hero](t, db, 10)
+
+	c.Update(1, func(h *Hero) { h.Level = 2 })
+	raw, _ := c.Get(2)
+	raw.Level = 3
+
+	if err := c.FlushAll(); err != nil {
+		t.Fatalf("FlushAll failed: %v", err)
+	}
+	stats := c.Stats()
+	if stats.DirtyMarked == 0 || stats.DirtyCompared == 0 {
+		t.Errorf("expected both dirty check paths to be used, got %+v", stats)
+	}
+	var rows []Hero
+	db.Order("id").Find(&rows)
+	if rows[0].Level != 2 || rows[1].Level != 3 {
+		t.Errorf("expected both changes to be flushed, got %+v", rows)
+	}
+
+	// 落库后标记被清除，之后未修改的对象通过比较判定为干净
+	marked := c.Stats().DirtyMarked
+	if keys := c.DirtyKeys(); len(keys) != 0 {
+		t.Errorf("expected no dirty keys after flush, got %v", keys)
+	}
+	if got := c.Stats().DirtyMarked; got != marked {
+		t.Errorf("expected the dirty bit to be cleared by the flush")
+	}
+}
@@ -0,0 +1,155 @@
+package cachedb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+// ErrSchemaDrift 表示模型与数据库中的表结构不一致
+var ErrSchemaDrift = errors.New("schema drift detected")
+
+// DriftKind 是结构差异的类型
+type DriftKind string
+
+const (
+	DriftMissingColumn DriftKind = "missing_column" // 模型有而表中没有的列，落库会失败或丢失该字段
+	DriftExtraColumn   DriftKind = "extra_column"   // 表中有而模型没有的列，只作提示
+	DriftTypeMismatch  DriftKind = "type_mismatch"  // 列的类型与字段类型不兼容
+)
+
+// SchemaDrift 描述一处模型与表结构的差异
+type SchemaDrift struct {
+	Kind     DriftKind
+	Column   string
+	Field    string // 模型中的字段名，DriftExtraColumn 时为空
+	Expected string // 字段的类型，DriftTypeMismatch 时有效
+	Actual   string // 列在数据库中的类型
+}
+
+func (d SchemaDrift) String() string {
+	switch d.Kind {
+	case DriftMissingColumn:
+		return fmt.Sprintf("column %s for field %s is missing", d.Column, d.Field)
+	case DriftExtraColumn:
+		return fmt.Sprintf("column %s (%s) has no field", d.Column, d.Actual)
+	default:
+		return fmt.Sprintf("column %s is %s but field %s is %s", d.Column, d.Actual, d.Field, d.Expected)
+	}
+}
+
+// WithSchemaCheck 在构造时比较模型字段与数据库中的列，发现缺失的列或类型不兼容时记录警告；
+// strict 为 true 时 NewWithCache 返回 ErrSchemaDrift。多出的列只记录为提示
+func WithSchemaCheck[T any](strict bool) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.schemaCheck = true
+		c.schemaStrict = strict
+	}
+}
+
+// CheckSchema 比较模型字段与数据库中的列，返回所有差异。
+// 未连接数据库或使用 WithSharding 时不检查
+func (c *CacheDB[T]) CheckSchema() ([]SchemaDrift, error) {
+	if c.db == nil || c.sharding != nil {
+		return nil, nil
+	}
+	table := c.defaultTable()
+	columns, err := c.db.Migrator().ColumnTypes(table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%w: table %s does not exist", ErrSchemaDrift, table)
+	}
+
+	live := make(map[string]string, len(columns))
+	for _, col := range columns {
+		live[col.Name()] = col.DatabaseTypeName()
+	}
+	var drifts []SchemaDrift
+	for _, name := range c.schema.DBNames {
+		f := c.schema.FieldsByDBName[name]
+		actual, ok := live[name]
+		if !ok {
+			drifts = append(drifts, SchemaDrift{Kind: DriftMissingColumn, Column: name, Field: f.Name})
+			continue
+		}
+		delete(live, name)
+		if !compatibleColumn(f, actual) {
+			drifts = append(drifts, SchemaDrift{Kind: DriftTypeMismatch, Column: name, Field: f.Name, Expected: string(f.DataType), Actual: actual})
+		}
+	}
+	for _, col := range columns {
+		if actual, extra := live[col.Name()]; extra {
+			drifts = append(drifts, SchemaDrift{Kind: DriftExtraColumn, Column: col.Name(), Actual: actual})
+		}
+	}
+	return drifts, nil
+}
+
+// checkSchema 在构造时执行 WithSchemaCheck
+func (c *CacheDB[T]) checkSchema() error {
+	drifts, err := c.CheckSchema()
+	if err != nil {
+		return err
+	}
+	var problems []string
+	for _, d := range drifts {
+		if d.Kind == DriftExtraColumn {
+			c.log(LogInfo, "Schema drift", "drift", d)
+			continue
+		}
+		c.log(LogWarn, "Schema drift", "drift", d)
+		problems = append(problems, d.String())
+	}
+	if c.schemaStrict && len(problems) > 0 {
+		return fmt.Errorf("%w in %s: %s", ErrSchemaDrift, c.defaultTable(), strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// compatibleColumn 粗略判断列类型能否存放字段，无法判断的类型视为兼容
+func compatibleColumn(f *schema.Field, column string) bool {
+	col := columnClass(column)
+	if col == "" {
+		return true
+	}
+	switch f.DataType {
+	case schema.Bool:
+		return col == "bool" || col == "int"
+	case schema.Int, schema.Uint:
+		return col == "int" || col == "float"
+	case schema.Float:
+		return col == "float" || col == "int"
+	case schema.String:
+		return col == "string" || col == "bytes"
+	case schema.Time:
+		return col == "time" || col == "string" || col == "int"
+	case schema.Bytes:
+		return col == "bytes" || col == "string"
+	}
+	return true // serializer 等自定义类型
+}
+
+// columnClass 把数据库的类型名归类，未知类型返回空
+func columnClass(column string) string {
+	t := strings.ToUpper(column)
+	switch {
+	case strings.Contains(t, "BOOL"):
+		return "bool"
+	case strings.Contains(t, "INT"), strings.Contains(t, "SERIAL"):
+		return "int"
+	case strings.Contains(t, "CHAR"), strings.Contains(t, "TEXT"), strings.Contains(t, "CLOB"), strings.Contains(t, "JSON"):
+		return "string"
+	case strings.Contains(t, "REAL"), strings.Contains(t, "FLOA"), strings.Contains(t, "DOUB"),
+		strings.Contains(t, "DEC"), strings.Contains(t, "NUMERIC"):
+		return "float"
+	case strings.Contains(t, "BLOB"), strings.Contains(t, "BINARY"), strings.Contains(t, "BYTEA"):
+		return "bytes"
+	case strings.Contains(t, "DATE"), strings.Contains(t, "TIME"):
+		return "time"
+	}
+	return ""
+}
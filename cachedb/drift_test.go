@@ -0,0 +1,55 @@
+package cachedb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSchemaCheck(t *testing.T) {
+	type Player struct {
+		ID    uint
+		Gold  int
+		Level int
+		Name  string
+	}
+	db := openTestDB(t)
+	// 部分迁移后的表：缺少 name 列，level 被改成了文本，多出一个 legacy 列
+	db.Exec("CREATE TABLE players (id integer PRIMARY KEY, gold integer, level text, legacy text)")
+
+	c := newTestCache[Player](t, db, 10)
+	drifts, err := c.CheckSchema()
+	if err != nil {
+		t.Fatalf("CheckSchema failed: %v", err)
+	}
+	kinds := map[string]DriftKind{}
+	for _, d := range drifts {
+		kinds[d.Column] = d.Kind
+	}
+	want := map[string]DriftKind{"name": DriftMissingColumn, "level": DriftTypeMismatch, "legacy": DriftExtraColumn}
+	if len(kinds) != len(want) {
+		t.Fatalf("unexpected drifts %v", drifts)
+	}
+	for col, kind := range want {
+		if kinds[col] != kind {
+			t.Errorf("column %s: expected %s, got %s", col, kind, kinds[col])
+		}
+	}
+
+	logs := &recordLogger{}
+	if _, err := NewWithCache[Player](db, 10, WithLogger[Player](logs), WithSchemaCheck[Player](false)); err != nil {
+		t.Errorf("non-strict check should only warn, got %v", err)
+	}
+	if len(logs.find("Schema drift")) != 3 {
+		t.Errorf("expected 3 drift logs, got %d", len(logs.find("Schema drift")))
+	}
+	if _, err := NewWithCache[Player](db, 10, WithSchemaCheck[Player](true)); !errors.Is(err, ErrSchemaDrift) {
+		t.Errorf("expected ErrSchemaDrift, got %v", err)
+	}
+
+	db.Exec("ALTER TABLE players ADD COLUMN name text")
+	db.Exec("ALTER TABLE players DROP COLUMN level")
+	db.Exec("ALTER TABLE players ADD COLUMN level integer")
+	if _, err := NewWithCache[Player](db, 10, WithSchemaCheck[Player](true)); err != nil {
+		t.Errorf("expected migrated table to pass, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package cachedb
+
+import (
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DryRunEntry 是一个脏 key 在刷盘时会产生的修改
+type DryRunEntry struct {
+	Key     interface{}
+	Changes []FieldChange
+	SQL     string // 逐行回写（Flush、淘汰回写）时执行的语句，使用自定义 Store 时为空
+}
+
+// DryRunPlan 是 FlushDryRun 的结果
+type DryRunPlan struct {
+	Entries []DryRunEntry // 按 key 排序
+	Batch   []string      // FlushAll 批量写回时执行的语句，未开启 WithBulkFlush 或使用自定义 Store 时为空
+}
+
+// FlushDryRun 计算刷盘会写入哪些 key、修改哪些字段以及执行的 SQL，但不访问数据库，
+// 也不改变任何对象的状态。可在为新的实体类型开启回写前核对行为
+func (c *CacheDB[T]) FlushDryRun() (*DryRunPlan, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	plan := &DryRunPlan{}
+	var keys []interface{}
+	var values []T
+	for key, value := range c.values {
+		old := c.copies[key]
+		if !c.modified(key, value) {
+			continue
+		}
+		entry := DryRunEntry{Key: key, Changes: c.diff(&old, value)}
+		c.redactChanges(entry.Changes)
+		if _, ok := c.store.(gormStore[T]); ok {
+			model, cpy := c.Redact(old), c.Redact(*value)
+			stmt := dryRun(c.keyDB(key)).Model(&model).Where(c.keyCondition(key)).Updates(&cpy).Statement
+			entry.SQL = explain(stmt)
+		}
+		plan.Entries = append(plan.Entries, entry)
+		keys = append(keys, key)
+		values = append(values, *value)
+	}
+	sort.Slice(plan.Entries, func(i, j int) bool {
+		return fmt.Sprint(plan.Entries[i].Key) < fmt.Sprint(plan.Entries[j].Key)
+	})
+
+	if _, ok := c.store.(gormStore[T]); !ok || c.flushBatch <= 1 || len(keys) == 0 {
+		return plan, nil
+	}
+	upsert := clause.OnConflict{Columns: []clause.Column{{Name: c.keyField.DBName}}, UpdateAll: true}
+	order, groups := c.groupByShard(keys)
+	for _, shard := range order {
+		idx := groups[shard]
+		for len(idx) > 0 {
+			n := min(c.flushBatch, len(idx))
+			rows := make([]T, 0, n)
+			for _, i := range idx[:n] {
+				rows = append(rows, c.Redact(values[i]))
+			}
+			stmt := dryRun(shard.DB.Table(shard.Table)).Clauses(upsert).Create(&rows).Statement
+			plan.Batch = append(plan.Batch, explain(stmt))
+			idx = idx[n:]
+		}
+	}
+	return plan, nil
+}
+
+// dryRun 返回只生成 SQL 而不执行的会话
+func dryRun(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{DryRun: true})
+}
+
+// explain 把语句和参数拼成可读的 SQL
+func explain(stmt *gorm.Statement) string {
+	return stmt.Dialector.Explain(stmt.SQL.String(), stmt.Vars...)
+}
@@ -0,0 +1,47 @@
+package cachedb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFlushDryRun(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+		Name string
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, Gold: 10, Name: "a"})
+	db.Create(&Player{ID: 2, Gold: 20, Name: "b"})
+
+	c := newTestCache[Player](t, db, 10, WithBulkFlush[Player](500))
+	p1, _ := c.Get(1)
+	c.Get(2)
+	p1.Gold = 15
+
+	plan, err := c.FlushDryRun()
+	if err != nil {
+		t.Fatalf("FlushDryRun failed: %v", err)
+	}
+	if len(plan.Entries) != 1 {
+		t.Fatalf("expected one dirty key, got %+v", plan.Entries)
+	}
+	e := plan.Entries[0]
+	if e.Key != uint(1) || len(e.Changes) != 1 || e.Changes[0].Column != "gold" || e.Changes[0].Old != 10 || e.Changes[0].New != 15 {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if !strings.HasPrefix(e.SQL, "UPDATE `players` SET") || !strings.Contains(e.SQL, "`gold`=15") {
+		t.Errorf("unexpected SQL %q", e.SQL)
+	}
+	if len(plan.Batch) != 1 || !strings.Contains(plan.Batch[0], "ON CONFLICT") {
+		t.Errorf("unexpected batch statements %v", plan.Batch)
+	}
+
+	// 不修改数据库，也不改变对象状态
+	var stored Player
+	db.First(&stored, 1)
+	if stored.Gold != 10 || len(c.DirtyKeys()) != 1 {
+		t.Errorf("dry run must not write, got %+v dirty=%v", stored, c.DirtyKeys())
+	}
+}
@@ -0,0 +1,161 @@
+package cachedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrFlushVerify 表示 FlushDurable 回读时发现数据库中的行与写入的值不一致
+var ErrFlushVerify = errors.New("flush verification failed")
+
+// FlushDurable 以两阶段的方式写回所有脏数据，适用于赛季结算快照等正确性优先于速度的场景：
+// 在事务中整行写入（包括零值字段），再在同一事务中回读比较，
+// 全部一致才提交，提交成功后才把对象标记为已落库。任何一步失败都会回滚，对象保持为脏。
+//
+// 回读比较要求各列能原样往返，例如 MySQL 的 DATETIME 列需要足够的精度；自动维护的时间戳列不参与比较。
+// 分布在多个库的分片各自使用一个事务，某个库失败时之前已提交的库不会回滚，但对象都保持为脏。
+// 执行期间持有缓存的锁
+func (c *CacheDB[T]) FlushDurable(ctx context.Context) error {
+	if c.readOnly {
+		return ErrReadOnly
+	}
+	if c.db == nil {
+		return fmt.Errorf("FlushDurable requires a database connection")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var keys []interface{}
+	var olds, values []T
+	for key, value := range c.values {
+		if !c.modified(key, value) {
+			continue
+		}
+		if err := c.checkPersist(key, value); err != nil {
+			return err
+		}
+		keys = append(keys, key)
+		olds = append(olds, c.copies[key])
+		values = append(values, *value) // 持有锁期间的快照，提交后由 rebase 深拷贝
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	order, groups := c.groupByShard(keys)
+	dbs, byDB := groupByDB(order)
+	for _, db := range dbs {
+		release := c.acquireDB()
+		err := c.flushTransaction(db.WithContext(ctx), func(tx *gorm.DB) error {
+			for _, shard := range byDB[db] {
+				if err := c.writeVerified(tx, shard.Table, groups[shard], keys, olds, values); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		release()
+		if err != nil {
+			return err
+		}
+	}
+
+	c.logEvent(EventSave, LogInfo, "Saved changes durably", "keys", len(keys), "reason", FlushManual)
+	for i, key := range keys {
+		c.runFlushHooks(key, FlushManual, olds[i], values[i])
+		// 以写入的快照为副本，期间发生的修改仍然是脏的
+		if err := c.rebase(key, &values[i]); err != nil {
+			return err
+		}
+		c.settle(key)
+	}
+	return nil
+}
+
+// writeVerified 在事务 tx 中整行写入 idx 对应的行，然后回读比较。
+// MySQL 对值未变的行返回 0 行，因此行是否存在只由回读判断；与普通刷盘一样，
+// 旧值未知（Set 一个未加载过的 key）且没有更新到任何行时按 key 插入新行
+func (c *CacheDB[T]) writeVerified(tx *gorm.DB, table string, idx []int, keys []interface{}, olds, values []T) error {
+	var zero T
+	for _, i := range idx {
+		result := tx.Table(table).Model(new(T)).Where(c.keyCondition(keys[i])).Select("*").Updates(&values[i])
+		if result.Error == nil && result.RowsAffected == 0 && c.equal(olds[i], zero) {
+			result = c.insertRow(tx.Session(&gorm.Session{NewDB: true}).Table(table), keys[i], &values[i])
+		}
+		if result.Error != nil {
+			return fmt.Errorf("failed to write key %v: %w", keys[i], result.Error)
+		}
+	}
+
+	ids := make([]interface{}, len(idx))
+	for j, i := range idx {
+		ids[j] = keys[i]
+	}
+	var rows []T
+	cond := clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: c.keyField.DBName}, Values: ids}
+	if err := tx.Table(table).Where(cond).Find(&rows).Error; err != nil {
+		return fmt.Errorf("failed to read back %s: %w", table, err)
+	}
+	stored := make(map[interface{}]*T, len(rows))
+	for j := range rows {
+		stored[c.keyOf(&rows[j])] = &rows[j]
+	}
+	for _, i := range idx {
+		row, ok := stored[keys[i]]
+		if !ok {
+			return fmt.Errorf("%w: key %v missing on read-back", ErrFlushVerify, keys[i])
+		}
+		if column, same := c.sameRow(tx.Statement.Context, &values[i], row); !same {
+			return fmt.Errorf("%w: key %v column %s differs on read-back", ErrFlushVerify, keys[i], column)
+		}
+	}
+	return nil
+}
+
+// sameRow 逐列比较两行，返回第一个不同的列。时间按时刻比较，自动维护的时间戳列被忽略
+func (c *CacheDB[T]) sameRow(ctx context.Context, want, got *T) (string, bool) {
+	wv, gv := reflect.ValueOf(want).Elem(), reflect.ValueOf(got).Elem()
+	for _, f := range c.schema.Fields {
+		if f.DBName == "" || f.AutoCreateTime > 0 || f.AutoUpdateTime > 0 {
+			continue
+		}
+		w := f.ReflectValueOf(ctx, wv).Interface()
+		g := f.ReflectValueOf(ctx, gv).Interface()
+		if !sameValue(w, g) {
+			return f.DBName, false
+		}
+	}
+	return "", true
+}
+
+// sameValue 比较两个列值，time.Time 使用 Equal
+func sameValue(a, b interface{}) bool {
+	switch at := a.(type) {
+	case time.Time:
+		bt, ok := b.(time.Time)
+		return ok && at.Equal(bt)
+	case *time.Time:
+		bt, ok := b.(*time.Time)
+		return ok && (at == nil) == (bt == nil) && (at == nil || at.Equal(*bt))
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// groupByDB 把分片按所在的库分组，保持首次出现的顺序
+func groupByDB(shards []Shard) ([]*gorm.DB, map[*gorm.DB][]Shard) {
+	byDB := make(map[*gorm.DB][]Shard)
+	var dbs []*gorm.DB
+	for _, shard := range shards {
+		if _, ok := byDB[shard.DB]; !ok {
+			dbs = append(dbs, shard.DB)
+		}
+		byDB[shard.DB] = append(byDB[shard.DB], shard)
+	}
+	return dbs, byDB
+}
@@ -0,0 +1,76 @@
+package cachedb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFlushDurable(t *testing.T) {
+	type Player struct {
+		ID       uint
+		Gold     int
+		Bag      []string `gorm:"serializer:json"`
+		LastSeen time.Time
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, Gold: 10})
+	db.Create(&Player{ID: 2, Gold: 20})
+	ctx := context.Background()
+
+	c := newTestCache[Player](t, db, 10)
+	p1, _ := c.Get(1)
+	p2, _ := c.Get(2)
+	p1.Gold = 0 // 零值也要写入
+	p1.Bag = []string{"sword"}
+	p1.LastSeen = time.Now()
+	p2.Gold = 25
+	if err := c.FlushDurable(ctx); err != nil {
+		t.Fatalf("FlushDurable failed: %v", err)
+	}
+	if dirty := c.DirtyKeys(); len(dirty) != 0 {
+		t.Errorf("expected all keys clean, got %v", dirty)
+	}
+	var stored Player
+	db.First(&stored, 1)
+	if stored.Gold != 0 || len(stored.Bag) != 1 {
+		t.Errorf("unexpected stored row %+v", stored)
+	}
+
+	// 回读不一致时整个事务回滚，对象保持为脏
+	db.Exec("CREATE TRIGGER clamp AFTER UPDATE ON players WHEN NEW.gold = 13 BEGIN UPDATE players SET gold = 0 WHERE id = NEW.id; END")
+	p1.Gold = 5
+	p2.Gold = 13
+	err := c.FlushDurable(ctx)
+	if !errors.Is(err, ErrFlushVerify) {
+		t.Fatalf("expected ErrFlushVerify, got %v", err)
+	}
+	if dirty := c.DirtyKeys(); len(dirty) != 2 {
+		t.Errorf("expected both keys to stay dirty, got %v", dirty)
+	}
+	db.First(&stored, 1)
+	if stored.Gold != 0 {
+		t.Errorf("expected key 1 to be rolled back, got %d", stored.Gold)
+	}
+
+	// 数据库中不存在的行同样视为失败
+	db.Exec("DROP TRIGGER clamp")
+	db.Delete(&Player{}, 2)
+	if err := c.FlushDurable(ctx); !errors.Is(err, ErrFlushVerify) {
+		t.Errorf("expected ErrFlushVerify for missing row, got %v", err)
+	}
+
+	// 旧值未知的 Set 与普通刷盘一样插入新行
+	if err := c.Set(3, Player{ID: 3, Gold: 30}); err != nil {
+		t.Fatal(err)
+	}
+	c.Invalidate(2) // 行已被外部删除，丢弃本地修改
+	if err := c.FlushDurable(ctx); err != nil {
+		t.Fatalf("FlushDurable failed for a new key: %v", err)
+	}
+	var inserted Player
+	if err := db.First(&inserted, 3).Error; err != nil || inserted.Gold != 30 {
+		t.Errorf("expected key 3 to be inserted, got %+v, %v", inserted, err)
+	}
+}
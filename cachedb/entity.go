@@ -0,0 +1,7 @@
+package cachedb
+
+// Entity 返回缓存实例的实体标签，即模型对应的表名（WithTable 指定时为该表名，分片时为模型的基础表名）。
+// 该实例输出的日志都带有 entity 字段，CacheStats 也带有同样的标签，便于多个缓存共存时按模型筛选
+func (c *CacheDB[T]) Entity() string {
+	return c.entity
+}
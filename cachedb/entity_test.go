@@ -0,0 +1,30 @@
+package cachedb
+
+import "testing"
+
+func TestEntityLabel(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1})
+
+	logs := &recordLogger{}
+	c := newTestCache[Player](t, db, 10, WithLogger[Player](logs))
+	if c.Entity() != "players" || c.Stats().Entity != "players" {
+		t.Errorf("expected entity players, got %q", c.Entity())
+	}
+	p, _ := c.Get(1)
+	p.Gold = 5
+	c.Flush(1)
+	saved := logs.find("Saved changes")
+	if len(saved) != 1 || saved[0].fields[0] != "entity" || saved[0].fields[1] != "players" {
+		t.Errorf("expected labelled log, got %+v", saved)
+	}
+
+	s1 := newTestCache[Player](t, db, 10, WithTable[Player]("players_s1"))
+	if s1.Entity() != "players_s1" {
+		t.Errorf("expected entity players_s1, got %q", s1.Entity())
+	}
+}
@@ -0,0 +1,45 @@
+package cachedb
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 调用方可以通过 errors.Is 判断的错误，其余的哨兵错误定义在各自的文件中（例如 ErrReadOnly、ErrQuarantined）
+var (
+	// ErrNotFound 表示数据库中不存在该 key，同时仍可通过 errors.Is 匹配 gorm.ErrRecordNotFound
+	ErrNotFound = errors.New("entity not found")
+	// ErrWriteFailed 表示写回数据库失败，errors.As 可以取出 *WriteError 获得 key 和失败次数
+	ErrWriteFailed = errors.New("write failed")
+	// ErrCacheClosed 表示缓存已经 Close，不再接受读写
+	ErrCacheClosed = errors.New("cache closed")
+	// ErrValidation 与 ErrInvalidEntity 相同，表示对象未通过校验
+	ErrValidation = ErrInvalidEntity
+)
+
+// WriteError 是一次失败的写回，匹配 ErrWriteFailed，并可通过 errors.Is/As 继续匹配底层错误
+type WriteError struct {
+	Key      interface{}
+	Attempts int // 上次成功落库后失败的写入次数，包括本次
+	Err      error
+}
+
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("failed to update key %v (attempt %d): %v", e.Key, e.Attempts, e.Err)
+}
+
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+func (e *WriteError) Is(target error) bool {
+	return target == ErrWriteFailed
+}
+
+// checkOpen 在缓存已经 Close 时返回 ErrCacheClosed
+func (c *CacheDB[T]) checkOpen() error {
+	if c.closed.Load() {
+		return ErrCacheClosed
+	}
+	return nil
+}
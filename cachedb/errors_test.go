@@ -0,0 +1,49 @@
+package cachedb
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestErrorTaxonomy(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1})
+	c := newTestCache[Player](t, db, 10, WithValidator[Player](func(_ interface{}, p *Player) error {
+		if p.Gold < 0 {
+			return errors.New("negative gold")
+		}
+		return nil
+	}))
+
+	if _, err := c.Get(2); !errors.Is(err, ErrNotFound) || !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("expected a missing row to match ErrNotFound and gorm.ErrRecordNotFound, got %v", err)
+	}
+
+	c.Update(1, func(p *Player) { p.Gold = -1 })
+	c.Flush(1)
+	err := c.Flush(1)
+	var werr *WriteError
+	if !errors.Is(err, ErrWriteFailed) || !errors.As(err, &werr) || !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected a validation write failure, got %v", err)
+	}
+	if werr.Key != uint(1) || werr.Attempts != 2 {
+		t.Errorf("WriteError = %+v, want key 1 after 2 attempts", werr)
+	}
+
+	c.Update(1, func(p *Player) { p.Gold = 0 })
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(1); !errors.Is(err, ErrCacheClosed) {
+		t.Errorf("expected Get after Close to fail with ErrCacheClosed, got %v", err)
+	}
+	if err := c.Set(1, Player{ID: 1}); !errors.Is(err, ErrCacheClosed) {
+		t.Errorf("expected Set after Close to fail with ErrCacheClosed, got %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package cachedb
+
+import "github.com/bluele/gcache"
+
+// EvictNow 立即淘汰 key，与容量不足时的淘汰走相同的回写路径。
+// 被固定或全表常驻的对象只回写不丢弃。返回 key 是否在缓存中
+func (c *CacheDB[T]) EvictNow(key interface{}) bool {
+	key = c.canonicalKey(key)
+	c.forgoChance(key)
+	return c.Cache.Remove(key)
+}
+
+// capacityProbe 是 SimulateCapacityPressure 临时占位的 key，不会被追踪，淘汰时不执行任何回调
+type capacityProbe int
+
+// SimulateCapacityPressure 模拟新数据涌入：按 LRU 顺序淘汰最久未访问的 n 个对象，
+// 用于在测试中触发淘汰回写而无需精确构造缓存容量。
+// 开启 WithPartitions 时 n 按各分区的对象数分摊，每个分区按自己的 LRU 顺序淘汰
+func (c *CacheDB[T]) SimulateCapacityPressure(n int) {
+	if p, ok := c.Cache.(*partitionedCache); ok {
+		p.pressure(n)
+		return
+	}
+	pressure(c.Cache, c.size, n)
+}
+
+// pressure 用占位 key 挤出 cache 中最久未访问的 n 个对象，size 为 cache 的容量
+func pressure(cache gcache.Cache, size, n int) {
+	n = min(n, cache.Len(false))
+	probes := size - cache.Len(false) + n // 先占满空位，之后每个占位淘汰一个对象
+	for i := 0; i < probes; i++ {
+		cache.Set(capacityProbe(i), nil)
+	}
+	for i := 0; i < probes; i++ {
+		cache.Remove(capacityProbe(i))
+	}
+}
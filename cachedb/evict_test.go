@@ -0,0 +1,59 @@
+package cachedb
+
+import "testing"
+
+func TestEvictNow(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1})
+
+	c := newTestCache[Player](t, db, 10)
+	c.Update(1, func(p *Player) { p.Gold = 5 })
+	if !c.EvictNow(1) {
+		t.Fatalf("expected key 1 to be cached")
+	}
+	if c.EvictNow(1) {
+		t.Errorf("expected key 1 to be gone after eviction")
+	}
+	var stored Player
+	db.First(&stored, 1)
+	if stored.Gold != 5 {
+		t.Errorf("expected eviction to write back gold 5, got %d", stored.Gold)
+	}
+}
+
+func TestSimulateCapacityPressure(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	for i := 1; i <= 3; i++ {
+		db.Create(&Player{ID: uint(i)})
+	}
+
+	c := newTestCache[Player](t, db, 10)
+	for i := 1; i <= 3; i++ {
+		c.Update(i, func(p *Player) { p.Gold = 9 })
+	}
+	c.Get(1) // 最近访问，应最后被淘汰
+
+	c.SimulateCapacityPressure(2)
+	if n := c.Cache.Len(false); n != 1 || !c.Cache.Has(uint(1)) {
+		t.Errorf("expected only key 1 to remain, len=%d", n)
+	}
+	var stored []Player
+	db.Where("gold = ?", 9).Find(&stored)
+	if len(stored) != 2 {
+		t.Errorf("expected 2 evicted rows written back, got %d", len(stored))
+	}
+	c.mu.Lock()
+	tracked := len(c.values)
+	c.mu.Unlock()
+	if tracked != 1 {
+		t.Errorf("expected 1 tracked key, got %d", tracked)
+	}
+}
@@ -0,0 +1,60 @@
+package cachedb
+
+import "reflect"
+
+// isFlat 判断类型是否只由标量组成（数字、布尔、字符串、time.Time 以及它们组成的数组和结构体），
+// 这类值直接赋值即为深拷贝，== 与 reflect.DeepEqual 的结果相同
+func isFlat(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	case reflect.Array:
+		return isFlat(t.Elem())
+	case reflect.Struct:
+		if t == timeType {
+			return true // Location 指针不可变，共享是安全的
+		}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" || !isFlat(f.Type) {
+				return false // 默认拷贝会跳过未导出字段，直接赋值会改变这一行为
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// chooseCopyPlan 在构造时选择拷贝与比较的实现：只由标量组成的模型直接赋值并用 == 比较，
+// 其余模型执行预先编译的计划（见 planBuilder），WithCopier 指定的拷贝函数保持不变
+func (c *CacheDB[T]) chooseCopyPlan() {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if isFlat(typ) {
+		if !c.customCopier {
+			c.copier = func(v T) (T, error) { return v, nil }
+		}
+		c.equal = func(a, b T) bool { return interface{}(a) == interface{}(b) }
+		return
+	}
+
+	b := newPlanBuilder()
+	equal := b.equalOf(typ)
+	c.equal = func(x, y T) bool {
+		return equal(reflect.ValueOf(&x).Elem(), reflect.ValueOf(&y).Elem())
+	}
+	if c.customCopier {
+		return
+	}
+	copyFn := b.copyOf(typ)
+	c.copier = func(src T) (T, error) {
+		var dst T
+		if err := copyFn(reflect.ValueOf(&dst).Elem(), reflect.ValueOf(&src).Elem()); err != nil {
+			var zero T
+			return zero, err
+		}
+		return dst, nil
+	}
+}
@@ -0,0 +1,60 @@
+package cachedb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIsFlat(t *testing.T) {
+	type Pos struct{ X, Y float64 }
+	cases := []struct {
+		v    interface{}
+		flat bool
+	}{
+		{struct {
+			ID   uint
+			Name string
+			At   time.Time
+			Pos  Pos
+			Grid [4]int8
+		}{}, true},
+		{struct{ Bag []string }{}, false},
+		{struct{ Owner *Pos }{}, false},
+		{struct{ Tags map[string]int }{}, false},
+		{struct{ secret int }{}, false},
+	}
+	for _, tc := range cases {
+		if got := isFlat(reflect.TypeOf(tc.v)); got != tc.flat {
+			t.Errorf("isFlat(%T) = %v, want %v", tc.v, got, tc.flat)
+		}
+	}
+}
+
+func TestFlatModelFastPath(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+		Name string
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, Gold: 10, Name: "a"})
+
+	c := newTestCache[Player](t, db, 10)
+	p, _ := c.Get(1)
+	if len(c.DirtyKeys()) != 0 {
+		t.Fatalf("expected clean entry after load")
+	}
+	p.Name = "b"
+	if !c.equal(Player{ID: 1}, Player{ID: 1}) || len(c.DirtyKeys()) != 1 {
+		t.Errorf("expected the modification to be detected")
+	}
+	if err := c.Flush(1); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	var stored Player
+	db.First(&stored, 1)
+	if stored.Name != "b" || len(c.DirtyKeys()) != 0 {
+		t.Errorf("unexpected stored row %+v", stored)
+	}
+}
@@ -0,0 +1,282 @@
+package cachedb
+
+import (
+	"fmt"
+	"hash/crc32"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FlushStats 是异步回写的统计信息
+type FlushStats struct {
+	Queued     int           // 当前排队中的 key 数
+	Completed  uint64        // 成功回写的次数
+	Failed     uint64        // 回写失败的次数
+	Overflowed uint64        // 队列满时按 OverflowPolicy 处理的次数
+	AvgLatency time.Duration // 平均排队时间（入队到开始回写）
+	MaxLatency time.Duration // 最长排队时间
+}
+
+// flushJob 是一次待执行的回写
+type flushJob struct {
+	key    interface{}
+	flush  func(key interface{}) error
+	queued time.Time
+}
+
+// FlushPool 用固定数量的协程执行回写，可以由多个缓存共享（见 WithFlushPool）。
+// 同一个 key 总是交给同一个协程，因此对同一 key 的写入不会被重排，不同 key 之间并行执行
+type FlushPool struct {
+	queues []chan flushJob
+	wg     sync.WaitGroup
+
+	mu     sync.RWMutex // 保护 closed，防止向已关闭的队列提交
+	closed bool
+
+	queued    atomic.Int64
+	completed atomic.Uint64
+	failed    atomic.Uint64
+	overflow  atomic.Uint64
+	waitSum   atomic.Int64 // 累计排队时间（纳秒）
+	waitMax   atomic.Int64
+}
+
+// OverflowPolicy 决定回写队列满时的处理方式，即数据库变慢时的行为
+type OverflowPolicy int
+
+const (
+	// OverflowBlock 阻塞淘汰操作直到队列有空位（默认）
+	OverflowBlock OverflowPolicy = iota
+	// OverflowInline 在淘汰回调中同步回写
+	OverflowInline
+	// OverflowSpill 把修改追加到预写日志，对象留在内存中等待之后的 Flush，需配合 WithWAL
+	OverflowSpill
+)
+
+// WithWriteQueue 设置每个刷盘协程的队列长度及队列满时的处理方式。
+// 使用共享的 FlushPool 时队列长度由 NewFlushPool 决定
+func WithWriteQueue[T any](size int, policy OverflowPolicy) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.queueSize = size
+		c.overflow = policy
+	}
+}
+
+// WithFlushWorkers 开启异步回写：淘汰时不再在缓存回调中同步写库，
+// 而是交给 n 个刷盘协程执行。回写完成前对象保持追踪，再次访问时直接放回缓存。
+// 使用完毕后需调用 Close 等待排队中的回写完成
+func WithFlushWorkers[T any](n int) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.flushWorkers = n
+	}
+}
+
+// WithFlushPool 使用共享的刷盘协程池开启异步回写，协程池的生命周期由创建者管理，
+// 缓存的 Close 不会停止它
+func WithFlushPool[T any](p *FlushPool) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.pool = p
+	}
+}
+
+// NewFlushPool 创建并启动 workers 个刷盘协程，每个协程的队列长度为 queueSize
+func NewFlushPool(workers, queueSize int) *FlushPool {
+	p := &FlushPool{
+		queues: make([]chan flushJob, workers),
+	}
+	for i := range p.queues {
+		p.queues[i] = make(chan flushJob, queueSize)
+		p.wg.Add(1)
+		go p.run(p.queues[i])
+	}
+	return p
+}
+
+// worker 返回 key 所属的协程编号
+func (p *FlushPool) worker(key interface{}) int {
+	return int(crc32.ChecksumIEEE([]byte(fmt.Sprint(key))) % uint32(len(p.queues)))
+}
+
+// submit 把 key 加入对应协程的队列，wait 为 true 时队列满会阻塞。
+// 已关闭或队列满（不等待）时返回 false
+func (p *FlushPool) submit(key interface{}, flush func(interface{}) error, wait bool) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return false
+	}
+	job := flushJob{key: key, flush: flush, queued: time.Now()}
+	queue := p.queues[p.worker(key)]
+	p.queued.Add(1)
+	if wait {
+		queue <- job
+		return true
+	}
+	select {
+	case queue <- job:
+		return true
+	default:
+		p.queued.Add(-1)
+		p.overflow.Add(1)
+		return false
+	}
+}
+
+func (p *FlushPool) run(queue chan flushJob) {
+	defer p.wg.Done()
+	for job := range queue {
+		p.queued.Add(-1)
+		wait := int64(time.Since(job.queued))
+		p.waitSum.Add(wait)
+		for {
+			cur := p.waitMax.Load()
+			if wait <= cur || p.waitMax.CompareAndSwap(cur, wait) {
+				break
+			}
+		}
+
+		if err := job.flush(job.key); err != nil {
+			p.failed.Add(1) // 提交回写的缓存负责记录日志
+			continue
+		}
+		p.completed.Add(1)
+	}
+}
+
+func (p *FlushPool) isClosed() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.closed
+}
+
+// Stop 关闭队列并等待排队中的回写完成，之后提交的回写会同步执行
+func (p *FlushPool) Stop() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	p.mu.Unlock()
+	for _, q := range p.queues {
+		close(q)
+	}
+	p.wg.Wait()
+}
+
+// Stats 返回协程池的统计信息
+func (p *FlushPool) Stats() FlushStats {
+	s := FlushStats{
+		Queued:     int(p.queued.Load()),
+		Completed:  p.completed.Load(),
+		Failed:     p.failed.Load(),
+		Overflowed: p.overflow.Load(),
+		MaxLatency: time.Duration(p.waitMax.Load()),
+	}
+	if n := s.Completed + s.Failed; n > 0 {
+		s.AvgLatency = time.Duration(p.waitSum.Load() / int64(n))
+	}
+	return s
+}
+
+// FlushStats 返回异步回写的统计信息，未开启 WithFlushWorkers 时返回零值
+func (c *CacheDB[T]) FlushStats() FlushStats {
+	if c.pool == nil {
+		return FlushStats{}
+	}
+	return c.pool.Stats()
+}
+
+// writeBehind 把淘汰的对象交给刷盘协程以 reason 回写，没有修改的对象直接停止追踪
+func (c *CacheDB[T]) writeBehind(key, evicted interface{}, reason FlushReason) {
+	c.mu.Lock()
+	if !c.current(key, evicted) {
+		c.mu.Unlock()
+		return // 已被移交、丢弃或被 Set 替换，无需回写
+	}
+	value := c.values[key]
+	if !c.modified(key, value) {
+		if !c.resident(key) {
+			c.untrack(key)
+		}
+		c.mu.Unlock()
+		return
+	}
+	c.detach(key) // 回写完成前保持追踪，Flush 成功后由 settle 停止追踪
+	c.setState(key, StateFlushing)
+	c.mu.Unlock()
+
+	if c.loop != nil {
+		c.loop.post(func() {
+			if err := c.flushBehind(key, reason); err != nil {
+				c.log(LogError, "Evict save failed", "key", key, "err", err)
+			}
+		})
+		return
+	}
+
+	flush := func(key interface{}) error {
+		err := c.flushBehind(key, reason)
+		if err != nil {
+			c.log(LogError, "Write-back failed", "key", key, "err", err)
+		}
+		return err
+	}
+	if c.pool.submit(key, flush, c.overflow == OverflowBlock) {
+		return
+	}
+	// 队列已满或已经 Close，Close 之后总是同步回写
+	if c.overflow == OverflowSpill && !c.pool.isClosed() {
+		err := c.spill(key)
+		if err == nil {
+			return
+		}
+		c.log(LogWarn, "WAL spill failed, writing inline", "key", key, "err", err)
+	}
+	if err := c.flushBehind(key, reason); err != nil {
+		c.log(LogError, "Evict save failed", "key", key, "err", err)
+	}
+}
+
+// flushBehind 回写已淘汰的对象，失败时把它追加到死信日志
+func (c *CacheDB[T]) flushBehind(key interface{}, reason FlushReason) error {
+	err := c.flush(key, reason)
+	if err != nil {
+		c.mu.Lock()
+		c.deadLetter(key)
+		c.mu.Unlock()
+	}
+	return err
+}
+
+// spill 把 key 的当前值追加到预写日志，对象继续滞留在内存中等待 Flush 重试
+func (c *CacheDB[T]) spill(key interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[key]
+	if !ok {
+		return nil
+	}
+	cpy, err := c.copier(*value)
+	if err != nil {
+		return err
+	}
+	c.setState(key, StateClean) // 不再排队，等待 Flush 重试
+	return c.wal.Append(key, cpy)
+}
+
+// Close 等待排队中的异步回写完成，并把剩余的脏数据写回数据库。
+// 共享的 FlushPool 不会被停止，排队中的回写会由它继续执行。Close 之后的读写返回 ErrCacheClosed
+func (c *CacheDB[T]) Close() error {
+	if c.pool != nil && c.ownsPool {
+		c.pool.Stop()
+	}
+	c.Pump()
+	c.actors.wg.Wait()
+	c.stopCoalesce()
+	c.closed.Store(true)
+	err := c.flushAll(FlushShutdown).first
+	c.stopL2()
+	return err
+}
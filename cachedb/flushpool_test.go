@@ -0,0 +1,156 @@
+package cachedb
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFlushWorkers(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	for i := 1; i <= 20; i++ {
+		db.Create(&Player{ID: uint(i)})
+	}
+
+	c := newTestCache[Player](t, db, 100, WithFlushWorkers[Player](4))
+	for round := 1; round <= 3; round++ {
+		for i := 1; i <= 20; i++ {
+			if err := c.Update(i, func(p *Player) { p.Gold = round * 10 }); err != nil {
+				t.Fatalf("Update failed: %v", err)
+			}
+			c.Cache.Remove(uint(i)) // 交给刷盘协程异步回写
+		}
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	var players []Player
+	db.Find(&players)
+	for _, p := range players {
+		if p.Gold != 30 {
+			t.Errorf("player %d: expected last write 30, got %d", p.ID, p.Gold)
+		}
+	}
+	stats := c.FlushStats()
+	if stats.Completed == 0 || stats.Failed != 0 || stats.Queued != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	c.mu.Lock()
+	tracked := len(c.values)
+	c.mu.Unlock()
+	if tracked != 0 {
+		t.Errorf("expected all evicted keys to be untracked, got %d", tracked)
+	}
+}
+
+func TestWriteQueueOverflow(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	for i := 1; i <= 3; i++ {
+		db.Create(&Player{ID: uint(i)})
+	}
+	walPath := filepath.Join(t.TempDir(), "players.wal")
+	wal, err := OpenFileWAL[Player](walPath)
+	if err != nil {
+		t.Fatalf("OpenFileWAL failed: %v", err)
+	}
+	defer wal.Close()
+
+	if _, err := NewWithCache[Player](db, 10, WithWriteQueue[Player](1, OverflowSpill)); err == nil {
+		t.Errorf("expected error for OverflowSpill without WAL")
+	}
+
+	pool := NewFlushPool(1, 1)
+	c := newTestCache[Player](t, db, 10,
+		WithFlushPool[Player](pool),
+		WithWriteQueue[Player](0, OverflowSpill),
+		WithWAL[Player](wal))
+
+	// 先让唯一的协程阻塞，模拟数据库变慢
+	release := make(chan struct{})
+	pool.submit("block", func(interface{}) error {
+		<-release
+		return nil
+	}, true)
+	for pool.Stats().Queued > 0 {
+		time.Sleep(time.Millisecond) // 等待协程取走阻塞任务
+	}
+
+	for i := 1; i <= 3; i++ {
+		c.Update(i, func(p *Player) { p.Gold = 100 })
+		c.Cache.Remove(uint(i))
+	}
+	// 第 1 个占满队列，第 2、3 个溢出到日志
+	if stats := c.FlushStats(); stats.Overflowed != 2 {
+		t.Fatalf("expected 2 overflows, got %+v", stats)
+	}
+	var stored Player
+	db.First(&stored, 3)
+	if stored.Gold != 0 {
+		t.Errorf("spilled key should not be written inline, got %d", stored.Gold)
+	}
+
+	// 模拟崩溃：日志中保留着 key 2、3 的修改
+	n, err := ReplayWAL(newTestCache[Player](t, db, 10), walPath)
+	if err != nil || n != 2 {
+		t.Fatalf("ReplayWAL = %d, %v", n, err)
+	}
+	var replayed Player
+	db.First(&replayed, 3)
+	if replayed.Gold != 100 {
+		t.Errorf("expected replayed gold 100, got %d", replayed.Gold)
+	}
+
+	close(release)
+	pool.Stop()
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	var players []Player
+	db.Find(&players)
+	for _, p := range players {
+		if p.Gold != 100 {
+			t.Errorf("player %d: expected gold 100, got %d", p.ID, p.Gold)
+		}
+	}
+}
+
+// failingSaveStore 的写回总是失败
+type failingSaveStore[T any] struct {
+	Store[T]
+}
+
+func (failingSaveStore[T]) Save(interface{}, *T, *T) error { return errors.New("db down") }
+
+func TestFlushWorkersLogFailures(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1})
+
+	logger := &recordLogger{}
+	c := newTestCache[Player](t, db, 10,
+		WithFlushWorkers[Player](1),
+		WithLogger[Player](logger),
+		WithStore(func(base Store[Player]) Store[Player] { return failingSaveStore[Player]{base} }))
+	c.Update(1, func(p *Player) { p.Gold = 10 })
+	c.Cache.Remove(uint(1))
+	c.Close()
+	if c.FlushStats().Failed == 0 {
+		t.Fatal("expected the write-back to fail")
+	}
+	if len(logger.find("Write-back failed")) == 0 {
+		t.Error("expected the failure to reach the cache's logger")
+	}
+}
@@ -0,0 +1,36 @@
+package cachedb
+
+// FlushReason 是一次落库的原因，记录在落库日志、FlushEvent、CDC 与 webhook 变更以及 Stats.FlushReasons 中，
+// 用于排查意外的数据库写入量
+type FlushReason string
+
+const (
+	FlushEvict          FlushReason = "evict"           // 容量淘汰、过期或 EvictNow
+	FlushPurge          FlushReason = "purge"           // 清空缓存，见 Purge
+	FlushAutosave       FlushReason = "autosave"        // 定时或合并窗口触发的自动落库，见 WithCoalesce、Checkpointer
+	FlushManual         FlushReason = "manual"          // 业务代码直接调用 Flush、FlushAll 等
+	FlushShutdown       FlushReason = "shutdown"        // Close 时的最终落库
+	FlushTransfer       FlushReason = "transfer"        // 移交所有权前的落库，见 TransferOwnership
+	FlushDirtyThreshold FlushReason = "dirty_threshold" // 脏数据超过阈值，供使用方的策略通过 FlushWithReason 标记
+)
+
+// FlushWithReason 与 Flush 相同，但以 reason 记录这次落库，供定时任务、脏数据阈值等自定义策略使用
+func (c *CacheDB[T]) FlushWithReason(key interface{}, reason FlushReason) error {
+	return c.flush(c.canonicalKey(key), reason)
+}
+
+// runFlushHooks 记录落库原因并执行 OnFlush 回调，调用方需持有 c.mu
+func (c *CacheDB[T]) runFlushHooks(key interface{}, reason FlushReason, old, new T) {
+	c.flushReason = reason
+	c.flushCounts[reason]++
+	for _, fn := range c.onFlush {
+		c.runFlushHook(fn, key, old, new)
+	}
+}
+
+// flushAll 以 reason 写回所有脏数据
+func (c *CacheDB[T]) flushAll(reason FlushReason) *FlushReport {
+	report := &FlushReport{reason: reason}
+	c.flushKeys(c.DirtyKeys(), report)
+	return report
+}
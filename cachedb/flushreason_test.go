@@ -0,0 +1,45 @@
+package cachedb
+
+import "testing"
+
+func TestFlushReasons(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	for id := uint(1); id <= 4; id++ {
+		db.Create(&Player{ID: id})
+	}
+	bus := NewMemoryBus[Player]()
+	var reasons []FlushReason
+	bus.SubscribeFlush(func(e FlushEvent[Player]) { reasons = append(reasons, e.Reason) })
+	c := newTestCache[Player](t, db, 1, WithFlushPublisher[Player](bus))
+
+	c.Update(1, func(p *Player) { p.Gold = 1 })
+	c.Get(2) // 淘汰 1
+	c.Update(2, func(p *Player) { p.Gold = 2 })
+	c.Flush(2)
+	c.Update(2, func(p *Player) { p.Gold = 3 })
+	c.FlushWithReason(2, FlushDirtyThreshold)
+	c.Update(2, func(p *Player) { p.Gold = 4 })
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []FlushReason{FlushEvict, FlushManual, FlushDirtyThreshold, FlushShutdown}
+	if len(reasons) != len(want) {
+		t.Fatalf("expected events %v, got %v", want, reasons)
+	}
+	for i := range want {
+		if reasons[i] != want[i] {
+			t.Errorf("event %d: expected reason %s, got %s", i, want[i], reasons[i])
+		}
+	}
+	stats := c.Stats().FlushReasons
+	for _, reason := range want {
+		if stats[string(reason)] != 1 {
+			t.Errorf("expected one %s flush in stats, got %v", reason, stats)
+		}
+	}
+}
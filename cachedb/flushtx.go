@@ -0,0 +1,180 @@
+package cachedb
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WithFlushTransaction 让 FlushAll 把每 size 行脏数据放在一个事务中写回，0 表示不使用事务。
+// 事务内仍按 WithBulkFlush 的批大小合并语句；事务失败时整组回滚，再逐行单独重试，
+// 因此个别行的失败不会中断整个检查点。只对默认的 gorm 存储生效
+func WithFlushTransaction[T any](size int) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.flushTx = size
+	}
+}
+
+// FlushTxOptions 是落库事务的设置，不同数据库的默认隔离级别不同
+// （MySQL 为 REPEATABLE READ，PostgreSQL 为 READ COMMITTED），会影响并发写入时的冲突行为
+type FlushTxOptions struct {
+	Isolation        sql.IsolationLevel // 隔离级别，sql.LevelDefault 使用数据库的默认值
+	StatementTimeout time.Duration      // 事务内每条语句的超时，按 Dialect.StatementTimeout 设置，0 表示不限制
+}
+
+// WithFlushTxOptions 设置落库事务（WithFlushTransaction、FlushDurable 以及 WithCollections 的写入）的
+// 隔离级别和语句超时。数据库不支持语句超时（未注册 Dialect.StatementTimeout）时忽略超时
+func WithFlushTxOptions[T any](opts FlushTxOptions) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.flushTxOpts = opts
+	}
+}
+
+// flushTransaction 按 WithFlushTxOptions 在 db 上开启落库事务并执行 fn
+func (c *CacheDB[T]) flushTransaction(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	var opts []*sql.TxOptions
+	if c.flushTxOpts.Isolation != sql.LevelDefault {
+		opts = append(opts, &sql.TxOptions{Isolation: c.flushTxOpts.Isolation})
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if timeout := c.flushTxOpts.StatementTimeout; timeout > 0 {
+			if set := dialectOf(tx).StatementTimeout; set != nil {
+				if err := tx.Session(&gorm.Session{NewDB: true}).Exec(set(timeout)).Error; err != nil {
+					return fmt.Errorf("failed to set statement timeout: %w", err)
+				}
+			}
+		}
+		return fn(tx)
+	}, opts...)
+}
+
+// FlushReport 是一次全量刷盘中每个 key 的结果
+type FlushReport struct {
+	Flushed []interface{}         // 成功落库的 key
+	Retried []interface{}         // 所在的批次或事务失败后单独重试过的 key
+	Failed  map[interface{}]error // 失败的 key 及原因
+
+	first  error       // 遇到的第一个错误，作为 FlushAll 的返回值
+	reason FlushReason // 落库原因，为空时视为 FlushManual
+}
+
+// record 记录一个 key 的结果
+func (r *FlushReport) record(key interface{}, err error, retried bool) {
+	if retried {
+		r.Retried = append(r.Retried, key)
+	}
+	if err == nil {
+		r.Flushed = append(r.Flushed, key)
+		return
+	}
+	if r.Failed == nil {
+		r.Failed = make(map[interface{}]error)
+	}
+	r.Failed[key] = err
+	if r.first == nil {
+		r.first = err
+	}
+}
+
+// Err 在有 key 失败时返回 *BatchError
+func (r *FlushReport) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	return &BatchError{Errors: r.Failed}
+}
+
+// FlushAllReport 将所有脏数据写回数据库并返回每个 key 的结果
+func (c *CacheDB[T]) FlushAllReport() *FlushReport {
+	return c.flushAll(FlushManual)
+}
+
+// flushKeys 按配置的分组方式写回 keys，结果记入 report
+func (c *CacheDB[T]) flushKeys(keys []interface{}, report *FlushReport) {
+	if report.reason == "" {
+		report.reason = FlushManual
+	}
+	save, size := c.chunkSaver()
+	if save == nil {
+		for _, key := range keys {
+			report.record(key, c.flush(key, report.reason), false)
+		}
+		return
+	}
+	for len(keys) > 0 {
+		n := min(size, len(keys))
+		c.flushChunk(save, keys[:n], report)
+		keys = keys[n:]
+	}
+}
+
+// chunkSaver 根据配置选择分组写回的方式及每组的行数，不支持分组时返回 nil
+func (c *CacheDB[T]) chunkSaver() (chunkSaver[T], int) {
+	if c.readOnly {
+		return nil, 0 // 逐个 Flush，本地修改被丢弃
+	}
+	if _, ok := c.store.(gormStore[T]); ok && c.flushTx > 0 {
+		return c.saveInTransaction, c.flushTx
+	}
+	if _, ok := c.store.(gormStore[T]); ok && len(c.collections) > 0 {
+		return nil, 0 // 批量 upsert 无法删除集合中消失的元素，逐个 Flush
+	}
+	if saver, ok := c.store.(BatchSaver[T]); ok && c.flushBatch > 1 {
+		return func(keys []interface{}, _ []T, values []*T) error {
+			return saver.SaveBatch(keys, values)
+		}, c.flushBatch
+	}
+	return nil, 0
+}
+
+// saveInTransaction 在事务中写回一组脏数据，分布在多个库的分片各自使用一个事务
+func (c *CacheDB[T]) saveInTransaction(keys []interface{}, olds []T, values []*T) error {
+	order, groups := c.groupByShard(keys)
+	dbs, byDB := groupByDB(order)
+
+	for _, db := range dbs {
+		conn, done := withTimeout(db, c.flushTimeout, ErrFlushTimeout)
+		err := done(c.flushTransaction(conn, func(tx *gorm.DB) error {
+			for _, shard := range byDB[db] {
+				if err := c.saveShard(tx, shard.Table, groups[shard], keys, olds, values); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveShard 在事务 tx 中写回同一张表的行，idx 为这些行在 keys 中的下标
+func (c *CacheDB[T]) saveShard(tx *gorm.DB, table string, idx []int, keys []interface{}, olds []T, values []*T) error {
+	if c.flushBatch > 1 && len(c.collections) == 0 {
+		for len(idx) > 0 {
+			n := min(c.flushBatch, len(idx))
+			rows := make([]T, 0, n)
+			for _, i := range idx[:n] {
+				rows = append(rows, *values[i])
+			}
+			if err := c.upsert(tx.Table(table), rows); err != nil {
+				return err
+			}
+			idx = idx[n:]
+		}
+		return nil
+	}
+	for _, i := range idx {
+		model := olds[i] // 与 gormStore.Save 相同，不修改副本
+		start := time.Now()
+		result := c.updateRow(tx.Table(table), keys[i], &model, values[i])
+		c.observeFlush(keys[i], start, result.RowsAffected)
+		if err := result.Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package cachedb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFlushTransactionIsolatesFailures(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int `gorm:"check:gold >= 0"`
+	}
+	for _, bulk := range []int{1, 500} {
+		db := openTestDB(t, &Player{})
+		db.Exec("DELETE FROM players")
+		for i := 1; i <= 10; i++ {
+			db.Create(&Player{ID: uint(i), Gold: 1})
+		}
+
+		c := newTestCache[Player](t, db, 100, WithFlushTransaction[Player](4), WithBulkFlush[Player](bulk))
+		for i := 1; i <= 10; i++ {
+			p, _ := c.Get(i)
+			p.Gold = 50
+		}
+		bad, _ := c.Get(3)
+		bad.Gold = -1 // 违反约束，所在事务整体回滚
+
+		report := c.FlushAllReport()
+		if len(report.Flushed) != 9 || len(report.Failed) != 1 || report.Failed[uint(3)] == nil {
+			t.Fatalf("bulk=%d: unexpected report %+v", bulk, report)
+		}
+		retried := false
+		for _, key := range report.Retried {
+			retried = retried || key == uint(3)
+		}
+		if !retried || len(report.Retried) > 4 { // DirtyKeys 无序，最后一个事务可能只有 2 行
+			t.Errorf("bulk=%d: expected only the failed transaction's keys to be retried, got %v", bulk, report.Retried)
+		}
+		var batchErr *BatchError
+		if !errors.As(report.Err(), &batchErr) {
+			t.Errorf("bulk=%d: expected BatchError, got %v", bulk, report.Err())
+		}
+
+		var flushed int64
+		db.Model(&Player{}).Where("gold = 50").Count(&flushed)
+		if flushed != 9 {
+			t.Errorf("bulk=%d: expected 9 rows written back, got %d", bulk, flushed)
+		}
+		if dirty := c.DirtyKeys(); len(dirty) != 1 {
+			t.Errorf("bulk=%d: expected only key 3 to stay dirty, got %v", bulk, dirty)
+		}
+	}
+}
+
+// isolationRecorder 记录开启事务时请求的隔离级别
+type isolationRecorder struct {
+	*sql.DB
+	levels []sql.IsolationLevel
+}
+
+func (r *isolationRecorder) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	level := sql.LevelDefault
+	if opts != nil {
+		level = opts.Isolation
+	}
+	r.levels = append(r.levels, level)
+	return r.DB.BeginTx(ctx, opts)
+}
+
+func TestFlushTxOptions(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1) // 之后的查询复用落库事务的连接
+	rec := &isolationRecorder{DB: sqlDB}
+	db.ConnPool, db.Statement.ConnPool = rec, rec
+	for i := 1; i <= 3; i++ {
+		db.Create(&Player{ID: uint(i)})
+	}
+
+	c := newTestCache[Player](t, db, 10, WithFlushTransaction[Player](10), WithFlushTxOptions[Player](FlushTxOptions{
+		Isolation:        sql.LevelReadCommitted,
+		StatementTimeout: 1500 * time.Millisecond,
+	}))
+	for i := 1; i <= 3; i++ {
+		c.Update(i, func(p *Player) { p.Gold = 10 })
+	}
+	rec.levels = nil
+	if err := c.FlushAll(); err != nil {
+		t.Fatalf("FlushAll failed: %v", err)
+	}
+	if len(rec.levels) != 1 || rec.levels[0] != sql.LevelReadCommitted {
+		t.Errorf("expected one READ COMMITTED transaction, got %v", rec.levels)
+	}
+	var timeout int
+	db.Raw("PRAGMA busy_timeout").Scan(&timeout)
+	if timeout != 1500 {
+		t.Errorf("expected the statement timeout to be applied, got %d", timeout)
+	}
+
+	if _, err := NewWithCache[Player](db, 10, WithFlushTxOptions[Player](FlushTxOptions{StatementTimeout: -time.Second})); err == nil {
+		t.Error("expected a negative timeout to be rejected")
+	}
+}
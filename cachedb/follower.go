@@ -0,0 +1,128 @@
+package cachedb
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrReadOnly 表示在只读的跟随缓存上执行了写操作
+var ErrReadOnly = errors.New("cache is read-only")
+
+// FlushEvent 是一次成功落库的通知，Value 为写入数据库的值
+type FlushEvent[T any] struct {
+	Entity string // 实体标签，见 Entity；设置了 WithNamespace 时带命名空间前缀
+	Key    interface{}
+	Value  T
+	Patch  []byte      // 设置了 WithDiffEncoder 时为编码后的字段修改
+	Reason FlushReason // 落库原因
+}
+
+// FlushPublisher 发布落库事件，由拥有数据的节点通过 WithFlushPublisher 使用
+type FlushPublisher[T any] interface {
+	PublishFlush(event FlushEvent[T]) error
+}
+
+// FlushSubscriber 订阅落库事件，由跟随缓存通过 Follow 使用。返回的 cancel 用于取消订阅
+type FlushSubscriber[T any] interface {
+	SubscribeFlush(handler func(FlushEvent[T])) (cancel func(), err error)
+}
+
+// WithFlushPublisher 在每次落库成功后发布 FlushEvent。发布在持有内部锁时同步执行，
+// 跨进程的实现应只把事件放入发送队列；发布失败只记录日志
+func WithFlushPublisher[T any](p FlushPublisher[T]) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.publisher = p
+	}
+}
+
+// WithReadOnly 把缓存设为只读的跟随模式：从不写回数据库，Set、Create、Delete 和 FlushDurable 返回 ErrReadOnly，
+// 对缓存对象的本地修改在刷盘或淘汰时被丢弃并记录警告。配合 Follow 接收拥有数据的节点的落库事件，
+// 适用于排行榜、社交动态等读多写少的服务
+func WithReadOnly[T any]() Option[T] {
+	return func(c *CacheDB[T]) {
+		c.readOnly = true
+	}
+}
+
+// Follow 订阅落库事件并刷新本地缓存中的对应对象，不在缓存中的 key 会被忽略（之后按需从数据库加载）。
+// 通常与 WithReadOnly 一起使用
+func (c *CacheDB[T]) Follow(sub FlushSubscriber[T]) (cancel func(), err error) {
+	return sub.SubscribeFlush(func(e FlushEvent[T]) {
+		if e.Entity != "" && e.Entity != c.qualifiedEntity() {
+			return
+		}
+		c.Post(func() { c.follow(e) })
+	})
+}
+
+// follow 用落库事件刷新已缓存的 key
+func (c *CacheDB[T]) follow(e FlushEvent[T]) {
+	var key interface{}
+	var err error
+	if s, ok := e.Key.(string); ok {
+		key, err = c.ParseKey(s) // 跨进程传输的事件以字符串携带 key
+	} else {
+		key, err = c.normalizeKey(e.Key)
+	}
+	if err != nil {
+		c.log(LogWarn, "Follow skipped event", "key", e.Key, "err", err)
+		return
+	}
+	c.mu.Lock()
+	_, cached := c.values[key]
+	c.mu.Unlock()
+	if !cached {
+		return
+	}
+	if err := c.setLocal(key, e.Value); err != nil {
+		c.log(LogError, "Follow refresh failed", "key", key, "err", err)
+	}
+}
+
+// publishFlush 是发布落库事件的 OnFlush 回调
+func (c *CacheDB[T]) publishFlush(key interface{}, old, new T) {
+	event := FlushEvent[T]{Entity: c.qualifiedEntity(), Key: key, Value: new, Reason: c.flushReason}
+	event.Patch, _ = c.encodeDiff(key, &old, &new)
+	if err := c.publisher.PublishFlush(event); err != nil {
+		c.log(LogError, "Flush publish failed", "key", key, "err", err)
+	}
+}
+
+// MemoryBus 是进程内的落库事件总线，同时实现 FlushPublisher 和 FlushSubscriber。
+// 事件同步地按订阅顺序分发，每个订阅者收到独立的深拷贝
+type MemoryBus[T any] struct {
+	mu       sync.RWMutex
+	next     int
+	handlers map[int]func(FlushEvent[T])
+}
+
+// NewMemoryBus 创建进程内事件总线
+func NewMemoryBus[T any]() *MemoryBus[T] {
+	return &MemoryBus[T]{handlers: make(map[int]func(FlushEvent[T]))}
+}
+
+func (b *MemoryBus[T]) PublishFlush(event FlushEvent[T]) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, h := range b.handlers {
+		value, err := DeepCopy(event.Value)
+		if err != nil {
+			return err
+		}
+		h(FlushEvent[T]{Entity: event.Entity, Key: event.Key, Value: value, Patch: append([]byte(nil), event.Patch...), Reason: event.Reason})
+	}
+	return nil
+}
+
+func (b *MemoryBus[T]) SubscribeFlush(handler func(FlushEvent[T])) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.next
+	b.next++
+	b.handlers[id] = handler
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}, nil
+}
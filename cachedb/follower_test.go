@@ -0,0 +1,65 @@
+package cachedb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReadOnlyFollower(t *testing.T) {
+	type Score struct {
+		ID     uint
+		Points int
+	}
+	db := openTestDB(t, &Score{})
+	db.Create(&Score{ID: 1, Points: 10})
+	db.Create(&Score{ID: 2, Points: 20})
+
+	bus := NewMemoryBus[Score]()
+	owner := newTestCache[Score](t, db, 10, WithFlushPublisher[Score](bus))
+	follower := newTestCache[Score](t, db, 10, WithReadOnly[Score]())
+	cancel, err := follower.Follow(bus)
+	if err != nil {
+		t.Fatalf("Follow failed: %v", err)
+	}
+	defer cancel()
+
+	if s, _ := follower.Get(1); s.Points != 10 {
+		t.Fatalf("unexpected initial points %d", s.Points)
+	}
+	s, _ := owner.Get(1)
+	s.Points = 15
+	if err := owner.Flush(1); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if got, _ := follower.Get(1); got.Points != 15 {
+		t.Errorf("expected follower to be refreshed to 15, got %d", got.Points)
+	}
+	if dirty := follower.DirtyKeys(); len(dirty) != 0 {
+		t.Errorf("refreshed entries must be clean, got %v", dirty)
+	}
+
+	// 未缓存的 key 不会被事件填充
+	s2, _ := owner.Get(2)
+	s2.Points = 25
+	owner.Flush(2)
+	follower.mu.Lock()
+	_, cached := follower.values[uint(2)]
+	follower.mu.Unlock()
+	if cached {
+		t.Errorf("events must not populate uncached keys")
+	}
+
+	if err := follower.Set(1, Score{ID: 1, Points: 99}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly from Set, got %v", err)
+	}
+	local, _ := follower.Get(1)
+	local.Points = 99 // 本地修改不会写回
+	if err := follower.FlushAll(); err != nil {
+		t.Fatalf("FlushAll failed: %v", err)
+	}
+	var stored Score
+	db.First(&stored, 1)
+	if stored.Points != 15 {
+		t.Errorf("read-only cache wrote to the database: %d", stored.Points)
+	}
+}
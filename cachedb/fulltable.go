@@ -0,0 +1,62 @@
+package cachedb
+
+import "fmt"
+
+// LoadAll 把整张表加载进内存并切换到全表常驻模式，适用于行数较少的表。
+// 此后所有行（包括之后 Set 的新行）都不会被丢弃：淘汰只会触发回写，
+// Filter/Scan 直接在内存中查询，脏数据仍按行追踪并写回
+func (c *CacheDB[T]) LoadAll() error {
+	tx, err := c.wholeTable()
+	if err != nil {
+		return err
+	}
+	var rows []T
+	if err := tx.Find(&rows).Error; err != nil {
+		return fmt.Errorf("failed to load table %s: %w", c.defaultTable(), err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fullTable = true
+	for i := range rows {
+		key := c.keyOf(&rows[i])
+		if _, tracked := c.values[key]; tracked {
+			continue // 已缓存的行可能有未落库的修改，保留内存中的版本
+		}
+		if err := c.rebase(key, &rows[i]); err != nil {
+			return err
+		}
+		c.values[key] = &rows[i]
+	}
+	return nil
+}
+
+// Scan 遍历内存中的所有行，fn 返回 false 时停止。
+// 遍历期间持有内部锁，fn 中不能调用该缓存的方法
+func (c *CacheDB[T]) Scan(fn func(key interface{}, value *T) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, value := range c.values {
+		if !fn(key, value) {
+			return
+		}
+	}
+}
+
+// Filter 返回内存中满足 pred 的所有行，开启 WithSafeReads 时返回深拷贝
+func (c *CacheDB[T]) Filter(pred func(*T) bool) []*T {
+	var out []*T
+	c.Scan(func(key interface{}, value *T) bool {
+		if !pred(value) {
+			return true
+		}
+		v, err := c.viewLocked(value)
+		if err != nil {
+			c.log(LogError, "Filter copy failed", "key", key, "err", err)
+			return true
+		}
+		out = append(out, v)
+		return true
+	})
+	return out
+}
@@ -0,0 +1,51 @@
+package cachedb
+
+import "testing"
+
+func TestLoadAll(t *testing.T) {
+	type Zone struct {
+		ID      uint
+		Name    string
+		Players int
+	}
+	db := openTestDB(t, &Zone{})
+	for i, n := range []int{5, 50, 500} {
+		db.Create(&Zone{ID: uint(i + 1), Name: "zone", Players: n})
+	}
+
+	c := newTestCache[Zone](t, db, 1) // 容量小于表大小
+	if err := c.LoadAll(); err != nil {
+		t.Fatalf("load all failed: %v", err)
+	}
+
+	busy := c.Filter(func(z *Zone) bool { return z.Players >= 50 })
+	if len(busy) != 2 {
+		t.Fatalf("expected 2 busy zones, got %d", len(busy))
+	}
+
+	z1, _ := c.Get(uint(1))
+	z1.Players = 6
+	z2, _ := c.Get(uint(2)) // 淘汰 zone 1，但它应保持常驻
+	z2.Players = 49
+
+	if got := c.Filter(func(z *Zone) bool { return z.Players >= 50 }); len(got) != 1 {
+		t.Errorf("expected in-memory filter to see mutation, got %d", len(got))
+	}
+	if again, _ := c.Get(uint(1)); again != z1 {
+		t.Errorf("full-table rows should stay resident")
+	}
+
+	var row Zone
+	db.First(&row, 1)
+	if row.Players != 6 {
+		t.Errorf("expected eviction to write back zone 1, got %d", row.Players)
+	}
+	if err := c.FlushAll(); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	var row2 Zone
+	db.First(&row2, 2)
+	if row2.Players != 49 {
+		t.Errorf("expected zone 2 flushed, got %d", row2.Players)
+	}
+}
@@ -0,0 +1,100 @@
+package cachedb
+
+import (
+	"fmt"
+	"sync"
+)
+
+// gameLoop 是单线程模式下等待主循环执行的任务队列
+type gameLoop struct {
+	mu    sync.Mutex
+	tasks []func()
+}
+
+// post 追加一个任务，可以从任意协程调用
+func (l *gameLoop) post(fn func()) {
+	l.mu.Lock()
+	l.tasks = append(l.tasks, fn)
+	l.mu.Unlock()
+}
+
+// take 取出最多 n 个任务，n <= 0 表示全部
+func (l *gameLoop) take(n int) []func() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n <= 0 || n > len(l.tasks) {
+		n = len(l.tasks)
+	}
+	tasks := l.tasks[:n:n]
+	l.tasks = l.tasks[n:]
+	return tasks
+}
+
+// WithGameLoop 开启单线程模式：缓存不再启动任何会访问对象的后台协程，
+// 淘汰回写、Follow 收到的刷新等工作排队，由游戏主循环调用 Step 或 Pump 时在主循环线程上执行。
+// 缓存的其他方法也只应在主循环线程上调用；其他协程需要访问缓存时通过 Post 提交。
+// 不能与 WithFlushWorkers、WithFlushPool 同时使用；检查点应在主循环中调用 Checkpointer.RunOnce
+func WithGameLoop[T any]() Option[T] {
+	return func(c *CacheDB[T]) {
+		c.loop = &gameLoop{}
+	}
+}
+
+// Post 提交一个在主循环下一次 Step 或 Pump 时执行的任务，可以从任意协程调用。
+// 未开启 WithGameLoop 时立即执行
+func (c *CacheDB[T]) Post(fn func()) {
+	if c.loop == nil {
+		fn()
+		return
+	}
+	c.loop.post(fn)
+}
+
+// Step 在调用方（主循环）线程上执行最多 n 个排队的任务，返回执行的数量。
+// 用于限制每帧花在缓存上的时间；未开启 WithGameLoop 时什么也不做
+func (c *CacheDB[T]) Step(n int) int {
+	if c.loop == nil {
+		return 0
+	}
+	tasks := c.loop.take(n)
+	for _, fn := range tasks {
+		c.runTask(fn)
+	}
+	return len(tasks)
+}
+
+// Pump 执行所有排队的任务，包括执行期间新提交的任务，返回执行的数量
+func (c *CacheDB[T]) Pump() int {
+	total := 0
+	for {
+		n := c.Step(0)
+		if n == 0 {
+			return total
+		}
+		total += n
+	}
+}
+
+// Pending 返回排队等待主循环执行的任务数
+func (c *CacheDB[T]) Pending() int {
+	if c.loop == nil {
+		return 0
+	}
+	c.loop.mu.Lock()
+	defer c.loop.mu.Unlock()
+	return len(c.loop.tasks)
+}
+
+// runTask 执行一个任务，panic 不会中断主循环
+func (c *CacheDB[T]) runTask(fn func()) {
+	defer c.recoverPanic("game loop task", nil, nil)
+	fn()
+}
+
+// checkGameLoop 检查单线程模式与异步回写的配置冲突
+func (c *CacheDB[T]) checkGameLoop() error {
+	if c.loop != nil && (c.flushWorkers > 0 || c.pool != nil) {
+		return fmt.Errorf("WithGameLoop cannot be combined with asynchronous flush workers")
+	}
+	return nil
+}
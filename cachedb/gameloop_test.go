@@ -0,0 +1,113 @@
+package cachedb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGameLoop(t *testing.T) {
+	type Hero struct {
+		ID    uint
+		Level int
+	}
+	db := openTestDB(t, &Hero{})
+	db.Create(&Hero{ID: 1, Level: 1})
+	db.Create(&Hero{ID: 2, Level: 1})
+
+	bus := NewMemoryBus[Hero]()
+	c := newTestCache[Hero](t, db, 10, WithGameLoop[Hero]())
+	if _, err := c.Follow(bus); err != nil {
+		t.Fatalf("Follow failed: %v", err)
+	}
+
+	h, _ := c.Get(1)
+	h.Level = 2
+	c.EvictNow(1)
+	var row Hero
+	db.First(&row, 1)
+	if row.Level != 1 || c.Pending() != 1 {
+		t.Fatalf("expected eviction write-back to wait for the loop, got %+v pending %d", row, c.Pending())
+	}
+	if again, _ := c.Get(1); again != h {
+		t.Errorf("expected the pending entry to be served from memory")
+	}
+
+	c.Get(2)
+	bus.PublishFlush(FlushEvent[Hero]{Key: uint(2), Value: Hero{ID: 2, Level: 9}})
+	done, posted := make(chan struct{}), make(chan struct{})
+	go func() {
+		c.Post(func() { close(done) })
+		close(posted)
+	}()
+	<-posted
+	select {
+	case <-done:
+		t.Fatalf("posted task ran off the loop thread")
+	default:
+	}
+
+	if n := c.Step(1); n != 1 {
+		t.Errorf("expected Step to run one task, ran %d", n)
+	}
+	db.First(&row, 1)
+	if row.Level != 2 {
+		t.Errorf("expected write-back after Step, got %+v", row)
+	}
+	if n := c.Pump(); n != 2 {
+		t.Errorf("expected Pump to drain the remaining tasks, ran %d", n)
+	}
+	<-done
+	if got, _ := c.Get(2); got.Level != 9 {
+		t.Errorf("expected follow refresh to apply on Pump, got %+v", got)
+	}
+}
+
+func TestGameLoopRejectsFlushWorkers(t *testing.T) {
+	type Hero struct {
+		ID uint
+	}
+	db := openTestDB(t, &Hero{})
+	if _, err := NewWithCache[Hero](db, 10, WithGameLoop[Hero](), WithFlushWorkers[Hero](2)); err == nil {
+		t.Errorf("expected WithGameLoop and WithFlushWorkers to conflict")
+	}
+}
+
+func TestGameLoopCoalesceAndVeto(t *testing.T) {
+	type Hero struct {
+		ID    uint
+		Level int
+	}
+	db := openTestDB(t, &Hero{})
+	db.Create(&Hero{ID: 1, Level: 1})
+	db.Create(&Hero{ID: 2, Level: 1})
+	c := newTestCache[Hero](t, db, 1, WithGameLoop[Hero](),
+		WithCoalesce[Hero](time.Millisecond, 10*time.Millisecond))
+	c.OnBeforeEvict(func(key interface{}, _ *Hero) bool { return key != uint(1) })
+
+	// 合并窗口到期后落库排队等待主循环
+	c.Update(1, func(h *Hero) { h.Level = 2 })
+	deadline := time.Now().Add(time.Second)
+	for c.Pending() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	var row Hero
+	db.First(&row, 1)
+	if row.Level != 1 || c.Pending() != 1 {
+		t.Fatalf("expected the coalesced flush to wait for the loop, got %+v pending %d", row, c.Pending())
+	}
+	c.Pump()
+	db.First(&row, 1)
+	if row.Level != 2 {
+		t.Errorf("expected the coalesced flush to run on Pump, got %+v", row)
+	}
+
+	// 被否决的淘汰同样由主循环放回
+	c.Get(2)
+	if c.Cache.Has(uint(1)) || c.Pending() != 1 {
+		t.Fatalf("expected the vetoed restore to wait for the loop, pending %d", c.Pending())
+	}
+	c.Pump()
+	if !c.Cache.Has(uint(1)) {
+		t.Error("expected the vetoed entry to be restored on Pump")
+	}
+}
@@ -0,0 +1,69 @@
+package cachedb
+
+// bump 为 key 分配新的代数，调用方需持有 c.mu。代数在整个缓存内单调递增，
+// 对象被淘汰后重新加载也会得到比之前更大的值
+func (c *CacheDB[T]) bump(key interface{}) {
+	if m := c.meta[key]; m != nil {
+		c.gen++
+		m.gen = c.gen
+	}
+	c.dropReplicas(key) // 副本已过时，下次 Get 按新值重新生成
+}
+
+// Generation 返回 key 当前的代数，未缓存时返回 false。代数在 Set、Update、Refresh、
+// 跟随刷新以及重新加载时递增，落库不会改变它；通过 Get 返回的指针直接修改对象不会被感知
+func (c *CacheDB[T]) Generation(key interface{}) (uint64, bool) {
+	key = c.canonicalKey(key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.values[key]; !ok {
+		return 0, false
+	}
+	return c.meta[key].gen, true
+}
+
+// GetWithGeneration 与 Get 相同，同时返回读取时对象的代数，配合 UpdateIfGeneration 实现比较并交换
+func (c *CacheDB[T]) GetWithGeneration(key interface{}) (*T, uint64, error) {
+	value, err := c.get(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	key = c.canonicalKey(key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var gen uint64
+	if m := c.meta[key]; m != nil && c.current(key, value) {
+		gen = m.gen
+	}
+	view, err := c.viewLocked(value)
+	return view, gen, err
+}
+
+// UpdateIfGeneration 仅在 key 的代数仍为 gen 时执行 fn，并返回修改后的新代数。
+// 期间对象被其他调用方修改或重新加载时返回 ErrConflict，调用方应重新读取后重试。fn 中不能调用该缓存的方法
+func (c *CacheDB[T]) UpdateIfGeneration(key interface{}, gen uint64, fn func(*T)) (uint64, error) {
+	value, err := c.get(key)
+	if err != nil {
+		return 0, err
+	}
+	key = c.canonicalKey(key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m := c.meta[key]
+	if m == nil || !c.current(key, value) || m.gen != gen {
+		return 0, ErrConflict
+	}
+	before, err := c.snapshotForReplication(key, value)
+	if err != nil {
+		return 0, err
+	}
+	fn(value)
+	if err := c.replicateLocked(key, value, before); err != nil {
+		return 0, err
+	}
+	m.marked = true
+	c.bump(key)
+	c.recordMutation(key, "UpdateIfGeneration", 1)
+	c.touchCoalesce(key)
+	return m.gen, nil
+}
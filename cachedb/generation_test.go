@@ -0,0 +1,48 @@
+package cachedb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUpdateIfGeneration(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1})
+	c := newTestCache[Player](t, db, 10)
+
+	_, gen, err := c.GetWithGeneration(1)
+	if err != nil || gen == 0 {
+		t.Fatalf("GetWithGeneration = %d %v", gen, err)
+	}
+	next, err := c.UpdateIfGeneration(1, gen, func(p *Player) { p.Gold += 10 })
+	if err != nil || next <= gen {
+		t.Fatalf("expected the update to succeed with a newer generation, got %d %v", next, err)
+	}
+	if _, err := c.UpdateIfGeneration(1, gen, func(p *Player) { p.Gold += 10 }); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected a stale generation to conflict, got %v", err)
+	}
+
+	// 落库不改变代数，其他修改会改变
+	if err := c.Flush(1); err != nil {
+		t.Fatal(err)
+	}
+	if g, _ := c.Generation(1); g != next {
+		t.Errorf("Generation after flush = %d, want %d", g, next)
+	}
+	c.Update(1, func(p *Player) { p.Gold++ })
+	if _, err := c.UpdateIfGeneration(1, next, func(p *Player) {}); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected Update to invalidate the generation, got %v", err)
+	}
+
+	p, _ := c.Get(1)
+	if p.Gold != 11 {
+		t.Errorf("Gold = %d, want 11", p.Gold)
+	}
+	if _, ok := c.Generation(2); ok {
+		t.Error("expected an uncached key to have no generation")
+	}
+}
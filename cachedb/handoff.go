@@ -0,0 +1,178 @@
+package cachedb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HandoffEntry 是移交给其他节点的一条缓存状态，保留了副本以便接收方继续追踪脏数据
+type HandoffEntry[T any] struct {
+	Key      interface{}
+	Value    T         // 当前内存中的值
+	Baseline T         // 最近一次落库时的副本
+	Since    time.Time // 副本的建立时间
+}
+
+// HandoffSender 负责把移交数据发送到目标节点，接收方应调用 ImportHandoff 安装收到的数据。
+// 内置基于 HTTP 的 HTTPHandoffSender 与 HandoffHandler，也可以实现为 gRPC 等其他传输方式
+type HandoffSender[T any] interface {
+	SendHandoff(ctx context.Context, target string, entries []HandoffEntry[T]) error
+}
+
+// ExportHandoff 导出指定 key 的常驻状态（包括未落库的修改），并将其从本地缓存移除且不回写数据库。
+// 不在缓存中的 key 会被忽略
+func (c *CacheDB[T]) ExportHandoff(keys []interface{}) []HandoffEntry[T] {
+	c.mu.Lock()
+	entries := make([]HandoffEntry[T], 0, len(keys))
+	for _, key := range keys {
+		key = c.canonicalKey(key)
+		value, ok := c.values[key]
+		if !ok {
+			continue
+		}
+		cpy, err := c.copier(*value)
+		if err != nil {
+			c.log(LogError, "Handoff export failed", "key", key, "err", err)
+			continue
+		}
+		entries = append(entries, HandoffEntry[T]{
+			Key:      key,
+			Value:    cpy,
+			Baseline: c.copies[key],
+			Since:    c.meta[key].since,
+		})
+		c.untrack(key) // 先解除追踪，淘汰回调便不会回写
+	}
+	c.mu.Unlock()
+
+	for _, e := range entries {
+		c.Cache.Remove(e.Key)
+	}
+	return entries
+}
+
+// ImportHandoff 安装其他节点移交过来的状态，差异会在之后的刷盘中写回数据库
+func (c *CacheDB[T]) ImportHandoff(entries []HandoffEntry[T]) error {
+	for _, e := range entries {
+		value := e.Value
+		e.Key = c.canonicalKey(e.Key)
+		c.mu.Lock()
+		c.copies[e.Key] = e.Baseline
+		c.values[e.Key] = &value
+		c.meta[e.Key] = &entryMeta{since: e.Since}
+		c.bump(e.Key)
+		c.retag(e.Key, &value)
+		c.mu.Unlock()
+
+		if err := c.cacheSet(e.Key, &value); err != nil {
+			return fmt.Errorf("failed to import key %v: %w", e.Key, err)
+		}
+	}
+	return nil
+}
+
+// Drain 将指定 key 移交给目标节点。发送失败时状态会被恢复到本地缓存
+func (c *CacheDB[T]) Drain(ctx context.Context, keys []interface{}, target string, sender HandoffSender[T]) error {
+	entries := c.ExportHandoff(keys)
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := sender.SendHandoff(ctx, target, entries); err != nil {
+		if restoreErr := c.ImportHandoff(entries); restoreErr != nil {
+			c.log(LogError, "Handoff restore failed", "err", restoreErr)
+		}
+		return fmt.Errorf("failed to hand off to %s: %w", target, err)
+	}
+	return nil
+}
+
+// handoffWire 是移交数据在 HTTP 请求体中的编码，key 以字符串传输，接收方用 ParseKey 还原
+type handoffWire[T any] struct {
+	Key      string    `json:"key"`
+	Value    T         `json:"value"`
+	Baseline T         `json:"baseline"`
+	Since    time.Time `json:"since"`
+}
+
+// HTTPHandoffSender 以 HTTP POST 把移交数据发送到 target，target 为接收节点上 HandoffHandler 的地址。
+// 对象按 encoding/json 编码，所有字段需能原样往返
+type HTTPHandoffSender[T any] struct {
+	Client *http.Client // 为空时使用 http.DefaultClient
+	Token  string       // 非空时携带 "Authorization: Bearer <Token>"
+}
+
+// SendHandoff 发送 entries，接收方返回 2xx 表示已全部安装
+func (s HTTPHandoffSender[T]) SendHandoff(ctx context.Context, target string, entries []HandoffEntry[T]) error {
+	wire := make([]handoffWire[T], len(entries))
+	for i, e := range entries {
+		wire[i] = handoffWire[T]{Key: fmt.Sprint(e.Key), Value: e.Value, Baseline: e.Baseline, Since: e.Since}
+	}
+	body, err := json.Marshal(wire)
+	if err != nil {
+		return fmt.Errorf("failed to encode handoff: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("handoff target responded %s", resp.Status)
+	}
+	return nil
+}
+
+// HandoffHandler 接收 HTTPHandoffSender 发送的移交数据并安装到 Cache。
+// 配置了 Token 时要求请求携带 "Authorization: Bearer <Token>"
+type HandoffHandler[T any] struct {
+	Cache *CacheDB[T]
+	Token string
+}
+
+// ServeHTTP 处理 POST 的 JSON 请求体，全部安装成功时返回 204
+func (h *HandoffHandler[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.Token != "" && r.Header.Get("Authorization") != "Bearer "+h.Token {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var wire []handoffWire[T]
+	if err := json.NewDecoder(r.Body).Decode(&wire); err != nil {
+		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	entries := make([]HandoffEntry[T], len(wire))
+	for i, e := range wire {
+		key, err := h.Cache.ParseKey(e.Key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		entries[i] = HandoffEntry[T]{Key: key, Value: e.Value, Baseline: e.Baseline, Since: e.Since}
+	}
+	if err := h.Cache.ImportHandoff(entries); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
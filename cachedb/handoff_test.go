@@ -0,0 +1,122 @@
+package cachedb
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+type localSender[T any] struct {
+	target *CacheDB[T]
+	err    error
+}
+
+func (s localSender[T]) SendHandoff(ctx context.Context, target string, entries []HandoffEntry[T]) error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.target.ImportHandoff(entries)
+}
+
+func TestDrainHandoff(t *testing.T) {
+	type Avatar struct {
+		ID   uint
+		Zone string
+	}
+	db := openTestDB(t, &Avatar{})
+	db.Create(&Avatar{ID: 1, Zone: "north"})
+
+	from := newTestCache[Avatar](t, db, 10)
+	to := newTestCache[Avatar](t, db, 10)
+
+	a, _ := from.Get(uint(1))
+	a.Zone = "south"
+
+	if err := from.Drain(context.Background(), []interface{}{uint(1)}, "node-b", localSender[Avatar]{target: to}); err != nil {
+		t.Fatalf("drain failed: %v", err)
+	}
+	if from.Cache.Has(uint(1)) {
+		t.Errorf("expected key removed from draining node")
+	}
+
+	var row Avatar
+	db.First(&row, 1)
+	if row.Zone != "north" {
+		t.Errorf("handoff should not write to db, got zone %q", row.Zone)
+	}
+
+	if keys := to.DirtyKeys(); len(keys) != 1 {
+		t.Fatalf("expected dirty state preserved on target, got %v", keys)
+	}
+	if err := to.FlushAll(); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	db.First(&row, 1)
+	if row.Zone != "south" {
+		t.Errorf("expected zone 'south' after target flush, got %q", row.Zone)
+	}
+}
+
+func TestDrainRestoresOnFailure(t *testing.T) {
+	type Avatar struct {
+		ID   uint
+		Zone string
+	}
+	db := openTestDB(t, &Avatar{})
+	db.Create(&Avatar{ID: 1, Zone: "north"})
+
+	c := newTestCache[Avatar](t, db, 10)
+	a, _ := c.Get(uint(1))
+	a.Zone = "east"
+
+	sendErr := errors.New("unreachable")
+	err := c.Drain(context.Background(), []interface{}{uint(1)}, "node-b", localSender[Avatar]{err: sendErr})
+	if !errors.Is(err, sendErr) {
+		t.Fatalf("expected send error, got %v", err)
+	}
+	got, err := c.Get(uint(1))
+	if err != nil || got.Zone != "east" {
+		t.Errorf("expected restored dirty value, got %+v (%v)", got, err)
+	}
+}
+
+func TestHTTPHandoff(t *testing.T) {
+	type Avatar struct {
+		ID   uint
+		Zone string
+	}
+	db := openTestDB(t, &Avatar{})
+	db.Create(&Avatar{ID: 1, Zone: "north"})
+
+	from := newTestCache[Avatar](t, db, 10)
+	to := newTestCache[Avatar](t, db, 10)
+	srv := httptest.NewServer(&HandoffHandler[Avatar]{Cache: to, Token: "secret"})
+	defer srv.Close()
+
+	a, _ := from.Get(uint(1))
+	a.Zone = "south"
+
+	// 鉴权失败时状态恢复到本地
+	if err := from.Drain(context.Background(), []interface{}{uint(1)}, srv.URL, HTTPHandoffSender[Avatar]{}); err == nil {
+		t.Fatal("expected an unauthorized handoff to fail")
+	}
+	if got, _ := from.Get(uint(1)); got.Zone != "south" {
+		t.Fatalf("expected the dirty value restored, got %+v", got)
+	}
+
+	if err := from.Drain(context.Background(), []interface{}{uint(1)}, srv.URL, HTTPHandoffSender[Avatar]{Token: "secret"}); err != nil {
+		t.Fatalf("drain failed: %v", err)
+	}
+	if keys := to.DirtyKeys(); len(keys) != 1 || keys[0] != uint(1) {
+		t.Fatalf("expected dirty state preserved on target under uint key, got %v", keys)
+	}
+	if err := to.FlushAll(); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	var row Avatar
+	db.First(&row, 1)
+	if row.Zone != "south" {
+		t.Errorf("expected zone 'south' after target flush, got %q", row.Zone)
+	}
+}
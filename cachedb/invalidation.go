@@ -0,0 +1,81 @@
+package cachedb
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// InvalidationRequest 是 GM 工具或批处理任务发送的外部修改通知
+type InvalidationRequest struct {
+	Entity string   `json:"entity"` // Registry 中注册的实体名
+	Keys   []string `json:"keys"`
+	Action string   `json:"action"` // "evict"（默认）或 "refresh"
+	Flush  bool     `json:"flush"`  // 先写回未落库的修改再淘汰或刷新，写回失败的 key 不做处理；默认丢弃修改
+}
+
+// InvalidationResult 是每个 key 的处理结果
+type InvalidationResult struct {
+	Key   string `json:"key"`
+	Error string `json:"error,omitempty"`
+}
+
+// InvalidationHandler 接收外部修改通知，并在注册表中淘汰或刷新对应的 key。
+// 也可用于线上修复内存中状态错误的对象：refresh 不论对象是否有未落库的修改都从数据库重新加载。
+// 配置了 Token 时要求请求携带 "Authorization: Bearer <Token>"
+type InvalidationHandler struct {
+	Registry *Registry
+	Token    string
+}
+
+// ServeHTTP 处理 POST 的 JSON 请求体，返回每个 key 的处理结果
+func (h *InvalidationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.Token != "" && r.Header.Get("Authorization") != "Bearer "+h.Token {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req InvalidationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Action == "" {
+		req.Action = "evict"
+	}
+	if req.Action != "evict" && req.Action != "refresh" {
+		http.Error(w, "unknown action "+req.Action, http.StatusBadRequest)
+		return
+	}
+	c, ok := h.Registry.Lookup(req.Entity)
+	if !ok {
+		http.Error(w, "unknown entity "+req.Entity, http.StatusNotFound)
+		return
+	}
+
+	results := make([]InvalidationResult, 0, len(req.Keys))
+	for _, raw := range req.Keys {
+		res := InvalidationResult{Key: raw}
+		key, err := c.ParseKey(raw)
+		if err == nil && req.Flush {
+			err = c.Flush(key)
+		}
+		if err == nil {
+			if req.Action == "refresh" {
+				err = c.Refresh(key)
+			} else {
+				c.Invalidate(key)
+			}
+		}
+		if err != nil {
+			res.Error = err.Error()
+		}
+		results = append(results, res)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
@@ -0,0 +1,81 @@
+package cachedb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInvalidationHandler(t *testing.T) {
+	type Account struct {
+		ID     uint
+		Banned bool
+		Gold   int
+	}
+	db := openTestDB(t, &Account{})
+	db.Create(&Account{ID: 1, Gold: 10})
+	db.Create(&Account{ID: 2, Gold: 20})
+
+	c := newTestCache[Account](t, db, 10)
+	reg := NewRegistry()
+	if err := reg.Register("accounts", c); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	if err := reg.Register("accounts", c); err == nil {
+		t.Fatalf("expected duplicate registration error")
+	}
+
+	a1, _ := c.Get(uint(1))
+	a2, _ := c.Get(uint(2))
+	a2.Gold = 999 // 本地未落库的修改会被丢弃
+
+	// GM 工具直接修改数据库
+	db.Model(&Account{}).Where("id IN ?", []uint{1, 2}).Update("banned", true)
+
+	h := &InvalidationHandler{Registry: reg, Token: "secret"}
+	post := func(body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/invalidate", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post(`{"entity":"accounts","keys":["1"]}`, "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+	if rec := post(`{"entity":"accounts","keys":["1"],"action":"refresh"}`, "secret"); rec.Code != http.StatusOK {
+		t.Fatalf("refresh failed: %d %s", rec.Code, rec.Body)
+	}
+	if !a1.Banned {
+		t.Errorf("expected cached pointer refreshed in place")
+	}
+
+	rec := post(`{"entity":"accounts","keys":["2","x"]}`, "secret")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"key":"x","error"`) {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Body)
+	}
+	got, _ := c.Get(uint(2))
+	if !got.Banned || got.Gold != 20 {
+		t.Errorf("expected reload from db after evict, got %+v", got)
+	}
+
+	if rec := post(`{"entity":"missing","keys":["1"]}`, "secret"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown entity, got %d", rec.Code)
+	}
+
+	// 先落库再刷新，保留玩家的进度
+	a1.Gold = 50
+	if rec := post(`{"entity":"accounts","keys":["1"],"action":"refresh","flush":true}`, "secret"); rec.Code != http.StatusOK {
+		t.Fatalf("refresh failed: %d %s", rec.Code, rec.Body)
+	}
+	var stored Account
+	db.First(&stored, 1)
+	if a1.Gold != 50 || stored.Gold != 50 {
+		t.Errorf("expected the local change to be flushed before reloading, got %+v in cache and %+v in DB", *a1, stored)
+	}
+	if keys := c.DirtyKeys(); len(keys) != 0 {
+		t.Errorf("expected no dirty keys after refresh, got %v", keys)
+	}
+}
@@ -0,0 +1,111 @@
+package cachedb
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// StreamMsg 是从持久化消息流收到的一条消息，处理完成后 Ack，需要重新投递时 Nak
+type StreamMsg interface {
+	Data() []byte
+	Ack() error
+	Nak() error
+}
+
+// JetStream 是 NATS JetStream 客户端中本包用到的部分，使用方用 nats.go 的 JetStreamContext 实现它：
+// Publish 对应 js.Publish；Subscribe 对应以 group 为 durable 名称的 js.QueueSubscribe 并开启 ManualAck，
+// group 为空时为每个订阅者创建独立的临时消费者。本包不直接依赖 nats.go，
+// 测试中可以使用进程内的 cachedbtest.MemJetStream
+type JetStream interface {
+	Publish(subject string, data []byte) error
+	Subscribe(subject, group string, handler func(StreamMsg)) (cancel func(), err error)
+}
+
+// streamFlushEvent 是落库事件在消息流中的编码，key 以字符串传输，接收方用 ParseKey 还原
+type streamFlushEvent[T any] struct {
+	Entity string      `json:"entity"`
+	Key    string      `json:"key"`
+	Value  T           `json:"value"`
+	Patch  []byte      `json:"patch,omitempty"`
+	Reason FlushReason `json:"reason,omitempty"`
+}
+
+// JetStreamBus 通过 JetStream 传输落库事件，同时实现 FlushPublisher 和 FlushSubscriber。
+// 消息在 handler 返回后才 Ack，handler panic 时 Nak 以便重新投递，因此同一事件可能被处理多次（至少一次）。
+// 同一 group 的多个订阅者分摊消息，适用于分析管道的消费者组；跟随缓存应使用各自独立的空 group
+type JetStreamBus[T any] struct {
+	js      JetStream
+	subject string
+	group   string
+}
+
+// NewJetStreamBus 创建在 subject 上收发事件的总线，subject 通常包含命名空间，例如 Namespace.Prefix(entity)
+func NewJetStreamBus[T any](js JetStream, subject, group string) *JetStreamBus[T] {
+	return &JetStreamBus[T]{js: js, subject: subject, group: group}
+}
+
+func (b *JetStreamBus[T]) PublishFlush(event FlushEvent[T]) error {
+	data, err := json.Marshal(streamFlushEvent[T]{
+		Entity: event.Entity,
+		Key:    fmt.Sprint(event.Key),
+		Value:  event.Value,
+		Patch:  event.Patch,
+		Reason: event.Reason,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode flush event: %w", err)
+	}
+	return b.js.Publish(b.subject, data)
+}
+
+func (b *JetStreamBus[T]) SubscribeFlush(handler func(FlushEvent[T])) (func(), error) {
+	return b.js.Subscribe(b.subject, b.group, func(msg StreamMsg) {
+		var e streamFlushEvent[T]
+		if err := json.Unmarshal(msg.Data(), &e); err != nil {
+			msg.Ack() // 无法解码的消息重新投递也不会成功
+			return
+		}
+		acked := false
+		defer func() {
+			if !acked {
+				msg.Nak()
+			}
+		}()
+		handler(FlushEvent[T]{Entity: e.Entity, Key: e.Key, Value: e.Value, Patch: e.Patch, Reason: e.Reason})
+		acked = true
+		msg.Ack()
+	})
+}
+
+// InvalidationEvent 是跨节点的失效通知，Keys 为字符串形式的 key
+type InvalidationEvent struct {
+	Entity string   `json:"entity"` // Registry 中的实体名
+	Keys   []string `json:"keys"`
+}
+
+// PublishInvalidation 在 subject 上发布失效通知
+func PublishInvalidation(js JetStream, subject string, event InvalidationEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode invalidation: %w", err)
+	}
+	return js.Publish(subject, data)
+}
+
+// SubscribeInvalidations 订阅 subject 上的失效通知并让注册表中对应的缓存失效，
+// 未知的实体和无法解析的 key 被跳过。每个节点应使用独立的空 group，以便都收到通知
+func (r *Registry) SubscribeInvalidations(js JetStream, subject, group string) (cancel func(), err error) {
+	return js.Subscribe(subject, group, func(msg StreamMsg) {
+		var e InvalidationEvent
+		if err := json.Unmarshal(msg.Data(), &e); err == nil {
+			if c, ok := r.Lookup(e.Entity); ok {
+				for _, raw := range e.Keys {
+					if key, err := c.ParseKey(raw); err == nil {
+						c.Invalidate(key)
+					}
+				}
+			}
+		}
+		msg.Ack()
+	})
+}
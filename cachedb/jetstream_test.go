@@ -0,0 +1,115 @@
+package cachedb
+
+import (
+	"sync"
+	"testing"
+)
+
+// fakeJetStream 同步投递消息：同一 group 轮流分配，Nak 的消息立即重投一次
+type fakeJetStream struct {
+	mu     sync.Mutex
+	subs   map[string][]func(StreamMsg) // group -> handlers
+	next   map[string]int
+	acks   int
+	redeli int
+}
+
+type fakeMsg struct {
+	data       []byte
+	js         *fakeJetStream
+	acked, nak bool
+}
+
+func (m *fakeMsg) Data() []byte { return m.data }
+func (m *fakeMsg) Ack() error   { m.acked = true; m.js.acks++; return nil }
+func (m *fakeMsg) Nak() error   { m.nak = true; return nil }
+
+func (js *fakeJetStream) Publish(_ string, data []byte) error {
+	js.mu.Lock()
+	var targets []func(StreamMsg)
+	for group, hs := range js.subs {
+		if group == "" {
+			targets = append(targets, hs...)
+			continue
+		}
+		targets = append(targets, hs[js.next[group]%len(hs)])
+		js.next[group]++
+	}
+	js.mu.Unlock()
+	for _, h := range targets {
+		js.deliver(h, data)
+	}
+	return nil
+}
+
+func (js *fakeJetStream) deliver(h func(StreamMsg), data []byte) {
+	msg := &fakeMsg{data: data, js: js}
+	func() {
+		defer func() { recover() }()
+		h(msg)
+	}()
+	if msg.nak || !msg.acked {
+		js.redeli++
+		h(&fakeMsg{data: data, js: js})
+	}
+}
+
+func (js *fakeJetStream) Subscribe(_ string, group string, handler func(StreamMsg)) (func(), error) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	if js.subs == nil {
+		js.subs, js.next = make(map[string][]func(StreamMsg)), make(map[string]int)
+	}
+	js.subs[group] = append(js.subs[group], handler)
+	return func() {}, nil
+}
+
+func TestJetStreamBus(t *testing.T) {
+	type Hero struct {
+		ID    uint
+		Level int
+	}
+	db := openTestDB(t, &Hero{})
+	db.Create(&Hero{ID: 1, Level: 1})
+	js := &fakeJetStream{}
+
+	owner := newTestCache[Hero](t, db, 10, WithFlushPublisher[Hero](NewJetStreamBus[Hero](js, "heroes", "")))
+	follower := newTestCache[Hero](t, db, 10, WithReadOnly[Hero]())
+	follower.Get(1)
+	if _, err := follower.Follow(NewJetStreamBus[Hero](js, "heroes", "")); err != nil {
+		t.Fatalf("Follow failed: %v", err)
+	}
+
+	// 同一消费者组中的两个分析消费者分摊事件，第一次处理失败的事件会被重投
+	var seen []uint
+	failOnce := true
+	analytics := NewJetStreamBus[Hero](js, "heroes", "analytics")
+	for i := 0; i < 2; i++ {
+		analytics.SubscribeFlush(func(e FlushEvent[Hero]) {
+			if failOnce {
+				failOnce = false
+				panic("pipeline down")
+			}
+			seen = append(seen, e.Value.ID)
+		})
+	}
+
+	owner.Update(1, func(h *Hero) { h.Level = 4 })
+	if err := owner.Flush(1); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if h, _ := follower.Get(1); h.Level != 4 {
+		t.Errorf("expected follower to apply the streamed event, got %+v", h)
+	}
+	if len(seen) != 1 || js.redeli != 1 {
+		t.Errorf("expected one redelivered event for the consumer group, seen %v redeliveries %d", seen, js.redeli)
+	}
+
+	reg := NewRegistry()
+	reg.Register("hero", follower)
+	reg.SubscribeInvalidations(js, "invalidate", "")
+	PublishInvalidation(js, "invalidate", InvalidationEvent{Entity: "hero", Keys: []string{"1"}})
+	if follower.Stats().Tracked != 0 {
+		t.Errorf("expected the invalidation to drop the cached key")
+	}
+}
@@ -0,0 +1,281 @@
+package cachedb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// jsonChange 是 JSON 列中一个路径上的修改
+type jsonChange struct {
+	path     []string
+	old, new interface{}
+	removed  bool
+}
+
+// jsonDialect 描述一种数据库的 JSON 局部更新语法，%s 为被修改的 JSON 表达式
+type jsonDialect struct {
+	base   string                    // 列的原始表达式
+	set    string                    // 把路径设为新值，参数依次为路径和 JSON 文本
+	remove string                    // 删除路径，参数为路径
+	value  string                    // 把 JSON 文本作为整列的值
+	path   func(seg []string) string // 路径参数的格式
+}
+
+// jsonDialects 是支持局部更新的数据库，其余数据库整列写入
+var jsonDialects = map[string]*jsonDialect{
+	"mysql": {
+		base: "%s", set: "JSON_SET(%s, ?, CAST(? AS JSON))", remove: "JSON_REMOVE(%s, ?)",
+		value: "CAST(? AS JSON)", path: dollarPath,
+	},
+	"sqlite": {
+		base: "%s", set: "json_set(%s, ?, json(?))", remove: "json_remove(%s, ?)",
+		value: "json(?)", path: dollarPath,
+	},
+	"postgres": {
+		base: "%s::jsonb", set: "jsonb_set(%s, ?::text[], ?::jsonb)", remove: "(%s #- ?::text[])",
+		value: "?::jsonb", path: arrayPath,
+	},
+}
+
+// dollarPath 生成 MySQL、SQLite 的路径，例如 $."bag"."sword"
+func dollarPath(seg []string) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, s := range seg {
+		b.WriteString(`."` + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), `"`, `\"`) + `"`)
+	}
+	return b.String()
+}
+
+// arrayPath 生成 PostgreSQL 的 text[] 路径，例如 {"bag","sword"}
+func arrayPath(seg []string) string {
+	quoted := make([]string, len(seg))
+	for i, s := range seg {
+		quoted[i] = `"` + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), `"`, `\"`) + `"`
+	}
+	return "{" + strings.Join(quoted, ",") + "}"
+}
+
+// isJSONField 判断字段是否以 `gorm:"serializer:json"` 存储为 JSON 列
+func isJSONField(f *schema.Field) bool {
+	_, ok := f.Serializer.(schema.JSONSerializer)
+	return ok
+}
+
+// jsonTree 把值转换为 encoding/json 的通用结构，数字保留原文
+func jsonTree(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var tree interface{}
+	err = dec.Decode(&tree)
+	return tree, err
+}
+
+// diffJSON 逐字段比较两个 JSON 列的值。两侧的顶层都是对象时返回各路径的修改，
+// 否则（例如旧值为 null）返回 false，需要整列写入
+func diffJSON(old, new interface{}) ([]jsonChange, bool) {
+	ot, err := jsonTree(old)
+	if err != nil {
+		return nil, false
+	}
+	nt, err := jsonTree(new)
+	if err != nil {
+		return nil, false
+	}
+	_, oObj := ot.(map[string]interface{})
+	_, nObj := nt.(map[string]interface{})
+	if !oObj || !nObj {
+		return nil, false
+	}
+	var changes []jsonChange
+	walkJSON(nil, ot, nt, &changes)
+	return changes, true
+}
+
+// walkJSON 递归比较对象，数组与标量按整体替换
+func walkJSON(path []string, old, new interface{}, out *[]jsonChange) {
+	om, oObj := old.(map[string]interface{})
+	nm, nObj := new.(map[string]interface{})
+	if !oObj || !nObj {
+		if !reflect.DeepEqual(old, new) {
+			*out = append(*out, jsonChange{path: path, old: old, new: new})
+		}
+		return
+	}
+	keys := make([]string, 0, len(om)+len(nm))
+	for k := range om {
+		keys = append(keys, k)
+	}
+	for k := range nm {
+		if _, ok := om[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		sub := append(append([]string(nil), path...), k)
+		ov, inOld := om[k]
+		nv, inNew := nm[k]
+		switch {
+		case !inNew:
+			*out = append(*out, jsonChange{path: sub, old: ov, removed: true})
+		case !inOld:
+			*out = append(*out, jsonChange{path: sub, new: nv})
+		default:
+			walkJSON(sub, ov, nv, out)
+		}
+	}
+}
+
+// jsonAssignments 在数据库支持 JSON 局部更新且有 JSON 列被修改时，返回按列的更新：
+// 修改过的普通列写入新值，JSON 列按路径生成 JSON_SET 一类的表达式，顶层不是对象的 JSON 列整列写入。
+// 不满足条件时返回 false，由调用方按原方式写入整个对象
+func (c *CacheDB[T]) jsonAssignments(db *gorm.DB, old, new *T) (map[string]interface{}, bool) {
+	if !c.hasJSON {
+		return nil, false
+	}
+	d := jsonDialects[db.Dialector.Name()]
+	if d == nil {
+		return nil, false
+	}
+	ov, nv := reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem()
+	out := make(map[string]interface{})
+	patched := false
+	for _, f := range c.schema.Fields {
+		if f.DBName == "" || !f.Updatable {
+			continue
+		}
+		o := f.ReflectValueOf(db.Statement.Context, ov).Interface()
+		n := f.ReflectValueOf(db.Statement.Context, nv).Interface()
+		if sameValue(o, n) {
+			continue
+		}
+		if !isJSONField(f) {
+			out[f.DBName] = n
+			continue
+		}
+		changes, ok := diffJSON(o, n)
+		if !ok {
+			data, err := json.Marshal(n)
+			if err != nil {
+				return nil, false
+			}
+			out[f.DBName] = gorm.Expr(d.value, string(data))
+			continue
+		}
+		expr, err := d.patch(db.Statement.Quote(f.DBName), changes)
+		if err != nil {
+			return nil, false
+		}
+		out[f.DBName] = expr
+		patched = true
+	}
+	return out, patched
+}
+
+// patch 把 changes 依次套用到列上，生成一个表达式
+func (d *jsonDialect) patch(column string, changes []jsonChange) (clause.Expr, error) {
+	sql := fmt.Sprintf(d.base, column)
+	var args []interface{}
+	for _, ch := range changes {
+		if ch.removed {
+			sql = fmt.Sprintf(d.remove, sql)
+			args = append(args, d.path(ch.path))
+			continue
+		}
+		data, err := json.Marshal(ch.new)
+		if err != nil {
+			return clause.Expr{}, err
+		}
+		sql = fmt.Sprintf(d.set, sql)
+		args = append(args, d.path(ch.path), string(data))
+	}
+	return clause.Expr{SQL: sql, Vars: args}, nil
+}
+
+// updateRow 把 new 相对 model（旧值的拷贝，gorm 会回填）的修改写入 db 中 key 所在的行，db 已指定表。
+// 设置了 WithCollections 时集合不随父对象写入，而是按元素增删，db 应处于事务中。
+// 旧值未知（Set 一个未加载过的 key）且没有更新到任何行时，按 key 插入新行
+func (c *CacheDB[T]) updateRow(db *gorm.DB, key interface{}, model, new *T) *gorm.DB {
+	var zero T
+	unknown := c.equal(*model, zero)
+	table := db.Statement.Table
+	if len(c.collections) > 0 {
+		db = db.Omit(clause.Associations)
+	}
+	var result *gorm.DB
+	var assignments map[string]interface{}
+	patched := false
+	if !unknown { // 旧值未知时与零值比较会漏掉设为零值的字段，整行写入
+		assignments, patched = c.jsonAssignments(db, model, new)
+	}
+	if patched {
+		result = db.Model(model).Where(c.keyCondition(key)).Updates(assignments)
+	} else if cols := c.updateColumns(model, new, unknown); len(cols) > 0 {
+		result = db.Model(model).Where(c.keyCondition(key)).Select(cols).Updates(new)
+	} else {
+		result = db // 没有修改过的列，例如 MarkDirty 后没有实际修改
+	}
+	if result.Error == nil && result.RowsAffected == 0 && unknown {
+		result = c.insertRow(db.Session(&gorm.Session{NewDB: true}).Table(table), key, new)
+	}
+	if result.Error == nil && len(c.collections) > 0 {
+		if err := c.saveCollections(db.Session(&gorm.Session{NewDB: true}), model, new); err != nil {
+			result.AddError(err)
+		}
+	}
+	return result
+}
+
+// updateColumns 返回 new 相对 old 修改过的列，旧值未知时返回全部可更新的列。
+// 按结构体 Updates 时 gorm 会跳过零值字段，因此需要显式 Select，把改成 0 或 "" 的字段也写入。
+// 没有设置 WithCollections 时关联仍随父对象保存
+func (c *CacheDB[T]) updateColumns(old, new *T, all bool) []string {
+	ctx := context.Background()
+	ov, nv := reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem()
+	var cols []string
+	for _, f := range c.schema.Fields {
+		if f.DBName == "" || !f.Updatable || f.PrimaryKey || f == c.keyField {
+			continue
+		}
+		if all || !sameValue(f.ReflectValueOf(ctx, ov).Interface(), f.ReflectValueOf(ctx, nv).Interface()) {
+			cols = append(cols, f.DBName)
+		}
+	}
+	if len(cols) > 0 && len(c.collections) == 0 {
+		start := len(cols)
+		for name := range c.schema.Relationships.Relations {
+			cols = append(cols, name)
+		}
+		sort.Strings(cols[start:])
+	}
+	return cols
+}
+
+// insertRow 以 key 为主键把 new 插入 db 指定的表。MySQL 对值未变的行也返回 0 行，
+// 此时行已存在，插入冲突时忽略
+func (c *CacheDB[T]) insertRow(db *gorm.DB, key interface{}, new *T) *gorm.DB {
+	row := *new // 不修改缓存中的对象
+	rv := reflect.ValueOf(&row).Elem()
+	if err := c.keyField.Set(db.Statement.Context, rv, key); err != nil {
+		db.AddError(fmt.Errorf("failed to set key %v: %w", key, err))
+		return db
+	}
+	if len(c.collections) > 0 {
+		db = db.Omit(clause.Associations)
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&row)
+}
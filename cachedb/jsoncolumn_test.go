@@ -0,0 +1,102 @@
+package cachedb
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestJSONColumnPartialUpdate(t *testing.T) {
+	type Stats struct {
+		Level int            `json:"level"`
+		Bag   map[string]int `json:"bag"`
+		Title string         `json:"title,omitempty"`
+	}
+	type Player struct {
+		ID    uint
+		Gold  int
+		Stats Stats `gorm:"serializer:json"`
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, Stats: Stats{Level: 1, Bag: map[string]int{"potion": 3}, Title: "rookie"}})
+
+	var statements []string
+	db.Callback().Update().After("gorm:update").Register("record_sql", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+	defer db.Callback().Update().Remove("record_sql")
+
+	c := newTestCache[Player](t, db, 10, WithDiffEncoder[Player](JSONPatchEncoder{}))
+	var patch string
+	c.OnFlushDiff(func(_ interface{}, p []byte) { patch = string(p) })
+
+	c.Update(1, func(p *Player) {
+		p.Gold = 5
+		p.Stats.Level = 2
+		p.Stats.Bag["sword"] = 1
+		p.Stats.Title = ""
+	})
+	if err := c.Flush(1); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if len(statements) != 1 || !strings.Contains(statements[0], "json_set") || !strings.Contains(statements[0], "json_remove") {
+		t.Fatalf("expected a single partial JSON update, got %v", statements)
+	}
+
+	var stored Player
+	db.First(&stored, 1)
+	want := Stats{Level: 2, Bag: map[string]int{"potion": 3, "sword": 1}}
+	if stored.Gold != 5 || stored.Stats.Level != want.Level || stored.Stats.Title != "" ||
+		len(stored.Stats.Bag) != 2 || stored.Stats.Bag["sword"] != 1 || stored.Stats.Bag["potion"] != 3 {
+		t.Errorf("expected %+v with gold 5, got %+v", want, stored)
+	}
+	for _, op := range []string{`"path":"/stats/bag/sword"`, `"op":"remove","path":"/stats/title"`, `"path":"/stats/level"`} {
+		if !strings.Contains(patch, op) {
+			t.Errorf("expected patch to contain %s, got %s", op, patch)
+		}
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	changes, ok := diffJSON(map[string]interface{}{"a": 1, "b": map[string]interface{}{"c": 1}}, map[string]interface{}{"a": 1, "b": map[string]interface{}{"c": 2}})
+	if !ok || len(changes) != 1 || strings.Join(changes[0].path, ".") != "b.c" {
+		t.Errorf("expected one change at b.c, got %+v", changes)
+	}
+	if _, ok := diffJSON(nil, map[string]interface{}{"a": 1}); ok {
+		t.Error("expected a null column to require a whole-column write")
+	}
+	if got := dollarPath([]string{"bag", `a"b`}); got != `$."bag"."a\"b"` {
+		t.Errorf("unexpected path %s", got)
+	}
+	if got := arrayPath([]string{"bag", "x"}); got != `{"bag","x"}` {
+		t.Errorf("unexpected path %s", got)
+	}
+}
+
+func TestJSONColumnSetUnknownKey(t *testing.T) {
+	type Stats struct {
+		Level int `json:"level"`
+	}
+	type Player struct {
+		ID    uint
+		Gold  int
+		Stats Stats `gorm:"serializer:json"`
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, Gold: 10, Stats: Stats{Level: 3}})
+
+	c := newTestCache[Player](t, db, 10)
+	// 未加载过的 key，旧值未知：零值字段也要写入
+	if err := c.Set(1, Player{ID: 1, Stats: Stats{Level: 4}}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := c.Flush(1); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	var stored Player
+	db.First(&stored, 1)
+	if stored.Gold != 0 || stored.Stats.Level != 4 {
+		t.Errorf("expected gold 0 and level 4, got %+v", stored)
+	}
+}
@@ -0,0 +1,148 @@
+package cachedb
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// L2 是进程外的共享二级缓存，例如 memcached（见 MemcacheL2）。
+// 只保存已落库的值：本地未命中时先查 L2，再查数据库；落库成功后在后台写入 L2，
+// Delete、Invalidate 和 Refresh 时删除，Refresh 总是从数据库重新读取
+type L2 interface {
+	Get(key string) (value []byte, ok bool, err error)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(key string) error
+	Touch(key string, ttl time.Duration) error // 延长过期时间，key 不存在时不视为错误
+}
+
+// WithL2 设置二级缓存及其过期时间。键为 NamespacedKey，多个服务共享时应配合 WithNamespace 使用；
+// 值按 JSON 编码。L2 出错只记录日志，不影响从数据库加载和落库
+func WithL2[T any](l2 L2, ttl time.Duration) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.l2 = l2
+		c.l2TTL = ttl
+	}
+}
+
+// loadL2 从二级缓存读取 key，未命中或出错时返回 false
+func (c *CacheDB[T]) loadL2(key interface{}) (T, bool) {
+	var entity T
+	data, ok, err := c.l2.Get(c.NamespacedKey(key))
+	if err != nil {
+		c.log(LogWarn, "L2 get failed", "key", key, "err", err)
+		return entity, false
+	}
+	if !ok {
+		return entity, false
+	}
+	if err := json.Unmarshal(data, &entity); err != nil {
+		c.log(LogWarn, "L2 value corrupt", "key", key, "err", err)
+		return entity, false
+	}
+	return entity, true
+}
+
+// l2Writer 在后台把落库的值写入 L2，OnFlush 回调持有 c.mu，不能在其中进行网络 I/O。
+// 同一个 key 只保留最新的待写值；删除会丢弃待写值，并与正在进行的写入串行，不会被之前排队的写入覆盖
+type l2Writer struct {
+	io      sync.Mutex // 串行化对 L2 的写入与删除
+	mu      sync.Mutex // 保护 pending 和 order
+	pending map[string][]byte
+	order   []string
+	wake    chan struct{}
+	stop    chan struct{}
+	once    sync.Once
+}
+
+// startL2 创建后台写入协程，在设置了 L2 时由构造函数调用
+func (c *CacheDB[T]) startL2() {
+	w := &l2Writer{
+		pending: make(map[string][]byte),
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+	}
+	c.l2w = w
+	go func() {
+		for {
+			select {
+			case <-w.wake:
+				c.drainL2()
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+}
+
+// stopL2 写入排队中的值并停止后台协程
+func (c *CacheDB[T]) stopL2() {
+	if c.l2w == nil {
+		return
+	}
+	c.l2w.once.Do(func() { close(c.l2w.stop) })
+	c.drainL2()
+}
+
+// storeL2 是把落库的值放入 L2 写入队列的 OnFlush 回调
+func (c *CacheDB[T]) storeL2(key interface{}, _, new T) {
+	data, err := json.Marshal(new)
+	if err != nil {
+		c.log(LogWarn, "L2 set failed", "key", key, "err", err)
+		return
+	}
+	w := c.l2w
+	k := c.NamespacedKey(key)
+	w.mu.Lock()
+	if _, ok := w.pending[k]; !ok {
+		w.order = append(w.order, k)
+	}
+	w.pending[k] = data
+	w.mu.Unlock()
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// drainL2 按入队顺序写入所有待写的值
+func (c *CacheDB[T]) drainL2() {
+	w := c.l2w
+	w.io.Lock()
+	defer w.io.Unlock()
+	for {
+		w.mu.Lock()
+		if len(w.order) == 0 {
+			w.mu.Unlock()
+			return
+		}
+		k := w.order[0]
+		w.order = w.order[1:]
+		data, ok := w.pending[k]
+		delete(w.pending, k)
+		w.mu.Unlock()
+		if !ok {
+			continue // 已被删除
+		}
+		if err := c.l2.Set(k, data, c.l2TTL); err != nil {
+			c.log(LogWarn, "L2 set failed", "key", k, "err", err)
+		}
+	}
+}
+
+// deleteL2 从二级缓存删除 key，并丢弃尚未写入的值。调用方不能持有 c.mu
+func (c *CacheDB[T]) deleteL2(key interface{}) {
+	if c.l2 == nil {
+		return
+	}
+	w := c.l2w
+	k := c.NamespacedKey(key)
+	w.io.Lock()
+	defer w.io.Unlock()
+	w.mu.Lock()
+	delete(w.pending, k)
+	w.mu.Unlock()
+	if err := c.l2.Delete(k); err != nil {
+		c.log(LogWarn, "L2 delete failed", "key", key, "err", err)
+	}
+}
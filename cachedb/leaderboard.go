@@ -0,0 +1,205 @@
+package cachedb
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RankEntry 是排行榜中的一项，Rank 从 1 开始
+type RankEntry struct {
+	Key   interface{}
+	Score int64
+	Rank  int
+}
+
+// RankSnapshot 是持久化到数据库的排名快照，使用前需要 AutoMigrate
+type RankSnapshot struct {
+	ID      uint   `gorm:"primaryKey"`
+	Board   string `gorm:"index"`
+	Rank    int
+	Key     string
+	Score   int64
+	TakenAt time.Time `gorm:"index"`
+}
+
+// Leaderboard 基于 CacheDB 维护按分数降序排列的内存排行榜
+type Leaderboard[T any] struct {
+	name  string
+	cache *CacheDB[T]
+	score func(*T) int64
+
+	mu      sync.RWMutex
+	entries []RankEntry           // 按分数降序，同分按 key 排序
+	scores  map[interface{}]int64 // key → 当前分数
+}
+
+// NewLeaderboard 创建排行榜，score 从缓存对象中计算分数
+func NewLeaderboard[T any](name string, cache *CacheDB[T], score func(*T) int64) *Leaderboard[T] {
+	return &Leaderboard[T]{
+		name:   name,
+		cache:  cache,
+		score:  score,
+		scores: make(map[interface{}]int64),
+	}
+}
+
+// Update 从缓存读取对象并刷新其分数，对象修改后调用
+func (lb *Leaderboard[T]) Update(key interface{}) error {
+	value, err := lb.cache.get(key)
+	if err != nil {
+		return err
+	}
+	lb.SetScore(key, lb.score(value))
+	return nil
+}
+
+// SetScore 直接设置 key 的分数
+func (lb *Leaderboard[T]) SetScore(key interface{}, score int64) {
+	key = lb.cache.canonicalKey(key)
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	if old, ok := lb.scores[key]; ok {
+		if old == score {
+			return
+		}
+		lb.removeLocked(key, old)
+	}
+	lb.scores[key] = score
+	i := lb.search(key, score)
+	lb.entries = append(lb.entries, RankEntry{})
+	copy(lb.entries[i+1:], lb.entries[i:])
+	lb.entries[i] = RankEntry{Key: key, Score: score}
+}
+
+// Remove 从排行榜移除 key
+func (lb *Leaderboard[T]) Remove(key interface{}) {
+	key = lb.cache.canonicalKey(key)
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	if score, ok := lb.scores[key]; ok {
+		lb.removeLocked(key, score)
+		delete(lb.scores, key)
+	}
+}
+
+func (lb *Leaderboard[T]) removeLocked(key interface{}, score int64) {
+	i := lb.search(key, score)
+	if i < len(lb.entries) && lb.entries[i].Key == key {
+		lb.entries = append(lb.entries[:i], lb.entries[i+1:]...)
+	}
+}
+
+// search 返回 (key, score) 在有序切片中的位置
+func (lb *Leaderboard[T]) search(key interface{}, score int64) int {
+	k := fmt.Sprint(key)
+	return sort.Search(len(lb.entries), func(i int) bool {
+		e := lb.entries[i]
+		if e.Score != score {
+			return e.Score < score
+		}
+		return fmt.Sprint(e.Key) >= k
+	})
+}
+
+// Len 返回排行榜中的条目数
+func (lb *Leaderboard[T]) Len() int {
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+	return len(lb.entries)
+}
+
+// Rank 返回 key 的名次（从 1 开始），不在榜上时返回 false
+func (lb *Leaderboard[T]) Rank(key interface{}) (int, bool) {
+	key = lb.cache.canonicalKey(key)
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+	score, ok := lb.scores[key]
+	if !ok {
+		return 0, false
+	}
+	return lb.search(key, score) + 1, true
+}
+
+// Top 返回前 n 名
+func (lb *Leaderboard[T]) Top(n int) []RankEntry {
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+	return lb.slice(0, n)
+}
+
+// Around 返回 key 前后各 n 名（包含 key 本身），不在榜上时返回 nil
+func (lb *Leaderboard[T]) Around(key interface{}, n int) []RankEntry {
+	key = lb.cache.canonicalKey(key)
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+	score, ok := lb.scores[key]
+	if !ok {
+		return nil
+	}
+	i := lb.search(key, score)
+	start := max(i-n, 0)
+	return lb.slice(start, i+n+1-start)
+}
+
+// slice 复制 [start, start+n) 区间并填充名次，调用方需持有读锁
+func (lb *Leaderboard[T]) slice(start, n int) []RankEntry {
+	end := min(start+n, len(lb.entries))
+	if start >= end {
+		return nil
+	}
+	out := make([]RankEntry, end-start)
+	for i := range out {
+		out[i] = lb.entries[start+i]
+		out[i].Rank = start + i + 1
+	}
+	return out
+}
+
+// SaveSnapshot 将当前排名写入 RankSnapshot 表
+func (lb *Leaderboard[T]) SaveSnapshot() error {
+	lb.mu.RLock()
+	now := time.Now()
+	rows := make([]RankSnapshot, len(lb.entries))
+	for i, e := range lb.entries {
+		rows[i] = RankSnapshot{
+			Board:   lb.name,
+			Rank:    i + 1,
+			Key:     fmt.Sprint(e.Key),
+			Score:   e.Score,
+			TakenAt: now,
+		}
+	}
+	lb.mu.RUnlock()
+
+	if len(rows) == 0 {
+		return nil
+	}
+	if err := lb.cache.db.CreateInBatches(rows, 500).Error; err != nil {
+		return fmt.Errorf("failed to save leaderboard %s snapshot: %w", lb.name, err)
+	}
+	return nil
+}
+
+// PersistEvery 按固定间隔保存排名快照，返回的函数用于停止
+func (lb *Leaderboard[T]) PersistEvery(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := lb.SaveSnapshot(); err != nil {
+					lb.cache.log(LogError, "Leaderboard snapshot failed", "leaderboard", lb.name, "err", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
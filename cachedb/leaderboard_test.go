@@ -0,0 +1,60 @@
+package cachedb
+
+import "testing"
+
+func TestLeaderboard(t *testing.T) {
+	type Fighter struct {
+		ID    uint
+		Power int64
+	}
+	db := openTestDB(t, &Fighter{}, &RankSnapshot{})
+	for i, p := range []int64{50, 80, 20, 80, 10} {
+		db.Create(&Fighter{ID: uint(i + 1), Power: p})
+	}
+
+	c := newTestCache[Fighter](t, db, 10)
+	lb := NewLeaderboard("power", c, func(f *Fighter) int64 { return f.Power })
+	for i := 1; i <= 5; i++ {
+		if err := lb.Update(uint(i)); err != nil {
+			t.Fatalf("update failed: %v", err)
+		}
+	}
+
+	top := lb.Top(3)
+	want := []uint{2, 4, 1}
+	for i, e := range top {
+		if e.Key != want[i] || e.Rank != i+1 {
+			t.Errorf("top[%d] = %+v, want key %d", i, e, want[i])
+		}
+	}
+
+	if rank, ok := lb.Rank(uint(3)); !ok || rank != 4 {
+		t.Errorf("expected rank 4 for key 3, got %d %v", rank, ok)
+	}
+
+	f, _ := c.Get(uint(5))
+	f.Power = 100
+	lb.Update(uint(5))
+	if rank, _ := lb.Rank(uint(5)); rank != 1 {
+		t.Errorf("expected key 5 to lead after update, got rank %d", rank)
+	}
+
+	around := lb.Around(uint(1), 1)
+	if len(around) != 3 || around[1].Key != uint(1) || around[1].Rank != 4 {
+		t.Errorf("unexpected around result %+v", around)
+	}
+
+	lb.Remove(uint(2))
+	if lb.Len() != 4 {
+		t.Errorf("expected 4 entries after remove, got %d", lb.Len())
+	}
+
+	if err := lb.SaveSnapshot(); err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+	var snap RankSnapshot
+	db.Where("board = ? AND rank = 1", "power").First(&snap)
+	if snap.Key != "5" || snap.Score != 100 {
+		t.Errorf("unexpected snapshot %+v", snap)
+	}
+}
@@ -0,0 +1,38 @@
+package cachedb
+
+import "sync"
+
+// Lease 是对缓存对象的一次租用。租用期间对象被固定（见 Pin）：即使被 gcache 淘汰也只回写而不丢弃，
+// 持有者对指针的修改不会丢失。修改完成后调用 Release，之后不应再使用该指针
+type Lease[T any] struct {
+	c     *CacheDB[T]
+	key   interface{}
+	value *T
+	once  sync.Once
+}
+
+// Acquire 获取 key 并租用，适用于跨多个步骤持有指针的游戏逻辑（例如战斗结算）。
+// 每个 Lease 都必须 Release，通常紧跟 defer
+func (c *CacheDB[T]) Acquire(key interface{}) (*Lease[T], error) {
+	key = c.canonicalKey(key)
+	value, err := c.Pin(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Lease[T]{c: c, key: key, value: value}, nil
+}
+
+// Key 返回租用的 key
+func (l *Lease[T]) Key() interface{} {
+	return l.key
+}
+
+// Value 返回租用的对象
+func (l *Lease[T]) Value() *T {
+	return l.value
+}
+
+// Release 归还租用，重复调用无效。租用期间对象已被淘汰时，修改在此时回写
+func (l *Lease[T]) Release() {
+	l.once.Do(func() { l.c.Unpin(l.key) })
+}
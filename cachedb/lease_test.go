@@ -0,0 +1,77 @@
+package cachedb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeaseSurvivesEviction(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, Gold: 10})
+
+	c := newTestCache[Player](t, db, 10)
+	lease, err := c.Acquire(1)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	c.Cache.Remove(uint(1)) // 租用期间被淘汰
+	lease.Value().Gold = 50 // 淘汰后的修改
+
+	if p, _ := c.Get(1); p != lease.Value() {
+		t.Errorf("expected Get to return the leased pointer")
+	}
+	c.Cache.Remove(uint(1))
+	lease.Release()
+	lease.Release() // 重复归还无效
+
+	c.mu.Lock()
+	pins := c.pins[uint(1)]
+	c.mu.Unlock()
+	if pins != 0 {
+		t.Errorf("expected no pins after release, got %d", pins)
+	}
+	var stored Player
+	db.First(&stored, 1)
+	if stored.Gold != 50 {
+		t.Errorf("expected the leased change to be written back, got %d", stored.Gold)
+	}
+}
+
+func TestUpdateAfterEviction(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, Gold: 10})
+
+	c := newTestCache[Player](t, db, 10)
+	c.Get(1)
+	// 模拟淘汰进行到一半：对象已停止追踪，但还留在 gcache 中，Update 的 get 会拿到这个孤立的指针
+	c.mu.Lock()
+	c.untrack(uint(1))
+	c.mu.Unlock()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		c.Cache.Remove(uint(1))
+	}()
+
+	if err := c.Update(1, func(p *Player) { p.Gold = 50 }); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if p, _ := c.Get(1); p.Gold != 50 {
+		t.Errorf("expected the update to apply to the reloaded object, got %d", p.Gold)
+	}
+	if err := c.FlushAll(); err != nil {
+		t.Fatalf("FlushAll failed: %v", err)
+	}
+	var stored Player
+	db.First(&stored, 1)
+	if stored.Gold != 50 {
+		t.Errorf("expected the update to be written back, got %d", stored.Gold)
+	}
+}
@@ -0,0 +1,134 @@
+package cachedb
+
+import "time"
+
+// EntryState 是缓存条目的生命周期状态
+type EntryState int
+
+const (
+	StateAbsent   EntryState = iota // 不在缓存中
+	StateLoading                    // 正在从数据库加载
+	StateClean                      // 与数据库一致
+	StateDirty                      // 有未落库的修改
+	StateFlushing                   // 已排队或正在写回
+	StateFailed                     // 上次写回失败，等待重试
+	StateDeleted                    // 最近通过 Delete 删除
+)
+
+func (s EntryState) String() string {
+	switch s {
+	case StateLoading:
+		return "loading"
+	case StateClean:
+		return "clean"
+	case StateDirty:
+		return "dirty"
+	case StateFlushing:
+		return "flushing"
+	case StateFailed:
+		return "failed"
+	case StateDeleted:
+		return "deleted"
+	default:
+		return "absent"
+	}
+}
+
+// maxTombstones 是保留的最近删除的 key 数，超过时全部清空
+const maxTombstones = 4096
+
+// EntryInfo 描述一个 key 在缓存中的状态
+type EntryInfo struct {
+	Key        interface{}
+	State      EntryState
+	Generation uint64        // 见 Generation
+	Age        time.Duration // 距上次与数据库一致的时间
+	Attempts   int           // 上次成功落库后失败的写入次数
+	LastError  error         // 最近一次写入失败的原因
+	Pinned     bool
+	Mutations  []Mutation // 最近的修改来源，最旧的在前，只在调试构建中记录，见 Mutation
+}
+
+// loadTicket 标记一次进行中的加载，加载期间 key 被删除时作废
+type loadTicket struct {
+	deleted bool
+}
+
+// setState 修改 key 记录的状态，调用方需持有 c.mu。
+// 记录的状态只有 Clean、Flushing 和 Failed 三种，Dirty 由 stateLocked 与副本比较得出
+func (c *CacheDB[T]) setState(key interface{}, state EntryState) {
+	if m := c.meta[key]; m != nil {
+		m.state = state
+	}
+}
+
+// startLoad 记录 key 开始加载，返回的 ticket 在加载完成后交给 finishLoad
+func (c *CacheDB[T]) startLoad(key interface{}) *loadTicket {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &loadTicket{}
+	c.loading[key] = t
+	return t
+}
+
+// finishLoad 清除加载记录，返回加载期间 key 是否被删除，调用方需持有 c.mu
+func (c *CacheDB[T]) finishLoad(key interface{}, t *loadTicket) (deleted bool) {
+	if c.loading[key] == t {
+		delete(c.loading, key)
+	}
+	return t.deleted
+}
+
+// tombstone 记录 key 已被删除，并作废进行中的加载，调用方需持有 c.mu
+func (c *CacheDB[T]) tombstone(key interface{}) {
+	if t := c.loading[key]; t != nil {
+		t.deleted = true
+	}
+	if len(c.deleted) >= maxTombstones {
+		c.deleted = make(map[interface{}]struct{})
+	}
+	c.deleted[key] = struct{}{}
+}
+
+// stateLocked 返回 key 的当前状态，调用方需持有 c.mu
+func (c *CacheDB[T]) stateLocked(key interface{}) EntryState {
+	value, tracked := c.values[key]
+	if !tracked {
+		if _, ok := c.loading[key]; ok {
+			return StateLoading
+		}
+		if _, ok := c.deleted[key]; ok {
+			return StateDeleted
+		}
+		return StateAbsent
+	}
+	m := c.meta[key]
+	if m == nil {
+		return StateClean
+	}
+	switch {
+	case m.state == StateFlushing:
+		return StateFlushing
+	case m.attempts > 0 || m.state == StateFailed:
+		return StateFailed
+	case c.modified(key, value):
+		return StateDirty
+	}
+	return StateClean
+}
+
+// EntryInfo 返回 key 的生命周期状态及落库信息
+func (c *CacheDB[T]) EntryInfo(key interface{}) EntryInfo {
+	key = c.canonicalKey(key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	info := EntryInfo{Key: key, State: c.stateLocked(key), Pinned: c.pins[key] > 0}
+	if m := c.meta[key]; m != nil {
+		info.Generation = m.gen
+		info.Age = c.clock.Now().Sub(m.since)
+		info.Attempts = m.attempts
+		info.LastError = m.lastErr
+		info.Mutations = append([]Mutation(nil), m.mutations...)
+	}
+	return info
+}
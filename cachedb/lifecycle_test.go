@@ -0,0 +1,80 @@
+package cachedb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEntryLifecycle(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1})
+	c := newTestCache[Player](t, db, 10, WithValidator[Player](func(_ interface{}, p *Player) error {
+		if p.Gold < 0 {
+			return errors.New("negative gold")
+		}
+		return nil
+	}))
+
+	state := func() EntryState { return c.EntryInfo(1).State }
+	if s := state(); s != StateAbsent {
+		t.Errorf("state before load = %v", s)
+	}
+	c.Get(1)
+	if s := state(); s != StateClean {
+		t.Errorf("state after load = %v", s)
+	}
+	c.Update(1, func(p *Player) { p.Gold = -1 })
+	if s := state(); s != StateDirty {
+		t.Errorf("state after update = %v", s)
+	}
+	c.Flush(1)
+	if info := c.EntryInfo(1); info.State != StateFailed || info.Attempts != 1 || info.LastError == nil {
+		t.Errorf("info after failed flush = %+v", info)
+	}
+	if got := c.Stats().States["failed"]; got != 1 {
+		t.Errorf("Stats().States[failed] = %d, want 1", got)
+	}
+	c.Update(1, func(p *Player) { p.Gold = 5 })
+	if err := c.Flush(1); err != nil || state() != StateClean {
+		t.Errorf("state after successful flush = %v %v", state(), err)
+	}
+	if err := c.Delete(1); err != nil || state() != StateDeleted {
+		t.Errorf("state after delete = %v %v", state(), err)
+	}
+}
+
+func TestDeleteDuringLoad(t *testing.T) {
+	type Player struct {
+		ID uint
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1})
+	loading, gate := make(chan struct{}), make(chan struct{})
+	c := newTestCache[Player](t, db, 10, WithStore[Player](func(base Store[Player]) Store[Player] {
+		return gatedStore[Player]{Store: base, loading: loading, gate: gate}
+	}))
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := c.Get(1)
+		errs <- err
+	}()
+	<-loading
+	if s := c.EntryInfo(1).State; s != StateLoading {
+		t.Errorf("state during load = %v", s)
+	}
+	if err := c.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+	close(gate)
+	if err := <-errs; !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the load racing with Delete to report not found, got %v", err)
+	}
+	if s := c.EntryInfo(1).State; s != StateDeleted {
+		t.Errorf("state after delete = %v", s)
+	}
+}
@@ -0,0 +1,131 @@
+package cachedb
+
+import (
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// ListCache 缓存以追加为主的子集合，例如玩家邮件、战斗日志。
+// Append 只写内存，刷盘时批量插入；Range 会把数据库中的分页与内存中的尾部合并。
+// ListCache 实现了 Flusher，可交给 Checkpointer 定期刷盘
+type ListCache[T any] struct {
+	db          *gorm.DB
+	ownerColumn string // 归属列，例如 "player_id"
+	orderColumn string // 追加顺序列，例如自增的 "id"
+	batchSize   int
+
+	mu      sync.Mutex
+	pending map[interface{}][]T // owner → 尚未落库的行，按追加顺序
+}
+
+// NewListCache 创建追加型集合缓存
+func NewListCache[T any](db *gorm.DB, ownerColumn, orderColumn string) *ListCache[T] {
+	return &ListCache[T]{
+		db:          db,
+		ownerColumn: ownerColumn,
+		orderColumn: orderColumn,
+		batchSize:   500,
+		pending:     make(map[interface{}][]T),
+	}
+}
+
+// Append 追加若干行到 owner 的集合，行中的归属列需由调用方填好
+func (l *ListCache[T]) Append(owner interface{}, rows ...T) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending[owner] = append(l.pending[owner], rows...)
+}
+
+// DirtyKeys 返回有未落库数据的 owner
+func (l *ListCache[T]) DirtyKeys() []interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	keys := make([]interface{}, 0, len(l.pending))
+	for owner := range l.pending {
+		keys = append(keys, owner)
+	}
+	return keys
+}
+
+// Flush 批量插入 owner 未落库的行，失败时保留在内存中等待重试
+func (l *ListCache[T]) Flush(owner interface{}) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rows := l.pending[owner]
+	if len(rows) == 0 {
+		return nil
+	}
+	if err := l.db.CreateInBatches(rows, l.batchSize).Error; err != nil {
+		return fmt.Errorf("failed to insert list rows for owner %v: %w", owner, err)
+	}
+	delete(l.pending, owner)
+	return nil
+}
+
+// FlushAll 刷盘所有 owner，返回遇到的第一个错误
+func (l *ListCache[T]) FlushAll() error {
+	var firstErr error
+	for _, owner := range l.DirtyKeys() {
+		if err := l.Flush(owner); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Len 返回 owner 集合的总长度（数据库 + 内存）
+func (l *ListCache[T]) Len(owner interface{}) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stored, err := l.countStored(owner)
+	if err != nil {
+		return 0, err
+	}
+	return stored + len(l.pending[owner]), nil
+}
+
+// Range 按追加顺序返回 owner 集合中 [offset, offset+limit) 的行
+func (l *ListCache[T]) Range(owner interface{}, offset, limit int) ([]T, error) {
+	if offset < 0 || limit <= 0 {
+		return nil, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stored, err := l.countStored(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []T
+	if offset < stored {
+		if err := l.db.Where(l.ownerColumn+" = ?", owner).
+			Order(l.orderColumn).
+			Offset(offset).
+			Limit(limit).
+			Find(&out).Error; err != nil {
+			return nil, fmt.Errorf("failed to query list rows for owner %v: %w", owner, err)
+		}
+	}
+
+	// 剩余部分从内存尾部补齐
+	tail := l.pending[owner]
+	start := max(offset-stored, 0)
+	for i := start; i < len(tail) && len(out) < limit; i++ {
+		out = append(out, tail[i])
+	}
+	return out, nil
+}
+
+// countStored 返回 owner 在数据库中的行数，调用方需持有 l.mu
+func (l *ListCache[T]) countStored(owner interface{}) (int, error) {
+	var count int64
+	if err := l.db.Model(new(T)).Where(l.ownerColumn+" = ?", owner).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count list rows for owner %v: %w", owner, err)
+	}
+	return int(count), nil
+}
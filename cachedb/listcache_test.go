@@ -0,0 +1,48 @@
+package cachedb
+
+import "testing"
+
+func TestListCache(t *testing.T) {
+	type Mail struct {
+		ID       uint
+		PlayerID uint
+		Subject  string
+	}
+	db := openTestDB(t, &Mail{})
+	db.Create(&Mail{PlayerID: 1, Subject: "welcome"})
+	db.Create(&Mail{PlayerID: 1, Subject: "reward"})
+
+	l := NewListCache[Mail](db, "player_id", "id")
+	l.Append(uint(1), Mail{PlayerID: 1, Subject: "gift"}, Mail{PlayerID: 1, Subject: "event"})
+
+	if n, err := l.Len(uint(1)); err != nil || n != 4 {
+		t.Fatalf("expected len 4, got %d (%v)", n, err)
+	}
+
+	page, err := l.Range(uint(1), 1, 2)
+	if err != nil {
+		t.Fatalf("range failed: %v", err)
+	}
+	if len(page) != 2 || page[0].Subject != "reward" || page[1].Subject != "gift" {
+		t.Fatalf("unexpected merged page %+v", page)
+	}
+
+	var count int64
+	db.Model(&Mail{}).Count(&count)
+	if count != 2 {
+		t.Fatalf("append should not touch db before flush, got %d rows", count)
+	}
+
+	if err := l.FlushAll(); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	db.Model(&Mail{}).Count(&count)
+	if count != 4 {
+		t.Errorf("expected 4 rows after flush, got %d", count)
+	}
+
+	page, _ = l.Range(uint(1), 2, 10)
+	if len(page) != 2 || page[0].Subject != "gift" || page[1].Subject != "event" {
+		t.Errorf("unexpected page after flush %+v", page)
+	}
+}
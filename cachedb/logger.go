@@ -0,0 +1,78 @@
+package cachedb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LogLevel 是日志级别
+type LogLevel int
+
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "DEBUG"
+	case LogInfo:
+		return "INFO"
+	case LogWarn:
+		return "WARN"
+	default:
+		return "ERROR"
+	}
+}
+
+// Logger 接收 CacheDB 输出的结构化日志，fields 为交替出现的键和值
+type Logger interface {
+	Log(level LogLevel, msg string, fields ...interface{})
+}
+
+// WithLogger 替换默认的日志输出（打印到标准输出）
+func WithLogger[T any](logger Logger) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.logger = logger
+	}
+}
+
+// stdLogger 把日志打印到标准输出，格式为 "msg: k=v k=v"
+type stdLogger struct{}
+
+func (stdLogger) Log(level LogLevel, msg string, fields ...interface{}) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s", level, msg)
+	for i := 0; i+1 < len(fields); i += 2 {
+		sep := " "
+		if i == 0 {
+			sep = ": "
+		}
+		fmt.Fprintf(&b, "%s%v=%v", sep, fields[i], fields[i+1])
+	}
+	fmt.Println(b.String())
+}
+
+// log 输出一条带实体标签的日志
+func (c *CacheDB[T]) log(level LogLevel, msg string, fields ...interface{}) {
+	if level < c.logLevel {
+		return
+	}
+	out := append([]interface{}{"entity", c.entity}, fields...)
+	for i := 0; i < len(out) && len(c.redacted) > 0; i++ {
+		// 记录对象本身时遮蔽敏感字段，见 Redact
+		switch v := out[i].(type) {
+		case T:
+			out[i] = c.Redact(v)
+		case *T:
+			if v != nil {
+				r := c.Redact(*v)
+				out[i] = &r
+			}
+		}
+	}
+	c.logger.Log(level, msg, out...)
+}
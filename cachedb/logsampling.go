@@ -0,0 +1,65 @@
+package cachedb
+
+import "sync/atomic"
+
+// LogEvent 是按操作输出的高频日志类型
+type LogEvent string
+
+const (
+	EventAdd   LogEvent = "add"   // 条目加入 gcache
+	EventEvict LogEvent = "evict" // 条目被淘汰并回写
+	EventPurge LogEvent = "purge" // 清空缓存时的回写
+	EventSave  LogEvent = "save"  // 修改落库
+)
+
+// LogPolicy 控制一类事件日志的输出
+type LogPolicy struct {
+	Disabled bool     // 不输出该类日志
+	Level    LogLevel // 输出使用的级别，零值表示使用事件的默认级别（save 为 LogInfo，其余为 LogDebug）
+	Sample   int      // 每 Sample 条只输出 1 条，0 或 1 表示全部输出
+}
+
+// eventPolicy 是 LogPolicy 及其采样计数
+type eventPolicy struct {
+	LogPolicy
+	count atomic.Uint64
+}
+
+// WithLogPolicy 设置一类事件日志的级别与采样，例如高流量的缓存关闭 add 日志、evict 日志每 100 条输出 1 条
+func WithLogPolicy[T any](event LogEvent, policy LogPolicy) Option[T] {
+	return func(c *CacheDB[T]) {
+		if c.logPolicies == nil {
+			c.logPolicies = make(map[LogEvent]*eventPolicy)
+		}
+		c.logPolicies[event] = &eventPolicy{LogPolicy: policy}
+	}
+}
+
+// WithLogLevel 设置最低日志级别，低于该级别的日志不会交给 Logger，默认全部输出
+func WithLogLevel[T any](level LogLevel) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.logLevel = level
+	}
+}
+
+// logEvent 按事件策略输出日志，采样时附带 sample 字段表示一条日志代表的事件数
+func (c *CacheDB[T]) logEvent(event LogEvent, level LogLevel, msg string, fields ...interface{}) {
+	p := c.logPolicies[event]
+	if p == nil {
+		c.log(level, msg, fields...)
+		return
+	}
+	if p.Disabled {
+		return
+	}
+	if p.Level != LogDebug {
+		level = p.Level
+	}
+	if p.Sample > 1 {
+		if (p.count.Add(1)-1)%uint64(p.Sample) != 0 {
+			return
+		}
+		fields = append(fields, "sample", p.Sample)
+	}
+	c.log(level, msg, fields...)
+}
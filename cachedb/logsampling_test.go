@@ -0,0 +1,44 @@
+package cachedb
+
+import "testing"
+
+func TestLogPolicy(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	for i := 1; i <= 10; i++ {
+		db.Create(&Player{ID: uint(i)})
+	}
+
+	logs := &recordLogger{}
+	c := newTestCache[Player](t, db, 10,
+		WithLogger[Player](logs),
+		WithLogPolicy[Player](EventAdd, LogPolicy{Disabled: true}),
+		WithLogPolicy[Player](EventSave, LogPolicy{Level: LogWarn, Sample: 4}))
+
+	for i := 1; i <= 10; i++ {
+		p, _ := c.Get(i)
+		p.Gold = i
+		c.Flush(i)
+	}
+	if got := logs.find("New cache added"); len(got) != 0 {
+		t.Errorf("add logs should be disabled, got %d", len(got))
+	}
+	saved := logs.find("Saved changes")
+	if len(saved) != 3 { // 第 1、5、9 条
+		t.Fatalf("expected 3 sampled save logs, got %d", len(saved))
+	}
+	if saved[0].level != LogWarn || saved[0].fields[len(saved[0].fields)-1] != 4 {
+		t.Errorf("unexpected sampled log %+v", saved[0])
+	}
+
+	quiet := newTestCache[Player](t, db, 10, WithLogger[Player](logs), WithLogLevel[Player](LogWarn))
+	p, _ := quiet.Get(1)
+	p.Gold = 100
+	quiet.Flush(1)
+	if got := logs.find("Saved changes"); len(got) != 3 {
+		t.Errorf("info logs should be filtered by level, got %d", len(got))
+	}
+}
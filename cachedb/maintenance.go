@@ -0,0 +1,121 @@
+package cachedb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RegistryError 汇总跨实体操作中每个失败的实体及原因
+type RegistryError struct {
+	Errors map[string]error
+}
+
+func (e *RegistryError) Error() string {
+	names := make([]string, 0, len(e.Errors))
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s: %v", name, e.Errors[name])
+	}
+	return fmt.Sprintf("%d entities failed (%s)", len(names), strings.Join(parts, "; "))
+}
+
+// Purge 把所有脏数据写回数据库并清空缓存，回写失败的对象会滞留在内存中等待重试，返回遇到的第一个错误
+func (c *CacheDB[T]) Purge() error {
+	err := c.flushAll(FlushPurge).first
+	c.Cache.Purge()
+	return err
+}
+
+// SetMaintenanceConcurrency 设置 FlushAll、PurgeAll 同时处理的实体数，默认 4
+func (r *Registry) SetMaintenanceConcurrency(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maintenance = n
+}
+
+// FlushAll 把所有已注册缓存的脏数据写回数据库，例如停服维护前的检查点。
+// 失败的实体通过 *RegistryError 返回；ctx 取消后尚未开始的实体记为 ctx.Err()
+func (r *Registry) FlushAll(ctx context.Context) error {
+	return r.each(ctx, func(c Managed) error {
+		if f, ok := c.(interface{ FlushAll() error }); ok {
+			return f.FlushAll()
+		}
+		var firstErr error
+		for _, key := range c.DirtyKeys() {
+			if err := c.Flush(key); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	})
+}
+
+// PurgeAll 把所有已注册缓存的脏数据写回数据库并清空缓存，不支持 Purge 的缓存只执行 FlushAll
+func (r *Registry) PurgeAll(ctx context.Context) error {
+	return r.each(ctx, func(c Managed) error {
+		if p, ok := c.(interface{ Purge() error }); ok {
+			return p.Purge()
+		}
+		if f, ok := c.(interface{ FlushAll() error }); ok {
+			return f.FlushAll()
+		}
+		return nil
+	})
+}
+
+// each 以有限的并发对每个已注册的缓存执行 fn
+func (r *Registry) each(ctx context.Context, fn func(Managed) error) error {
+	r.mu.RLock()
+	names := r.namesLocked()
+	caches := make([]Managed, len(names))
+	for i, name := range names {
+		caches[i] = r.caches[name]
+	}
+	limit := r.maintenance
+	r.mu.RUnlock()
+	if limit <= 0 {
+		limit = 4
+	}
+
+	var mu sync.Mutex
+	failed := make(map[string]error)
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, limit)
+	for i, c := range caches {
+		if ctx.Err() == nil {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			mu.Lock()
+			failed[names[i]] = err
+			mu.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func(name string, c Managed) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(c); err != nil {
+				mu.Lock()
+				failed[name] = err
+				mu.Unlock()
+			}
+		}(names[i], c)
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return &RegistryError{Errors: failed}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package cachedb
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRegistryFlushAndPurgeAll(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Name string
+	}
+	type Guild struct {
+		ID   uint
+		Name string
+	}
+	db := openTestDB(t, &Player{}, &Guild{})
+	db.Create(&Player{ID: 1, Name: "a"})
+	db.Create(&Guild{ID: 1, Name: "g"})
+
+	chaos := NewChaosStore[Guild](nil, ChaosConfig{})
+	reg := NewRegistry()
+	reg.AddDB("game", db)
+	reg.SetMaintenanceConcurrency(1)
+	players, _ := RegisterCache[Player](reg, "player", "game", 10)
+	guilds, _ := RegisterCache[Guild](reg, "guild", "game", 10, WithStore(func(base Store[Guild]) Store[Guild] {
+		chaos.inner = base
+		return chaos
+	}))
+
+	players.Update(1, func(p *Player) { p.Name = "b" })
+	guilds.Update(1, func(g *Guild) { g.Name = "h" })
+	chaos.SetConfig(ChaosConfig{ErrorRate: 1})
+
+	err := reg.FlushAll(context.Background())
+	var regErr *RegistryError
+	if !errors.As(err, &regErr) || len(regErr.Errors) != 1 || regErr.Errors["guild"] == nil {
+		t.Fatalf("expected only guild to fail, got %v", err)
+	}
+	var p Player
+	db.First(&p, 1)
+	if p.Name != "b" {
+		t.Errorf("expected player to be flushed, got %+v", p)
+	}
+
+	chaos.SetConfig(ChaosConfig{})
+	if err := reg.PurgeAll(context.Background()); err != nil {
+		t.Fatalf("PurgeAll failed: %v", err)
+	}
+	if players.Cache.Len(false) != 0 || guilds.Cache.Len(false) != 0 {
+		t.Errorf("expected caches to be purged")
+	}
+	var g Guild
+	db.First(&g, 1)
+	if g.Name != "h" {
+		t.Errorf("expected guild to be flushed on purge, got %+v", g)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := reg.FlushAll(ctx); !errors.As(err, &regErr) {
+		t.Errorf("expected cancelled context to be reported, got %v", err)
+	}
+}
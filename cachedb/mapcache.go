@@ -0,0 +1,216 @@
+package cachedb
+
+import (
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// slotState 槽位的脏状态
+type slotState uint8
+
+const (
+	slotDirty   slotState = iota + 1 // 需要 upsert
+	slotDeleted                      // 需要删除
+)
+
+// slotMap 是一个 owner 下的全部槽位
+type slotMap[K comparable, V any] struct {
+	rows  map[K]*V
+	state map[K]slotState
+}
+
+// MapCache 以 (owner, slot) 为键缓存背包、任务进度等子表，按槽位追踪脏状态，
+// 刷盘时批量 upsert/delete。表中 (ownerColumn, slotColumn) 需为主键或唯一索引。
+// MapCache 实现了 Flusher，可交给 Checkpointer 定期刷盘
+type MapCache[K comparable, V any] struct {
+	db          *gorm.DB
+	ownerColumn string
+	slotColumn  string
+	slotOf      func(*V) K // 从行中取出槽位
+
+	mu     sync.Mutex
+	owners map[interface{}]*slotMap[K, V]
+}
+
+// NewMapCache 创建子表缓存
+func NewMapCache[K comparable, V any](db *gorm.DB, ownerColumn, slotColumn string, slotOf func(*V) K) *MapCache[K, V] {
+	return &MapCache[K, V]{
+		db:          db,
+		ownerColumn: ownerColumn,
+		slotColumn:  slotColumn,
+		slotOf:      slotOf,
+		owners:      make(map[interface{}]*slotMap[K, V]),
+	}
+}
+
+// load 返回 owner 的槽位，未缓存时从数据库整体加载，调用方需持有 m.mu
+func (m *MapCache[K, V]) load(owner interface{}) (*slotMap[K, V], error) {
+	if sm, ok := m.owners[owner]; ok {
+		return sm, nil
+	}
+
+	var rows []V
+	if err := m.db.Where(m.ownerColumn+" = ?", owner).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load slots for owner %v: %w", owner, err)
+	}
+	sm := &slotMap[K, V]{
+		rows:  make(map[K]*V, len(rows)),
+		state: make(map[K]slotState),
+	}
+	for i := range rows {
+		sm.rows[m.slotOf(&rows[i])] = &rows[i]
+	}
+	m.owners[owner] = sm
+	return sm, nil
+}
+
+// Get 返回槽位中的行，返回的指针被修改后需调用 MarkDirty
+func (m *MapCache[K, V]) Get(owner interface{}, slot K) (*V, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sm, err := m.load(owner)
+	if err != nil {
+		return nil, false, err
+	}
+	v, ok := sm.rows[slot]
+	return v, ok, nil
+}
+
+// All 返回 owner 的全部槽位
+func (m *MapCache[K, V]) All(owner interface{}) (map[K]*V, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sm, err := m.load(owner)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[K]*V, len(sm.rows))
+	for k, v := range sm.rows {
+		out[k] = v
+	}
+	return out, nil
+}
+
+// Put 写入槽位并标记为脏，槽位由 slotOf(&value) 决定
+func (m *MapCache[K, V]) Put(owner interface{}, value V) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sm, err := m.load(owner)
+	if err != nil {
+		return err
+	}
+	slot := m.slotOf(&value)
+	sm.rows[slot] = &value
+	sm.state[slot] = slotDirty
+	return nil
+}
+
+// MarkDirty 标记通过指针原地修改过的槽位
+func (m *MapCache[K, V]) MarkDirty(owner interface{}, slot K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if sm, ok := m.owners[owner]; ok {
+		if _, exists := sm.rows[slot]; exists {
+			sm.state[slot] = slotDirty
+		}
+	}
+}
+
+// Delete 清空槽位，刷盘时删除对应的行
+func (m *MapCache[K, V]) Delete(owner interface{}, slot K) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sm, err := m.load(owner)
+	if err != nil {
+		return err
+	}
+	delete(sm.rows, slot)
+	sm.state[slot] = slotDeleted
+	return nil
+}
+
+// DirtyKeys 返回有未落库槽位的 owner
+func (m *MapCache[K, V]) DirtyKeys() []interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []interface{}
+	for owner, sm := range m.owners {
+		if len(sm.state) > 0 {
+			keys = append(keys, owner)
+		}
+	}
+	return keys
+}
+
+// Flush 在一个事务内批量 upsert 修改过的槽位并删除清空的槽位
+func (m *MapCache[K, V]) Flush(owner interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.flushLocked(owner)
+}
+
+// flushLocked 刷盘 owner 的槽位，调用方需持有 m.mu
+func (m *MapCache[K, V]) flushLocked(owner interface{}) error {
+	sm, ok := m.owners[owner]
+	if !ok || len(sm.state) == 0 {
+		return nil
+	}
+
+	var upserts []*V
+	var deletes []K
+	for slot, st := range sm.state {
+		if st == slotDeleted {
+			deletes = append(deletes, slot)
+		} else {
+			upserts = append(upserts, sm.rows[slot])
+		}
+	}
+
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if len(upserts) > 0 {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: m.ownerColumn}, {Name: m.slotColumn}},
+				UpdateAll: true,
+			}).Create(upserts).Error; err != nil {
+				return err
+			}
+		}
+		if len(deletes) > 0 {
+			if err := tx.Where(m.ownerColumn+" = ? AND "+m.slotColumn+" IN ?", owner, deletes).
+				Delete(new(V)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to flush slots for owner %v: %w", owner, err)
+	}
+	sm.state = make(map[K]slotState)
+	return nil
+}
+
+// FlushAll 刷盘所有 owner，返回遇到的第一个错误
+func (m *MapCache[K, V]) FlushAll() error {
+	var firstErr error
+	for _, owner := range m.DirtyKeys() {
+		if err := m.Flush(owner); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Evict 刷盘后把 owner 移出缓存，刷盘与移出在同一次加锁内完成，期间的 Put 不会丢失
+func (m *MapCache[K, V]) Evict(owner interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.flushLocked(owner); err != nil {
+		return err
+	}
+	delete(m.owners, owner)
+	return nil
+}
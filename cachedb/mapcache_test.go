@@ -0,0 +1,49 @@
+package cachedb
+
+import "testing"
+
+func TestMapCache(t *testing.T) {
+	type Slot struct {
+		PlayerID uint `gorm:"primaryKey"`
+		Slot     int  `gorm:"primaryKey"`
+		ItemID   int
+		Count    int
+	}
+	db := openTestDB(t, &Slot{})
+	db.Create(&Slot{PlayerID: 1, Slot: 0, ItemID: 100, Count: 1})
+	db.Create(&Slot{PlayerID: 1, Slot: 1, ItemID: 200, Count: 5})
+
+	m := NewMapCache[int, Slot](db, "player_id", "slot", func(s *Slot) int { return s.Slot })
+
+	s, ok, err := m.Get(uint(1), 1)
+	if err != nil || !ok || s.ItemID != 200 {
+		t.Fatalf("unexpected slot %+v %v %v", s, ok, err)
+	}
+	s.Count = 4
+	m.MarkDirty(uint(1), 1)
+
+	if err := m.Put(uint(1), Slot{PlayerID: 1, Slot: 2, ItemID: 300, Count: 1}); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	if err := m.Delete(uint(1), 0); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+
+	if keys := m.DirtyKeys(); len(keys) != 1 {
+		t.Fatalf("expected one dirty owner, got %v", keys)
+	}
+	if err := m.Evict(uint(1)); err != nil {
+		t.Fatalf("evict failed: %v", err)
+	}
+
+	var rows []Slot
+	db.Order("slot").Find(&rows)
+	if len(rows) != 2 || rows[0].Slot != 1 || rows[0].Count != 4 || rows[1].ItemID != 300 {
+		t.Errorf("unexpected rows after flush %+v", rows)
+	}
+
+	all, err := m.All(uint(1))
+	if err != nil || len(all) != 2 {
+		t.Errorf("expected reload of 2 slots, got %v (%v)", all, err)
+	}
+}
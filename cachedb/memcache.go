@@ -0,0 +1,185 @@
+package cachedb
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemcacheL2 是基于 memcached 文本协议的 L2 实现，按 key 的哈希把请求分配到各个服务器。
+// 每个服务器维护一个连接，出错后在下次请求时重新连接
+type MemcacheL2 struct {
+	servers []*memcacheConn
+	timeout time.Duration
+}
+
+// memcacheConn 是到一个服务器的连接，mu 保证请求与响应成对
+type memcacheConn struct {
+	addr string
+	mu   sync.Mutex
+	conn net.Conn
+	rw   *bufio.ReadWriter
+}
+
+// NewMemcacheL2 创建连接到 addrs 的 memcached 客户端，连接在首次使用时建立
+func NewMemcacheL2(timeout time.Duration, addrs ...string) *MemcacheL2 {
+	m := &MemcacheL2{timeout: timeout}
+	for _, addr := range addrs {
+		m.servers = append(m.servers, &memcacheConn{addr: addr})
+	}
+	return m
+}
+
+// memcacheMaxTTL 是 memcached 按相对秒数解释过期时间的上限，超过时需要使用绝对时间戳
+const memcacheMaxTTL = 30 * 24 * time.Hour
+
+func (m *MemcacheL2) Get(key string) ([]byte, bool, error) {
+	var value []byte
+	found := false
+	err := m.do(key, fmt.Sprintf("get %s\r\n", key), func(r *bufio.Reader) error {
+		for {
+			line, err := readLine(r)
+			if err != nil {
+				return err
+			}
+			if line == "END" {
+				return nil
+			}
+			// VALUE <key> <flags> <bytes>
+			fields := strings.Fields(line)
+			if len(fields) < 4 || fields[0] != "VALUE" {
+				return fmt.Errorf("memcache: unexpected response %q", line)
+			}
+			n, err := strconv.Atoi(fields[3])
+			if err != nil {
+				return fmt.Errorf("memcache: bad value length %q", line)
+			}
+			value = make([]byte, n+2)
+			if _, err := io.ReadFull(r, value); err != nil {
+				return err
+			}
+			value, found = value[:n], true
+		}
+	})
+	return value, found, err
+}
+
+func (m *MemcacheL2) Set(key string, value []byte, ttl time.Duration) error {
+	cmd := fmt.Sprintf("set %s 0 %d %d\r\n%s\r\n", key, expiry(ttl), len(value), value)
+	return m.do(key, cmd, expect("STORED"))
+}
+
+func (m *MemcacheL2) Delete(key string) error {
+	return m.do(key, fmt.Sprintf("delete %s\r\n", key), expect("DELETED", "NOT_FOUND"))
+}
+
+func (m *MemcacheL2) Touch(key string, ttl time.Duration) error {
+	return m.do(key, fmt.Sprintf("touch %s %d\r\n", key, expiry(ttl)), expect("TOUCHED", "NOT_FOUND"))
+}
+
+// Close 关闭所有连接
+func (m *MemcacheL2) Close() error {
+	for _, s := range m.servers {
+		s.mu.Lock()
+		if s.conn != nil {
+			s.conn.Close()
+			s.conn = nil
+		}
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// do 在 key 所属的服务器上发送命令并用 read 解析响应，网络错误时关闭连接以便重连
+func (m *MemcacheL2) do(key, cmd string, read func(*bufio.Reader) error) error {
+	if err := checkMemcacheKey(key); err != nil {
+		return err
+	}
+	if len(m.servers) == 0 {
+		return errors.New("memcache: no servers configured")
+	}
+	s := m.servers[crc32.ChecksumIEEE([]byte(key))%uint32(len(m.servers))]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		conn, err := net.DialTimeout("tcp", s.addr, m.timeout)
+		if err != nil {
+			return fmt.Errorf("memcache: failed to connect %s: %w", s.addr, err)
+		}
+		s.conn = conn
+		s.rw = bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	}
+	if m.timeout > 0 {
+		s.conn.SetDeadline(time.Now().Add(m.timeout))
+	}
+	_, err := s.rw.WriteString(cmd)
+	if err == nil {
+		err = s.rw.Flush()
+	}
+	if err == nil {
+		err = read(s.rw.Reader)
+	}
+	var netErr net.Error
+	if err != nil && (errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)) {
+		s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+// expect 返回只接受指定单行响应的解析函数
+func expect(ok ...string) func(*bufio.Reader) error {
+	return func(r *bufio.Reader) error {
+		line, err := readLine(r)
+		if err != nil {
+			return err
+		}
+		for _, s := range ok {
+			if line == s {
+				return nil
+			}
+		}
+		return fmt.Errorf("memcache: unexpected response %q", line)
+	}
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		return "", err
+	}
+	return string(bytes.TrimRight(line, "\r\n")), nil
+}
+
+// expiry 把 ttl 转换为 memcached 的过期时间，0 表示不过期
+func expiry(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	if ttl > memcacheMaxTTL {
+		return time.Now().Add(ttl).Unix()
+	}
+	return int64((ttl + time.Second - 1) / time.Second)
+}
+
+// checkMemcacheKey 检查 key 是否符合协议限制：不超过 250 字节且不含空白和控制字符
+func checkMemcacheKey(key string) error {
+	if len(key) == 0 || len(key) > 250 {
+		return fmt.Errorf("memcache: invalid key length %d", len(key))
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return fmt.Errorf("memcache: invalid character in key %q", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package cachedb
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMemcached 是只支持 get/set/delete/touch 的内存版 memcached 服务器
+type fakeMemcached struct {
+	mu   sync.Mutex
+	data map[string][]byte
+	ttls map[string]string
+	ln   net.Listener
+}
+
+func startFakeMemcached(t *testing.T) *fakeMemcached {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	m := &fakeMemcached{data: make(map[string][]byte), ttls: make(map[string]string), ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go m.serve(conn)
+		}
+	}()
+	return m
+}
+
+func (m *fakeMemcached) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		f := strings.Fields(line)
+		m.mu.Lock()
+		switch f[0] {
+		case "get":
+			if v, ok := m.data[f[1]]; ok {
+				fmt.Fprintf(conn, "VALUE %s 0 %d\r\n%s\r\n", f[1], len(v), v)
+			}
+			fmt.Fprint(conn, "END\r\n")
+		case "set":
+			n, _ := strconv.Atoi(f[4])
+			buf := make([]byte, n+2)
+			io.ReadFull(r, buf)
+			m.data[f[1]], m.ttls[f[1]] = buf[:n], f[3]
+			fmt.Fprint(conn, "STORED\r\n")
+		case "delete", "touch":
+			if _, ok := m.data[f[1]]; !ok {
+				fmt.Fprint(conn, "NOT_FOUND\r\n")
+			} else if f[0] == "delete" {
+				delete(m.data, f[1])
+				fmt.Fprint(conn, "DELETED\r\n")
+			} else {
+				m.ttls[f[1]] = f[2]
+				fmt.Fprint(conn, "TOUCHED\r\n")
+			}
+		}
+		m.mu.Unlock()
+	}
+}
+
+func TestMemcacheL2(t *testing.T) {
+	srv := startFakeMemcached(t)
+	mc := NewMemcacheL2(time.Second, srv.ln.Addr().String())
+	defer mc.Close()
+
+	if _, ok, err := mc.Get("missing"); ok || err != nil {
+		t.Errorf("expected a miss, got %v %v", ok, err)
+	}
+	if err := mc.Set("k", []byte("hello\r\nworld"), time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if v, ok, err := mc.Get("k"); !ok || err != nil || string(v) != "hello\r\nworld" {
+		t.Errorf("unexpected Get %q %v %v", v, ok, err)
+	}
+	if err := mc.Touch("k", 90*time.Second); err != nil || srv.ttls["k"] != "90" {
+		t.Errorf("unexpected Touch %v ttl %s", err, srv.ttls["k"])
+	}
+	if err := mc.Delete("k"); err != nil {
+		t.Errorf("Delete failed: %v", err)
+	}
+	if err := mc.Delete("k"); err != nil {
+		t.Errorf("expected deleting a missing key to succeed, got %v", err)
+	}
+	if err := mc.Set("bad key", nil, 0); err == nil {
+		t.Errorf("expected keys with spaces to be rejected")
+	}
+}
+
+func TestCacheWithL2(t *testing.T) {
+	type Hero struct {
+		ID    uint
+		Level int
+	}
+	db := openTestDB(t, &Hero{})
+	db.Create(&Hero{ID: 1, Level: 1})
+	srv := startFakeMemcached(t)
+	mc := NewMemcacheL2(time.Second, srv.ln.Addr().String())
+	defer mc.Close()
+	ns := Namespace{App: "l2test", Env: "prod"}
+
+	c := newTestCache[Hero](t, db, 10, WithL2[Hero](mc, time.Minute), WithNamespace[Hero](ns))
+	c.Update(1, func(h *Hero) { h.Level = 2 })
+	if err := c.Flush(1); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	c.drainL2() // 落库后在后台写入 L2
+	if v, ok, _ := mc.Get("l2test:prod:heros:1"); !ok || !strings.Contains(string(v), `"Level":2`) {
+		t.Errorf("expected the flushed value in L2, got %s", v)
+	}
+
+	// 另一个节点冷启动时从 L2 读取，不访问数据库
+	db.Model(&Hero{}).Where("id = ?", 1).Update("level", 7)
+	other := newTestCache[Hero](t, db, 10, WithL2[Hero](mc, time.Minute), WithNamespace[Hero](ns))
+	if h, err := other.Get(1); err != nil || h.Level != 2 {
+		t.Errorf("expected L2 hit, got %+v %v", h, err)
+	}
+
+	// Refresh 不读取 L2，并删除其中的旧值
+	db.Model(&Hero{}).Where("id = ?", 1).Update("level", 999)
+	if err := other.Refresh(1); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if h, _ := other.Get(1); h.Level != 999 {
+		t.Errorf("expected Refresh to read the database, got level %d", h.Level)
+	}
+	if _, ok, _ := mc.Get("l2test:prod:heros:1"); ok {
+		t.Errorf("expected Refresh to remove the L2 entry")
+	}
+
+	// Invalidate 同样删除 L2，之后的加载读取数据库
+	c.Update(1, func(h *Hero) { h.Level = 3 })
+	c.Flush(1)
+	c.drainL2()
+	db.Model(&Hero{}).Where("id = ?", 1).Update("level", 5)
+	other.Invalidate(1)
+	if _, ok, _ := mc.Get("l2test:prod:heros:1"); ok {
+		t.Errorf("expected Invalidate to remove the L2 entry")
+	}
+	if h, _ := other.Get(1); h.Level != 5 {
+		t.Errorf("expected a load after Invalidate to read the database, got level %d", h.Level)
+	}
+
+	if err := c.Delete(1); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, ok, _ := mc.Get("l2test:prod:heros:1"); ok {
+		t.Errorf("expected Delete to remove the L2 entry")
+	}
+}
+
+func TestL2WriteOutsideLock(t *testing.T) {
+	type Hero struct {
+		ID    uint
+		Level int
+	}
+	db := openTestDB(t, &Hero{})
+	db.Create(&Hero{ID: 1, Level: 1})
+	l2 := &blockingL2{entered: make(chan struct{}, 1), release: make(chan struct{})}
+	c := newTestCache[Hero](t, db, 10, WithL2[Hero](l2, time.Minute))
+	c.Update(1, func(h *Hero) { h.Level = 2 })
+
+	flushed := make(chan error, 1)
+	go func() { flushed <- c.Flush(1) }()
+	select {
+	case err := <-flushed:
+		if err != nil {
+			t.Fatalf("Flush failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Flush blocked on the L2 write")
+	}
+	<-l2.entered
+	// L2 写入进行中时缓存的锁可用
+	if err := c.Update(1, func(h *Hero) { h.Level = 3 }); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	close(l2.release)
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+}
+
+// blockingL2 的 Set 在 release 关闭前阻塞
+type blockingL2 struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (l *blockingL2) Get(string) ([]byte, bool, error)  { return nil, false, nil }
+func (l *blockingL2) Delete(string) error               { return nil }
+func (l *blockingL2) Touch(string, time.Duration) error { return nil }
+func (l *blockingL2) Set(string, []byte, time.Duration) error {
+	select {
+	case l.entered <- struct{}{}:
+	default:
+	}
+	<-l.release
+	return nil
+}
@@ -0,0 +1,179 @@
+package cachedb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BatchError 记录批量操作中每个失败的 key 及原因，批量读取时数据库中不存在的 key 对应 gorm.ErrRecordNotFound
+type BatchError struct {
+	Errors map[interface{}]error
+}
+
+func (e *BatchError) Error() string {
+	keys := make([]string, 0, len(e.Errors))
+	for key, err := range e.Errors {
+		keys = append(keys, fmt.Sprintf("%v: %v", key, err))
+	}
+	sort.Strings(keys)
+	return fmt.Sprintf("%d keys failed (%s)", len(e.Errors), strings.Join(keys, "; "))
+}
+
+// WithBatchLoad 设置 MGet/Warm 每条 IN 查询的 key 数量及同时执行的查询数，默认 500 与 4
+func WithBatchLoad[T any](batchSize, concurrency int) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.batchSize = batchSize
+		c.batchConcurrency = concurrency
+	}
+}
+
+// MGet 批量获取多个 key。已缓存的直接返回，其余按所在的库和表分组、按批大小切分后并发执行 IN 查询。
+// 部分 key 失败时仍返回成功的部分，同时返回 *BatchError
+func (c *CacheDB[T]) MGet(keys []interface{}) (map[interface{}]*T, error) {
+	if err := c.checkOpen(); err != nil {
+		return nil, err
+	}
+	values, err := c.loadMany(keys)
+	out := make(map[interface{}]*T, len(values))
+	for key, value := range values {
+		v, viewErr := c.view(value)
+		if viewErr != nil {
+			return nil, viewErr
+		}
+		out[key] = v
+	}
+	return out, err
+}
+
+// Warm 预先把 keys 加载进缓存，例如开服或玩家批量登录前。失败的 key 通过 *BatchError 返回
+func (c *CacheDB[T]) Warm(keys []interface{}) error {
+	_, err := c.loadMany(keys)
+	return err
+}
+
+// loadMany 返回 keys 对应的缓存对象，未缓存的批量从数据库加载
+func (c *CacheDB[T]) loadMany(keys []interface{}) (map[interface{}]*T, error) {
+	out := make(map[interface{}]*T, len(keys))
+	failed := make(map[interface{}]error)
+
+	type batch struct {
+		shard Shard
+		keys  []interface{}
+	}
+	groups := make(map[Shard][]interface{})
+	var order []Shard
+	for _, raw := range keys {
+		key, err := c.normalizeKey(raw)
+		if err != nil {
+			failed[raw] = err
+			continue
+		}
+		if _, dup := out[key]; dup {
+			continue
+		}
+		if err := c.checkQuarantine(key); err != nil {
+			failed[key] = err
+			continue
+		}
+		c.mu.Lock()
+		_, tracked := c.values[key]
+		c.mu.Unlock()
+		if tracked {
+			value, err := c.get(key)
+			if err != nil {
+				failed[key] = err
+				continue
+			}
+			out[key] = value
+			continue
+		}
+		if c.absent(key) {
+			continue // 一定不在数据库中
+		}
+		s := c.shardFor(key)
+		if _, ok := groups[s]; !ok {
+			order = append(order, s)
+		}
+		groups[s] = append(groups[s], key)
+	}
+
+	var batches []batch
+	for _, s := range order {
+		pending := groups[s]
+		for len(pending) > 0 {
+			n := min(c.batchSize, len(pending))
+			batches = append(batches, batch{shard: s, keys: pending[:n]})
+			pending = pending[n:]
+		}
+	}
+
+	var mu sync.Mutex
+	load := func(b batch) {
+		loaded, err := c.loadBatch(b.shard, b.keys)
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, key := range b.keys {
+			switch value, ok := loaded[key]; {
+			case err != nil:
+				failed[key] = err
+			case !ok:
+				failed[key] = gorm.ErrRecordNotFound
+			default:
+				out[key] = value
+			}
+		}
+	}
+	if c.loop != nil {
+		// 单线程模式下在调用方线程上依次执行
+		for _, b := range batches {
+			load(b)
+		}
+	} else {
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, c.batchConcurrency)
+		for _, b := range batches {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(b batch) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				load(b)
+			}(b)
+		}
+		wg.Wait()
+	}
+
+	if len(failed) > 0 {
+		return out, &BatchError{Errors: failed}
+	}
+	return out, nil
+}
+
+// loadBatch 用一条 IN 查询加载同一张表中的 keys 并纳入缓存
+func (c *CacheDB[T]) loadBatch(s Shard, keys []interface{}) (map[interface{}]*T, error) {
+	var rows []T
+	cond := clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: c.keyField.DBName}, Values: keys}
+	release := c.acquireDB()
+	db, done := withTimeout(c.preload(s.DB.Table(s.Table)), c.loadTimeout, ErrLoadTimeout)
+	err := done(db.Where(cond).Find(&rows).Error)
+	release()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load keys from %s: %w", s.Table, err)
+	}
+	out := make(map[interface{}]*T, len(rows))
+	for i := range rows {
+		key := c.keyOf(&rows[i])
+		value, err := c.adopt(key, &rows[i])
+		if err != nil {
+			return nil, err
+		}
+		out[key] = value
+	}
+	return out, nil
+}
@@ -0,0 +1,56 @@
+package cachedb
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestMGetBatches(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	for i := 1; i <= 20; i++ {
+		db.Create(&Player{ID: uint(i), Gold: i})
+	}
+
+	var queries int
+	db.Callback().Query().After("gorm:query").Register("count_queries", func(*gorm.DB) { queries++ })
+	defer db.Callback().Query().Remove("count_queries")
+
+	c := newTestCache[Player](t, db, 100, WithBatchLoad[Player](5, 1))
+	c.Get(1) // 已缓存的 key 不再查询
+	queries = 0
+
+	keys := []interface{}{}
+	for i := 1; i <= 22; i++ {
+		keys = append(keys, i)
+	}
+	got, err := c.MGet(keys)
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected BatchError, got %v", err)
+	}
+	for _, key := range []uint{21, 22} {
+		if !errors.Is(batchErr.Errors[key], gorm.ErrRecordNotFound) {
+			t.Errorf("expected key %d to be not found, got %v", key, batchErr.Errors[key])
+		}
+	}
+	if len(got) != 20 || got[uint(7)].Gold != 7 {
+		t.Errorf("unexpected result size %d", len(got))
+	}
+	if queries != 5 { // 21 个未缓存的 key，每批 5 个
+		t.Errorf("expected 5 batched queries, got %d", queries)
+	}
+
+	p, _ := c.Get(7)
+	if p != got[uint(7)] {
+		t.Errorf("MGet should return the cached object")
+	}
+	if err := c.Warm([]interface{}{1, 2, 3}); err != nil {
+		t.Errorf("Warm failed: %v", err)
+	}
+}
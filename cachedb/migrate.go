@@ -0,0 +1,188 @@
+package cachedb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// MigrationStep 是一条待执行的 DDL
+type MigrationStep struct {
+	Entity string // 注册时的实体名
+	Table  string
+	SQL    string
+}
+
+// migratable 由 *CacheDB[T] 实现，提供迁移所需的连接与模型
+type migratable interface {
+	migration() (*gorm.DB, interface{})
+}
+
+func (c *CacheDB[T]) migration() (*gorm.DB, interface{}) {
+	if c.db == nil || c.sharding != nil {
+		return nil, nil // 按 key 分表的物理表由使用方自行创建
+	}
+	if c.tableName != "" {
+		return c.db.Table(c.tableName), new(T)
+	}
+	return c.db, new(T)
+}
+
+// migrationEntity 是参与迁移的一个实体
+type migrationEntity struct {
+	name   string
+	db     *gorm.DB
+	model  interface{}
+	schema *schema.Schema
+	table  string
+}
+
+// AutoMigrate 按外键依赖顺序（被引用的表在前）对所有注册的缓存执行 gorm AutoMigrate，
+// 返回缺失的表、列和索引对应的 DDL。dryRun 为 true 时只返回 DDL 而不修改数据库。
+// 使用 WithSharding 分片的缓存不参与迁移
+func (r *Registry) AutoMigrate(ctx context.Context, dryRun bool) ([]MigrationStep, error) {
+	entities, err := r.migrationOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	var steps []MigrationStep
+	for _, e := range entities {
+		sqls, err := pendingDDL(ctx, e)
+		if err != nil {
+			return steps, fmt.Errorf("failed to plan migration for %s: %w", e.name, err)
+		}
+		for _, sql := range sqls {
+			steps = append(steps, MigrationStep{Entity: e.name, Table: e.table, SQL: sql})
+		}
+		if dryRun || len(sqls) == 0 {
+			continue
+		}
+		if err := e.db.WithContext(ctx).AutoMigrate(e.model); err != nil {
+			return steps, fmt.Errorf("failed to migrate %s: %w", e.name, err)
+		}
+	}
+	return steps, nil
+}
+
+// migrationOrder 收集可迁移的实体并按外键依赖拓扑排序，同一层按实体名排序
+func (r *Registry) migrationOrder() ([]*migrationEntity, error) {
+	r.mu.RLock()
+	byTable := make(map[string]*migrationEntity)
+	var entities []*migrationEntity
+	for _, name := range r.namesLocked() {
+		m, ok := r.caches[name].(migratable)
+		if !ok {
+			continue
+		}
+		db, model := m.migration()
+		if db == nil {
+			continue
+		}
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			r.mu.RUnlock()
+			return nil, fmt.Errorf("failed to parse model of %s: %w", name, err)
+		}
+		e := &migrationEntity{name: name, db: db, model: model, schema: stmt.Schema, table: stmt.Schema.Table}
+		if db.Statement.Table != "" {
+			e.table = db.Statement.Table
+		}
+		byTable[e.table] = e
+		entities = append(entities, e)
+	}
+	r.mu.RUnlock()
+
+	// deps[a] 为 a 的外键引用的表
+	deps := make(map[*migrationEntity][]*migrationEntity)
+	for _, e := range entities {
+		for _, rel := range e.schema.Relationships.Relations {
+			cons := rel.ParseConstraint()
+			if cons == nil || cons.Schema != e.schema {
+				continue
+			}
+			if ref, ok := byTable[cons.ReferenceSchema.Table]; ok && ref != e {
+				deps[e] = append(deps[e], ref)
+			}
+		}
+	}
+
+	var ordered []*migrationEntity
+	done := make(map[*migrationEntity]bool)
+	for len(ordered) < len(entities) {
+		var ready []*migrationEntity
+		for _, e := range entities {
+			if done[e] {
+				continue
+			}
+			blocked := false
+			for _, d := range deps[e] {
+				if !done[d] {
+					blocked = true
+					break
+				}
+			}
+			if !blocked {
+				ready = append(ready, e)
+			}
+		}
+		if len(ready) == 0 {
+			return nil, fmt.Errorf("circular foreign key dependency between entities")
+		}
+		sort.Slice(ready, func(i, j int) bool { return ready[i].name < ready[j].name })
+		for _, e := range ready {
+			done[e] = true
+		}
+		ordered = append(ordered, ready...)
+	}
+	return ordered, nil
+}
+
+// pendingDDL 对比数据库与模型，返回缺失的表、列和索引对应的 DDL
+func pendingDDL(ctx context.Context, e *migrationEntity) ([]string, error) {
+	live := e.db.WithContext(ctx).Migrator()
+	capture := &ddlCapture{}
+	dry := e.db.Session(&gorm.Session{DryRun: true, Logger: capture, Context: ctx}).Migrator()
+
+	if !live.HasTable(e.model) {
+		if err := dry.CreateTable(e.model); err != nil {
+			return nil, err
+		}
+		return capture.sqls, nil
+	}
+	for _, name := range e.schema.DBNames {
+		if !live.HasColumn(e.model, name) {
+			if err := dry.AddColumn(e.model, name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, idx := range e.schema.ParseIndexes() {
+		if !live.HasIndex(e.model, idx.Name) {
+			if err := dry.CreateIndex(e.model, idx.Name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return capture.sqls, nil
+}
+
+// ddlCapture 是只记录 SQL 的 gorm 日志，用于在 DryRun 模式下收集 DDL
+type ddlCapture struct {
+	sqls []string
+}
+
+func (l *ddlCapture) LogMode(logger.LogLevel) logger.Interface      { return l }
+func (l *ddlCapture) Info(context.Context, string, ...interface{})  {}
+func (l *ddlCapture) Warn(context.Context, string, ...interface{})  {}
+func (l *ddlCapture) Error(context.Context, string, ...interface{}) {}
+func (l *ddlCapture) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	if sql, _ := fc(); sql != "" {
+		l.sqls = append(l.sqls, sql)
+	}
+}
@@ -0,0 +1,59 @@
+package cachedb
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRegistryAutoMigrate(t *testing.T) {
+	type Guild struct {
+		ID   uint
+		Name string
+	}
+	type Member struct {
+		ID      uint
+		GuildID uint
+		Guild   Guild
+	}
+	db := openTestDB(t)
+	reg := NewRegistry()
+	reg.AddDB("game", db)
+	// 按名称排序 member 在 guild 之后才对，这里故意让依赖方名称靠前
+	if _, err := RegisterCache[Member](reg, "a_member", "game", 10); err != nil {
+		t.Fatalf("RegisterCache failed: %v", err)
+	}
+	if _, err := RegisterCache[Guild](reg, "b_guild", "game", 10); err != nil {
+		t.Fatalf("RegisterCache failed: %v", err)
+	}
+
+	ctx := context.Background()
+	steps, err := reg.AutoMigrate(ctx, true)
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if len(steps) < 2 || steps[0].Entity != "b_guild" || !strings.Contains(steps[0].SQL, "CREATE TABLE") {
+		t.Fatalf("expected guilds to be created first, got %+v", steps)
+	}
+	if db.Migrator().HasTable(&Guild{}) {
+		t.Fatalf("dry run should not create tables")
+	}
+
+	if _, err := reg.AutoMigrate(ctx, false); err != nil {
+		t.Fatalf("AutoMigrate failed: %v", err)
+	}
+	if !db.Migrator().HasTable(&Guild{}) || !db.Migrator().HasTable(&Member{}) {
+		t.Fatalf("expected tables to be created")
+	}
+	if steps, _ := reg.AutoMigrate(ctx, true); len(steps) != 0 {
+		t.Errorf("expected no pending DDL after migration, got %+v", steps)
+	}
+
+	if err := db.Migrator().DropColumn(&Guild{}, "name"); err != nil {
+		t.Fatalf("DropColumn failed: %v", err)
+	}
+	steps, _ = reg.AutoMigrate(ctx, true)
+	if len(steps) != 1 || !strings.Contains(steps[0].SQL, "ADD `name`") {
+		t.Errorf("expected ADD COLUMN name, got %+v", steps)
+	}
+}
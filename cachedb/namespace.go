@@ -0,0 +1,88 @@
+package cachedb
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Namespace 是多个服务共享外部缓存或事件通道时的键前缀，
+// 完整的键为 app:env:entity:key，避免不同服务或环境的同名实体互相覆盖
+type Namespace struct {
+	App string
+	Env string
+}
+
+// Prefix 返回实体在该命名空间下的前缀（不含 key）
+func (ns Namespace) Prefix(entity string) string {
+	return strings.Join([]string{ns.App, ns.Env, entity}, ":")
+}
+
+// Key 返回实体中某个 key 的完整键
+func (ns Namespace) Key(entity string, key interface{}) string {
+	return fmt.Sprintf("%s:%v", ns.Prefix(entity), key)
+}
+
+// Parse 把完整键拆分为实体和 key，不属于该命名空间时返回 false
+func (ns Namespace) Parse(full string) (entity, key string, ok bool) {
+	rest, found := strings.CutPrefix(full, ns.App+":"+ns.Env+":")
+	if !found {
+		return "", "", false
+	}
+	return strings.Cut(rest, ":")
+}
+
+// namespaces 记录进程内已占用的前缀及其模型类型，用于发现两个不同模型使用了同一个前缀
+var namespaces = struct {
+	sync.Mutex
+	owners map[string]reflect.Type
+}{owners: make(map[string]reflect.Type)}
+
+// claimNamespace 占用前缀，已被其他模型类型占用时返回错误
+func claimNamespace(prefix string, typ reflect.Type) error {
+	namespaces.Lock()
+	defer namespaces.Unlock()
+	if owner, ok := namespaces.owners[prefix]; ok && owner != typ {
+		return fmt.Errorf("namespace %q already used by %s, cannot reuse it for %s", prefix, owner, typ)
+	}
+	namespaces.owners[prefix] = typ
+	return nil
+}
+
+// WithNamespace 为缓存设置命名空间：发布的落库事件以 Namespace.Prefix 作为 Entity，
+// Follow 只接收同一命名空间的事件，NamespacedKey 返回外部缓存使用的完整键。
+// 同一进程内两个不同模型使用相同前缀时构造失败
+func WithNamespace[T any](ns Namespace) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.namespace = &ns
+	}
+}
+
+// SetNamespace 为之后通过 RegisterCache 创建的缓存设置命名空间
+func (r *Registry) SetNamespace(ns Namespace) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.namespace = &ns
+}
+
+// NamespacedKey 返回 key 在外部缓存或事件通道中使用的完整键，未设置命名空间时只包含实体和 key
+func (c *CacheDB[T]) NamespacedKey(key interface{}) string {
+	return fmt.Sprintf("%s:%v", c.qualifiedEntity(), c.canonicalKey(key))
+}
+
+// qualifiedEntity 返回带命名空间前缀的实体标签
+func (c *CacheDB[T]) qualifiedEntity() string {
+	if c.namespace == nil {
+		return c.entity
+	}
+	return c.namespace.Prefix(c.entity)
+}
+
+// checkNamespace 在构造时占用命名空间前缀
+func (c *CacheDB[T]) checkNamespace() error {
+	if c.namespace == nil {
+		return nil
+	}
+	return claimNamespace(c.qualifiedEntity(), reflect.TypeOf((*T)(nil)).Elem())
+}
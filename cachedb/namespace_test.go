@@ -0,0 +1,63 @@
+package cachedb
+
+import (
+	"testing"
+)
+
+func TestNamespace(t *testing.T) {
+	ns := Namespace{App: "battle", Env: "prod"}
+	if got := ns.Key("heroes", 42); got != "battle:prod:heroes:42" {
+		t.Errorf("unexpected key %q", got)
+	}
+	if entity, key, ok := ns.Parse("battle:prod:heroes:42"); !ok || entity != "heroes" || key != "42" {
+		t.Errorf("unexpected parse %q %q %v", entity, key, ok)
+	}
+	if _, _, ok := (Namespace{App: "battle", Env: "test"}).Parse("battle:prod:heroes:42"); ok {
+		t.Errorf("expected keys from another environment to be rejected")
+	}
+}
+
+func TestRegistryNamespace(t *testing.T) {
+	type Hero struct {
+		ID    uint
+		Level int
+	}
+	type Other struct {
+		ID uint
+	}
+	db := openTestDB(t, &Hero{})
+	db.Create(&Hero{ID: 1, Level: 1})
+
+	reg := NewRegistry()
+	reg.AddDB("game", db)
+	reg.SetNamespace(Namespace{App: "nstest", Env: "prod"})
+	bus := NewMemoryBus[Hero]()
+	var events []FlushEvent[Hero]
+	bus.SubscribeFlush(func(e FlushEvent[Hero]) { events = append(events, e) })
+	c, err := RegisterCache[Hero](reg, "hero", "game", 10, WithFlushPublisher[Hero](bus))
+	if err != nil {
+		t.Fatalf("RegisterCache failed: %v", err)
+	}
+	if got := c.NamespacedKey(1); got != "nstest:prod:heros:1" {
+		t.Errorf("unexpected namespaced key %q", got)
+	}
+
+	c.Update(1, func(h *Hero) { h.Level = 2 })
+	c.Flush(1)
+	if len(events) != 1 || events[0].Entity != "nstest:prod:heros" {
+		t.Errorf("expected namespaced flush events, got %+v", events)
+	}
+
+	// 另一个环境的跟随缓存忽略该事件
+	staging := newTestCache[Hero](t, db, 10, WithReadOnly[Hero](), WithNamespace[Hero](Namespace{App: "nstest", Env: "staging"}))
+	staging.Get(1)
+	staging.Follow(bus)
+	bus.PublishFlush(FlushEvent[Hero]{Entity: "nstest:prod:heros", Key: uint(1), Value: Hero{ID: 1, Level: 9}})
+	if h, _ := staging.Get(1); h.Level == 9 {
+		t.Errorf("expected events from another namespace to be ignored")
+	}
+
+	if _, err := NewWithCache[Other](db, 10, WithTable[Other]("heros"), WithNamespace[Other](Namespace{App: "nstest", Env: "prod"})); err == nil {
+		t.Errorf("expected a namespace collision between different models")
+	}
+}
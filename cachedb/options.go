@@ -0,0 +1,4 @@
+package cachedb
+
+// Option 用于定制 CacheDB 的行为
+type Option[T any] func(*CacheDB[T])
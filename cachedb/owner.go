@@ -0,0 +1,215 @@
+package cachedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// ErrFlushCycle 表示 FlushBefore 声明的落库顺序形成了环
+var ErrFlushCycle = errors.New("flush dependency cycle")
+
+// WithOwner 声明对象所属的逻辑归属者（例如玩家 ID），供 Registry.FlushOwner 把同一归属者在多个缓存中的修改放在一个事务中落库
+func WithOwner[T any](fn func(key interface{}, value *T) interface{}) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.owner = fn
+	}
+}
+
+// ownerFunc 是 WithOwner 设置的归属者函数
+type ownerFunc[T any] func(key interface{}, value *T) interface{}
+
+// ownerBatch 是 FlushOwner 期间一个缓存中属于该归属者的脏数据
+type ownerBatch[T any] struct {
+	keys   []interface{}
+	olds   []T
+	values []*T
+}
+
+// ownerFlusher 由设置了 WithOwner 的 *CacheDB[T] 实现。beginOwner 收集脏数据并持有 c.mu，
+// 直到 endOwner 根据事务结果更新副本后释放
+type ownerFlusher interface {
+	ownerDB() (*gorm.DB, bool)
+	beginOwner(owner interface{}) error
+	saveOwner(tx *gorm.DB) error
+	endOwner(committed bool)
+}
+
+func (c *CacheDB[T]) ownerDB() (*gorm.DB, bool) {
+	return c.db, c.owner != nil
+}
+
+func (c *CacheDB[T]) beginOwner(owner interface{}) error {
+	c.mu.Lock()
+	batch := &ownerBatch[T]{}
+	for key, value := range c.values {
+		if c.owner(key, value) != owner || !c.modified(key, value) {
+			continue
+		}
+		if c.shardFor(key).DB != c.db {
+			c.mu.Unlock()
+			return fmt.Errorf("key %v is routed to another database", key)
+		}
+		if err := c.checkPersist(key, value); err != nil {
+			c.mu.Unlock()
+			return err
+		}
+		batch.keys = append(batch.keys, key)
+		batch.olds = append(batch.olds, c.copies[key])
+		batch.values = append(batch.values, value)
+	}
+	c.ownerBatch = batch
+	return nil
+}
+
+func (c *CacheDB[T]) saveOwner(tx *gorm.DB) error {
+	b := c.ownerBatch
+	order, groups := c.groupByShard(b.keys)
+	for _, shard := range order {
+		if err := c.saveShard(tx, shard.Table, groups[shard], b.keys, b.olds, b.values); err != nil {
+			return fmt.Errorf("%s: %w", c.entity, err)
+		}
+	}
+	return nil
+}
+
+func (c *CacheDB[T]) endOwner(committed bool) {
+	defer c.mu.Unlock()
+	b := c.ownerBatch
+	c.ownerBatch = nil
+	if !committed {
+		return
+	}
+	for i, key := range b.keys {
+		c.runFlushHooks(key, FlushManual, b.olds[i], *b.values[i])
+		if err := c.rebase(key, b.values[i]); err != nil {
+			c.log(LogError, "Owner flush rebase failed", "key", key, "err", err)
+			continue
+		}
+		c.settle(key)
+	}
+}
+
+// FlushBefore 声明同一归属者的 first 必须先于 then 落库，例如玩家先于引用它的背包行。
+// 声明会形成环时返回 ErrFlushCycle。实体名可以在注册缓存之前声明
+func (r *Registry) FlushBefore(first, then string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.flushDeps == nil {
+		r.flushDeps = make(map[string][]string)
+	}
+	r.flushDeps[then] = append(r.flushDeps[then], first)
+	if _, err := r.flushOrderLocked(); err != nil {
+		deps := r.flushDeps[then]
+		r.flushDeps[then] = deps[:len(deps)-1]
+		return err
+	}
+	return nil
+}
+
+// flushOrderLocked 按 FlushBefore 声明的依赖对所有实体名做拓扑排序，同一层按名称排序，调用方需持有 r.mu
+func (r *Registry) flushOrderLocked() ([]string, error) {
+	names := make(map[string]bool)
+	for name := range r.caches {
+		names[name] = true
+	}
+	for then, firsts := range r.flushDeps {
+		names[then] = true
+		for _, first := range firsts {
+			names[first] = true
+		}
+	}
+	indegree := make(map[string]int)
+	next := make(map[string][]string)
+	for then, firsts := range r.flushDeps {
+		for _, first := range firsts {
+			indegree[then]++
+			next[first] = append(next[first], then)
+		}
+	}
+	var ready []string
+	for name := range names {
+		if indegree[name] == 0 {
+			ready = append(ready, name)
+		}
+	}
+	var order []string
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		name := ready[0]
+		ready = ready[1:]
+		order = append(order, name)
+		for _, then := range next[name] {
+			if indegree[then]--; indegree[then] == 0 {
+				ready = append(ready, then)
+			}
+		}
+	}
+	if len(order) < len(names) {
+		return nil, ErrFlushCycle
+	}
+	return order, nil
+}
+
+// FlushOwner 在一个事务中按 FlushBefore 声明的顺序写回 owner 在所有设置了 WithOwner 的缓存中的修改，
+// 要么全部落库，要么全部保留为脏数据。owner 需与 WithOwner 返回的值类型相同，参与的缓存必须使用同一个数据库连接；
+// 事务期间这些缓存的内部锁被依次持有，其他操作会等待事务结束
+func (r *Registry) FlushOwner(ctx context.Context, owner interface{}) error {
+	r.mu.RLock()
+	order, err := r.flushOrderLocked()
+	var flushers []ownerFlusher
+	var names []string
+	var db *gorm.DB
+	for _, name := range order {
+		f, ok := r.caches[name].(ownerFlusher)
+		if !ok {
+			continue
+		}
+		fdb, owned := f.ownerDB()
+		if !owned {
+			continue
+		}
+		if db != nil && fdb != db {
+			err = fmt.Errorf("cache %q uses a different database; FlushOwner needs a single transaction", name)
+		}
+		db = fdb
+		flushers = append(flushers, f)
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+	if err != nil || len(flushers) == 0 {
+		return err
+	}
+
+	begun := 0
+	defer func() {
+		for _, f := range flushers[:begun] {
+			f.endOwner(false)
+		}
+	}()
+	for _, f := range flushers {
+		if err := f.beginOwner(owner); err != nil {
+			return err
+		}
+		begun++
+	}
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, f := range flushers {
+			if err := f.saveOwner(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to flush owner %v: %w", owner, err)
+	}
+	for _, f := range flushers {
+		f.endOwner(true)
+	}
+	begun = 0
+	return nil
+}
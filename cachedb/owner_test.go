@@ -0,0 +1,79 @@
+package cachedb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestFlushOwner(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	type Item struct {
+		ID       uint
+		PlayerID uint
+		Count    int
+	}
+	db := openTestDB(t, &Player{}, &Item{})
+	db.Create(&Player{ID: 1})
+	db.Create(&Player{ID: 2})
+	db.Create(&Item{ID: 10, PlayerID: 1})
+	db.Create(&Item{ID: 11, PlayerID: 1})
+	db.Create(&Item{ID: 20, PlayerID: 2})
+
+	var order []string
+	record := func(tx *gorm.DB) { order = append(order, tx.Statement.Table) }
+	db.Callback().Create().Before("gorm:create").Register("test:order", record) // 批量写回使用 upsert
+	db.Callback().Update().Before("gorm:update").Register("test:order", record)
+
+	r := NewRegistry()
+	r.AddDB("game", db)
+	// 按名称排序时 items 在 players 之前，依赖声明使 players 先落库
+	if err := r.FlushBefore("players", "items"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.FlushBefore("items", "players"); !errors.Is(err, ErrFlushCycle) {
+		t.Errorf("expected a cycle to be rejected, got %v", err)
+	}
+	players, _ := RegisterCache[Player](r, "players", "game", 10, WithBulkFlush[Player](500),
+		WithOwner[Player](func(key interface{}, _ *Player) interface{} { return key }))
+	items, _ := RegisterCache[Item](r, "items", "game", 10, WithBulkFlush[Item](500),
+		WithOwner[Item](func(_ interface{}, it *Item) interface{} { return it.PlayerID }))
+
+	players.Update(1, func(p *Player) { p.Gold = 100 })
+	players.Update(2, func(p *Player) { p.Gold = 200 })
+	for _, id := range []int{10, 11, 20} {
+		items.Update(id, func(it *Item) { it.Count = 5 })
+	}
+
+	if err := r.FlushOwner(context.Background(), uint(1)); err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 2 || order[0] != "players" || order[1] != "items" {
+		t.Errorf("expected players to flush before items, got %v", order)
+	}
+	if dirty := players.DirtyKeys(); len(dirty) != 1 || dirty[0] != uint(2) {
+		t.Errorf("expected only player 2 to stay dirty, got %v", dirty)
+	}
+	if dirty := items.DirtyKeys(); len(dirty) != 1 || dirty[0] != uint(20) {
+		t.Errorf("expected only item 20 to stay dirty, got %v", dirty)
+	}
+
+	// 事务失败时整个归属者保持为脏
+	db.Migrator().DropTable(&Item{})
+	if err := r.FlushOwner(context.Background(), uint(2)); err == nil {
+		t.Fatal("expected the owner flush to fail")
+	}
+	var p Player
+	db.First(&p, 2)
+	if p.Gold != 0 {
+		t.Errorf("expected the player update to be rolled back, got %+v", p)
+	}
+	if dirty := players.DirtyKeys(); len(dirty) != 1 {
+		t.Errorf("expected player 2 to stay dirty after rollback, got %v", dirty)
+	}
+}
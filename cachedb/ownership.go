@@ -0,0 +1,194 @@
+package cachedb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrPinned 表示 key 正被本地的 Pin、Lease 或保存点持有，不能移交
+var ErrPinned = errors.New("entity is pinned")
+
+// OwnershipTransport 是集群模式下移交实体所有权所需的能力，例如基于 etcd 租约和 gRPC 的实现。
+// 内置基于 HTTP 的 HTTPOwnershipTransport 与 TransferHandler
+type OwnershipTransport interface {
+	// ReleaseLease 释放本节点对 key 的所有权租约，之后目标节点才能获取
+	ReleaseLease(ctx context.Context, key interface{}) error
+	// NotifyTransfer 通知 target 接管 key。payload 为序列化后的实体，未随同发送时为 nil，
+	// 接收方应调用 AcceptTransfer
+	NotifyTransfer(ctx context.Context, target string, key interface{}, payload []byte) error
+}
+
+// TransferOwnership 把 key 的所有权移交给节点 target，是角色跨服旅行等场景的基本操作：
+// 先把未落库的修改写回数据库并从本地缓存移除，再释放租约、通知目标节点。
+// ship 为 true 时随通知发送 JSON 序列化的实体，目标节点无需再查询数据库。
+// key 被本地持有时返回 ErrPinned；落库失败时 key 留在本地不受影响。
+// 租约释放或通知失败时本地已不再持有 key，之后的访问会从数据库重新加载
+func (c *CacheDB[T]) TransferOwnership(ctx context.Context, key interface{}, target string, transport OwnershipTransport, ship bool) error {
+	if err := c.checkOpen(); err != nil {
+		return err
+	}
+	key, err := c.normalizeKey(key)
+	if err != nil {
+		return err
+	}
+	payload, err := c.surrender(key, ship)
+	if err != nil {
+		return err
+	}
+	if err := transport.ReleaseLease(ctx, key); err != nil {
+		return fmt.Errorf("failed to release lease on key %v: %w", key, err)
+	}
+	if err := transport.NotifyTransfer(ctx, target, key, payload); err != nil {
+		return fmt.Errorf("failed to notify %s of key %v: %w", target, key, err)
+	}
+	c.log(LogInfo, "Transferred ownership", "key", key, "target", target)
+	return nil
+}
+
+// surrender 在同一次加锁中写回 key 的修改并停止追踪，期间的修改不会丢失。ship 为 true 时返回序列化的实体
+func (c *CacheDB[T]) surrender(key interface{}, ship bool) ([]byte, error) {
+	c.mu.Lock()
+	value, ok := c.values[key]
+	if !ok {
+		c.mu.Unlock()
+		if !ship {
+			return nil, nil
+		}
+		// 未缓存时从数据库读取要发送的实体
+		row, err := c.loadRow(key)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(row)
+	}
+	if c.pins[key] > 0 {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("%w: key %v", ErrPinned, key)
+	}
+	if err := c.saveIfModified(key, value, FlushTransfer); err != nil {
+		c.mu.Unlock()
+		return nil, err
+	}
+	var payload []byte
+	if ship {
+		var err error
+		if payload, err = json.Marshal(value); err != nil {
+			c.mu.Unlock()
+			return nil, fmt.Errorf("failed to encode key %v: %w", key, err)
+		}
+	}
+	c.untrack(key) // 先解除追踪，淘汰回调便不会回写
+	c.mu.Unlock()
+
+	c.Cache.Remove(key)
+	return payload, nil
+}
+
+// AcceptTransfer 由接收方在收到移交通知时调用，payload 非空时安装随同发送的实体，
+// 该实体已在移交前落库，因此被视为与数据库一致；payload 为空时无需处理，之后按需从数据库加载
+func (c *CacheDB[T]) AcceptTransfer(key interface{}, payload []byte) error {
+	if len(payload) == 0 {
+		return nil
+	}
+	key, err := c.normalizeKey(key)
+	if err != nil {
+		return err
+	}
+	var value T
+	if err := json.Unmarshal(payload, &value); err != nil {
+		return fmt.Errorf("failed to decode key %v: %w", key, err)
+	}
+	return c.setLocal(key, value)
+}
+
+// transferWire 是移交通知在 HTTP 请求体中的编码，key 以字符串传输，接收方用 ParseKey 还原
+type transferWire struct {
+	Key     string          `json:"key"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// HTTPOwnershipTransport 以 HTTP POST 通知目标节点接管 key，target 为接收节点上 TransferHandler 的地址。
+// 租约由 Release 释放，未设置时视为没有租约服务，ReleaseLease 直接返回
+type HTTPOwnershipTransport struct {
+	Client  *http.Client // 为空时使用 http.DefaultClient
+	Token   string       // 非空时携带 "Authorization: Bearer <Token>"
+	Release func(ctx context.Context, key interface{}) error
+}
+
+var _ OwnershipTransport = HTTPOwnershipTransport{}
+
+// ReleaseLease 调用 Release 释放 key 的租约
+func (t HTTPOwnershipTransport) ReleaseLease(ctx context.Context, key interface{}) error {
+	if t.Release == nil {
+		return nil
+	}
+	return t.Release(ctx, key)
+}
+
+// NotifyTransfer 把 key 与随同发送的实体发送到 target，接收方返回 2xx 表示已接管
+func (t HTTPOwnershipTransport) NotifyTransfer(ctx context.Context, target string, key interface{}, payload []byte) error {
+	body, err := json.Marshal(transferWire{Key: fmt.Sprint(key), Payload: payload})
+	if err != nil {
+		return fmt.Errorf("failed to encode transfer: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+t.Token)
+	}
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("transfer target responded %s", resp.Status)
+	}
+	return nil
+}
+
+// TransferHandler 接收 HTTPOwnershipTransport 发送的移交通知并交给 Cache 的 AcceptTransfer。
+// 配置了 Token 时要求请求携带 "Authorization: Bearer <Token>"
+type TransferHandler[T any] struct {
+	Cache *CacheDB[T]
+	Token string
+}
+
+// ServeHTTP 处理 POST 的 JSON 请求体，接管成功时返回 204
+func (h *TransferHandler[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.Token != "" && r.Header.Get("Authorization") != "Bearer "+h.Token {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var wire transferWire
+	if err := json.NewDecoder(r.Body).Decode(&wire); err != nil {
+		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	key, err := h.Cache.ParseKey(wire.Key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.Cache.AcceptTransfer(key, wire.Payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
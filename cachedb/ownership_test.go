@@ -0,0 +1,120 @@
+package cachedb
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+// loopbackTransport 把移交通知直接交给另一个节点的缓存
+type loopbackTransport[T any] struct {
+	released []interface{}
+	nodes    map[string]*CacheDB[T]
+}
+
+func (l *loopbackTransport[T]) ReleaseLease(_ context.Context, key interface{}) error {
+	l.released = append(l.released, key)
+	return nil
+}
+
+func (l *loopbackTransport[T]) NotifyTransfer(_ context.Context, target string, key interface{}, payload []byte) error {
+	return l.nodes[target].AcceptTransfer(key, payload)
+}
+
+func TestTransferOwnership(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, Gold: 10})
+
+	a := newTestCache[Player](t, db, 10)
+	b := newTestCache[Player](t, db, 10)
+	transport := &loopbackTransport[Player]{nodes: map[string]*CacheDB[Player]{"b": b}}
+
+	lease, _ := a.Acquire(1)
+	if err := a.TransferOwnership(context.Background(), 1, "b", transport, true); !errors.Is(err, ErrPinned) {
+		t.Errorf("expected ErrPinned while leased, got %v", err)
+	}
+	lease.Release()
+
+	a.Update(1, func(p *Player) { p.Gold = 20 })
+	if err := a.TransferOwnership(context.Background(), 1, "b", transport, true); err != nil {
+		t.Fatalf("TransferOwnership failed: %v", err)
+	}
+	var stored Player
+	db.First(&stored, 1)
+	if stored.Gold != 20 {
+		t.Errorf("expected the change to be flushed before transfer, got gold %d", stored.Gold)
+	}
+	if a.Cache.Has(uint(1)) || a.EntryInfo(1).State != StateAbsent {
+		t.Error("expected the source node to drop the entity")
+	}
+	if len(transport.released) != 1 {
+		t.Errorf("expected the lease to be released once, got %v", transport.released)
+	}
+	if !b.Cache.Has(uint(1)) {
+		t.Fatal("expected the shipped entity to be installed on the target")
+	}
+	if p, _ := b.Get(1); p.Gold != 20 {
+		t.Errorf("expected gold 20 on the target, got %d", p.Gold)
+	}
+	if keys := b.DirtyKeys(); len(keys) != 0 {
+		t.Errorf("expected the shipped entity to be clean, got %v", keys)
+	}
+}
+
+func TestHTTPOwnershipTransport(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, Gold: 10})
+	db.Create(&Player{ID: 2, Gold: 30})
+
+	a := newTestCache[Player](t, db, 10)
+	b := newTestCache[Player](t, db, 10)
+	srv := httptest.NewServer(&TransferHandler[Player]{Cache: b, Token: "secret"})
+	defer srv.Close()
+	var released []interface{}
+	transport := HTTPOwnershipTransport{
+		Token: "secret",
+		Release: func(_ context.Context, key interface{}) error {
+			released = append(released, key)
+			return nil
+		},
+	}
+
+	a.Update(1, func(p *Player) { p.Gold = 20 })
+	if err := a.TransferOwnership(context.Background(), 1, srv.URL, transport, true); err != nil {
+		t.Fatalf("TransferOwnership failed: %v", err)
+	}
+	if n := a.Stats().FlushReasons[string(FlushTransfer)]; n != 1 {
+		t.Errorf("expected one transfer flush, got %d", n)
+	}
+	if len(released) != 1 || released[0] != uint(1) {
+		t.Errorf("expected the lease on key 1 to be released, got %v", released)
+	}
+	if !b.Cache.Has(uint(1)) {
+		t.Fatal("expected the shipped entity to be installed on the target under a uint key")
+	}
+	if p, _ := b.Get(1); p.Gold != 20 {
+		t.Errorf("expected gold 20 on the target, got %d", p.Gold)
+	}
+
+	// 不随同发送时目标节点之后从数据库加载
+	if err := a.TransferOwnership(context.Background(), 2, srv.URL, transport, false); err != nil {
+		t.Fatalf("TransferOwnership without payload failed: %v", err)
+	}
+	if b.Cache.Has(uint(2)) {
+		t.Error("expected nothing installed without a payload")
+	}
+
+	transport.Token = ""
+	if err := a.TransferOwnership(context.Background(), 1, srv.URL, transport, false); err == nil {
+		t.Error("expected an unauthorized notification to fail")
+	}
+}
@@ -0,0 +1,244 @@
+package cachedb
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bluele/gcache"
+)
+
+// Partition 是缓存容量的一个分区，Share 为占总容量的比例
+type Partition struct {
+	Name  string
+	Share float64
+}
+
+// partitionSpec 是 WithPartitions 的配置
+type partitionSpec struct {
+	route func(key interface{}) string
+	parts []Partition
+}
+
+// WithPartitions 把缓存容量按比例划分为多个分区，各分区独立执行 LRU 淘汰，
+// 例如在线玩家 70%、离线玩家 20%、管理员固定 10%，大量离线查询不会挤掉在线玩家的状态。
+// route 返回 key 所属的分区名，未知的名字归入第一个分区；key 所属的分区在进入缓存时确定，离开缓存前不变。
+// 每个分区至少容纳一个对象，比例之和不能超过 1
+func WithPartitions[T any](route func(key interface{}) string, parts ...Partition) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.partitions = &partitionSpec{route: route, parts: parts}
+	}
+}
+
+// check 在构造时检查分区配置
+func (s *partitionSpec) check() error {
+	if len(s.parts) == 0 {
+		return fmt.Errorf("WithPartitions requires at least one partition")
+	}
+	seen := make(map[string]bool, len(s.parts))
+	total := 0.0
+	for _, p := range s.parts {
+		if p.Share <= 0 {
+			return fmt.Errorf("partition %q: share must be positive", p.Name)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("partition %q declared twice", p.Name)
+		}
+		seen[p.Name] = true
+		total += p.Share
+	}
+	if total > 1+1e-9 {
+		return fmt.Errorf("partition shares add up to %.2f, more than 1", total)
+	}
+	return nil
+}
+
+// partitionedCache 把 key 分派到多个独立的 LRU，对外表现为一个 gcache.Cache。
+// 内嵌第一个分区以满足接口中未导出的方法，其余方法都按 key 分派或汇总
+type partitionedCache struct {
+	gcache.Cache
+	route func(key interface{}) string
+	names []string
+	parts map[string]gcache.Cache
+	sizes map[string]int // 各分区的容量
+
+	mu   sync.Mutex
+	home map[interface{}]string // 已在缓存中的 key 所属的分区
+}
+
+// buildPartitions 为每个分区创建一个 LRU
+func (c *CacheDB[T]) buildPartitions(size int) gcache.Cache {
+	spec := c.partitions
+	p := &partitionedCache{
+		route: spec.route,
+		parts: make(map[string]gcache.Cache, len(spec.parts)),
+		sizes: make(map[string]int, len(spec.parts)),
+		home:  make(map[interface{}]string),
+	}
+	evicted, added := c.evictToDB(), c.logCacheAdd()
+	for _, part := range spec.parts {
+		name := part.Name
+		p.names = append(p.names, name)
+		p.sizes[name] = max(1, int(float64(size)*part.Share))
+		p.parts[name] = c.newLRU(p.sizes[name]).
+			AddedFunc(func(key, value interface{}) {
+				p.mu.Lock()
+				p.home[key] = name
+				p.mu.Unlock()
+				added(key, value)
+			}).
+			EvictedFunc(func(key, value interface{}) {
+				p.mu.Lock()
+				if p.home[key] == name {
+					delete(p.home, key)
+				}
+				p.mu.Unlock()
+				evicted(key, value)
+			}).
+			Build()
+	}
+	p.Cache = p.parts[p.names[0]]
+	return p
+}
+
+// PartitionLen 返回分区 name 中的对象数，未开启 WithPartitions 或分区不存在时返回 -1
+func (c *CacheDB[T]) PartitionLen(name string) int {
+	p, ok := c.Cache.(*partitionedCache)
+	if !ok {
+		return -1
+	}
+	part, ok := p.parts[name]
+	if !ok {
+		return -1
+	}
+	return part.Len(false)
+}
+
+// pressure 把要淘汰的 n 个对象按各分区的对象数分摊，占位 key 直接放入各分区，不经过 route
+func (p *partitionedCache) pressure(n int) {
+	total := p.Len(false)
+	if total == 0 || n <= 0 {
+		return
+	}
+	n = min(n, total)
+	quota := make(map[string]int, len(p.names))
+	left := n
+	for _, name := range p.names {
+		quota[name] = n * p.parts[name].Len(false) / total
+		left -= quota[name]
+	}
+	for _, name := range p.names { // 取整剩下的依次分给还有对象的分区
+		if left == 0 {
+			break
+		}
+		if quota[name] < p.parts[name].Len(false) {
+			quota[name]++
+			left--
+		}
+	}
+	for _, name := range p.names {
+		if quota[name] > 0 {
+			pressure(p.parts[name], p.sizes[name], quota[name])
+		}
+	}
+}
+
+// of 返回 key 所在的分区，不在缓存中时按 route 选择
+func (p *partitionedCache) of(key interface{}) gcache.Cache {
+	p.mu.Lock()
+	name, ok := p.home[key]
+	p.mu.Unlock()
+	if !ok {
+		name = p.route(key)
+	}
+	if part, ok := p.parts[name]; ok {
+		return part
+	}
+	return p.parts[p.names[0]]
+}
+
+func (p *partitionedCache) Set(key, value interface{}) error {
+	return p.of(key).Set(key, value)
+}
+
+func (p *partitionedCache) SetWithExpire(key, value interface{}, expiration time.Duration) error {
+	return p.of(key).SetWithExpire(key, value, expiration)
+}
+
+func (p *partitionedCache) Get(key interface{}) (interface{}, error) {
+	return p.of(key).Get(key)
+}
+
+func (p *partitionedCache) GetIFPresent(key interface{}) (interface{}, error) {
+	return p.of(key).GetIFPresent(key)
+}
+
+func (p *partitionedCache) Remove(key interface{}) bool {
+	return p.of(key).Remove(key)
+}
+
+func (p *partitionedCache) Has(key interface{}) bool {
+	return p.of(key).Has(key)
+}
+
+func (p *partitionedCache) GetALL(checkExpired bool) map[interface{}]interface{} {
+	all := make(map[interface{}]interface{})
+	for _, name := range p.names {
+		for k, v := range p.parts[name].GetALL(checkExpired) {
+			all[k] = v
+		}
+	}
+	return all
+}
+
+func (p *partitionedCache) Keys(checkExpired bool) []interface{} {
+	var keys []interface{}
+	for _, name := range p.names {
+		keys = append(keys, p.parts[name].Keys(checkExpired)...)
+	}
+	return keys
+}
+
+func (p *partitionedCache) Len(checkExpired bool) int {
+	n := 0
+	for _, name := range p.names {
+		n += p.parts[name].Len(checkExpired)
+	}
+	return n
+}
+
+func (p *partitionedCache) Purge() {
+	for _, name := range p.names {
+		p.parts[name].Purge()
+	}
+	p.mu.Lock()
+	p.home = make(map[interface{}]string)
+	p.mu.Unlock()
+}
+
+func (p *partitionedCache) HitCount() uint64 {
+	var n uint64
+	for _, part := range p.parts {
+		n += part.HitCount()
+	}
+	return n
+}
+
+func (p *partitionedCache) MissCount() uint64 {
+	var n uint64
+	for _, part := range p.parts {
+		n += part.MissCount()
+	}
+	return n
+}
+
+func (p *partitionedCache) LookupCount() uint64 {
+	return p.HitCount() + p.MissCount()
+}
+
+func (p *partitionedCache) HitRate() float64 {
+	if n := p.LookupCount(); n > 0 {
+		return float64(p.HitCount()) / float64(n)
+	}
+	return 0
+}
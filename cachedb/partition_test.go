@@ -0,0 +1,112 @@
+package cachedb
+
+import "testing"
+
+func TestPartitions(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	for id := uint(1); id <= 30; id++ {
+		db.Create(&Player{ID: id})
+	}
+	online := func(key interface{}) string {
+		if key.(uint) <= 5 {
+			return "online"
+		}
+		return "offline"
+	}
+	if _, err := NewWithCache[Player](db, 10, WithPartitions[Player](online, Partition{"online", 0.7}, Partition{"offline", 0.5})); err == nil {
+		t.Error("expected shares above 1 to be rejected")
+	}
+	c := newTestCache[Player](t, db, 10, WithPartitions[Player](online, Partition{"online", 0.7}, Partition{"offline", 0.3}))
+
+	for id := 1; id <= 5; id++ {
+		c.Update(id, func(p *Player) { p.Gold = 10 })
+	}
+	for id := 6; id <= 30; id++ {
+		c.Get(id) // 大量离线查询
+	}
+	if n := c.PartitionLen("online"); n != 5 {
+		t.Errorf("expected online players to stay cached, got %d", n)
+	}
+	if n := c.PartitionLen("offline"); n != 3 {
+		t.Errorf("expected the offline partition to hold 3, got %d", n)
+	}
+	if n := c.Cache.Len(false); n != 8 {
+		t.Errorf("expected 8 cached entries in total, got %d", n)
+	}
+	for id := uint(1); id <= 5; id++ {
+		if !c.Cache.Has(id) {
+			t.Errorf("expected online player %d to be cached", id)
+		}
+	}
+	if c.PartitionLen("nope") != -1 {
+		t.Error("expected -1 for an unknown partition")
+	}
+
+	// 各分区的淘汰照常回写
+	c.Update(6, func(p *Player) { p.Gold = 20 })
+	c.Get(7)
+	c.Get(8)
+	c.Get(9)
+	var stored Player
+	db.First(&stored, 6)
+	if stored.Gold != 20 {
+		t.Errorf("expected the evicted offline player to be written back, got gold %d", stored.Gold)
+	}
+	if err := c.FlushAll(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPartitionsCapacityPressure(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	for id := uint(1); id <= 10; id++ {
+		db.Create(&Player{ID: id})
+	}
+	online := func(key interface{}) string { // 只接受 uint，占位 key 不能传给它
+		if key.(uint) <= 5 {
+			return "online"
+		}
+		return "offline"
+	}
+	c := newTestCache[Player](t, db, 10, WithPartitions[Player](online, Partition{"online", 0.5}, Partition{"offline", 0.5}))
+	var evicted []interface{}
+	c.OnEvict(func(key interface{}) { evicted = append(evicted, key) })
+	for id := 1; id <= 4; id++ {
+		c.Update(id, func(p *Player) { p.Gold = 7 })
+	}
+	for id := 6; id <= 7; id++ {
+		c.Get(id)
+	}
+
+	c.SimulateCapacityPressure(3)
+	if n := c.PartitionLen("online"); n != 2 {
+		t.Errorf("expected 2 online players to remain, got %d", n)
+	}
+	if n := c.PartitionLen("offline"); n != 1 {
+		t.Errorf("expected 1 offline player to remain, got %d", n)
+	}
+	if !c.Cache.Has(uint(3)) || !c.Cache.Has(uint(4)) || !c.Cache.Has(uint(7)) {
+		t.Error("expected each partition to evict its least recently used players")
+	}
+	var stored []Player
+	db.Where("gold = ?", 7).Find(&stored)
+	if len(stored) != 2 {
+		t.Errorf("expected 2 evicted online players written back, got %d", len(stored))
+	}
+	for _, key := range evicted {
+		if _, ok := key.(uint); !ok {
+			t.Errorf("expected OnEvict to see only cached keys, got %T %v", key, key)
+		}
+	}
+	if len(evicted) != 3 {
+		t.Errorf("expected 3 evictions, got %v", evicted)
+	}
+}
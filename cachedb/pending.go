@@ -0,0 +1,54 @@
+package cachedb
+
+import (
+	"sort"
+	"time"
+)
+
+// entryMeta 是缓存条目的落库状态
+type entryMeta struct {
+	since    time.Time  // 副本建立的时间，即上次与数据库一致的时间
+	attempts int        // 上次成功落库后失败的写入次数
+	lastErr  error      // 最近一次写入失败的原因
+	detached bool       // 已离开 gcache 但回写失败，保留在内存中等待重试
+	shed     bool       // 降载期间被淘汰，推迟回写并保留旧值
+	forced   bool       // 由 ForceRelease 放回，下次落库跳过校验
+	marked   bool       // 通过 Update 或 Set 修改过，判断是否为脏时无需比较
+	gen      uint64     // 对象的代数，每次通过缓存修改或重新加载时递增，见 Generation
+	state    EntryState // 记录的生命周期状态，见 setState
+	spared   bool       // 作为脏对象已获得过一次淘汰豁免，见 WithDirtySecondChance
+
+	mutations []Mutation // 最近的修改来源，只在调试构建中记录，见 Mutation
+}
+
+// PendingWrite 描述一个等待落库的 key
+type PendingWrite struct {
+	Key       interface{}
+	Age       time.Duration // 距上次与数据库一致的时间，即修改最多已等待多久
+	Attempts  int           // 失败的写入次数
+	LastError error         // 最近一次写入失败的原因
+}
+
+// PendingWrites 返回所有有未落库修改的 key，按等待时间从长到短排序，
+// 即进程此刻退出时会丢失的数据
+func (c *CacheDB[T]) PendingWrites() []PendingWrite {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	var out []PendingWrite
+	for key, value := range c.values {
+		if !c.modified(key, value) {
+			continue
+		}
+		pw := PendingWrite{Key: key}
+		if m := c.meta[key]; m != nil {
+			pw.Age = now.Sub(m.since)
+			pw.Attempts = m.attempts
+			pw.LastError = m.lastErr
+		}
+		out = append(out, pw)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Age > out[j].Age })
+	return out
+}
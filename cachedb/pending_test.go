@@ -0,0 +1,55 @@
+package cachedb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPendingWrites(t *testing.T) {
+	type Pet struct {
+		ID   uint
+		Name string `gorm:"unique"`
+	}
+	db := openTestDB(t, &Pet{})
+	db.Create(&Pet{ID: 1, Name: "rex"})
+	db.Create(&Pet{ID: 2, Name: "tom"})
+
+	c := newTestCache[Pet](t, db, 10)
+	p1, _ := c.Get(uint(1))
+	p2, _ := c.Get(uint(2))
+	if len(c.PendingWrites()) != 0 {
+		t.Fatalf("expected no pending writes")
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	p1.Name = "tom" // 违反唯一约束，写入会失败
+	p2.Name = "jerry"
+
+	if err := c.Flush(uint(1)); err == nil {
+		t.Fatalf("expected unique constraint failure")
+	}
+	c.Flush(uint(1))
+
+	pending := c.PendingWrites()
+	if len(pending) != 2 {
+		t.Fatalf("expected 2 pending writes, got %+v", pending)
+	}
+	for _, pw := range pending {
+		if pw.Age <= time.Millisecond {
+			t.Errorf("expected age over 1ms, got %v", pw.Age)
+		}
+		if pw.Key == uint(1) && (pw.Attempts != 2 || pw.LastError == nil) {
+			t.Errorf("expected 2 failed attempts for key 1, got %+v", pw)
+		}
+		if pw.Key == uint(2) && pw.Attempts != 0 {
+			t.Errorf("expected no attempts for key 2, got %+v", pw)
+		}
+	}
+
+	if err := c.Flush(uint(2)); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	if pending := c.PendingWrites(); len(pending) != 1 || pending[0].Key != uint(1) {
+		t.Errorf("expected only key 1 pending, got %+v", pending)
+	}
+}
@@ -0,0 +1,269 @@
+package cachedb
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+)
+
+// copyPlan 把 src 深拷贝到 dst，语义与 copyRecursive 相同
+type copyPlan func(dst, src reflect.Value) error
+
+// equalPlan 比较两个值，语义与 reflect.DeepEqual 相同（不处理循环引用）
+type equalPlan func(a, b reflect.Value) bool
+
+// planBuilder 按类型编译拷贝与比较计划，构造时执行一次，之后的拷贝和比较不再遍历类型信息
+type planBuilder struct {
+	copies map[reflect.Type]*copyPlan
+	equals map[reflect.Type]*equalPlan
+}
+
+func newPlanBuilder() *planBuilder {
+	return &planBuilder{
+		copies: make(map[reflect.Type]*copyPlan),
+		equals: make(map[reflect.Type]*equalPlan),
+	}
+}
+
+// copyOf 返回 t 的拷贝计划，递归类型通过间接引用延迟到执行时
+func (b *planBuilder) copyOf(t reflect.Type) copyPlan {
+	if p, ok := b.copies[t]; ok {
+		return func(dst, src reflect.Value) error { return (*p)(dst, src) }
+	}
+	p := new(copyPlan)
+	b.copies[t] = p
+	*p = b.buildCopy(t)
+	return *p
+}
+
+func (b *planBuilder) buildCopy(t reflect.Type) copyPlan {
+	if isFlat(t) {
+		return func(dst, src reflect.Value) error {
+			dst.Set(src)
+			return nil
+		}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		elem := b.copyOf(t.Elem())
+		return func(dst, src reflect.Value) error {
+			if src.IsNil() {
+				return nil
+			}
+			dst.Set(reflect.New(t.Elem()))
+			return elem(dst.Elem(), src.Elem())
+		}
+
+	case reflect.Interface:
+		// 动态类型在编译时未知，退回逐次反射
+		return func(dst, src reflect.Value) error {
+			if src.IsNil() {
+				return nil
+			}
+			v := src.Elem()
+			cpy := reflect.New(v.Type()).Elem()
+			if err := copyRecursive(v, cpy); err != nil {
+				return err
+			}
+			dst.Set(cpy)
+			return nil
+		}
+
+	case reflect.Struct:
+		type fieldPlan struct {
+			index int
+			name  string
+			copy  copyPlan
+		}
+		var fields []fieldPlan
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue // 跳过未导出字段
+			}
+			fields = append(fields, fieldPlan{index: i, name: f.Name, copy: b.copyOf(f.Type)})
+		}
+		return func(dst, src reflect.Value) error {
+			for _, f := range fields {
+				if err := f.copy(dst.Field(f.index), src.Field(f.index)); err != nil {
+					return fmt.Errorf("%s.%w", f.name, err)
+				}
+			}
+			return nil
+		}
+
+	case reflect.Slice:
+		elem := b.copyOf(t.Elem())
+		flat := isFlat(t.Elem())
+		return func(dst, src reflect.Value) error {
+			if src.IsNil() {
+				return nil
+			}
+			dst.Set(reflect.MakeSlice(t, src.Len(), src.Cap()))
+			if flat {
+				reflect.Copy(dst, src)
+				return nil
+			}
+			for i := 0; i < src.Len(); i++ {
+				if err := elem(dst.Index(i), src.Index(i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+	case reflect.Array:
+		elem := b.copyOf(t.Elem())
+		return func(dst, src reflect.Value) error {
+			for i := 0; i < src.Len(); i++ {
+				if err := elem(dst.Index(i), src.Index(i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+	case reflect.Map:
+		elem := b.copyOf(t.Elem())
+		flat := isFlat(t.Elem())
+		return func(dst, src reflect.Value) error {
+			if src.IsNil() {
+				return nil
+			}
+			dst.Set(reflect.MakeMapWithSize(t, src.Len()))
+			iter := src.MapRange()
+			for iter.Next() {
+				v := iter.Value()
+				if !flat {
+					cpy := reflect.New(t.Elem()).Elem()
+					if err := elem(cpy, v); err != nil {
+						return err
+					}
+					v = cpy
+				}
+				dst.SetMapIndex(iter.Key(), v)
+			}
+			return nil
+		}
+
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return func(dst, src reflect.Value) error {
+			return fmt.Errorf("%w: %s", ErrUncopyable, t)
+		}
+	}
+	return func(dst, src reflect.Value) error {
+		dst.Set(src)
+		return nil
+	}
+}
+
+// equalOf 返回 t 的比较计划，递归类型通过间接引用延迟到执行时
+func (b *planBuilder) equalOf(t reflect.Type) equalPlan {
+	if p, ok := b.equals[t]; ok {
+		return func(x, y reflect.Value) bool { return (*p)(x, y) }
+	}
+	p := new(equalPlan)
+	b.equals[t] = p
+	*p = b.buildEqual(t)
+	return *p
+}
+
+func (b *planBuilder) buildEqual(t reflect.Type) equalPlan {
+	switch t.Kind() {
+	case reflect.Bool:
+		return func(x, y reflect.Value) bool { return x.Bool() == y.Bool() }
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(x, y reflect.Value) bool { return x.Int() == y.Int() }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(x, y reflect.Value) bool { return x.Uint() == y.Uint() }
+	case reflect.Float32, reflect.Float64:
+		return func(x, y reflect.Value) bool { return x.Float() == y.Float() }
+	case reflect.Complex64, reflect.Complex128:
+		return func(x, y reflect.Value) bool { return x.Complex() == y.Complex() }
+	case reflect.String:
+		return func(x, y reflect.Value) bool { return x.String() == y.String() }
+
+	case reflect.Ptr:
+		elem := b.equalOf(t.Elem())
+		return func(x, y reflect.Value) bool {
+			if x.IsNil() || y.IsNil() {
+				return x.IsNil() == y.IsNil()
+			}
+			return x.Pointer() == y.Pointer() || elem(x.Elem(), y.Elem())
+		}
+
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath != "" {
+				// 未导出字段（例如 time.Time）只能通过 Interface 整体比较
+				return func(x, y reflect.Value) bool { return reflect.DeepEqual(x.Interface(), y.Interface()) }
+			}
+		}
+		fields := make([]equalPlan, t.NumField())
+		for i := range fields {
+			fields[i] = b.equalOf(t.Field(i).Type)
+		}
+		return func(x, y reflect.Value) bool {
+			for i, eq := range fields {
+				if !eq(x.Field(i), y.Field(i)) {
+					return false
+				}
+			}
+			return true
+		}
+
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return func(x, y reflect.Value) bool {
+				return x.IsNil() == y.IsNil() && bytes.Equal(x.Bytes(), y.Bytes())
+			}
+		}
+		elem := b.equalOf(t.Elem())
+		return func(x, y reflect.Value) bool {
+			if x.IsNil() != y.IsNil() || x.Len() != y.Len() {
+				return false
+			}
+			if x.Pointer() == y.Pointer() {
+				return true
+			}
+			for i := 0; i < x.Len(); i++ {
+				if !elem(x.Index(i), y.Index(i)) {
+					return false
+				}
+			}
+			return true
+		}
+
+	case reflect.Array:
+		elem := b.equalOf(t.Elem())
+		return func(x, y reflect.Value) bool {
+			for i := 0; i < x.Len(); i++ {
+				if !elem(x.Index(i), y.Index(i)) {
+					return false
+				}
+			}
+			return true
+		}
+
+	case reflect.Map:
+		elem := b.equalOf(t.Elem())
+		return func(x, y reflect.Value) bool {
+			if x.IsNil() != y.IsNil() || x.Len() != y.Len() {
+				return false
+			}
+			if x.Pointer() == y.Pointer() {
+				return true
+			}
+			iter := x.MapRange()
+			for iter.Next() {
+				v := y.MapIndex(iter.Key())
+				if !v.IsValid() || !elem(iter.Value(), v) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	// interface、func、chan 等按 DeepEqual 的规则逐次比较
+	return func(x, y reflect.Value) bool { return reflect.DeepEqual(x.Interface(), y.Interface()) }
+}
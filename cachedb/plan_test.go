@@ -0,0 +1,82 @@
+package cachedb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type planNode struct {
+	Name     string
+	Children []*planNode
+}
+
+type planModel struct {
+	ID      uint
+	At      time.Time
+	Bag     []string
+	Items   map[string][]int
+	Owner   *planNode
+	Extra   interface{}
+	Grid    [2][]byte
+	private int
+}
+
+func TestCompiledPlansMatchReflection(t *testing.T) {
+	src := planModel{
+		ID:      1,
+		At:      time.Now(),
+		Bag:     []string{"sword"},
+		Items:   map[string][]int{"gem": {1, 2}},
+		Owner:   &planNode{Name: "root", Children: []*planNode{{Name: "leaf"}}},
+		Extra:   map[string]int{"x": 1},
+		Grid:    [2][]byte{{1}, nil},
+		private: 7,
+	}
+	b := newPlanBuilder()
+	copyFn := b.copyOf(reflect.TypeOf(src))
+	equal := b.equalOf(reflect.TypeOf(src))
+
+	var got planModel
+	if err := copyFn(reflect.ValueOf(&got).Elem(), reflect.ValueOf(src)); err != nil {
+		t.Fatalf("copy failed: %v", err)
+	}
+	want, _ := deepCopy(src)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("compiled copy differs from copyRecursive:\n%+v\n%+v", got, want)
+	}
+	if got.Owner == src.Owner || &got.Bag[0] == &src.Bag[0] || got.Owner.Children[0] == src.Owner.Children[0] {
+		t.Errorf("copy shares memory with the source")
+	}
+
+	mutations := []func(*planModel){
+		func(m *planModel) { m.Bag[0] = "axe" },
+		func(m *planModel) { m.Items["gem"][1] = 3 },
+		func(m *planModel) { m.Owner.Children[0].Name = "x" },
+		func(m *planModel) { m.Extra.(map[string]int)["x"] = 2 },
+		func(m *planModel) { m.Grid[1] = []byte{} },
+		func(m *planModel) { m.At = m.At.Add(time.Second) },
+		func(m *planModel) { m.private = 8 },
+	}
+	for i, mutate := range mutations {
+		a, _ := deepCopy(src)
+		a.private = src.private
+		bb, _ := deepCopy(src)
+		bb.private = src.private
+		if !equal(reflect.ValueOf(a), reflect.ValueOf(bb)) {
+			t.Fatalf("mutation %d: expected equal before mutation", i)
+		}
+		mutate(&bb)
+		if got, want := equal(reflect.ValueOf(a), reflect.ValueOf(bb)), reflect.DeepEqual(a, bb); got != want {
+			t.Errorf("mutation %d: compiled equal = %v, DeepEqual = %v", i, got, want)
+		}
+	}
+
+	type bad struct{ Fn interface{} }
+	var dst bad
+	err := newPlanBuilder().copyOf(reflect.TypeOf(bad{}))(reflect.ValueOf(&dst).Elem(), reflect.ValueOf(bad{Fn: func() {}}))
+	if !errors.Is(err, ErrUncopyable) {
+		t.Errorf("expected ErrUncopyable, got %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package cachedb
+
+// matching 返回满足 pred 的已缓存 key 及其中有未落库修改的 key。
+// pred 在持有内部锁时调用，不能再调用该缓存的方法
+func (c *CacheDB[T]) matching(pred func(key interface{}, value *T) bool) (keys, dirty []interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, value := range c.values {
+		if !pred(key, value) {
+			continue
+		}
+		keys = append(keys, key)
+		if c.modified(key, value) {
+			dirty = append(dirty, key)
+		}
+	}
+	return keys, dirty
+}
+
+// FlushWhere 把满足 pred 的脏对象写回数据库，例如关闭某个区服前先落库该区的玩家。
+// 写回按 FlushAll 的方式分组；有 key 失败时返回 *BatchError。pred 不能再调用该缓存的方法
+func (c *CacheDB[T]) FlushWhere(pred func(key interface{}, value *T) bool) error {
+	_, dirty := c.matching(pred)
+	report := &FlushReport{}
+	c.flushKeys(dirty, report)
+	return report.Err()
+}
+
+// EvictWhere 写回并淘汰满足 pred 的对象，返回被淘汰的数量。写回失败的对象留在缓存中并通过 *BatchError 返回，
+// 被固定或全表常驻的对象只回写不丢弃，与 EvictNow 相同。pred 不能再调用该缓存的方法
+func (c *CacheDB[T]) EvictWhere(pred func(key interface{}, value *T) bool) (int, error) {
+	return c.evictKeys(c.matching(pred))
+}
+
+// evictKeys 写回 dirty 后淘汰 keys 中落库成功或无需落库的对象
+func (c *CacheDB[T]) evictKeys(keys, dirty []interface{}) (int, error) {
+	report := &FlushReport{}
+	c.flushKeys(dirty, report)
+
+	evicted := 0
+	for _, key := range keys {
+		if _, failed := report.Failed[key]; failed {
+			continue
+		}
+		c.mu.Lock()
+		resident := c.resident(key)
+		c.mu.Unlock()
+		if c.Cache.Remove(key) && !resident {
+			evicted++
+		}
+	}
+	return evicted, report.Err()
+}
@@ -0,0 +1,75 @@
+package cachedb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFlushAndEvictWhere(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Zone int
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	for i := 1; i <= 4; i++ {
+		db.Create(&Player{ID: uint(i), Zone: i % 2, Gold: 0})
+	}
+	c := newTestCache[Player](t, db, 10)
+	for i := 1; i <= 4; i++ {
+		p, _ := c.Get(i)
+		p.Gold = 100
+	}
+	inZone := func(zone int) func(interface{}, *Player) bool {
+		return func(_ interface{}, p *Player) bool { return p.Zone == zone }
+	}
+
+	if err := c.FlushWhere(inZone(0)); err != nil {
+		t.Fatal(err)
+	}
+	if dirty := c.DirtyKeys(); len(dirty) != 2 {
+		t.Errorf("expected only zone 1 to stay dirty, got %v", dirty)
+	}
+
+	c.Pin(1)
+	evicted, err := c.EvictWhere(inZone(1))
+	if err != nil || evicted != 1 {
+		t.Fatalf("expected one zone 1 player to be evicted, got %d %v", evicted, err)
+	}
+	var rows []Player
+	db.Where("gold = ?", 100).Find(&rows)
+	if len(rows) != 4 {
+		t.Errorf("expected all players to be written back, got %+v", rows)
+	}
+	if _, cached := c.values[uint(3)]; cached {
+		t.Error("expected player 3 to be evicted")
+	}
+	if _, cached := c.values[uint(1)]; !cached {
+		t.Error("expected pinned player 1 to stay cached")
+	}
+}
+
+func TestEvictWhereKeepsFailed(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1})
+	c := newTestCache[Player](t, db, 10, WithValidator[Player](func(_ interface{}, p *Player) error {
+		if p.Gold < 0 {
+			return errors.New("negative gold")
+		}
+		return nil
+	}))
+	p, _ := c.Get(1)
+	p.Gold = -1
+	evicted, err := c.EvictWhere(func(interface{}, *Player) bool { return true })
+	var batch *BatchError
+	if evicted != 0 || !errors.As(err, &batch) {
+		t.Fatalf("expected the invalid player to stay cached, got %d %v", evicted, err)
+	}
+	if _, cached := c.values[uint(1)]; !cached {
+		t.Error("expected player 1 to stay cached")
+	}
+}
@@ -0,0 +1,239 @@
+package cachedb
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// presenceIndex 是已存在 key 的布隆过滤器：判断为不存在的 key 一定不在数据库中，
+// 判断为存在的 key 可能误判，仍需查询数据库。删除的 key 要等到下次 BuildPresence 才会从过滤器中移除
+type presenceIndex struct {
+	mu    sync.RWMutex
+	bits  []uint64
+	k     int
+	ready bool          // BuildPresence 完成后才用于判断
+	built time.Time     // 上次建立的时间
+	scans int           // 正在进行的 BuildPresence 数量
+	added []interface{} // 扫描期间通过缓存出现的 key，扫描结束后合并进新的过滤器
+
+	rebuilding atomic.Bool // 是否有后台重建正在进行
+}
+
+// newPresenceIndex 按预计的 key 数量和误判率确定位数与哈希次数
+func newPresenceIndex(expected int, fpRate float64) *presenceIndex {
+	n := math.Max(float64(expected), 1)
+	m := math.Ceil(-n * math.Log(fpRate) / (math.Ln2 * math.Ln2))
+	k := int(math.Max(math.Round(m/n*math.Ln2), 1))
+	return &presenceIndex{bits: make([]uint64, (int(m)+63)/64), k: k}
+}
+
+// positions 用双重哈希计算 key 对应的 k 个位
+func (p *presenceIndex) positions(key interface{}, fn func(bit uint64)) {
+	h := fnv.New64a()
+	fmt.Fprint(h, key)
+	sum := h.Sum64()
+	h1, h2 := sum&0xffffffff, sum>>32|1
+	m := uint64(len(p.bits)) * 64
+	for i := 0; i < p.k; i++ {
+		fn((h1 + uint64(i)*h2) % m)
+	}
+}
+
+func (p *presenceIndex) add(key interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.positions(key, func(bit uint64) { p.bits[bit/64] |= 1 << (bit % 64) })
+	if p.scans > 0 {
+		p.added = append(p.added, key)
+	}
+}
+
+// beginScan 开始记录扫描期间出现的 key
+func (p *presenceIndex) beginScan() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.scans == 0 {
+		p.added = nil
+	}
+	p.scans++
+}
+
+// endScan 结束一次扫描，bits 非空时连同扫描期间出现的 key 和 tracked 替换原有的过滤器
+func (p *presenceIndex) endScan(bits []uint64, tracked []interface{}, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if bits != nil {
+		set := func(bit uint64) { bits[bit/64] |= 1 << (bit % 64) }
+		for _, key := range p.added {
+			p.positions(key, set)
+		}
+		for _, key := range tracked {
+			p.positions(key, set)
+		}
+		p.bits = bits
+		p.ready = true
+		p.built = now
+	}
+	p.scans--
+	if p.scans == 0 {
+		p.added = nil
+	}
+}
+
+// absent 在过滤器已建立且 key 一定不存在时返回 true
+func (p *presenceIndex) absent(key interface{}) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if !p.ready {
+		return false
+	}
+	missing := false
+	p.positions(key, func(bit uint64) {
+		if p.bits[bit/64]&(1<<(bit%64)) == 0 {
+			missing = true
+		}
+	})
+	return missing
+}
+
+// WithPresenceIndex 为已存在的 key 维护布隆过滤器，expected 为预计的行数，fpRate 为误判率（例如 0.01）。
+// 调用 BuildPresence 扫描全部 key 后，对一定不存在的 key 的加载、MGet 和 Exists 不再查询数据库。
+// 过滤器只感知通过该缓存（包括跟随与移交）出现的 key，其他进程插入的行需要重新 BuildPresence 才能被发现
+func WithPresenceIndex[T any](expected int, fpRate float64) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.presence = newPresenceIndex(expected, fpRate)
+	}
+}
+
+// BuildPresence 只查询 key 列重建过滤器，已删除的 key 会被清除；扫描期间通过缓存出现的 key 和
+// 已缓存（可能尚未落库）的 key 会被保留。不支持分表（返回 ErrTableRouted）。未设置 WithPresenceIndex 时不做任何事
+func (c *CacheDB[T]) BuildPresence() error {
+	if c.presence == nil {
+		return nil
+	}
+	db, err := c.wholeTable()
+	if err != nil {
+		return err
+	}
+	p := c.presence
+	p.beginScan()
+	var bits []uint64
+	var tracked []interface{}
+	defer func() { p.endScan(bits, tracked, c.clock.Now()) }()
+
+	var keys []interface{}
+	release := c.acquireDB()
+	err = db.Pluck(c.keyField.DBName, &keys).Error
+	release()
+	if err != nil {
+		return fmt.Errorf("failed to scan keys: %w", err)
+	}
+
+	scanned := make([]uint64, len(p.bits))
+	set := func(bit uint64) { scanned[bit/64] |= 1 << (bit % 64) }
+	for _, raw := range keys {
+		key, err := c.normalizeKey(raw)
+		if err != nil {
+			return err
+		}
+		p.positions(key, set)
+	}
+	c.mu.Lock()
+	for key := range c.values {
+		tracked = append(tracked, key)
+	}
+	c.mu.Unlock()
+	bits = scanned
+	return nil
+}
+
+// WithPresenceRebuild 让过滤器每隔 interval 在后台重新扫描 key 列，以发现其他进程插入的行并清除已删除的 key。
+// 重建由查询触发，距上次建立超过 interval 的第一次判断会启动一次后台重建，需配合 WithPresenceIndex 使用
+func WithPresenceRebuild[T any](interval time.Duration) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.presenceRebuild = interval
+	}
+}
+
+// maybeRebuildPresence 在过滤器过期时启动后台重建，同一时间只有一次重建
+func (c *CacheDB[T]) maybeRebuildPresence() {
+	p := c.presence
+	p.mu.RLock()
+	due := p.ready && c.presenceRebuild > 0 && c.clock.Now().Sub(p.built) > c.presenceRebuild
+	p.mu.RUnlock()
+	if !due || !p.rebuilding.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer p.rebuilding.Store(false)
+		if err := c.BuildPresence(); err != nil {
+			c.log(LogError, "Presence rebuild failed", "err", err)
+		}
+	}()
+}
+
+// missedPresence 记录过滤器判断可能存在、但数据库中不存在的 key，即一次误判
+func (c *CacheDB[T]) missedPresence() {
+	if c.presence == nil {
+		return
+	}
+	c.presence.mu.RLock()
+	ready := c.presence.ready
+	c.presence.mu.RUnlock()
+	if ready {
+		c.counters.presenceMisses.Add(1)
+	}
+}
+
+// markPresent 记录 key 已存在
+func (c *CacheDB[T]) markPresent(key interface{}) {
+	if c.presence != nil {
+		c.presence.add(key)
+	}
+}
+
+// absent 判断 key 是否一定不在数据库中
+func (c *CacheDB[T]) absent(key interface{}) bool {
+	if c.presence == nil {
+		return false
+	}
+	c.maybeRebuildPresence()
+	if !c.presence.absent(key) {
+		return false
+	}
+	c.counters.presenceSkips.Add(1)
+	return true
+}
+
+// Exists 判断 key 是否存在：已缓存时直接返回 true，过滤器判断一定不存在时返回 false，
+// 其余情况只查询 key 列，不会把行加载进缓存
+func (c *CacheDB[T]) Exists(key interface{}) (bool, error) {
+	key, err := c.normalizeKey(key)
+	if err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	_, tracked := c.values[key]
+	c.mu.Unlock()
+	if tracked {
+		return true, nil
+	}
+	if c.absent(key) {
+		return false, nil
+	}
+	var count int64
+	release := c.acquireDB()
+	err = c.keyDB(key).Where(c.keyCondition(key)).Limit(1).Count(&count).Error
+	release()
+	if err != nil {
+		return false, fmt.Errorf("failed to check key %v: %w", key, err)
+	}
+	if count == 0 {
+		c.missedPresence()
+	}
+	return count > 0, nil
+}
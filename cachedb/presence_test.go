@@ -0,0 +1,139 @@
+package cachedb
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPresenceIndex(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Name string
+	}
+	db := openTestDB(t, &Player{})
+	for i := 1; i <= 50; i++ {
+		db.Create(&Player{ID: uint(i)})
+	}
+	c := newTestCache[Player](t, db, 100, WithPresenceIndex[Player](1000, 0.001))
+
+	// 建立前不做判断
+	if ok, err := c.Exists(51); ok || err != nil {
+		t.Fatalf("Exists(51) = %v %v", ok, err)
+	}
+	if skips := c.Stats().PresenceSkips; skips != 0 {
+		t.Errorf("expected no skips before BuildPresence, got %d", skips)
+	}
+
+	if err := c.BuildPresence(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 50; i++ {
+		if ok, err := c.Exists(i); !ok || err != nil {
+			t.Fatalf("Exists(%d) = %v %v", i, ok, err)
+		}
+	}
+	if _, err := c.Get(1000); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected an absent key to be not found, got %v", err)
+	}
+	got, err := c.MGet([]interface{}{2, 2000, 3000})
+	if err != nil || len(got) != 1 {
+		t.Errorf("MGet = %v %v", got, err)
+	}
+	if skips := c.Stats().PresenceSkips; skips < 3 {
+		t.Errorf("expected absent keys to skip the DB, got %d skips", skips)
+	}
+
+	// 通过缓存创建的 key 立即可见
+	if _, err := c.Create(Player{ID: 60, Name: "new"}); err != nil {
+		t.Fatal(err)
+	}
+	c.Invalidate(60)
+	if p, err := c.Get(60); err != nil || p.Name != "new" {
+		t.Errorf("expected the created key to be loadable, got %+v %v", p, err)
+	}
+}
+
+func TestPresenceRebuildAndMisses(t *testing.T) {
+	type Player struct {
+		ID uint
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1})
+	db.Create(&Player{ID: 2})
+	clock := &manualClock{now: time.Now()}
+	c := newTestCache[Player](t, db, 10, WithClock[Player](clock),
+		WithPresenceIndex[Player](100, 0.001), WithPresenceRebuild[Player](time.Minute))
+	if err := c.BuildPresence(); err != nil {
+		t.Fatal(err)
+	}
+
+	db.Delete(&Player{}, 2) // 其他进程删除
+	if _, err := c.Get(2); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected key 2 to be gone, got %v", err)
+	}
+	if misses := c.Stats().PresenceMisses; misses != 1 {
+		t.Errorf("PresenceMisses = %d, want 1", misses)
+	}
+
+	db.Create(&Player{ID: 3}) // 其他进程插入，重建前不可见
+	if ok, _ := c.Exists(3); ok {
+		t.Fatal("expected key 3 to be unknown before the rebuild")
+	}
+	clock.now = clock.now.Add(2 * time.Minute)
+	c.Exists(3) // 触发后台重建
+	deadline := time.Now().Add(time.Second)
+	for {
+		if ok, _ := c.Exists(3); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the rebuild to discover key 3")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPresenceRebuildClearsDeleted(t *testing.T) {
+	type Player struct {
+		ID uint
+	}
+	db := openTestDB(t, &Player{})
+	for i := uint(1); i <= 50; i++ {
+		db.Create(&Player{ID: i})
+	}
+	c := newTestCache[Player](t, db, 100, WithPresenceIndex[Player](100, 0.001))
+	if err := c.BuildPresence(); err != nil {
+		t.Fatal(err)
+	}
+	c.Set(100, Player{ID: 100}) // 尚未落库
+
+	db.Where("id > ?", 10).Delete(&Player{}) // 其他进程删除
+	if err := c.BuildPresence(); err != nil {
+		t.Fatal(err)
+	}
+	absent := func(key uint) bool {
+		k, err := c.normalizeKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c.presence.absent(k)
+	}
+	cleared := 0
+	for i := uint(11); i <= 50; i++ {
+		if absent(i) {
+			cleared++
+		}
+	}
+	if cleared < 35 {
+		t.Errorf("only %d of 40 deleted keys cleared by the rebuild", cleared)
+	}
+	for i := uint(1); i <= 10; i++ {
+		if absent(i) {
+			t.Errorf("key %d still in the table but reported absent", i)
+		}
+	}
+	if absent(100) {
+		t.Error("unflushed key 100 reported absent after the rebuild")
+	}
+}
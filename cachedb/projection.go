@@ -0,0 +1,156 @@
+package cachedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/bluele/gcache"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Projection 只加载并缓存实体的部分列，例如社交列表需要的名字、等级和头像，
+// 使热门列表占用的内存远小于完整对象。P 是只包含这些列的结构体，必须包含与实体 key 同名的列。
+// 投影是只读的：实体落库时若修改了投影中的列，或者通过 Delete 删除，对应的投影会被丢弃并在下次读取时重新加载
+type Projection[T, P any] struct {
+	source  *CacheDB[T]
+	cache   gcache.Cache
+	columns []string
+	watched map[string]bool // 投影包含的列，实体落库时只关心这些列的修改
+	key     *schema.Field   // P 中的 key 字段
+}
+
+// NewProjection 基于 source 创建投影缓存，size 为最多缓存的投影数，ttl 为有效期（0 表示不过期）。
+// 需在 source 开始使用前创建，以便注册失效回调
+func NewProjection[T, P any](source *CacheDB[T], size int, ttl time.Duration) (*Projection[T, P], error) {
+	stmt := &gorm.Statement{DB: source.db}
+	if err := stmt.Parse(new(P)); err != nil {
+		return nil, fmt.Errorf("failed to parse projection: %w", err)
+	}
+	p := &Projection[T, P]{source: source, watched: make(map[string]bool)}
+	for _, f := range stmt.Schema.Fields {
+		if f.DBName == "" {
+			continue
+		}
+		if source.schema.LookUpField(f.DBName) == nil {
+			return nil, fmt.Errorf("projection %s: column %q not found in %s", stmt.Schema.Name, f.DBName, source.schema.Name)
+		}
+		if f.DBName == source.keyField.DBName {
+			p.key = f
+		}
+		p.columns = append(p.columns, f.DBName)
+		p.watched[f.DBName] = true
+	}
+	if p.key == nil {
+		return nil, fmt.Errorf("projection %s must include key column %q", stmt.Schema.Name, source.keyField.DBName)
+	}
+
+	builder := gcache.New(size).LRU().LoaderFunc(p.load)
+	if ttl > 0 {
+		builder.Expiration(ttl)
+	}
+	p.cache = builder.Build()
+	source.OnFlush(p.onFlush)
+	source.OnDelete(p.Invalidate)
+	return p, nil
+}
+
+// load 只查询投影中的列
+func (p *Projection[T, P]) load(key interface{}) (interface{}, error) {
+	c := p.source
+	var row P
+	release := c.acquireDB()
+	err := c.keyDB(key).Select(p.columns).Where(c.keyCondition(key)).Take(&row).Error
+	release()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to load projection: %w: %w", ErrNotFound, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load projection: %w", err)
+	}
+	return row, nil
+}
+
+// Get 返回 key 的投影，未缓存时只查询投影中的列
+func (p *Projection[T, P]) Get(key interface{}) (P, error) {
+	var zero P
+	key, err := p.source.normalizeKey(key)
+	if err != nil {
+		return zero, err
+	}
+	v, err := p.cache.Get(key)
+	if err != nil {
+		return zero, err
+	}
+	return v.(P), nil
+}
+
+// MGet 批量返回投影，未缓存的 key 按表分组用一条 IN 查询加载。数据库中不存在的 key 不出现在结果中
+func (p *Projection[T, P]) MGet(keys []interface{}) (map[interface{}]P, error) {
+	c := p.source
+	out := make(map[interface{}]P, len(keys))
+	groups := make(map[Shard][]interface{})
+	var order []Shard
+	for _, raw := range keys {
+		key, err := c.normalizeKey(raw)
+		if err != nil {
+			return out, err
+		}
+		if p.cache.Has(key) {
+			if v, err := p.cache.Get(key); err == nil {
+				out[key] = v.(P)
+				continue
+			}
+		}
+		s := c.shardFor(key)
+		if _, ok := groups[s]; !ok {
+			order = append(order, s)
+		}
+		groups[s] = append(groups[s], key)
+	}
+
+	for _, s := range order {
+		var rows []P
+		cond := clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: c.keyField.DBName}, Values: groups[s]}
+		release := c.acquireDB()
+		err := s.DB.Table(s.Table).Select(p.columns).Where(cond).Find(&rows).Error
+		release()
+		if err != nil {
+			return out, fmt.Errorf("failed to load projections from %s: %w", s.Table, err)
+		}
+		for _, row := range rows {
+			v, _ := p.key.ValueOf(context.Background(), reflect.ValueOf(&row).Elem())
+			key, err := c.normalizeKey(v)
+			if err != nil {
+				return out, err
+			}
+			out[key] = row
+			p.cache.Set(key, row)
+		}
+	}
+	return out, nil
+}
+
+// Invalidate 丢弃 key 的投影
+func (p *Projection[T, P]) Invalidate(key interface{}) {
+	p.cache.Remove(p.source.canonicalKey(key))
+}
+
+// Purge 丢弃所有投影
+func (p *Projection[T, P]) Purge() {
+	p.cache.Purge()
+}
+
+// onFlush 在实体落库修改了投影中的列时丢弃对应的投影
+func (p *Projection[T, P]) onFlush(key interface{}, old, new T) {
+	for _, ch := range p.source.diff(&old, &new) {
+		if p.watched[ch.Column] {
+			p.cache.Remove(key)
+			return
+		}
+	}
+}
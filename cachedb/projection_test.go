@@ -0,0 +1,66 @@
+package cachedb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProjection(t *testing.T) {
+	type Player struct {
+		ID     uint
+		Name   string
+		Level  int
+		Gold   int
+		Avatar string
+	}
+	type Card struct {
+		ID    uint
+		Name  string
+		Level int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, Name: "alice", Level: 3, Gold: 100})
+	db.Create(&Player{ID: 2, Name: "bob", Level: 5})
+	db.Create(&Player{ID: 3, Name: "carol", Level: 7})
+	c := newTestCache[Player](t, db, 10)
+	cards, err := NewProjection[Player, Card](c, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	card, err := cards.Get(1)
+	if err != nil || card != (Card{ID: 1, Name: "alice", Level: 3}) {
+		t.Fatalf("Get = %+v %v", card, err)
+	}
+	got, err := cards.MGet([]interface{}{1, 2, 3, 4})
+	if err != nil || len(got) != 3 || got[uint(3)].Name != "carol" {
+		t.Fatalf("MGet = %+v %v", got, err)
+	}
+
+	// 修改投影之外的列不会丢弃投影
+	db.Model(&Player{}).Where("id = ?", 1).Update("name", "external")
+	c.Update(1, func(p *Player) { p.Gold = 200 })
+	c.Flush(1)
+	if card, _ := cards.Get(1); card.Name != "alice" {
+		t.Errorf("expected the projection to survive an unrelated change, got %+v", card)
+	}
+	c.Update(1, func(p *Player) { p.Level = 4 })
+	c.Flush(1)
+	if card, _ := cards.Get(1); card.Level != 4 {
+		t.Errorf("expected the projection to be reloaded after a level change, got %+v", card)
+	}
+
+	if err := c.Delete(2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cards.Get(2); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a deleted player to have no projection, got %v", err)
+	}
+
+	type Bad struct {
+		Name string
+	}
+	if _, err := NewProjection[Player, Bad](c, 10, 0); err == nil {
+		t.Error("expected a projection without the key column to be rejected")
+	}
+}
@@ -0,0 +1,44 @@
+package cachedb
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// provenanceDepth 是每个 key 保留的最近修改来源数
+const provenanceDepth = 8
+
+// Mutation 是一次经缓存方法的修改来源，只在以 gamecache_debug 构建标签编译时记录：
+//
+//	go build -tags gamecache_debug ./...
+type Mutation struct {
+	Op         string    // 修改所用的方法，例如 "Update"、"Set"、"MarkDirty"
+	Caller     string    // 调用方的 file:line
+	Function   string    // 调用方的函数名
+	At         time.Time // 修改的时间
+	Generation uint64    // 修改后的代数，见 Generation
+}
+
+// recordMutation 在调试构建中记录 key 的修改来源，skip 为调用方与 recordMutation 之间的缓存方法层数。
+// 调用方需持有 c.mu，且已经 bump 过代数
+func (c *CacheDB[T]) recordMutation(key interface{}, op string, skip int) {
+	if !provenanceEnabled {
+		return
+	}
+	m := c.meta[key]
+	if m == nil {
+		return
+	}
+	mu := Mutation{Op: op, At: c.clock.Now(), Generation: m.gen}
+	if pc, file, line, ok := runtime.Caller(skip + 1); ok {
+		mu.Caller = fmt.Sprintf("%s:%d", file, line)
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			mu.Function = fn.Name()
+		}
+	}
+	m.mutations = append(m.mutations, mu)
+	if len(m.mutations) > provenanceDepth {
+		m.mutations = m.mutations[len(m.mutations)-provenanceDepth:]
+	}
+}
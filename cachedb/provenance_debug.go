@@ -0,0 +1,6 @@
+//go:build gamecache_debug
+
+package cachedb
+
+// provenanceEnabled 表示记录修改来源，见 Mutation
+const provenanceEnabled = true
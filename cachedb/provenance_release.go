@@ -0,0 +1,6 @@
+//go:build !gamecache_debug
+
+package cachedb
+
+// provenanceEnabled 在非调试构建中关闭修改来源的记录，见 Mutation
+const provenanceEnabled = false
@@ -0,0 +1,56 @@
+package cachedb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMutationProvenance(t *testing.T) {
+	type Player struct {
+		ID uint
+		HP int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, HP: 100})
+
+	c := newTestCache[Player](t, db, 10)
+	p, _ := c.Get(1)
+	c.Update(1, func(p *Player) { p.HP = 90 })
+	p.HP = 80
+	c.MarkDirty(1)
+	c.Set(1, Player{ID: 1, HP: 70})
+	gen, _ := c.Generation(1)
+	if _, err := c.UpdateIfGeneration(1, gen, func(p *Player) { p.HP = 60 }); err != nil {
+		t.Fatal(err)
+	}
+	if keys := c.DirtyKeys(); len(keys) != 1 {
+		t.Errorf("expected key 1 to be dirty, got %v", keys)
+	}
+
+	muts := c.EntryInfo(1).Mutations
+	if !provenanceEnabled {
+		if len(muts) != 0 {
+			t.Errorf("expected no provenance outside debug builds, got %+v", muts)
+		}
+		return
+	}
+	ops := []string{"Update", "MarkDirty", "Set", "UpdateIfGeneration"}
+	if len(muts) != len(ops) {
+		t.Fatalf("expected %d mutations, got %+v", len(ops), muts)
+	}
+	for i, m := range muts {
+		if m.Op != ops[i] || !strings.Contains(m.Caller, "provenance_test.go:") || !strings.Contains(m.Function, "TestMutationProvenance") {
+			t.Errorf("unexpected mutation %d: %+v", i, m)
+		}
+	}
+	if muts[3].Generation <= muts[0].Generation {
+		t.Errorf("expected generations to increase, got %+v", muts)
+	}
+
+	if err := c.Flush(1); err != nil {
+		t.Fatal(err)
+	}
+	if after := c.EntryInfo(1).Mutations; len(after) != len(ops) {
+		t.Errorf("expected the flush to keep %d mutations, got %+v", len(ops), after)
+	}
+}
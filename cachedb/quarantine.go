@@ -0,0 +1,168 @@
+package cachedb
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrQuarantined 表示 key 已被隔离，在处理之前既不会落库也不会返回给调用方
+var ErrQuarantined = errors.New("entity quarantined")
+
+// Quarantined 描述一个被隔离的对象
+type Quarantined[T any] struct {
+	Key    interface{}
+	Value  T         // 被隔离时内存中的值
+	Reason error     // 隔离原因
+	Since  time.Time // 隔离时间
+}
+
+// quarantined 是隔离区中的一项，baseline 是隔离前与数据库一致的副本
+type quarantined[T any] struct {
+	value    *T
+	baseline T
+	reason   error
+	since    time.Time
+}
+
+// WithQuarantine 把未通过校验（ErrInvalidEntity）的对象移入隔离区，而不是让它一直保持为脏并反复重试。
+// 隔离的对象不会落库，Get 等读取返回 ErrQuarantined，需要通过 FixQuarantined、
+// ForceRelease 或 DiscardQuarantined 处理
+func WithQuarantine[T any]() Option[T] {
+	return func(c *CacheDB[T]) {
+		c.quarantine = make(map[interface{}]*quarantined[T])
+	}
+}
+
+// Quarantine 把 key 移入隔离区，用于业务自行发现的异常，例如冲突检测发现的可疑数据。
+// 未开启 WithQuarantine 或 key 未缓存时返回错误
+func (c *CacheDB[T]) Quarantine(key interface{}, reason error) error {
+	key, err := c.normalizeKey(key)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.quarantine == nil {
+		return fmt.Errorf("quarantine not enabled")
+	}
+	if _, ok := c.values[key]; !ok {
+		return fmt.Errorf("key %v not cached", key)
+	}
+	c.isolate(key, reason)
+	return nil
+}
+
+// isolate 停止追踪 key 并把它放入隔离区，调用方需持有 c.mu。
+// gcache 中残留的条目由 get 拦截，淘汰时因不再是追踪的对象而被忽略
+func (c *CacheDB[T]) isolate(key interface{}, reason error) {
+	c.quarantine[key] = &quarantined[T]{
+		value:    c.values[key],
+		baseline: c.copies[key],
+		reason:   reason,
+		since:    c.clock.Now(),
+	}
+	c.untrack(key)
+	c.log(LogError, "Quarantined entity", "key", key, "reason", reason)
+}
+
+// quarantineInvalid 在开启隔离时把未通过校验的追踪对象移入隔离区，调用方需持有 c.mu
+func (c *CacheDB[T]) quarantineInvalid(key interface{}, value *T, err error) {
+	if c.quarantine != nil && errors.Is(err, ErrInvalidEntity) && c.current(key, value) {
+		c.isolate(key, err)
+	}
+}
+
+// checkQuarantine 在 key 被隔离时返回 ErrQuarantined
+func (c *CacheDB[T]) checkQuarantine(key interface{}) error {
+	if c.quarantine == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if q, ok := c.quarantine[key]; ok {
+		return fmt.Errorf("%w: key %v: %v", ErrQuarantined, key, q.reason)
+	}
+	return nil
+}
+
+// QuarantinedEntries 返回隔离区中所有对象的深拷贝（敏感字段已遮蔽，见 Redact），按隔离时间排序
+func (c *CacheDB[T]) QuarantinedEntries() ([]Quarantined[T], error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]Quarantined[T], 0, len(c.quarantine))
+	for key, q := range c.quarantine {
+		cpy, err := c.copier(*q.value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy key %v: %w", key, err)
+		}
+		c.redactInPlace(&cpy)
+		out = append(out, Quarantined[T]{Key: key, Value: cpy, Reason: q.reason, Since: q.since})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Since.Before(out[j].Since) })
+	return out, nil
+}
+
+// FixQuarantined 用 fn 修正被隔离的对象，通过校验后放回缓存并等待正常落库，否则仍留在隔离区
+func (c *CacheDB[T]) FixQuarantined(key interface{}, fn func(*T)) error {
+	key = c.canonicalKey(key)
+	c.mu.Lock()
+	q, ok := c.quarantine[key]
+	if !ok {
+		c.mu.Unlock()
+		return fmt.Errorf("key %v not quarantined", key)
+	}
+	fn(q.value)
+	if err := c.checkValid(key, q.value); err != nil {
+		q.reason = err
+		c.mu.Unlock()
+		return err
+	}
+	c.mu.Unlock()
+	return c.release(key, q, false)
+}
+
+// ForceRelease 把被隔离的对象原样放回缓存，下次落库时跳过校验
+func (c *CacheDB[T]) ForceRelease(key interface{}) error {
+	key = c.canonicalKey(key)
+	c.mu.Lock()
+	q, ok := c.quarantine[key]
+	c.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("key %v not quarantined", key)
+	}
+	c.log(LogWarn, "Force releasing quarantined entity", "key", key, "reason", q.reason)
+	return c.release(key, q, true)
+}
+
+// release 以隔离前的副本为基准把对象放回缓存。forced 为 true 时下次落库跳过校验
+func (c *CacheDB[T]) release(key interface{}, q *quarantined[T], forced bool) error {
+	c.mu.Lock()
+	if c.quarantine[key] != q {
+		c.mu.Unlock()
+		return fmt.Errorf("key %v not quarantined", key)
+	}
+	delete(c.quarantine, key)
+	c.copies[key] = q.baseline
+	c.meta[key] = &entryMeta{since: q.since, forced: forced}
+	c.bump(key)
+	c.values[key] = q.value
+	c.retag(key, q.value)
+	c.mu.Unlock()
+	return c.cacheSet(key, q.value)
+}
+
+// DiscardQuarantined 丢弃被隔离的对象，之后的读取从数据库重新加载
+func (c *CacheDB[T]) DiscardQuarantined(key interface{}) error {
+	key = c.canonicalKey(key)
+	c.mu.Lock()
+	_, ok := c.quarantine[key]
+	delete(c.quarantine, key)
+	c.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("key %v not quarantined", key)
+	}
+	c.Cache.Remove(key)
+	return nil
+}
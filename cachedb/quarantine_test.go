@@ -0,0 +1,75 @@
+package cachedb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestQuarantine(t *testing.T) {
+	db := openTestDB(t, &validatedPlayer{})
+	db.Create(&validatedPlayer{ID: 1, Name: "a", Gold: 10})
+	db.Create(&validatedPlayer{ID: 2, Name: "b", Gold: 10})
+	c := newTestCache[validatedPlayer](t, db, 10, WithQuarantine[validatedPlayer]())
+
+	p, _ := c.Get(1)
+	p.Gold = -5
+	if err := c.Flush(1); !errors.Is(err, ErrInvalidEntity) {
+		t.Fatalf("expected ErrInvalidEntity, got %v", err)
+	}
+	if _, err := c.Get(1); !errors.Is(err, ErrQuarantined) {
+		t.Fatalf("expected quarantined entity not to be served, got %v", err)
+	}
+	if _, err := c.MGet([]interface{}{uint(1)}); err == nil {
+		t.Errorf("expected MGet to report the quarantined key")
+	}
+	if err := c.FlushAll(); err != nil {
+		t.Errorf("expected quarantined entity to be skipped by FlushAll, got %v", err)
+	}
+	entries, _ := c.QuarantinedEntries()
+	if len(entries) != 1 || entries[0].Value.Gold != -5 || !errors.Is(entries[0].Reason, ErrInvalidEntity) {
+		t.Fatalf("unexpected quarantine entries %+v", entries)
+	}
+	if s := c.Stats(); s.Quarantined != 1 {
+		t.Errorf("expected one quarantined entity, got %d", s.Quarantined)
+	}
+
+	if err := c.FixQuarantined(1, func(p *validatedPlayer) { p.Gold = -1 }); !errors.Is(err, ErrInvalidEntity) {
+		t.Errorf("expected a still-invalid fix to be rejected, got %v", err)
+	}
+	if err := c.FixQuarantined(1, func(p *validatedPlayer) { p.Gold = 3 }); err != nil {
+		t.Fatalf("FixQuarantined failed: %v", err)
+	}
+	if err := c.Flush(1); err != nil {
+		t.Fatalf("Flush after fix failed: %v", err)
+	}
+	var row validatedPlayer
+	db.First(&row, 1)
+	if row.Gold != 3 {
+		t.Errorf("expected fixed value to be persisted, got %+v", row)
+	}
+
+	q, _ := c.Get(2)
+	q.Gold = -7
+	c.Flush(2)
+	if err := c.ForceRelease(2); err != nil {
+		t.Fatalf("ForceRelease failed: %v", err)
+	}
+	if err := c.Flush(2); err != nil {
+		t.Fatalf("Flush after force release failed: %v", err)
+	}
+	var forced validatedPlayer
+	db.First(&forced, 2)
+	if forced.Gold != -7 {
+		t.Errorf("expected force-released value to be persisted, got %+v", forced)
+	}
+
+	q, _ = c.Get(2)
+	q.Gold = -8
+	c.Flush(2)
+	if err := c.DiscardQuarantined(2); err != nil {
+		t.Fatalf("DiscardQuarantined failed: %v", err)
+	}
+	if got, err := c.Get(2); err != nil || got.Gold != -7 {
+		t.Errorf("expected discarded entity to reload from DB, got %+v %v", got, err)
+	}
+}
@@ -0,0 +1,189 @@
+package cachedb
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Query 描述一个可缓存的查询条件
+type Query struct {
+	Where string        // 例如 "guild_id = ?"
+	Args  []interface{} // Where 中占位符对应的参数
+	Order string        // 例如 "level desc, id"
+}
+
+// normalize 生成查询的规范化缓存键，多余的空白不影响结果。
+// 参数按 Go 语法逐个编码，字符串带引号，{"ab","c"} 与 {"a","bc"} 不会得到相同的键
+func (q Query) normalize() string {
+	args := make([]string, len(q.Args))
+	for i, arg := range q.Args {
+		args[i] = fmt.Sprintf("%#v", arg)
+	}
+	return strings.Join(strings.Fields(q.Where), " ") + "|" +
+		strings.Join(args, ",") + "|" +
+		strings.Join(strings.Fields(q.Order), " ")
+}
+
+// queryResult 是一次缓存的查询结果
+type queryResult struct {
+	keys    []interface{}
+	expires time.Time
+}
+
+// countResult 是一次缓存的 COUNT 结果
+type countResult struct {
+	count   int64
+	expires time.Time
+}
+
+// queryCache 缓存查询结果中的主键列表和 COUNT 结果。任意一行的落库都可能让它进入或离开某个结果，
+// 因此通过缓存落库、新建或删除任意一行时整体失效
+type queryCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	clock   Clock
+	results map[string]queryResult
+	counts  map[string]countResult
+}
+
+func newQueryCache(ttl time.Duration) *queryCache {
+	return &queryCache{
+		ttl:     ttl,
+		clock:   realClock{},
+		results: make(map[string]queryResult),
+		counts:  make(map[string]countResult),
+	}
+}
+
+// WithQueryTTL 设置 CachedPage、CachedCount 等查询结果的有效期，默认 30 秒
+func WithQueryTTL[T any](ttl time.Duration) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.queries.ttl = ttl
+	}
+}
+
+func (qc *queryCache) get(id string) ([]interface{}, bool) {
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+	r, ok := qc.results[id]
+	if !ok {
+		return nil, false
+	}
+	if qc.clock.Now().After(r.expires) {
+		delete(qc.results, id)
+		return nil, false
+	}
+	return r.keys, true
+}
+
+func (qc *queryCache) put(id string, keys []interface{}) {
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+	qc.results[id] = queryResult{keys: keys, expires: qc.clock.Now().Add(qc.ttl)}
+}
+
+// invalidateAll 清空所有结果
+func (qc *queryCache) invalidateAll() {
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+	qc.results = make(map[string]queryResult)
+	qc.counts = make(map[string]countResult)
+}
+
+func (qc *queryCache) getCount(id string) (int64, bool) {
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+	r, ok := qc.counts[id]
+	if !ok || qc.clock.Now().After(r.expires) {
+		delete(qc.counts, id)
+		return 0, false
+	}
+	return r.count, true
+}
+
+func (qc *queryCache) putCount(id string, count int64) {
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+	qc.counts[id] = countResult{count: count, expires: qc.clock.Now().Add(qc.ttl)}
+}
+
+// CachedPage 返回查询结果的第 page 页（从 0 开始）。结果按规范化的查询和页码缓存，
+// 通过缓存落库任意一行后失效（包括原本不在页中、修改后才满足条件的行）；成员对象本身来自缓存，与 Get 返回的对象一致
+func (c *CacheDB[T]) CachedPage(q Query, page, size int) ([]*T, error) {
+	if page < 0 || size <= 0 {
+		return nil, fmt.Errorf("invalid page %d size %d", page, size)
+	}
+	id := fmt.Sprintf("page|%s|%d|%d", q.normalize(), page, size)
+
+	if keys, ok := c.queries.get(id); ok {
+		out := make([]*T, 0, len(keys))
+		for _, key := range keys {
+			value, err := c.Get(key)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, value)
+		}
+		return out, nil
+	}
+
+	tx, err := c.wholeTable()
+	if err != nil {
+		return nil, err
+	}
+	var rows []T
+	if q.Where != "" {
+		tx = tx.Where(q.Where, q.Args...)
+	}
+	if q.Order != "" {
+		tx = tx.Order(q.Order)
+	}
+	if err := tx.Offset(page * size).Limit(size).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to query page: %w", err)
+	}
+
+	out := make([]*T, len(rows))
+	keys := make([]interface{}, len(rows))
+	for i := range rows {
+		keys[i] = c.keyOf(&rows[i])
+		value, err := c.adopt(keys[i], &rows[i])
+		if err != nil {
+			return nil, err
+		}
+		if out[i], err = c.view(value); err != nil {
+			return nil, err
+		}
+	}
+	c.queries.put(id, keys)
+	return out, nil
+}
+
+// InvalidateQueries 清空所有缓存的查询结果，例如批量导入数据之后
+func (c *CacheDB[T]) InvalidateQueries() {
+	c.queries.invalidateAll()
+}
+
+// CachedCount 返回满足查询的行数，结果缓存到有效期结束，
+// 或者直到通过该缓存落库、Create/Delete 了任意一行
+func (c *CacheDB[T]) CachedCount(q Query) (int64, error) {
+	id := "count|" + q.normalize()
+	if count, ok := c.queries.getCount(id); ok {
+		return count, nil
+	}
+
+	tx, err := c.wholeTable()
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	if q.Where != "" {
+		tx = tx.Where(q.Where, q.Args...)
+	}
+	if err := tx.Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count: %w", err)
+	}
+	c.queries.putCount(id, count)
+	return count, nil
+}
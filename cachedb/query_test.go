@@ -0,0 +1,111 @@
+package cachedb
+
+import "testing"
+
+func TestCachedPage(t *testing.T) {
+	type Friend struct {
+		ID      uint
+		OwnerID uint
+		Level   int
+	}
+	db := openTestDB(t, &Friend{})
+	for i := 1; i <= 5; i++ {
+		db.Create(&Friend{ID: uint(i), OwnerID: 1, Level: i * 10})
+	}
+
+	c := newTestCache[Friend](t, db, 10)
+	q := Query{Where: "owner_id = ?", Args: []interface{}{1}, Order: "level desc"}
+
+	page, err := c.CachedPage(q, 0, 2)
+	if err != nil {
+		t.Fatalf("page failed: %v", err)
+	}
+	if len(page) != 2 || page[0].ID != 5 || page[1].ID != 4 {
+		t.Fatalf("unexpected page %+v", page)
+	}
+	if got, _ := c.Get(uint(5)); got != page[0] {
+		t.Errorf("page members should be the cached objects")
+	}
+
+	// 绕过缓存的修改不会影响已缓存的页
+	db.Model(&Friend{}).Where("id = ?", 1).Update("level", 100)
+	again, _ := c.CachedPage(Query{Where: "owner_id  =  ?", Args: []interface{}{1}, Order: "level desc"}, 0, 2)
+	if again[0].ID != 5 {
+		t.Fatalf("expected cached page for normalized query, got %+v", again[0])
+	}
+
+	// 成员落库后该页失效
+	page[1].Level = 45
+	if err := c.Flush(uint(4)); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	fresh, _ := c.CachedPage(q, 0, 2)
+	if fresh[0].ID != 1 || fresh[1].ID != 5 {
+		t.Errorf("expected page re-queried after member flush, got %d,%d", fresh[0].ID, fresh[1].ID)
+	}
+
+	// 原本不在页中的行落库后满足条件，页同样失效
+	db.Create(&Friend{ID: 6, OwnerID: 2, Level: 500})
+	c.Update(uint(6), func(f *Friend) { f.OwnerID = 1 })
+	if err := c.Flush(uint(6)); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	joined, _ := c.CachedPage(q, 0, 2)
+	if joined[0].ID != 6 {
+		t.Errorf("expected a row that newly matches to invalidate the page, got %d", joined[0].ID)
+	}
+}
+
+func TestQueryNormalizeArgs(t *testing.T) {
+	a := Query{Where: "name = ? AND tag = ?", Args: []interface{}{"ab", "c"}}
+	b := Query{Where: "name = ? AND tag = ?", Args: []interface{}{"a", "bc"}}
+	if a.normalize() == b.normalize() {
+		t.Errorf("expected distinct keys, both are %q", a.normalize())
+	}
+	if x, y := (Query{Args: []interface{}{1}}), (Query{Args: []interface{}{"1"}}); x.normalize() == y.normalize() {
+		t.Errorf("expected 1 and \"1\" to produce distinct keys, both are %q", x.normalize())
+	}
+}
+
+func TestCachedCount(t *testing.T) {
+	type Lobby struct {
+		ID     uint
+		Region string
+	}
+	db := openTestDB(t, &Lobby{})
+	db.Create(&Lobby{Region: "eu"})
+	db.Create(&Lobby{Region: "eu"})
+
+	c := newTestCache[Lobby](t, db, 10)
+	q := Query{Where: "region = ?", Args: []interface{}{"eu"}}
+
+	if n, err := c.CachedCount(q); err != nil || n != 2 {
+		t.Fatalf("expected count 2, got %d (%v)", n, err)
+	}
+
+	db.Create(&Lobby{Region: "eu"}) // 绕过缓存
+	if n, _ := c.CachedCount(q); n != 2 {
+		t.Fatalf("expected cached count 2, got %d", n)
+	}
+
+	created, err := c.Create(Lobby{Region: "eu"})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if got, _ := c.Get(created.ID); got != created {
+		t.Errorf("created row should be cached")
+	}
+	if n, _ := c.CachedCount(q); n != 4 {
+		t.Fatalf("expected count 4 after Create, got %d", n)
+	}
+
+	if err := c.Delete(created.ID); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if n, _ := c.CachedCount(q); n != 3 {
+		t.Errorf("expected count 3 after Delete, got %d", n)
+	}
+	if c.Cache.Has(created.ID) {
+		t.Errorf("deleted row should be removed from cache")
+	}
+}
@@ -0,0 +1,41 @@
+package cachedb
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// DB 返回底层的 gorm 连接。直接修改缓存中表的数据会让缓存与数据库不一致，
+// 这类操作应通过 InvalidateAfter 执行
+func (c *CacheDB[T]) DB() *gorm.DB {
+	return c.db
+}
+
+// InvalidateAfter 在事务内执行原生 gorm 操作，并保持缓存一致：
+// 执行前先把受影响 key 的修改落库，避免之后被旧值覆盖；执行成功后丢弃这些 key 的缓存
+// （常驻对象原地刷新），并清空查询结果缓存。未指定 keys 时作用于所有已缓存的 key
+func (c *CacheDB[T]) InvalidateAfter(fn func(tx *gorm.DB) error, keys ...interface{}) error {
+	if len(keys) == 0 {
+		c.mu.Lock()
+		for key := range c.values {
+			keys = append(keys, key)
+		}
+		c.mu.Unlock()
+	}
+
+	for _, key := range keys {
+		if err := c.Flush(key); err != nil {
+			return fmt.Errorf("failed to flush before raw operation: %w", err)
+		}
+	}
+	if err := c.db.Transaction(fn); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		c.Invalidate(key)
+	}
+	c.queries.invalidateAll()
+	return nil
+}
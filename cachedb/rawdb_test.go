@@ -0,0 +1,42 @@
+package cachedb
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestInvalidateAfter(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+		Name string
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, Gold: 10})
+	db.Create(&Player{ID: 2, Gold: 10})
+
+	c := newTestCache[Player](t, db, 10)
+	p1, _ := c.Get(1)
+	p2, _ := c.Pin(2)
+	defer c.Unpin(2)
+	p1.Name = "alice" // 未落库的修改不能被原生操作丢掉
+
+	err := c.InvalidateAfter(func(tx *gorm.DB) error {
+		return tx.Model(&Player{}).Where("1 = 1").Update("gold", gorm.Expr("gold * 2")).Error
+	})
+	if err != nil {
+		t.Fatalf("InvalidateAfter failed: %v", err)
+	}
+
+	fresh, _ := c.Get(1)
+	if fresh.Gold != 20 || fresh.Name != "alice" {
+		t.Errorf("expected reloaded row with gold 20 and name alice, got %+v", fresh)
+	}
+	if p2.Gold != 20 {
+		t.Errorf("expected pinned object to be refreshed in place, got %+v", p2)
+	}
+	if c.DB() != db {
+		t.Errorf("DB should return the underlying connection")
+	}
+}
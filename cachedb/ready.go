@@ -0,0 +1,80 @@
+package cachedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNotReady 表示缓存尚未完成预热，见 WithReadyGate
+var ErrNotReady = errors.New("cache not ready")
+
+// readyGate 是预热完成前的门闩
+type readyGate struct {
+	ch   chan struct{} // MarkReady 时关闭
+	once sync.Once
+	wait time.Duration // Get 最多等待的时间，不大于 0 时立即失败
+}
+
+// WithReadyGate 让缓存以未就绪（冷）状态启动，预热（LoadAll、Warm、WarmFromQuery 等）完成后由调用方 MarkReady，
+// 以免游戏服在冷缓存上接入玩家、把加载压力全部压到数据库。未就绪时 Get 最多等待 wait，仍未就绪则返回 ErrNotReady；
+// wait 不大于 0 时立即返回 ErrNotReady。预热本身和 Update、Set 等其他操作不受影响
+func WithReadyGate[T any](wait time.Duration) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.ready = &readyGate{ch: make(chan struct{}), wait: wait}
+	}
+}
+
+// Ready 报告缓存是否已完成预热，未设置 WithReadyGate 时始终为 true
+func (c *CacheDB[T]) Ready() bool {
+	if c.ready == nil {
+		return true
+	}
+	select {
+	case <-c.ready.ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// MarkReady 标记预热完成，唤醒等待中的 Get。可以重复调用
+func (c *CacheDB[T]) MarkReady() {
+	if c.ready == nil {
+		return
+	}
+	c.ready.once.Do(func() {
+		close(c.ready.ch)
+		c.log(LogInfo, "Cache ready")
+	})
+}
+
+// WaitReady 阻塞到缓存完成预热或 ctx 结束，例如游戏服在开放登录前等待所有缓存就绪
+func (c *CacheDB[T]) WaitReady(ctx context.Context) error {
+	if c.ready == nil {
+		return nil
+	}
+	select {
+	case <-c.ready.ch:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %w", ErrNotReady, ctx.Err())
+	}
+}
+
+// awaitReady 在 Get 开始时按 WithReadyGate 等待预热完成
+func (c *CacheDB[T]) awaitReady() error {
+	if c.ready == nil || c.Ready() {
+		return nil
+	}
+	if c.ready.wait > 0 {
+		select {
+		case <-c.ready.ch:
+			return nil
+		case <-c.clock.After(c.ready.wait):
+		}
+	}
+	return ErrNotReady
+}
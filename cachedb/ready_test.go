@@ -0,0 +1,53 @@
+package cachedb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReadyGate(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, Gold: 10})
+	db.Create(&Player{ID: 2, Gold: 20})
+
+	plain := newTestCache[Player](t, db, 10)
+	if !plain.Ready() {
+		t.Error("expected a cache without a gate to be ready")
+	}
+
+	failFast := newTestCache[Player](t, db, 10, WithReadyGate[Player](0))
+	if _, err := failFast.Get(1); !errors.Is(err, ErrNotReady) {
+		t.Errorf("expected ErrNotReady before warm-up, got %v", err)
+	}
+	if err := failFast.Warm([]interface{}{1}); err != nil {
+		t.Fatalf("expected warm-up to bypass the gate, got %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := failFast.WaitReady(ctx); !errors.Is(err, ErrNotReady) {
+		t.Errorf("expected WaitReady to time out, got %v", err)
+	}
+	failFast.MarkReady()
+	failFast.MarkReady()
+	if p, err := failFast.Get(1); err != nil || p.Gold != 10 {
+		t.Errorf("expected Get to succeed once ready, got %v, %v", p, err)
+	}
+
+	blocking := newTestCache[Player](t, db, 10, WithReadyGate[Player](time.Second))
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		blocking.MarkReady()
+	}()
+	if p, err := blocking.Get(2); err != nil || p.Gold != 20 {
+		t.Errorf("expected Get to wait for warm-up, got %v, %v", p, err)
+	}
+	if !blocking.Ready() || blocking.WaitReady(context.Background()) != nil {
+		t.Error("expected the cache to report ready")
+	}
+}
@@ -0,0 +1,139 @@
+package cachedb
+
+import "testing"
+
+// gatedStore 在 gate 关闭前阻塞 Load，用于构造加载与 Set 的竞争
+type gatedStore[T any] struct {
+	Store[T]
+	loading chan struct{}
+	gate    chan struct{}
+}
+
+func (s gatedStore[T]) Load(key interface{}) (T, error) {
+	s.loading <- struct{}{}
+	<-s.gate
+	return s.Store.Load(key)
+}
+
+func TestSetReadYourWrites(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, Gold: 10})
+	db.Create(&Player{ID: 2, Gold: 20})
+
+	c := newTestCache[Player](t, db, 10)
+	if err := c.Set(1, Player{ID: 1, Gold: 11}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	c.Cache.Remove(uint(1)) // 淘汰时先回写，再重新加载
+	if p, _ := c.Get(1); p.Gold != 11 {
+		t.Errorf("expected gold 11 after eviction, got %d", p.Gold)
+	}
+	var stored Player
+	db.First(&stored, 1)
+	if stored.Gold != 11 {
+		t.Errorf("expected Set value to be written back on eviction, got %d", stored.Gold)
+	}
+
+	// 加载进行中时 Set，加载结果不能覆盖新值
+	gated := gatedStore[Player]{loading: make(chan struct{}), gate: make(chan struct{})}
+	raced := newTestCache[Player](t, db, 10, WithStore(func(base Store[Player]) Store[Player] {
+		gated.Store = base
+		return gated
+	}))
+	done := make(chan *Player)
+	go func() {
+		p, _ := raced.Get(2)
+		done <- p
+	}()
+	<-gated.loading
+	if err := raced.Set(2, Player{ID: 2, Gold: 22}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	close(gated.gate)
+	if p := <-done; p.Gold != 22 {
+		t.Errorf("racing load returned stale gold %d", p.Gold)
+	}
+	if p, _ := raced.Get(2); p.Gold != 22 {
+		t.Errorf("expected gold 22, got %d", p.Gold)
+	}
+	if dirty := raced.DirtyKeys(); len(dirty) != 1 {
+		t.Errorf("expected the Set value to stay dirty, got %v", dirty)
+	}
+}
+
+func TestSetNewKeyInsertsRow(t *testing.T) {
+	type Hero struct {
+		ID   uint
+		Name string
+		Gold int
+	}
+	db := openTestDB(t, &Hero{})
+	c := newTestCache[Hero](t, db, 10)
+	if err := c.Set(7, Hero{Name: "new", Gold: 5}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := c.Flush(7); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	var stored Hero
+	if err := db.First(&stored, 7).Error; err != nil {
+		t.Fatalf("expected Flush to create the row: %v", err)
+	}
+	if stored.Name != "new" || stored.Gold != 5 {
+		t.Errorf("unexpected row %+v", stored)
+	}
+	if keys := c.DirtyKeys(); len(keys) != 0 {
+		t.Errorf("expected no dirty keys, got %v", keys)
+	}
+
+	// 数据库中已有的行仍按 UPDATE 写入
+	c.Set(7, Hero{ID: 7, Name: "renamed", Gold: 6})
+	if err := c.Flush(7); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	var rows []Hero
+	db.Find(&rows)
+	if len(rows) != 1 || rows[0].Name != "renamed" || rows[0].Gold != 6 {
+		t.Errorf("unexpected rows %+v", rows)
+	}
+}
+
+func TestZeroValueWriteBack(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+		Name string
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, Gold: 10, Name: "a"})
+	db.Create(&Player{ID: 2, Gold: 20, Name: "b"})
+
+	c := newTestCache[Player](t, db, 10)
+	if err := c.Set(1, Player{ID: 1}); err != nil { // 未加载过，旧值未知
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := c.Update(2, func(p *Player) { p.Gold, p.Name = 0, "" }); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := c.FlushAll(); err != nil {
+		t.Fatalf("FlushAll failed: %v", err)
+	}
+	if keys := c.DirtyKeys(); len(keys) != 0 {
+		t.Errorf("expected no dirty keys after flush, got %v", keys)
+	}
+	for id := uint(1); id <= 2; id++ {
+		c.Cache.Remove(id)
+		if p, _ := c.Get(id); p.Gold != 0 || p.Name != "" {
+			t.Errorf("expected zero values for %d after reload, got %+v", id, p)
+		}
+		var stored Player
+		db.First(&stored, id)
+		if stored.Gold != 0 || stored.Name != "" {
+			t.Errorf("expected zero values written back for %d, got %+v", id, stored)
+		}
+	}
+}
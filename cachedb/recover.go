@@ -0,0 +1,30 @@
+package cachedb
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// ErrUncopyable 表示值中包含无法深拷贝的类型（chan、func、unsafe.Pointer）
+var ErrUncopyable = errors.New("value cannot be deep copied")
+
+// recoverPanic 捕获缓存回调中的 panic 并打印堆栈，避免在 gcache 回调中崩溃整个进程。
+// errp 不为空时把 panic 转换为错误返回给调用方
+func (c *CacheDB[T]) recoverPanic(op string, key interface{}, errp *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	err := fmt.Errorf("panic in %s callback for key %v: %v", op, key, r)
+	c.log(LogError, "Recovered panic", "err", err, "stack", string(debug.Stack()))
+	if errp != nil {
+		*errp = err
+	}
+}
+
+// runFlushHook 执行用户注册的落库回调，回调 panic 不影响其他回调和落库结果
+func (c *CacheDB[T]) runFlushHook(fn func(key interface{}, old, new T), key interface{}, old, new T) {
+	defer c.recoverPanic("flush hook", key, nil)
+	fn(key, old, new)
+}
@@ -0,0 +1,58 @@
+package cachedb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDeepCopyUnsupportedKinds(t *testing.T) {
+	type Bag struct {
+		Items  [2][]int
+		OnUse  func()
+		Notify chan int
+	}
+	if _, err := deepCopy(Bag{OnUse: func() {}}); !errors.Is(err, ErrUncopyable) {
+		t.Errorf("expected ErrUncopyable for func field, got %v", err)
+	}
+	if _, err := deepCopy(struct{ C chan int }{}); !errors.Is(err, ErrUncopyable) {
+		t.Errorf("expected ErrUncopyable for chan field, got %v", err)
+	}
+
+	type Grid struct{ Cells [2][]int }
+	src := Grid{Cells: [2][]int{{1}, {2}}}
+	cpy, err := deepCopy(src)
+	if err != nil {
+		t.Fatalf("copy failed: %v", err)
+	}
+	cpy.Cells[0][0] = 9
+	if src.Cells[0][0] != 1 {
+		t.Errorf("array elements must be deep copied")
+	}
+}
+
+func TestFlushHookPanicRecovered(t *testing.T) {
+	type Quest struct {
+		ID   uint
+		Step int
+	}
+	db := openTestDB(t, &Quest{})
+	db.Create(&Quest{ID: 1})
+
+	c := newTestCache[Quest](t, db, 10)
+	var called bool
+	c.OnFlush(func(interface{}, Quest, Quest) { panic("boom") })
+	c.OnFlush(func(interface{}, Quest, Quest) { called = true })
+
+	q, _ := c.Get(uint(1))
+	q.Step = 3
+	c.Cache.Purge() // 淘汰回调中 panic 不应导致进程崩溃
+
+	if !called {
+		t.Errorf("later hooks should still run after a panicking hook")
+	}
+	var row Quest
+	db.First(&row, 1)
+	if row.Step != 3 {
+		t.Errorf("expected write to succeed, got step %d", row.Step)
+	}
+}
@@ -0,0 +1,63 @@
+package cachedb
+
+// WithDeadLetter 设置死信日志：淘汰、清空或 Unpin 时回写失败而滞留在内存中的对象会被追加到该日志，
+// 成功落库后提交。进程在重试成功前崩溃时，这些修改可由 Recover 在启动时恢复。
+// 可以使用 FileWAL，但不应与 WithWAL 共用同一个文件
+func WithDeadLetter[T any](log WriteAheadLog[T]) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.deadLetters = log
+	}
+}
+
+// deadLetter 把回写失败的对象追加到死信日志，调用方需持有 c.mu
+func (c *CacheDB[T]) deadLetter(key interface{}) {
+	if c.deadLetters == nil {
+		return
+	}
+	value, ok := c.values[key]
+	if !ok {
+		return
+	}
+	cpy, err := c.copier(*value)
+	if err == nil {
+		c.redactInPlace(&cpy) // 死信日志可能被运维人员查看，敏感字段不写入，其未落库的修改也因此无法恢复
+		err = c.deadLetters.Append(key, cpy)
+	}
+	if err != nil {
+		c.log(LogError, "Dead letter append failed", "key", key, "err", err)
+	}
+}
+
+// RecoveryConfig 指定启动恢复时需要重放的日志文件，为空的路径会被跳过
+type RecoveryConfig struct {
+	WALPath        string // WithWAL 使用的预写日志
+	DeadLetterPath string // WithDeadLetter 使用的死信日志
+}
+
+// RecoveryReport 是启动恢复的结果
+type RecoveryReport struct {
+	WAL         int // 从预写日志恢复的 key 数
+	DeadLetters int // 从死信日志恢复的 key 数
+}
+
+// Recover 在启动时把上次崩溃前未落库的修改写回数据库：先重放预写日志，再重放死信日志，
+// 同一个 key 以死信日志中较新的记录为准。恢复的 key 会从缓存中失效，之后的读取得到数据库中的最新值。
+// 应在打开日志文件（OpenFileWAL）和开始接受请求之前调用
+func Recover[T any](c *CacheDB[T], cfg RecoveryConfig) (RecoveryReport, error) {
+	var report RecoveryReport
+	var err error
+	if cfg.WALPath != "" {
+		if report.WAL, err = ReplayWAL(c, cfg.WALPath); err != nil {
+			return report, err
+		}
+	}
+	if cfg.DeadLetterPath != "" {
+		if report.DeadLetters, err = replayLog(c, cfg.DeadLetterPath, c.redactedColumns()); err != nil {
+			return report, err
+		}
+	}
+	if report.WAL+report.DeadLetters > 0 {
+		c.log(LogInfo, "Recovered unflushed changes", "wal", report.WAL, "dead_letters", report.DeadLetters)
+	}
+	return report, nil
+}
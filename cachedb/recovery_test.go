@@ -0,0 +1,53 @@
+package cachedb
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRecoverDeadLetters(t *testing.T) {
+	type Pet struct {
+		ID   uint
+		Name string `gorm:"unique"`
+	}
+	db := openTestDB(t, &Pet{})
+	db.Create(&Pet{ID: 1, Name: "rex"})
+	db.Create(&Pet{ID: 2, Name: "tom"})
+	db.Create(&Pet{ID: 3, Name: "max"})
+
+	path := filepath.Join(t.TempDir(), "dead.log")
+	letters, err := OpenFileWAL[Pet](path)
+	if err != nil {
+		t.Fatalf("OpenFileWAL failed: %v", err)
+	}
+	c := newTestCache[Pet](t, db, 10, WithDeadLetter[Pet](letters))
+
+	rex, _ := c.Get(1)
+	rex.Name = "tom" // 违反唯一约束，淘汰时回写失败
+	c.Cache.Remove(uint(1))
+	pet, _ := c.Get(3)
+	pet.Name = "rex"
+	c.Cache.Remove(uint(3)) // 与 1 交换名字，同样失败
+	pet.Name = "bob"
+	if err := c.Flush(3); err != nil { // 重试成功后死信被提交
+		t.Fatalf("Flush failed: %v", err)
+	}
+	letters.Close() // 模拟进程在 key 1 重试成功前崩溃
+
+	db.Delete(&Pet{}, 2) // 冲突在重启前被人工解决
+	restarted := newTestCache[Pet](t, db, 10)
+	report, err := Recover(restarted, RecoveryConfig{DeadLetterPath: path})
+	if err != nil {
+		t.Fatalf("Recover failed: %v", err)
+	}
+	if report.DeadLetters != 1 || report.WAL != 0 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	got, _ := restarted.Get(1)
+	if got.Name != "tom" {
+		t.Errorf("expected the dead-lettered change to be recovered, got %q", got.Name)
+	}
+	if got, _ := restarted.Get(3); got.Name != "bob" {
+		t.Errorf("expected committed key to keep its flushed value, got %q", got.Name)
+	}
+}
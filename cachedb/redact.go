@@ -0,0 +1,77 @@
+package cachedb
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+// RedactMask 是被遮蔽的字符串字段显示的值，其他类型的字段显示为零值
+const RedactMask = "***"
+
+// redactedFields 返回带有 `cache:"redact"` 标签的字段。这些字段正常落库，
+// 但在导出、死信日志和 FlushDryRun 等面向运维的输出中被遮蔽
+func redactedFields(sch *schema.Schema) []*schema.Field {
+	var fields []*schema.Field
+	for _, f := range sch.Fields {
+		for _, opt := range strings.Split(f.Tag.Get("cache"), ",") {
+			if strings.TrimSpace(opt) == "redact" {
+				fields = append(fields, f)
+				break
+			}
+		}
+	}
+	return fields
+}
+
+// Redact 返回 value 的副本，其中 `cache:"redact"` 标记的字段被遮蔽，用于管理接口输出或日志。
+// 副本与 value 互不共享可变状态
+func (c *CacheDB[T]) Redact(value T) T {
+	if len(c.redacted) == 0 {
+		return value
+	}
+	cpy, err := c.copier(value)
+	if err != nil {
+		var zero T // 无法拷贝时不冒泄漏的风险
+		return zero
+	}
+	c.redactInPlace(&cpy)
+	return cpy
+}
+
+// redactInPlace 遮蔽 value 中标记的字段
+func (c *CacheDB[T]) redactInPlace(value *T) {
+	rv := reflect.ValueOf(value).Elem()
+	for _, f := range c.redacted {
+		fv := f.ReflectValueOf(context.Background(), rv)
+		if fv.Kind() == reflect.String {
+			fv.SetString(RedactMask)
+		} else {
+			fv.Set(reflect.Zero(fv.Type()))
+		}
+	}
+}
+
+// redactChanges 遮蔽字段修改中的敏感值
+func (c *CacheDB[T]) redactChanges(changes []FieldChange) {
+	for i := range changes {
+		for _, f := range c.redacted {
+			if changes[i].Field == f.Name {
+				changes[i].Old, changes[i].New = RedactMask, RedactMask
+			}
+		}
+	}
+}
+
+// redactedColumns 返回被遮蔽字段对应的列，重放死信日志时跳过这些列以免把遮蔽值写入数据库
+func (c *CacheDB[T]) redactedColumns() []string {
+	cols := make([]string, 0, len(c.redacted))
+	for _, f := range c.redacted {
+		if f.DBName != "" {
+			cols = append(cols, f.DBName)
+		}
+	}
+	return cols
+}
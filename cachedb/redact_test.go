@@ -0,0 +1,77 @@
+package cachedb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	type Account struct {
+		ID       uint
+		Name     string `gorm:"unique"`
+		Password string `cache:"redact"`
+		Balance  int    `cache:"redact"`
+	}
+	db := openTestDB(t, &Account{})
+	db.Create(&Account{ID: 1, Name: "a", Password: "hunter2", Balance: 10})
+	db.Create(&Account{ID: 2, Name: "b", Password: "secret", Balance: 20})
+
+	path := filepath.Join(t.TempDir(), "dead.log")
+	letters, err := OpenFileWAL[Account](path)
+	if err != nil {
+		t.Fatalf("OpenFileWAL failed: %v", err)
+	}
+	c := newTestCache[Account](t, db, 10, WithDeadLetter[Account](letters))
+
+	a, _ := c.Get(1)
+	if r := c.Redact(*a); r.Password != RedactMask || r.Balance != 0 || r.Name != "a" || a.Password != "hunter2" {
+		t.Errorf("unexpected redacted copy %+v of %+v", r, a)
+	}
+
+	logs := &recordLogger{}
+	c.logger = logs
+	c.log(LogError, "dump", "value", a)
+	if e := logs.find("dump"); len(e) != 1 || strings.Contains(fmt.Sprint(e[0].fields...), "hunter2") {
+		t.Errorf("expected logged entity to be redacted, got %+v", e)
+	}
+
+	a.Password = "newpass"
+	plan, err := c.FlushDryRun()
+	if err != nil {
+		t.Fatalf("FlushDryRun failed: %v", err)
+	}
+	entry := plan.Entries[0]
+	if entry.Changes[0].New != RedactMask || strings.Contains(entry.SQL, "newpass") || strings.Contains(entry.SQL, "hunter2") {
+		t.Errorf("expected dry run to mask secrets, got %+v", entry)
+	}
+	if err := c.Flush(1); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	var row Account
+	db.First(&row, 1)
+	if row.Password != "newpass" {
+		t.Errorf("expected redacted field to be persisted unmasked, got %+v", row)
+	}
+
+	a.Name = "b" // 违反唯一约束，淘汰时进入死信日志
+	c.Cache.Remove(uint(1))
+	letters.Close()
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "newpass") || !strings.Contains(string(data), RedactMask) {
+		t.Errorf("expected dead letter to be redacted, got %s", data)
+	}
+
+	db.Delete(&Account{}, 2)
+	restarted := newTestCache[Account](t, db, 10)
+	if _, err := Recover(restarted, RecoveryConfig{DeadLetterPath: path}); err != nil {
+		t.Fatalf("Recover failed: %v", err)
+	}
+	var recovered Account
+	db.First(&recovered, 1)
+	if recovered.Name != "b" || recovered.Password != "newpass" || recovered.Balance != 10 {
+		t.Errorf("expected recovery to keep redacted columns intact, got %+v", recovered)
+	}
+}
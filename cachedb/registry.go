@@ -0,0 +1,161 @@
+package cachedb
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Managed 是注册到 Registry 的缓存需要实现的接口，*CacheDB[T] 实现了该接口
+type Managed interface {
+	Flusher
+	Invalidate(key interface{})
+	Refresh(key interface{}) error
+	ParseKey(s string) (interface{}, error)
+}
+
+var _ Managed = (*CacheDB[struct{ ID uint }])(nil)
+
+// Registry 按实体名管理多个缓存，供跨缓存的运维操作使用。
+// 不同实体可以使用不同的数据库连接（账号库、游戏库、日志库），共享一组刷盘协程，
+// 停服时通过一次 Close 全部落库
+type Registry struct {
+	mu     sync.RWMutex
+	caches map[string]Managed
+	dbs    map[string]*gorm.DB
+	pool   *FlushPool
+
+	limiter     *DBLimiter // 共享的数据库并发限制，未设置时为 nil
+	namespace   *Namespace // 注册的缓存使用的命名空间，未设置时为 nil
+	maintenance int        // FlushAll、PurgeAll 的并发数
+
+	flushDeps map[string][]string // 实体 -> 必须先于它落库的实体，见 FlushBefore
+}
+
+// NewRegistry 创建空的注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		caches: make(map[string]Managed),
+		dbs:    make(map[string]*gorm.DB),
+	}
+}
+
+// AddDB 以 name 登记数据库连接，供 RegisterCache 使用
+func (r *Registry) AddDB(name string, db *gorm.DB) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dbs[name] = db
+}
+
+// DB 返回 name 对应的数据库连接
+func (r *Registry) DB(name string) (*gorm.DB, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	db, ok := r.dbs[name]
+	return db, ok
+}
+
+// ShareFlushPool 创建所有通过 RegisterCache 注册的缓存共享的刷盘协程池，
+// 需在注册缓存之前调用，协程池由 Close 停止
+func (r *Registry) ShareFlushPool(workers, queueSize int) *FlushPool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pool == nil {
+		r.pool = NewFlushPool(workers, queueSize)
+	}
+	return r.pool
+}
+
+// RegisterCache 在 dbName 对应的数据库上创建缓存并以 name 注册，
+// 设置了共享协程池时该缓存使用它异步回写
+func RegisterCache[T any](r *Registry, name, dbName string, size int, opts ...Option[T]) (*CacheDB[T], error) {
+	db, ok := r.DB(dbName)
+	if !ok {
+		return nil, fmt.Errorf("database %q not registered", dbName)
+	}
+	r.mu.RLock()
+	pool, limiter, ns := r.pool, r.limiter, r.namespace
+	r.mu.RUnlock()
+	if ns != nil {
+		opts = append([]Option[T]{WithNamespace[T](*ns)}, opts...)
+	}
+	if pool != nil {
+		opts = append([]Option[T]{WithFlushPool[T](pool)}, opts...)
+	}
+	if limiter != nil {
+		opts = append([]Option[T]{WithDBLimiter[T](limiter)}, opts...)
+	}
+
+	c, err := NewWithCache[T](db, size, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Register(name, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close 等待共享协程池中排队的回写完成，然后依次关闭所有缓存（实现了 Close 的缓存会把剩余脏数据落库）。
+// 数据库连接不会被关闭。返回遇到的第一个错误
+func (r *Registry) Close() error {
+	r.mu.RLock()
+	pool := r.pool
+	caches := make([]Managed, 0, len(r.caches))
+	for _, name := range r.namesLocked() {
+		caches = append(caches, r.caches[name])
+	}
+	r.mu.RUnlock()
+
+	if pool != nil {
+		pool.Stop()
+	}
+	var firstErr error
+	for _, c := range caches {
+		closer, ok := c.(interface{ Close() error })
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Register 以 name 注册缓存，重复注册返回错误
+func (r *Registry) Register(name string, c Managed) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.caches[name]; exists {
+		return fmt.Errorf("cache %q already registered", name)
+	}
+	r.caches[name] = c
+	return nil
+}
+
+// Lookup 返回 name 对应的缓存
+func (r *Registry) Lookup(name string) (Managed, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.caches[name]
+	return c, ok
+}
+
+// Names 返回所有已注册的实体名，按名称排序
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.namesLocked()
+}
+
+func (r *Registry) namesLocked() []string {
+	names := make([]string, 0, len(r.caches))
+	for name := range r.caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,59 @@
+package cachedb
+
+import (
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestRegistryMultiDB(t *testing.T) {
+	type Account struct {
+		ID    uint
+		Email string
+	}
+	type Hero struct {
+		ID    uint
+		Level int
+	}
+	accounts := openTestDB(t, &Account{})
+	game, err := gorm.Open(sqlite.Open("file:"+t.Name()+"_game?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	game.AutoMigrate(&Hero{})
+	accounts.Create(&Account{ID: 1, Email: "a@example.com"})
+	game.Create(&Hero{ID: 1, Level: 1})
+
+	reg := NewRegistry()
+	reg.AddDB("accounts", accounts)
+	reg.AddDB("game", game)
+	reg.ShareFlushPool(2, 16)
+
+	accountCache, err := RegisterCache[Account](reg, "account", "accounts", 10)
+	if err != nil {
+		t.Fatalf("RegisterCache failed: %v", err)
+	}
+	heroCache, err := RegisterCache[Hero](reg, "hero", "game", 10)
+	if err != nil {
+		t.Fatalf("RegisterCache failed: %v", err)
+	}
+	if _, err := RegisterCache[Hero](reg, "log", "logs", 10); err == nil {
+		t.Errorf("expected error for unknown database")
+	}
+
+	accountCache.Update(1, func(a *Account) { a.Email = "b@example.com" })
+	heroCache.Update(1, func(h *Hero) { h.Level = 2 })
+	heroCache.EvictNow(1) // 经共享协程池异步回写
+
+	if err := reg.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	var account Account
+	accounts.First(&account, 1)
+	var hero Hero
+	game.First(&hero, 1)
+	if account.Email != "b@example.com" || hero.Level != 2 {
+		t.Errorf("expected both databases to be flushed, got %+v %+v", account, hero)
+	}
+}
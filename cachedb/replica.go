@@ -0,0 +1,128 @@
+package cachedb
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// replicaSet 是热点 key 的只读副本，见 WithReadReplicas
+type replicaSet[T any] struct {
+	copies []*T
+	next   atomic.Uint64
+}
+
+// replicaIndex 是所有热点 key 的副本，由自身的读写锁保护，Get 命中副本时不需要 c.mu
+type replicaIndex[T any] struct {
+	n   int
+	hot func(key interface{}) bool
+
+	mu   sync.RWMutex
+	sets map[interface{}]*replicaSet[T]
+}
+
+// WithReadReplicas 为 hot 返回 true 的 key（例如被成千上万个会话读取的名人角色）维护 n 份只读副本，
+// Get 轮流返回其中一份，读取方不再争用同一个指针和同一条缓存行。
+// 副本在首次 Get 时按当前值生成，落库时整体替换；Set、Update、Refresh 等修改会丢弃副本，
+// 下次 Get 按修改后的值重新生成，因此修改后的 Get 能读到自己的写入。
+// 副本是共享的，调用方不能修改；副本命中时不更新 LRU 顺序，热点对象应配合 Pin 常驻。不能与 WithSafeReads 同时使用
+func WithReadReplicas[T any](n int, hot func(key interface{}) bool) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.replicas = &replicaIndex[T]{n: n, hot: hot, sets: make(map[interface{}]*replicaSet[T])}
+	}
+}
+
+// check 在构造时检查副本配置
+func (r *replicaIndex[T]) check(safeReads bool) error {
+	if r.n < 1 {
+		return fmt.Errorf("WithReadReplicas requires at least one replica, got %d", r.n)
+	}
+	if safeReads {
+		return fmt.Errorf("WithReadReplicas cannot be combined with WithSafeReads")
+	}
+	return nil
+}
+
+// pick 轮流返回 key 的一份副本，尚未生成时返回 nil
+func (r *replicaIndex[T]) pick(key interface{}) *T {
+	r.mu.RLock()
+	set := r.sets[key]
+	r.mu.RUnlock()
+	if set == nil {
+		return nil
+	}
+	return set.copies[set.next.Add(1)%uint64(len(set.copies))]
+}
+
+// readReplica 在 key 已有副本时返回其中一份，不经过 gcache 与 c.mu
+func (c *CacheDB[T]) readReplica(key interface{}) *T {
+	if c.replicas == nil || c.checkOpen() != nil {
+		return nil
+	}
+	key, err := c.normalizeKey(key)
+	if err != nil {
+		return nil
+	}
+	cpy := c.replicas.pick(key)
+	if cpy != nil {
+		c.counters.replicaReads.Add(1)
+	}
+	return cpy
+}
+
+// replicate 为热点 key 按 value 生成副本并返回其中一份；key 不是热点或 value 已不是追踪的对象时返回 nil
+func (c *CacheDB[T]) replicate(key interface{}, value *T) (*T, error) {
+	if c.replicas == nil || !c.replicas.hot(key) {
+		return nil, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.current(key, value) {
+		return nil, nil // 期间已被淘汰或替换，本次直接返回对象
+	}
+	if err := c.resetReplicas(key, value, true); err != nil {
+		return nil, err
+	}
+	return c.replicas.pick(key), nil
+}
+
+// refreshReplicas 在对象落库后替换已有的副本，调用方需持有 c.mu
+func (c *CacheDB[T]) refreshReplicas(key interface{}, value *T) error {
+	if c.replicas == nil {
+		return nil
+	}
+	return c.resetReplicas(key, value, false)
+}
+
+// resetReplicas 按 value 重新生成 key 的副本，create 为 false 时只替换已有的副本，调用方需持有 c.mu
+func (c *CacheDB[T]) resetReplicas(key interface{}, value *T, create bool) error {
+	r := c.replicas
+	r.mu.RLock()
+	_, ok := r.sets[key]
+	r.mu.RUnlock()
+	if !ok && !create {
+		return nil
+	}
+	set := &replicaSet[T]{copies: make([]*T, r.n)}
+	for i := range set.copies {
+		cpy, err := c.copier(*value)
+		if err != nil {
+			return fmt.Errorf("failed to replicate key %v: %w", key, err)
+		}
+		set.copies[i] = &cpy
+	}
+	r.mu.Lock()
+	r.sets[key] = set
+	r.mu.Unlock()
+	return nil
+}
+
+// dropReplicas 在对象停止追踪时丢弃它的副本，调用方需持有 c.mu
+func (c *CacheDB[T]) dropReplicas(key interface{}) {
+	if c.replicas == nil {
+		return
+	}
+	c.replicas.mu.Lock()
+	delete(c.replicas.sets, key)
+	c.replicas.mu.Unlock()
+}
@@ -0,0 +1,74 @@
+package cachedb
+
+import "testing"
+
+func TestReadReplicas(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, Gold: 10})
+	db.Create(&Player{ID: 2, Gold: 10})
+
+	if _, err := NewWithCache[Player](db, 10, WithReadReplicas[Player](0, func(interface{}) bool { return true })); err == nil {
+		t.Error("expected zero replicas to be rejected")
+	}
+	celebrity := func(key interface{}) bool { return key.(uint) == 1 }
+	c := newTestCache[Player](t, db, 10, WithReadReplicas[Player](3, celebrity))
+
+	seen := make(map[*Player]bool)
+	for i := 0; i < 6; i++ {
+		p, err := c.Get(1)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if p.Gold != 10 {
+			t.Errorf("expected gold 10, got %d", p.Gold)
+		}
+		seen[p] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("expected reads to spread over 3 replicas, got %d", len(seen))
+	}
+	pinned, _ := c.Pin(1)
+	c.Unpin(1)
+	if seen[pinned] {
+		t.Error("expected replicas to be distinct from the cached object")
+	}
+	cold, _ := c.Get(2)
+	if direct, _ := c.Pin(2); cold != direct {
+		t.Error("expected a cold key to return the cached object")
+	}
+	c.Unpin(2)
+
+	// 修改后的 Get 读到自己的写入
+	c.Update(1, func(p *Player) { p.Gold = 20 })
+	if p, _ := c.Get(1); p.Gold != 20 {
+		t.Errorf("expected Get after Update to see gold 20, got %d", p.Gold)
+	}
+	if err := c.Set(1, Player{ID: 1, Gold: 99}); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := c.Get(1); p.Gold != 99 {
+		t.Errorf("expected Get after Set to see gold 99, got %d", p.Gold)
+	}
+	c.MarkDirty(1)
+	if err := c.Flush(1); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := c.Get(1); p.Gold != 99 {
+		t.Errorf("expected replicas after flush to keep gold 99, got %d", p.Gold)
+	}
+	if c.Stats().ReplicaReads == 0 {
+		t.Error("expected replica reads to be counted")
+	}
+
+	// 删除后副本随之丢弃
+	if err := c.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(1); err == nil {
+		t.Error("expected a deleted key to miss instead of returning a stale replica")
+	}
+}
@@ -0,0 +1,98 @@
+package cachedb
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoQuorum 表示 LocalReplicator 没有得到多数派的确认
+var ErrNoQuorum = errors.New("replication quorum not reached")
+
+// Replicator 把写操作复制到跟随节点，Replicate 应在多数派确认后才返回，
+// 例如基于 raft 日志提交的实现。数据库仍然是最终的持久化存储。
+// 复制模式是实验性的：本包只内置进程内的 LocalReplicator，跨进程的 raft 实现需要调用方提供
+type Replicator[T any] interface {
+	Replicate(key interface{}, value T) error
+}
+
+// ReplicaApplier 是跟随节点应用已提交写操作的接口，*CacheDB 通过 ApplyReplicated 实现
+type ReplicaApplier[T any] interface {
+	ApplyReplicated(key interface{}, value T) error
+}
+
+var _ ReplicaApplier[struct{}] = (*CacheDB[struct{}])(nil)
+
+// LocalReplicator 是进程内的参考实现：依次应用到所有跟随节点，
+// 连同领导节点在内的多数派应用成功即确认，否则返回 ErrNoQuorum。
+// 它不会撤销已应用到部分跟随节点的值，也不做选主和日志重放，只适用于测试和单进程部署
+type LocalReplicator[T any] struct {
+	Followers []ReplicaApplier[T]
+}
+
+// Replicate 把 value 应用到跟随节点，见 LocalReplicator
+func (r *LocalReplicator[T]) Replicate(key interface{}, value T) error {
+	need := (len(r.Followers) + 1) / 2 // 领导节点自身算一票
+	acks := 0
+	var firstErr error
+	for _, f := range r.Followers {
+		if err := f.ApplyReplicated(key, value); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		acks++
+	}
+	if acks < need {
+		return fmt.Errorf("%w: %d of %d followers applied key %v: %w", ErrNoQuorum, acks, len(r.Followers), key, firstErr)
+	}
+	return nil
+}
+
+// WithReplicator 开启实验性的复制模式：Set 在持有内部锁时先经复制器提交，成功后才修改本地缓存；
+// Update、UpdateIfGeneration 在持有内部锁时提交修改后的值，失败时撤销 fn 的修改；
+// MarkDirty、Transfer 和保存点的 Rollback 同样在返回前提交。直接修改 Get 返回的指针不会被感知，
+// 需随后调用 MarkDirty 才会复制。适用于拍卖行、世界 Boss 等关键的共享状态
+func WithReplicator[T any](r Replicator[T]) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.replicator = r
+	}
+}
+
+// snapshotForReplication 在修改前拷贝 value，用于复制失败时撤销，未配置复制器时返回 nil
+func (c *CacheDB[T]) snapshotForReplication(key interface{}, value *T) (*T, error) {
+	if c.replicator == nil {
+		return nil, nil
+	}
+	before, err := c.copier(*value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy key %v: %w", key, err)
+	}
+	return &before, nil
+}
+
+// replicateLocked 经复制器提交 key 修改后的值，失败时把对象恢复为 before（为 nil 时保留修改）。
+// 调用方需持有 c.mu，复制期间该缓存的其他操作都会等待
+func (c *CacheDB[T]) replicateLocked(key interface{}, value, before *T) error {
+	if c.replicator == nil {
+		return nil
+	}
+	if err := c.replicator.Replicate(key, *value); err != nil {
+		if before != nil {
+			*value = *before
+		}
+		return fmt.Errorf("failed to replicate key %v: %w", key, err)
+	}
+	return nil
+}
+
+// ApplyReplicated 在跟随节点上应用已提交的写操作。值与 Set 一样标记为脏并保留原有的副本，
+// 跟随节点刷盘时会写入它（与领导节点写入相同的值）；跟随节点被提升为领导节点后，
+// 原领导节点尚未落库的修改也会由它写入数据库
+func (c *CacheDB[T]) ApplyReplicated(key interface{}, value T) error {
+	key, err := c.normalizeKey(key)
+	if err != nil {
+		return err
+	}
+	return c.put(key, value, "ApplyReplicated", false)
+}
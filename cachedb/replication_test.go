@@ -0,0 +1,184 @@
+package cachedb
+
+import (
+	"errors"
+	"testing"
+)
+
+// quorumReplicator 在所有跟随节点应用成功后才确认
+type quorumReplicator[T any] struct {
+	followers []*CacheDB[T]
+	err       error
+}
+
+func (r *quorumReplicator[T]) Replicate(key interface{}, value T) error {
+	if r.err != nil {
+		return r.err
+	}
+	for _, f := range r.followers {
+		if err := f.ApplyReplicated(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestReplicatedSet(t *testing.T) {
+	type Auction struct {
+		ID  uint
+		Bid int
+	}
+	db := openTestDB(t, &Auction{})
+	db.Create(&Auction{ID: 1, Bid: 100})
+
+	follower := newTestCache[Auction](t, db, 10)
+	r := &quorumReplicator[Auction]{followers: []*CacheDB[Auction]{follower}}
+	leader := newTestCache[Auction](t, db, 10, WithReplicator[Auction](r))
+
+	if err := leader.Set(uint(1), Auction{ID: 1, Bid: 150}); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	got, err := follower.Get(uint(1))
+	if err != nil || got.Bid != 150 {
+		t.Errorf("expected follower to see bid 150, got %+v (%v)", got, err)
+	}
+
+	r.err = errors.New("no quorum")
+	if err := leader.Set(uint(1), Auction{ID: 1, Bid: 200}); !errors.Is(err, r.err) {
+		t.Fatalf("expected replication error, got %v", err)
+	}
+	if got, _ := leader.Get(uint(1)); got.Bid != 150 {
+		t.Errorf("failed replication must not modify leader, got bid %d", got.Bid)
+	}
+}
+
+func TestReplicatedMutations(t *testing.T) {
+	type Auction struct {
+		ID  uint
+		Bid int64
+	}
+	db := openTestDB(t, &Auction{}, &TransferAudit{})
+	db.Create(&Auction{ID: 1, Bid: 100})
+	db.Create(&Auction{ID: 2, Bid: 100})
+
+	follower := newTestCache[Auction](t, db, 10)
+	r := &quorumReplicator[Auction]{followers: []*CacheDB[Auction]{follower}}
+	leader := newTestCache[Auction](t, db, 10, WithReplicator[Auction](r))
+	bid := func(key uint) int64 {
+		t.Helper()
+		p, err := follower.Get(key)
+		if err != nil {
+			t.Fatalf("follower Get failed: %v", err)
+		}
+		return p.Bid
+	}
+
+	if err := leader.Update(uint(1), func(a *Auction) { a.Bid = 110 }); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got := bid(1); got != 110 {
+		t.Errorf("expected Update to be replicated, got %d", got)
+	}
+	gen, _ := leader.Generation(uint(1))
+	if _, err := leader.UpdateIfGeneration(uint(1), gen, func(a *Auction) { a.Bid = 120 }); err != nil {
+		t.Fatalf("UpdateIfGeneration failed: %v", err)
+	}
+	if got := bid(1); got != 120 {
+		t.Errorf("expected UpdateIfGeneration to be replicated, got %d", got)
+	}
+	p, _ := leader.Get(uint(1))
+	p.Bid = 130
+	if err := leader.MarkDirty(uint(1)); err != nil {
+		t.Fatalf("MarkDirty failed: %v", err)
+	}
+	if got := bid(1); got != 130 {
+		t.Errorf("expected MarkDirty to replicate the current value, got %d", got)
+	}
+	spec := TransferSpec[Auction]{
+		Column:  "bid",
+		Balance: func(a *Auction) int64 { return a.Bid },
+		Set:     func(a *Auction, v int64) { a.Bid = v },
+	}
+	if err := Transfer(leader, uint(1), uint(2), 30, spec); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+	if a, b := bid(1), bid(2); a != 100 || b != 130 {
+		t.Errorf("expected Transfer to be replicated as 100 and 130, got %d and %d", a, b)
+	}
+
+	// 复制失败时撤销 Update 的修改
+	r.err = errors.New("no quorum")
+	if err := leader.Update(uint(1), func(a *Auction) { a.Bid = 999 }); !errors.Is(err, r.err) {
+		t.Fatalf("expected replication error, got %v", err)
+	}
+	if p, _ := leader.Get(uint(1)); p.Bid != 100 {
+		t.Errorf("failed replication must roll back Update, got bid %d", p.Bid)
+	}
+}
+
+// downReplica 模拟不可达的跟随节点
+type downReplica[T any] struct{}
+
+func (downReplica[T]) ApplyReplicated(interface{}, T) error { return errors.New("unreachable") }
+
+func TestLocalReplicator(t *testing.T) {
+	type Auction struct {
+		ID  uint
+		Bid int
+	}
+	db := openTestDB(t, &Auction{})
+	db.Create(&Auction{ID: 1, Bid: 100})
+
+	follower := newTestCache[Auction](t, db, 10)
+	r := &LocalReplicator[Auction]{Followers: []ReplicaApplier[Auction]{follower, downReplica[Auction]{}}}
+	leader := newTestCache[Auction](t, db, 10, WithReplicator[Auction](r))
+
+	// 三个节点中领导与一个跟随节点确认，构成多数派
+	if err := leader.Update(uint(1), func(a *Auction) { a.Bid = 150 }); err != nil {
+		t.Fatalf("expected a majority to acknowledge, got %v", err)
+	}
+	if got, _ := follower.Get(uint(1)); got.Bid != 150 {
+		t.Errorf("expected follower to see bid 150, got %d", got.Bid)
+	}
+
+	r.Followers = append(r.Followers, downReplica[Auction]{})
+	err := leader.Update(uint(1), func(a *Auction) { a.Bid = 200 })
+	if !errors.Is(err, ErrNoQuorum) {
+		t.Fatalf("expected ErrNoQuorum with 2 of 4 nodes, got %v", err)
+	}
+	if got, _ := leader.Get(uint(1)); got.Bid != 150 {
+		t.Errorf("failed replication must roll back the leader, got bid %d", got.Bid)
+	}
+}
+
+func TestReplicaPromotion(t *testing.T) {
+	type Auction struct {
+		ID  uint
+		Bid int
+	}
+	db := openTestDB(t, &Auction{})
+	db.Create(&Auction{ID: 1, Bid: 100})
+
+	follower := newTestCache[Auction](t, db, 10)
+	r := &LocalReplicator[Auction]{Followers: []ReplicaApplier[Auction]{follower}}
+	leader := newTestCache[Auction](t, db, 10, WithReplicator[Auction](r))
+
+	if err := leader.Update(uint(1), func(a *Auction) { a.Bid = 150 }); err != nil {
+		t.Fatal(err)
+	}
+	if err := leader.Set(uint(2), Auction{ID: 2, Bid: 50}); err != nil {
+		t.Fatal(err)
+	}
+	// 领导节点在落库前失效，跟随节点被提升后负责写入
+	if keys := follower.DirtyKeys(); len(keys) != 2 {
+		t.Fatalf("expected replicated values to be dirty on the follower, got %v", keys)
+	}
+	if err := follower.FlushAll(); err != nil {
+		t.Fatal(err)
+	}
+	var rows []Auction
+	db.Order("id").Find(&rows)
+	if len(rows) != 2 || rows[0].Bid != 150 || rows[1].Bid != 50 {
+		t.Errorf("expected the promoted follower to persist replicated writes, got %+v", rows)
+	}
+}
@@ -0,0 +1,144 @@
+package cachedb
+
+import (
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Router 使用带虚拟节点的一致性哈希把 key 映射到缓存节点
+type Router struct {
+	mu          sync.RWMutex
+	replicas    int
+	nodes       map[string]struct{}
+	ring        *hashRing
+	onRebalance []func(Rebalance)
+}
+
+// hashRing 是不可变的环快照，节点变化时整体替换
+type hashRing struct {
+	hashes []uint32
+	owners map[uint32]string
+}
+
+// Rebalance 描述一次节点变化，可用于找出需要迁移的 key
+type Rebalance struct {
+	Node   string // 加入或离开的节点
+	Joined bool   // true 表示加入，false 表示离开
+
+	before, after *hashRing
+}
+
+// Moved 返回 key 在本次变化前后的归属节点，以及归属是否发生了变化
+func (rb Rebalance) Moved(key interface{}) (from, to string, moved bool) {
+	from = rb.before.lookup(key)
+	to = rb.after.lookup(key)
+	return from, to, from != to
+}
+
+// NewRouter 创建路由器，replicas 为每个物理节点的虚拟节点数，默认 100
+func NewRouter(replicas int) *Router {
+	if replicas <= 0 {
+		replicas = 100
+	}
+	return &Router{
+		replicas: replicas,
+		nodes:    make(map[string]struct{}),
+		ring:     &hashRing{owners: map[uint32]string{}},
+	}
+}
+
+// OnRebalance 注册节点变化回调，回调在路由表更新后同步执行
+func (r *Router) OnRebalance(fn func(Rebalance)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onRebalance = append(r.onRebalance, fn)
+}
+
+// AddNode 加入节点，已存在时忽略
+func (r *Router) AddNode(node string) {
+	r.update(node, true)
+}
+
+// RemoveNode 移除节点，不存在时忽略
+func (r *Router) RemoveNode(node string) {
+	r.update(node, false)
+}
+
+func (r *Router) update(node string, join bool) {
+	r.mu.Lock()
+	_, exists := r.nodes[node]
+	if exists == join {
+		r.mu.Unlock()
+		return
+	}
+	if join {
+		r.nodes[node] = struct{}{}
+	} else {
+		delete(r.nodes, node)
+	}
+
+	before := r.ring
+	r.ring = r.build()
+	rb := Rebalance{Node: node, Joined: join, before: before, after: r.ring}
+	callbacks := append([]func(Rebalance){}, r.onRebalance...)
+	r.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(rb)
+	}
+}
+
+// build 根据当前节点集合重建哈希环，调用方需持有 r.mu
+func (r *Router) build() *hashRing {
+	ring := &hashRing{owners: make(map[uint32]string, len(r.nodes)*r.replicas)}
+	for node := range r.nodes {
+		for i := 0; i < r.replicas; i++ {
+			h := crc32.ChecksumIEEE([]byte(strconv.Itoa(i) + "#" + node))
+			// 哈希冲突时取字典序较小的节点，保证结果与插入顺序无关
+			if owner, ok := ring.owners[h]; ok && owner < node {
+				continue
+			}
+			if _, ok := ring.owners[h]; !ok {
+				ring.hashes = append(ring.hashes, h)
+			}
+			ring.owners[h] = node
+		}
+	}
+	sort.Slice(ring.hashes, func(i, j int) bool { return ring.hashes[i] < ring.hashes[j] })
+	return ring
+}
+
+// Route 返回 key 所属的节点，没有节点时返回空字符串
+func (r *Router) Route(key interface{}) string {
+	r.mu.RLock()
+	ring := r.ring
+	r.mu.RUnlock()
+	return ring.lookup(key)
+}
+
+// Nodes 返回当前所有节点，按名称排序
+func (r *Router) Nodes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	nodes := make([]string, 0, len(r.nodes))
+	for node := range r.nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+func (ring *hashRing) lookup(key interface{}) string {
+	if len(ring.hashes) == 0 {
+		return ""
+	}
+	h := crc32.ChecksumIEEE([]byte(fmt.Sprint(key)))
+	i := sort.Search(len(ring.hashes), func(i int) bool { return ring.hashes[i] >= h })
+	if i == len(ring.hashes) {
+		i = 0
+	}
+	return ring.owners[ring.hashes[i]]
+}
@@ -0,0 +1,57 @@
+package cachedb
+
+import "testing"
+
+func TestRouter(t *testing.T) {
+	r := NewRouter(50)
+	if got := r.Route(1); got != "" {
+		t.Fatalf("expected empty route without nodes, got %q", got)
+	}
+	r.AddNode("a")
+	r.AddNode("b")
+	r.AddNode("c")
+
+	counts := map[string]int{}
+	before := map[int]string{}
+	for i := 0; i < 3000; i++ {
+		node := r.Route(i)
+		counts[node]++
+		before[i] = node
+	}
+	for _, node := range r.Nodes() {
+		if counts[node] < 500 {
+			t.Errorf("node %s got too few keys: %d", node, counts[node])
+		}
+	}
+
+	var rebalanced bool
+	r.OnRebalance(func(rb Rebalance) {
+		rebalanced = true
+		if rb.Node != "d" || !rb.Joined {
+			t.Errorf("unexpected rebalance %+v", rb)
+		}
+		for i := 0; i < 3000; i++ {
+			from, to, moved := rb.Moved(i)
+			if from != before[i] {
+				t.Fatalf("key %d: from %q, want %q", i, from, before[i])
+			}
+			if moved && to != "d" {
+				t.Fatalf("key %d moved to %q, expected only moves to new node", i, to)
+			}
+		}
+	})
+	r.AddNode("d")
+	if !rebalanced {
+		t.Fatalf("expected rebalance callback")
+	}
+
+	var moved int
+	for i := 0; i < 3000; i++ {
+		if r.Route(i) != before[i] {
+			moved++
+		}
+	}
+	if moved == 0 || moved > 1500 {
+		t.Errorf("expected roughly a quarter of keys to move, got %d", moved)
+	}
+}
@@ -0,0 +1,33 @@
+package cachedb
+
+// WithSafeReads 让 Get、CachedPage、Filter 返回深拷贝而不是缓存中的对象，
+// 只读的调用方无法意外修改共享状态，修改必须通过 Update 或 Set 完成。
+// Pin 与 Scan 仍直接暴露缓存中的对象
+func WithSafeReads[T any]() Option[T] {
+	return func(c *CacheDB[T]) {
+		c.safeReads = true
+	}
+}
+
+// view 返回交给调用方的对象，开启 WithSafeReads 时为深拷贝。
+// 拷贝时持有内部锁，不会读到 Update 修改到一半的对象
+func (c *CacheDB[T]) view(value *T) (*T, error) {
+	if !c.safeReads {
+		return value, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.viewLocked(value)
+}
+
+// viewLocked 与 view 相同，调用方需持有 c.mu
+func (c *CacheDB[T]) viewLocked(value *T) (*T, error) {
+	if !c.safeReads {
+		return value, nil
+	}
+	cpy, err := c.copier(*value)
+	if err != nil {
+		return nil, err
+	}
+	return &cpy, nil
+}
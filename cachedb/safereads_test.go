@@ -0,0 +1,38 @@
+package cachedb
+
+import "testing"
+
+func TestSafeReads(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, Gold: 10})
+
+	c := newTestCache[Player](t, db, 10, WithSafeReads[Player]())
+	p, err := c.Get(1)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	p.Gold = 999 // 修改拷贝不影响缓存
+	if keys := c.DirtyKeys(); len(keys) != 0 {
+		t.Errorf("expected no dirty keys after mutating a copy, got %v", keys)
+	}
+	again, _ := c.Get(1)
+	if again == p || again.Gold != 10 {
+		t.Errorf("expected a fresh copy with gold 10, got %+v", again)
+	}
+
+	if err := c.Update(1, func(p *Player) { p.Gold = 20 }); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := c.FlushAll(); err != nil {
+		t.Fatalf("FlushAll failed: %v", err)
+	}
+	var stored Player
+	db.First(&stored, 1)
+	if stored.Gold != 20 {
+		t.Errorf("expected gold 20, got %d", stored.Gold)
+	}
+}
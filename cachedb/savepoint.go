@@ -0,0 +1,219 @@
+package cachedb
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoSavepoint 表示 key 没有进行中的保存点
+var ErrNoSavepoint = errors.New("no savepoint")
+
+// savepoint 是 Begin 时对象的快照
+type savepoint[T any] struct {
+	value  T
+	marked bool
+}
+
+// Begin 在投机性的修改（例如应用未经验证的客户端操作）之前为 key 建立保存点，之后通过 Commit 保留修改，
+// 或通过 Rollback 恢复到 Begin 时的状态，两者都不访问数据库。
+// 保存点期间 key 被固定（见 Pin）且淘汰被否决，投机性的修改不会因淘汰写回数据库，
+// 但显式的 Flush、FlushAll 仍会写入当前状态。同一个 key 同时只能有一个保存点
+func (c *CacheDB[T]) Begin(key interface{}) error {
+	if c.readOnly {
+		return ErrReadOnly
+	}
+	key = c.canonicalKey(key)
+	value, err := c.Pin(key)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	err = c.beginLocked(key, value)
+	c.mu.Unlock()
+	if err != nil {
+		c.Unpin(key)
+	}
+	return err
+}
+
+// beginLocked 记录 value 的快照，调用方需持有 c.mu
+func (c *CacheDB[T]) beginLocked(key interface{}, value *T) error {
+	if _, ok := c.savepoints[key]; ok {
+		return fmt.Errorf("key %v already has a savepoint", key)
+	}
+	cpy, err := c.copier(*value)
+	if err != nil {
+		return fmt.Errorf("failed to copy key %v: %w", key, err)
+	}
+	if c.savepoints == nil {
+		c.savepoints = make(map[interface{}]*savepoint[T])
+	}
+	sp := &savepoint[T]{value: cpy}
+	if m := c.meta[key]; m != nil {
+		sp.marked = m.marked
+	}
+	c.savepoints[key] = sp
+	c.openSavepoints.Add(1)
+	return nil
+}
+
+// Commit 结束 key 的保存点并保留期间的修改，修改按正常流程落库
+func (c *CacheDB[T]) Commit(key interface{}) error {
+	key = c.canonicalKey(key)
+	c.mu.Lock()
+	_, ok := c.savepoints[key]
+	c.endSavepoint(key)
+	c.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: key %v", ErrNoSavepoint, key)
+	}
+	c.Unpin(key)
+	return nil
+}
+
+// Rollback 把 key 原地恢复到 Begin 时的状态并结束保存点，持有该指针的调用方能看到恢复后的值。
+// 期间对象被 Delete 时返回 ErrNotFound
+func (c *CacheDB[T]) Rollback(key interface{}) error {
+	key = c.canonicalKey(key)
+	c.mu.Lock()
+	sp, ok := c.savepoints[key]
+	c.endSavepoint(key)
+	if !ok {
+		c.mu.Unlock()
+		return fmt.Errorf("%w: key %v", ErrNoSavepoint, key)
+	}
+	err := c.restoreLocked(key, sp)
+	value := c.values[key]
+	c.mu.Unlock()
+	c.Unpin(key)
+	if value == nil {
+		return fmt.Errorf("failed to roll back: %w: key %v deleted", ErrNotFound, key)
+	}
+	return err
+}
+
+// endSavepoint 删除 key 的保存点，调用方需持有 c.mu
+func (c *CacheDB[T]) endSavepoint(key interface{}) {
+	if _, ok := c.savepoints[key]; ok {
+		delete(c.savepoints, key)
+		c.openSavepoints.Add(-1)
+	}
+}
+
+// restoreLocked 把追踪的对象恢复为 sp 的快照并经复制器提交，对象已不再追踪时不做处理，调用方需持有 c.mu
+func (c *CacheDB[T]) restoreLocked(key interface{}, sp *savepoint[T]) error {
+	value, ok := c.values[key]
+	if !ok {
+		return nil
+	}
+	*value = sp.value
+	// 快照之后没有落库时，副本仍与快照一致，比较即可判断是否为脏；期间落过库则比较会发现差异
+	c.meta[key].marked = sp.marked
+	c.bump(key)
+	c.retag(key, value)
+	return c.replicateLocked(key, value, nil)
+}
+
+// Savepoint 是覆盖多个 key 的保存点，由 BeginAll 创建
+type Savepoint[T any] struct {
+	c    *CacheDB[T]
+	keys []interface{}
+	done bool
+}
+
+// BeginAll 为 keys 一起建立保存点（例如对决中的双方），任一 key 失败时不建立任何保存点。
+// 所有快照在同一次加锁中生成，之后通过 Savepoint 的 Commit 或 Rollback 一起结束，
+// 任一参与者的修改失败时可以把所有参与者一致地恢复。各 key 的行为与 Begin 相同
+func (c *CacheDB[T]) BeginAll(keys ...interface{}) (*Savepoint[T], error) {
+	if c.readOnly {
+		return nil, ErrReadOnly
+	}
+	sp := &Savepoint[T]{c: c}
+	values := make([]*T, 0, len(keys))
+	seen := make(map[interface{}]bool, len(keys))
+	for _, key := range keys {
+		key = c.canonicalKey(key)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		value, err := c.Pin(key)
+		if err != nil {
+			sp.unpin()
+			return nil, err
+		}
+		sp.keys = append(sp.keys, key)
+		values = append(values, value)
+	}
+
+	c.mu.Lock()
+	for i, key := range sp.keys {
+		if err := c.beginLocked(key, values[i]); err != nil {
+			for _, started := range sp.keys[:i] {
+				c.endSavepoint(started)
+			}
+			c.mu.Unlock()
+			sp.unpin()
+			return nil, err
+		}
+	}
+	c.mu.Unlock()
+	return sp, nil
+}
+
+// Keys 返回保存点覆盖的 key
+func (s *Savepoint[T]) Keys() []interface{} {
+	return s.keys
+}
+
+// Commit 结束保存点并保留所有 key 的修改
+func (s *Savepoint[T]) Commit() error {
+	if s.done {
+		return ErrNoSavepoint
+	}
+	s.done = true
+	s.c.mu.Lock()
+	for _, key := range s.keys {
+		s.c.endSavepoint(key)
+	}
+	s.c.mu.Unlock()
+	s.unpin()
+	return nil
+}
+
+// Rollback 在同一次加锁中把所有 key 恢复到 BeginAll 时的状态并结束保存点。
+// 期间有 key 被 Delete 时其余 key 照常恢复，返回 ErrNotFound
+func (s *Savepoint[T]) Rollback() error {
+	if s.done {
+		return ErrNoSavepoint
+	}
+	s.done = true
+	c := s.c
+	var missing []interface{}
+	var err error
+	c.mu.Lock()
+	for _, key := range s.keys {
+		if sp, ok := c.savepoints[key]; ok {
+			if rerr := c.restoreLocked(key, sp); rerr != nil && err == nil {
+				err = rerr
+			}
+			c.endSavepoint(key)
+		}
+		if _, ok := c.values[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	c.mu.Unlock()
+	s.unpin()
+	if len(missing) > 0 {
+		return fmt.Errorf("failed to roll back: %w: keys %v deleted", ErrNotFound, missing)
+	}
+	return err
+}
+
+// unpin 释放 BeginAll 固定的 key
+func (s *Savepoint[T]) unpin() {
+	for _, key := range s.keys {
+		s.c.Unpin(key)
+	}
+}
@@ -0,0 +1,109 @@
+package cachedb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSavepoint(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+		HP   int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, Gold: 10, HP: 100})
+	db.Create(&Player{ID: 2})
+
+	c := newTestCache[Player](t, db, 1)
+	p, _ := c.Get(1)
+	if err := c.Begin(1); err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	if err := c.Begin(1); err == nil {
+		t.Error("expected a second savepoint on the same key to be rejected")
+	}
+	c.Update(1, func(p *Player) { p.Gold = 999; p.HP = 0 }) // 未经验证的操作
+	c.Get(2)                                                // 容量为 1，保存点期间不会被淘汰写回
+	var stored Player
+	db.First(&stored, 1)
+	if stored.Gold != 10 {
+		t.Errorf("expected speculative changes to stay out of the database, got gold %d", stored.Gold)
+	}
+	if err := c.Rollback(1); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if p.Gold != 10 || p.HP != 100 {
+		t.Errorf("expected the entity to be restored in place, got %+v", *p)
+	}
+	if keys := c.DirtyKeys(); len(keys) != 0 {
+		t.Errorf("expected no dirty keys after rollback, got %v", keys)
+	}
+	if err := c.Rollback(1); !errors.Is(err, ErrNoSavepoint) {
+		t.Errorf("expected ErrNoSavepoint, got %v", err)
+	}
+
+	// Commit 保留修改
+	c.Begin(1)
+	c.Update(1, func(p *Player) { p.Gold = 20 })
+	if err := c.Commit(1); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if err := c.FlushAll(); err != nil {
+		t.Fatal(err)
+	}
+	db.First(&stored, 1)
+	if stored.Gold != 20 {
+		t.Errorf("expected committed gold 20, got %d", stored.Gold)
+	}
+	if info := c.EntryInfo(1); info.Pinned {
+		t.Error("expected the key to be unpinned after commit")
+	}
+}
+
+func TestSavepointMultiKey(t *testing.T) {
+	type Player struct {
+		ID uint
+		HP int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, HP: 100})
+	db.Create(&Player{ID: 2, HP: 100})
+
+	c := newTestCache[Player](t, db, 10)
+	if err := c.Begin(2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.BeginAll(1, 2); err == nil {
+		t.Error("expected BeginAll to fail when a participant already has a savepoint")
+	}
+	if err := c.Begin(1); err != nil {
+		t.Errorf("expected a failed BeginAll to leave no savepoint behind, got %v", err)
+	}
+	c.Commit(1)
+	c.Commit(2)
+
+	duel, err := c.BeginAll(1, 2, uint(1))
+	if err != nil {
+		t.Fatalf("BeginAll failed: %v", err)
+	}
+	if n := len(duel.Keys()); n != 2 {
+		t.Errorf("expected duplicate keys to be merged, got %d keys", n)
+	}
+	c.Update(1, func(p *Player) { p.HP -= 30 })
+	c.Update(2, func(p *Player) { p.HP -= 50 })
+	if err := duel.Rollback(); err != nil { // 其中一方的结算失败
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	for _, id := range []uint{1, 2} {
+		if p, _ := c.Get(id); p.HP != 100 {
+			t.Errorf("expected player %d to be restored, got HP %d", id, p.HP)
+		}
+	}
+	if err := duel.Commit(); !errors.Is(err, ErrNoSavepoint) {
+		t.Errorf("expected a finished savepoint to reject Commit, got %v", err)
+	}
+	if keys := c.DirtyKeys(); len(keys) != 0 {
+		t.Errorf("expected no dirty keys, got %v", keys)
+	}
+}
@@ -0,0 +1,178 @@
+package cachedb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// WithKeyField 指定作为缓存 key 的字段（Go 字段名或列名）。
+// 默认使用 gorm 解析出的主键（遵循 primaryKey 标签），复合主键或以唯一列作为 key 的模型需要显式指定
+func WithKeyField[T any](name string) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.keyName = name
+	}
+}
+
+// parseSchema 解析模型的 gorm schema。db 为 nil（例如只使用自定义 Store）时按默认命名规则解析
+func (c *CacheDB[T]) parseSchema() (*schema.Schema, error) {
+	if c.db == nil {
+		sch, err := schema.Parse(new(T), &sync.Map{}, schema.NamingStrategy{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse model: %w", err)
+		}
+		return sch, nil
+	}
+	stmt := &gorm.Statement{DB: c.db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, fmt.Errorf("failed to parse model: %w", err)
+	}
+	return stmt.Schema, nil
+}
+
+// resolveKeyField 解析 schema 并确定 key 字段
+func (c *CacheDB[T]) resolveKeyField() error {
+	sch, err := c.parseSchema()
+	if err != nil {
+		return err
+	}
+
+	var field *schema.Field
+	switch {
+	case c.keyName != "":
+		if field = sch.LookUpField(c.keyName); field == nil {
+			return fmt.Errorf("model %s has no field %q", sch.Name, c.keyName)
+		}
+	case sch.PrioritizedPrimaryField != nil:
+		field = sch.PrioritizedPrimaryField
+	case len(sch.PrimaryFields) > 1:
+		return fmt.Errorf("model %s has a composite primary key; choose a key with WithKeyField", sch.Name)
+	default:
+		return fmt.Errorf("model %s has no primary key", sch.Name)
+	}
+
+	c.schema = sch
+	c.keyField = field
+	return nil
+}
+
+// keyCondition 返回按 key 定位一行的查询条件
+func (c *CacheDB[T]) keyCondition(key interface{}) clause.Expression {
+	return clause.Eq{
+		Column: clause.Column{Table: clause.CurrentTable, Name: c.keyField.DBName},
+		Value:  key,
+	}
+}
+
+// keyOf 从对象中取出 key
+func (c *CacheDB[T]) keyOf(value *T) interface{} {
+	key, _ := c.keyField.ValueOf(context.Background(), reflect.ValueOf(value).Elem())
+	return key
+}
+
+// ParseKey 把外部传入的字符串 key（例如来自 HTTP 请求）转换为 key 字段的类型
+func (c *CacheDB[T]) ParseKey(s string) (interface{}, error) {
+	return parseKey(c.keyField.FieldType, s)
+}
+
+// parseKey 把字符串 s 转换为 typ 类型的 key，见 ParseKey
+func parseKey(typ reflect.Type, s string) (interface{}, error) {
+	v := reflect.New(typ).Elem()
+	switch typ.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, typ.Bits())
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", s, err)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, typ.Bits())
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", s, err)
+		}
+		v.SetUint(n)
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", typ)
+	}
+	return v.Interface(), nil
+}
+
+// normalizeKey 把 key 转换为 key 字段的类型，避免 uint(1) 与 int(1) 被当作两个 key 分别缓存。
+// 数值之间按值转换（溢出时报错），字符串会按 ParseKey 解析，其他类型原样返回
+func (c *CacheDB[T]) normalizeKey(key interface{}) (interface{}, error) {
+	return normalizeKeyTo(c.keyField.FieldType, key)
+}
+
+// normalizeKeyTo 把 key 转换为 typ 类型，规则见 normalizeKey，供没有 CacheDB 的缓存（例如 ConfigCache）使用
+func normalizeKeyTo(typ reflect.Type, key interface{}) (interface{}, error) {
+	if key == nil {
+		return nil, fmt.Errorf("nil key")
+	}
+	v := reflect.ValueOf(key)
+	if v.Type() == typ {
+		return key, nil
+	}
+
+	out := reflect.New(typ).Elem()
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if out.OverflowInt(v.Int()) {
+				return nil, fmt.Errorf("key %v overflows %s", key, typ)
+			}
+			out.SetInt(v.Int())
+			return out.Interface(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if v.Uint() > math.MaxInt64 || out.OverflowInt(int64(v.Uint())) {
+				return nil, fmt.Errorf("key %v overflows %s", key, typ)
+			}
+			out.SetInt(int64(v.Uint()))
+			return out.Interface(), nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if v.Int() < 0 || out.OverflowUint(uint64(v.Int())) {
+				return nil, fmt.Errorf("key %v overflows %s", key, typ)
+			}
+			out.SetUint(uint64(v.Int()))
+			return out.Interface(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if out.OverflowUint(v.Uint()) {
+				return nil, fmt.Errorf("key %v overflows %s", key, typ)
+			}
+			out.SetUint(v.Uint())
+			return out.Interface(), nil
+		}
+	case reflect.String:
+		if v.Kind() == reflect.String {
+			out.SetString(v.String())
+			return out.Interface(), nil
+		}
+	default:
+		return key, nil
+	}
+
+	if v.Kind() == reflect.String {
+		return parseKey(typ, v.String())
+	}
+	return nil, fmt.Errorf("key %v of type %T does not match key field type %s", key, key, typ)
+}
+
+// canonicalKey 与 normalizeKey 相同，但无法转换时原样返回，供不返回错误的方法使用
+func (c *CacheDB[T]) canonicalKey(key interface{}) interface{} {
+	if k, err := c.normalizeKey(key); err == nil {
+		return k
+	}
+	return key
+}
@@ -0,0 +1,126 @@
+package cachedb
+
+import "testing"
+
+func TestStringPrimaryKey(t *testing.T) {
+	type Item struct {
+		Code  string `gorm:"primaryKey"`
+		Price int
+	}
+	db := openTestDB(t, &Item{})
+	db.Create(&Item{Code: "sword", Price: 100})
+	db.Create(&Item{Code: "shield", Price: 80})
+
+	cache := newTestCache[Item](t, db, 10)
+	item, err := cache.Get("sword")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	item.Price = 120
+	if err := cache.Flush("sword"); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	var stored Item
+	db.First(&stored, "code = ?", "shield")
+	if stored.Price != 80 {
+		t.Errorf("unrelated row was modified: %+v", stored)
+	}
+	var saved Item
+	db.First(&saved, "code = ?", "sword")
+	if saved.Price != 120 {
+		t.Errorf("expected price 120, got %d", saved.Price)
+	}
+
+	key, err := cache.ParseKey("shield")
+	if err != nil || key != "shield" {
+		t.Errorf("ParseKey = %v, %v", key, err)
+	}
+	if err := cache.Delete("shield"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	var count int64
+	db.Model(&Item{}).Count(&count)
+	if count != 1 {
+		t.Errorf("expected 1 row after delete, got %d", count)
+	}
+}
+
+func TestWithKeyField(t *testing.T) {
+	type Membership struct {
+		GuildID  uint   `gorm:"primaryKey"`
+		PlayerID uint   `gorm:"primaryKey"`
+		Ticket   string `gorm:"uniqueIndex"`
+		Role     string
+	}
+	db := openTestDB(t, &Membership{})
+	db.Create(&Membership{GuildID: 1, PlayerID: 2, Ticket: "t-1", Role: "member"})
+
+	if _, err := NewWithCache[Membership](db, 10); err == nil {
+		t.Errorf("expected error for composite primary key without WithKeyField")
+	}
+	if _, err := NewWithCache[Membership](db, 10, WithKeyField[Membership]("Missing")); err == nil {
+		t.Errorf("expected error for unknown key field")
+	}
+
+	cache := newTestCache[Membership](t, db, 10, WithKeyField[Membership]("ticket"))
+	m, err := cache.Get("t-1")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	m.Role = "officer"
+	if err := cache.Flush("t-1"); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	var stored Membership
+	db.First(&stored, "ticket = ?", "t-1")
+	if stored.Role != "officer" {
+		t.Errorf("expected role officer, got %q", stored.Role)
+	}
+}
+
+func TestKeyNormalization(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, Gold: 10})
+
+	cache := newTestCache[Player](t, db, 10)
+	a, err := cache.Get(uint(1))
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	b, err := cache.Get(1)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	c, err := cache.Get("1")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if a != b || a != c {
+		t.Errorf("expected the same cached object for uint, int and string keys")
+	}
+	if n := cache.Cache.Len(false); n != 1 {
+		t.Errorf("expected 1 cache entry, got %d", n)
+	}
+
+	b.Gold = 20
+	if err := cache.Flush(int64(1)); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	var stored Player
+	db.First(&stored, 1)
+	if stored.Gold != 20 {
+		t.Errorf("expected gold 20, got %d", stored.Gold)
+	}
+
+	if _, err := cache.Get(-1); err == nil {
+		t.Errorf("expected error for negative key")
+	}
+	if _, err := cache.Get(1.5); err == nil {
+		t.Errorf("expected error for float key")
+	}
+}
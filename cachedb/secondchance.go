@@ -0,0 +1,33 @@
+package cachedb
+
+// WithDirtySecondChance 让容量淘汰优先选择干净的对象：脏对象第一次被淘汰时放回缓存，
+// 由下一个最久未访问的对象代替，脏数据因此留在内存中等待 Flush 等受控的落库路径写回。
+// 同一份修改只会获得一次机会，再次被淘汰时正常回写；落库后重新获得机会
+func WithDirtySecondChance[T any]() Option[T] {
+	return func(c *CacheDB[T]) {
+		c.secondChance = true
+	}
+}
+
+// spare 判断脏对象是否获得第二次机会并记录已使用，调用方需持有 c.mu
+func (c *CacheDB[T]) spare(key interface{}, value *T) bool {
+	m := c.meta[key]
+	if !c.secondChance || m == nil || m.spared || !c.modified(key, value) {
+		return false
+	}
+	m.spared = true
+	c.counters.secondChances.Add(1)
+	return true
+}
+
+// forgoChance 让主动淘汰的 key 不再获得第二次机会
+func (c *CacheDB[T]) forgoChance(key interface{}) {
+	if !c.secondChance {
+		return
+	}
+	c.mu.Lock()
+	if m := c.meta[key]; m != nil {
+		m.spared = true
+	}
+	c.mu.Unlock()
+}
@@ -0,0 +1,68 @@
+package cachedb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDirtySecondChance(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	for id := uint(1); id <= 4; id++ {
+		db.Create(&Player{ID: id})
+	}
+	c := newTestCache[Player](t, db, 2, WithDirtySecondChance[Player]())
+
+	waitCached := func(key uint) bool {
+		deadline := time.Now().Add(time.Second)
+		for !c.Cache.Has(key) && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		return c.Cache.Has(key)
+	}
+
+	c.Update(1, func(p *Player) { p.Gold = 100 })
+	c.Get(2)
+	c.Get(3) // 脏的 1 获得第二次机会，干净的 2 被淘汰
+	if !waitCached(1) {
+		t.Fatal("expected the dirty entry to be kept")
+	}
+	if c.Cache.Has(uint(2)) {
+		t.Error("expected the clean entry to be evicted instead")
+	}
+	var stored Player
+	db.First(&stored, 1)
+	if stored.Gold != 0 {
+		t.Errorf("expected no forced write-back, got gold %d", stored.Gold)
+	}
+	if n := c.Stats().SecondChances; n != 1 {
+		t.Errorf("expected 1 second chance, got %d", n)
+	}
+
+	// 机会已用完，再次被淘汰时正常回写
+	c.Get(3)
+	c.Get(4)
+	time.Sleep(10 * time.Millisecond)
+	if c.Cache.Has(uint(1)) {
+		t.Error("expected the entry to be evicted on its second eviction")
+	}
+	db.First(&stored, 1)
+	if stored.Gold != 100 {
+		t.Errorf("expected the entry to be written back, got gold %d", stored.Gold)
+	}
+
+	// 主动淘汰不受影响
+	c.Update(2, func(p *Player) { p.Gold = 50 })
+	c.EvictNow(2)
+	if c.Cache.Has(uint(2)) {
+		t.Error("expected EvictNow to evict a dirty entry directly")
+	}
+	var second Player
+	db.First(&second, 2)
+	if second.Gold != 50 {
+		t.Errorf("expected EvictNow to write back, got gold %d", second.Gold)
+	}
+}
@@ -0,0 +1,75 @@
+package cachedb
+
+import (
+	"time"
+
+	"github.com/bluele/gcache"
+)
+
+// EntityCache 是 CacheDB 与 SessionCache 共有的读写接口，
+// 游戏逻辑依赖它即可在有无数据库支撑的缓存之间切换
+type EntityCache[T any] interface {
+	Get(key interface{}) (*T, error)
+	Set(key interface{}, value T) error
+}
+
+var (
+	_ EntityCache[struct{}] = (*CacheDB[struct{}])(nil)
+	_ EntityCache[struct{}] = (*SessionCache[struct{}])(nil)
+)
+
+// SessionCache 是没有数据库支撑的纯内存缓存，用于匹配票据、会话令牌等短期数据
+type SessionCache[T any] struct {
+	Cache gcache.Cache
+	ttl   time.Duration
+}
+
+// NewSessionCache 创建会话缓存，ttl 为默认有效期
+func NewSessionCache[T any](size int, ttl time.Duration) *SessionCache[T] {
+	return &SessionCache[T]{
+		Cache: gcache.New(size).LRU().Expiration(ttl).Build(),
+		ttl:   ttl,
+	}
+}
+
+// Get 获取未过期的值，不存在时返回 gcache.KeyNotFoundError
+func (s *SessionCache[T]) Get(key interface{}) (*T, error) {
+	val, err := s.Cache.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return val.(*T), nil
+}
+
+// Set 以默认有效期设置值
+func (s *SessionCache[T]) Set(key interface{}, value T) error {
+	return s.Cache.Set(key, &value)
+}
+
+// SetWithTTL 以指定有效期设置值
+func (s *SessionCache[T]) SetWithTTL(key interface{}, value T, ttl time.Duration) error {
+	return s.Cache.SetWithExpire(key, &value, ttl)
+}
+
+// Take 取出并删除值，适合一次性票据。并发 Take 同一个 key 时只有删除成功的一方得到值，
+// 其余返回 ErrNotFound
+func (s *SessionCache[T]) Take(key interface{}) (*T, error) {
+	v, err := s.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if !s.Cache.Remove(key) {
+		return nil, ErrNotFound
+	}
+	return v, nil
+}
+
+// Remove 删除值
+func (s *SessionCache[T]) Remove(key interface{}) bool {
+	return s.Cache.Remove(key)
+}
+
+// Len 返回未过期的条目数
+func (s *SessionCache[T]) Len() int {
+	return s.Cache.Len(true)
+}
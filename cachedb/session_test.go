@@ -0,0 +1,62 @@
+package cachedb
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSessionCache(t *testing.T) {
+	type Ticket struct {
+		Player string
+		Mode   string
+	}
+	var c EntityCache[Ticket] = NewSessionCache[Ticket](10, time.Minute)
+	if err := c.Set("t1", Ticket{Player: "alice", Mode: "ranked"}); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	got, err := c.Get("t1")
+	if err != nil || got.Player != "alice" {
+		t.Fatalf("unexpected ticket %+v (%v)", got, err)
+	}
+
+	s := c.(*SessionCache[Ticket])
+	if _, err := s.Take("t1"); err != nil {
+		t.Fatalf("take failed: %v", err)
+	}
+	if _, err := s.Get("t1"); err == nil {
+		t.Errorf("expected ticket consumed by Take")
+	}
+
+	s.SetWithTTL("t2", Ticket{Player: "bob"}, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, err := s.Get("t2"); err == nil {
+		t.Errorf("expected ticket expired")
+	}
+	if s.Len() != 0 {
+		t.Errorf("expected empty cache, got %d", s.Len())
+	}
+}
+
+func TestSessionTakeOnce(t *testing.T) {
+	s := NewSessionCache[string](10, time.Minute)
+	for round := 0; round < 100; round++ {
+		s.Set("ticket", "match")
+		var wg sync.WaitGroup
+		var taken atomic.Int32
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := s.Take("ticket"); err == nil {
+					taken.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		if n := taken.Load(); n != 1 {
+			t.Fatalf("expected exactly one Take to succeed, got %d", n)
+		}
+	}
+}
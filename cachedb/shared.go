@@ -0,0 +1,162 @@
+package cachedb
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrNotWriter 表示调用方不是共享对象当前的写入者
+var ErrNotWriter = errors.New("session is not the writer of this entity")
+
+// sharedState 是一个共享对象的成员与写入者信息
+type sharedState[T any] struct {
+	writer  string
+	members map[string]func(key interface{}, value T)
+}
+
+// SharedCache 管理被多个玩家引用的共享对象（公会、队伍）：
+// 成员加入时固定对象，同一时刻只有一个写入者，修改后通知所有成员会话
+type SharedCache[T any] struct {
+	cache *CacheDB[T]
+
+	mu     sync.Mutex
+	shared map[interface{}]*sharedState[T]
+}
+
+// NewSharedCache 基于 cache 创建共享对象缓存
+func NewSharedCache[T any](cache *CacheDB[T]) *SharedCache[T] {
+	return &SharedCache[T]{
+		cache:  cache,
+		shared: make(map[interface{}]*sharedState[T]),
+	}
+}
+
+// Join 让会话加入共享对象并订阅变更，对象在有成员期间保持固定。
+// notify 收到的是修改后的深拷贝，可以安全地跨协程使用
+func (s *SharedCache[T]) Join(key interface{}, session string, notify func(key interface{}, value T)) (*T, error) {
+	key = s.cache.canonicalKey(key)
+	value, err := s.cache.Pin(key)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.shared[key]
+	if !ok {
+		st = &sharedState[T]{members: make(map[string]func(interface{}, T))}
+		s.shared[key] = st
+	}
+	if _, joined := st.members[session]; joined {
+		s.cache.Unpin(key) // 重复加入只保留一次固定
+	}
+	st.members[session] = notify
+	return value, nil
+}
+
+// Leave 让会话离开共享对象，若它是写入者则同时释放写权限
+func (s *SharedCache[T]) Leave(key interface{}, session string) {
+	key = s.cache.canonicalKey(key)
+	s.mu.Lock()
+	st, ok := s.shared[key]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	if _, joined := st.members[session]; !joined {
+		s.mu.Unlock()
+		return
+	}
+	delete(st.members, session)
+	if st.writer == session {
+		st.writer = ""
+	}
+	if len(st.members) == 0 {
+		delete(s.shared, key)
+	}
+	s.mu.Unlock()
+
+	s.cache.Unpin(key)
+}
+
+// ClaimWriter 申请成为写入者，已有其他写入者时返回 ErrNotWriter
+func (s *SharedCache[T]) ClaimWriter(key interface{}, session string) error {
+	key = s.cache.canonicalKey(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.shared[key]
+	if !ok {
+		return ErrNotWriter
+	}
+	if _, joined := st.members[session]; !joined {
+		return ErrNotWriter
+	}
+	if st.writer != "" && st.writer != session {
+		return ErrNotWriter
+	}
+	st.writer = session
+	return nil
+}
+
+// ReleaseWriter 释放写权限
+func (s *SharedCache[T]) ReleaseWriter(key interface{}, session string) {
+	key = s.cache.canonicalKey(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.shared[key]; ok && st.writer == session {
+		st.writer = ""
+	}
+}
+
+// Update 由写入者修改共享对象，完成后把新值通知给所有成员
+func (s *SharedCache[T]) Update(key interface{}, session string, fn func(*T)) error {
+	key = s.cache.canonicalKey(key)
+	s.mu.Lock()
+	st, ok := s.shared[key]
+	if !ok || st.writer != session {
+		s.mu.Unlock()
+		return ErrNotWriter
+	}
+	// 经由缓存的 Update 修改，与其他写入路径一样加锁、标记为脏并递增代数
+	var snapshot T
+	var copyErr error
+	err := s.cache.Update(key, func(value *T) {
+		fn(value)
+		snapshot, copyErr = s.cache.copier(*value)
+	})
+	if err == nil {
+		err = copyErr
+	}
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	notifies := make([]func(interface{}, T), 0, len(st.members))
+	for _, notify := range st.members {
+		if notify != nil {
+			notifies = append(notifies, notify)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, notify := range notifies {
+		notify(key, snapshot)
+	}
+	return nil
+}
+
+// Members 返回共享对象当前的成员会话
+func (s *SharedCache[T]) Members(key interface{}) []string {
+	key = s.cache.canonicalKey(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.shared[key]
+	if !ok {
+		return nil
+	}
+	out := make([]string, 0, len(st.members))
+	for session := range st.members {
+		out = append(out, session)
+	}
+	return out
+}
@@ -0,0 +1,72 @@
+package cachedb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSharedCache(t *testing.T) {
+	type Guild struct {
+		ID     uint
+		Notice string
+	}
+	db := openTestDB(t, &Guild{})
+	db.Create(&Guild{ID: 1, Notice: "hello"})
+	db.Create(&Guild{ID: 2, Notice: "other"})
+
+	c := newTestCache[Guild](t, db, 1)
+	s := NewSharedCache(c)
+
+	got := map[string]string{}
+	notify := func(session string) func(interface{}, Guild) {
+		return func(key interface{}, g Guild) { got[session] = g.Notice }
+	}
+	g, err := s.Join(uint(1), "alice", notify("alice"))
+	if err != nil {
+		t.Fatalf("join failed: %v", err)
+	}
+	if _, err := s.Join(uint(1), "bob", notify("bob")); err != nil {
+		t.Fatalf("join failed: %v", err)
+	}
+
+	if err := s.ClaimWriter(uint(1), "alice"); err != nil {
+		t.Fatalf("claim failed: %v", err)
+	}
+	if err := s.ClaimWriter(uint(1), "bob"); !errors.Is(err, ErrNotWriter) {
+		t.Fatalf("expected second writer rejected, got %v", err)
+	}
+	if err := s.Update(uint(1), "bob", func(*Guild) {}); !errors.Is(err, ErrNotWriter) {
+		t.Fatalf("expected non-writer update rejected, got %v", err)
+	}
+
+	// 容量为 1，加载另一个 key 会淘汰被固定的公会
+	if _, err := c.Get(uint(2)); err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	gen, _ := c.Generation(uint(1))
+	if err := s.Update(uint(1), "alice", func(g *Guild) { g.Notice = "raid tonight" }); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if got["alice"] != "raid tonight" || got["bob"] != "raid tonight" {
+		t.Errorf("expected fan-out to all members, got %v", got)
+	}
+	if after, _ := c.Generation(uint(1)); after <= gen {
+		t.Errorf("expected the update to bump the generation, got %d after %d", after, gen)
+	}
+	if keys := c.DirtyKeys(); len(keys) != 1 {
+		t.Errorf("expected the shared entity to be dirty, got %v", keys)
+	}
+	if again, _ := c.Get(uint(1)); again != g {
+		t.Errorf("pinned entity should keep the same pointer after eviction")
+	}
+
+	s.Leave(uint(1), "alice")
+	s.Leave(uint(1), "bob")
+	c.Cache.Purge()
+
+	var row Guild
+	db.First(&row, 1)
+	if row.Notice != "raid tonight" {
+		t.Errorf("expected notice persisted, got %q", row.Notice)
+	}
+}
@@ -0,0 +1,177 @@
+package cachedb
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ShedConfig 描述基于连接池等待情况的降载策略
+type ShedConfig struct {
+	// WaitThreshold 是一个采样周期内新增的连接等待次数，达到该值即开启降载，必须大于 0
+	WaitThreshold int64
+	// Interval 是采样周期，默认 1 秒
+	Interval time.Duration
+	// MaxStale 是降载期间过期对象最多可继续使用多久，超过后仍从数据库重新加载。0 表示不限制
+	MaxStale time.Duration
+	// Stats 返回连接池统计，默认使用缓存所在数据库的 sql.DB.Stats
+	Stats func() sql.DBStats
+	// MaxDeferred 是降载期间最多推迟回写、保留在缓存外的对象数，超过后按正常流程回写并丢弃，
+	// 避免持续降载时内存无限增长。默认与缓存容量相同
+	MaxDeferred int
+}
+
+// shedState 记录最近一次采样的结果
+type shedState struct {
+	cfg ShedConfig
+
+	mu       sync.Mutex // 保护 last 和 lastWait
+	last     time.Time
+	lastWait int64
+	active   atomic.Bool
+
+	held map[interface{}]struct{} // 推迟回写而保留的 key，由 c.mu 保护
+}
+
+// WithLoadShedding 在连接池出现排队时降载：缓存过期的对象暂不回写也不丢弃，
+// 再次访问时直接使用内存中的旧值而不查询数据库；推迟的修改由 FlushAll 或检查点写回。
+// 降载状态和计数见 Stats
+func WithLoadShedding[T any](cfg ShedConfig) Option[T] {
+	return func(c *CacheDB[T]) {
+		if cfg.Interval <= 0 {
+			cfg.Interval = time.Second
+		}
+		c.shed = &shedState{cfg: cfg, held: make(map[interface{}]struct{})}
+	}
+}
+
+// check 在构造时检查降载配置，size 为缓存容量
+func (s *shedState) check(size int) error {
+	if s.cfg.WaitThreshold <= 0 {
+		return fmt.Errorf("WithLoadShedding: WaitThreshold must be positive, got %d", s.cfg.WaitThreshold)
+	}
+	if s.cfg.MaxDeferred < 0 {
+		return fmt.Errorf("WithLoadShedding: negative MaxDeferred %d", s.cfg.MaxDeferred)
+	}
+	if s.cfg.MaxDeferred == 0 {
+		s.cfg.MaxDeferred = size
+	}
+	return nil
+}
+
+// Shedding 报告当前是否处于降载状态
+func (c *CacheDB[T]) Shedding() bool {
+	if c.shed == nil {
+		return false
+	}
+	c.sampleShed()
+	return c.shed.active.Load()
+}
+
+// sampleShed 每个采样周期读取一次连接池统计并更新降载状态
+func (c *CacheDB[T]) sampleShed() {
+	s := c.shed
+	now := c.clock.Now()
+	s.mu.Lock()
+	if !s.last.IsZero() && now.Sub(s.last) < s.cfg.Interval {
+		s.mu.Unlock()
+		return
+	}
+	stats, ok := c.poolStats()
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	first := s.last.IsZero()
+	delta := stats.WaitCount - s.lastWait
+	s.last, s.lastWait = now, stats.WaitCount
+	s.mu.Unlock()
+	if first {
+		return
+	}
+
+	active := delta >= s.cfg.WaitThreshold
+	if s.active.Swap(active) != active {
+		if active {
+			c.log(LogWarn, "Load shedding started", "waits", delta, "wait_duration", stats.WaitDuration)
+		} else {
+			c.log(LogInfo, "Load shedding stopped")
+			c.releaseShed()
+		}
+	}
+}
+
+// poolStats 返回连接池统计
+func (c *CacheDB[T]) poolStats() (sql.DBStats, bool) {
+	if c.shed.cfg.Stats != nil {
+		return c.shed.cfg.Stats(), true
+	}
+	if c.db == nil {
+		return sql.DBStats{}, false
+	}
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return sql.DBStats{}, false
+	}
+	return sqlDB.Stats(), true
+}
+
+// shedEvict 在降载期间保留被淘汰的对象，返回 false 表示按正常流程回写。
+// 已保留 MaxDeferred 个对象时不再推迟
+func (c *CacheDB[T]) shedEvict(key, value interface{}) bool {
+	if !c.Shedding() {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.current(key, value) || c.resident(key) {
+		return false
+	}
+	if len(c.shed.held) >= c.shed.cfg.MaxDeferred {
+		return false
+	}
+	m := c.meta[key]
+	m.detached = true
+	m.shed = true
+	c.shed.held[key] = struct{}{}
+	c.counters.shedFlushes.Add(1)
+	c.logEvent(EventEvict, LogDebug, "Deferred eviction while shedding", "key", key)
+	return true
+}
+
+// releaseShed 在降载结束后停止追踪保留下来的干净对象，有修改的对象仍等待 FlushAll 写回
+func (c *CacheDB[T]) releaseShed() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, m := range c.meta {
+		if m.shed && c.equal(c.copies[key], *c.values[key]) {
+			c.untrack(key)
+		}
+	}
+}
+
+// reuseShed 判断降载期间保留的对象能否直接放回缓存，过旧的干净对象会停止追踪以便重新加载，调用方需持有 c.mu
+func (c *CacheDB[T]) reuseShed(key interface{}) bool {
+	m := c.meta[key]
+	if !m.shed {
+		return true
+	}
+	maxStale := c.shed.cfg.MaxStale
+	if maxStale > 0 && c.clock.Now().Sub(m.since) > maxStale && c.equal(c.copies[key], *c.values[key]) {
+		c.untrack(key)
+		return false
+	}
+	m.shed = false
+	c.unshed(key)
+	c.counters.shedLoads.Add(1)
+	return true
+}
+
+// unshed 把 key 移出降载保留的对象，调用方需持有 c.mu
+func (c *CacheDB[T]) unshed(key interface{}) {
+	if c.shed != nil {
+		delete(c.shed.held, key)
+	}
+}
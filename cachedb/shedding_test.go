@@ -0,0 +1,115 @@
+package cachedb
+
+import (
+	"database/sql"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadShedding(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Name string
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, Name: "a"})
+	db.Create(&Player{ID: 2, Name: "a"})
+
+	var congested atomic.Bool
+	var waits int64
+	c := newTestCache[Player](t, db, 10, WithLoadShedding[Player](ShedConfig{
+		WaitThreshold: 5,
+		Interval:      time.Nanosecond,
+		Stats: func() sql.DBStats {
+			if congested.Load() {
+				waits += 10
+			}
+			return sql.DBStats{WaitCount: waits}
+		},
+	}))
+
+	p, _ := c.Get(1)
+	c.Get(2)
+	if c.Shedding() {
+		t.Fatalf("expected no shedding without connection waits")
+	}
+	congested.Store(true)
+	if !c.Shedding() {
+		t.Fatalf("expected shedding once connection waits spike")
+	}
+
+	p.Name = "b"
+	c.EvictNow(1)
+	c.EvictNow(2)
+	var row Player
+	db.First(&row, 1)
+	if row.Name != "a" {
+		t.Errorf("expected eviction flush to be deferred, got %+v", row)
+	}
+
+	db.Model(&Player{}).Where("id = ?", 1).Update("name", "db")
+	again, err := c.Get(1)
+	if err != nil || again != p || again.Name != "b" {
+		t.Errorf("expected the retained value to be served without a load, got %+v %v", again, err)
+	}
+	stats := c.Stats()
+	if !stats.Shedding || stats.ShedFlushes != 2 || stats.ShedLoads != 1 {
+		t.Errorf("unexpected shedding stats %+v", stats)
+	}
+
+	if err := c.FlushAll(); err != nil {
+		t.Fatalf("FlushAll failed: %v", err)
+	}
+	db.First(&row, 1)
+	if row.Name != "b" {
+		t.Errorf("expected deferred change to be flushed, got %+v", row)
+	}
+
+	congested.Store(false)
+	if c.Shedding() {
+		t.Fatalf("expected shedding to stop")
+	}
+	if s := c.Stats(); s.Tracked != 1 {
+		t.Errorf("expected retained clean entry to be released, tracked %d", s.Tracked)
+	}
+}
+
+func TestLoadSheddingLimits(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Name string
+	}
+	db := openTestDB(t, &Player{})
+	for id := uint(1); id <= 3; id++ {
+		db.Create(&Player{ID: id, Name: "a"})
+	}
+	if _, err := NewWithCache[Player](db, 10, WithLoadShedding[Player](ShedConfig{})); err == nil {
+		t.Error("expected a zero WaitThreshold to be rejected")
+	}
+
+	c := newTestCache[Player](t, db, 10, WithLoadShedding[Player](ShedConfig{
+		WaitThreshold: 1,
+		Interval:      time.Nanosecond,
+		MaxDeferred:   2,
+		Stats:         func() sql.DBStats { return sql.DBStats{WaitCount: time.Now().UnixNano()} },
+	}))
+	c.Shedding() // 第一次采样只记录基准
+	if !c.Shedding() {
+		t.Fatal("expected shedding")
+	}
+	for id := 1; id <= 3; id++ {
+		c.Update(id, func(p *Player) { p.Name = "b" })
+	}
+	for id := 1; id <= 3; id++ {
+		c.EvictNow(id)
+	}
+	if n := c.Stats().ShedFlushes; n != 2 {
+		t.Errorf("expected 2 deferred evictions, got %d", n)
+	}
+	var row Player
+	db.First(&row, 3)
+	if row.Name != "b" {
+		t.Errorf("expected evictions beyond MaxDeferred to be written back, got %+v", row)
+	}
+}
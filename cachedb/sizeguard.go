@@ -0,0 +1,46 @@
+package cachedb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrEntityTooLarge 表示对象序列化后超过了 WithMaxEntitySize 的限制
+var ErrEntityTooLarge = errors.New("entity exceeds max size")
+
+// SizePolicy 是对象超过大小限制时的处理方式
+type SizePolicy int
+
+const (
+	SizeWarn   SizePolicy = iota // 记录警告，照常写入
+	SizeReject                   // 拒绝 Set 和落库，对象保持为脏，直到体积降回限制以内
+)
+
+// WithMaxEntitySize 限制对象按 JSON 序列化后的字节数，防止失控增长的背包等字段撑爆内存或
+// 在落库时超出列的长度限制。Set、Create 以及每次落库前检查，0 表示不限制
+func WithMaxEntitySize[T any](maxBytes int, policy SizePolicy) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.maxSize = maxBytes
+		c.sizePolicy = policy
+	}
+}
+
+// checkSize 检查 value 的序列化大小，超限时记录警告，SizeReject 时返回 ErrEntityTooLarge
+func (c *CacheDB[T]) checkSize(key interface{}, value *T) error {
+	if c.maxSize <= 0 {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to measure key %v: %w", key, err)
+	}
+	if len(data) <= c.maxSize {
+		return nil
+	}
+	c.log(LogWarn, "Entity too large", "key", key, "size", len(data), "limit", c.maxSize)
+	if c.sizePolicy == SizeReject {
+		return fmt.Errorf("%w: key %v is %d bytes, limit %d", ErrEntityTooLarge, key, len(data), c.maxSize)
+	}
+	return nil
+}
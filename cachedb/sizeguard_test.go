@@ -0,0 +1,50 @@
+package cachedb
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMaxEntitySize(t *testing.T) {
+	type Player struct {
+		ID  uint
+		Bag []string `gorm:"serializer:json"`
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1})
+
+	logs := &recordLogger{}
+	c := newTestCache[Player](t, db, 10, WithLogger[Player](logs), WithMaxEntitySize[Player](64, SizeReject))
+	big := []string{strings.Repeat("x", 100)}
+
+	if err := c.Set(2, Player{ID: 2, Bag: big}); !errors.Is(err, ErrEntityTooLarge) {
+		t.Errorf("expected Set to be rejected, got %v", err)
+	}
+	if _, err := c.Create(Player{ID: 3, Bag: big}); !errors.Is(err, ErrEntityTooLarge) {
+		t.Errorf("expected Create to be rejected, got %v", err)
+	}
+
+	p, _ := c.Get(1)
+	p.Bag = big
+	if err := c.FlushAll(); !errors.Is(err, ErrEntityTooLarge) {
+		t.Fatalf("expected flush to be rejected, got %v", err)
+	}
+	if dirty := c.DirtyKeys(); len(dirty) != 1 {
+		t.Errorf("expected the oversized entity to stay dirty, got %v", dirty)
+	}
+	p.Bag = []string{"sword"}
+	if err := c.Flush(1); err != nil {
+		t.Errorf("expected flush to succeed once back under the limit, got %v", err)
+	}
+
+	warn := newTestCache[Player](t, db, 10, WithLogger[Player](logs), WithMaxEntitySize[Player](64, SizeWarn))
+	p, _ = warn.Get(1)
+	p.Bag = big
+	if err := warn.Flush(1); err != nil {
+		t.Errorf("SizeWarn should not block the flush, got %v", err)
+	}
+	if len(logs.find("Entity too large")) < 4 {
+		t.Errorf("expected a warning for every oversized entity")
+	}
+}
@@ -0,0 +1,50 @@
+package cachedb
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// FlushSlice 描述分时刷盘的节奏：每个 Tick 写回开始时脏 key 总数的 Fraction，
+// 把一次全量刷盘摊开，避免在游戏主循环中造成卡顿
+type FlushSlice struct {
+	Fraction float64       // 每个 Tick 写回的比例，默认 0.05
+	Tick     time.Duration // 两批之间的间隔，默认 100ms
+	MinKeys  int           // 每批至少写回的 key 数，默认 1
+}
+
+// FlushSliced 按 slice 的节奏分批写回当前所有脏数据，每批仍按 WithBulkFlush、WithFlushTransaction 合并写入。
+// 期间产生的新修改留给下一次刷盘。ctx 取消后尚未写回的 key 以 ctx.Err() 记入报告的 Failed，仍保持为脏
+func (c *CacheDB[T]) FlushSliced(ctx context.Context, slice FlushSlice) *FlushReport {
+	if slice.Fraction <= 0 {
+		slice.Fraction = 0.05
+	}
+	if slice.Tick <= 0 {
+		slice.Tick = 100 * time.Millisecond
+	}
+	if slice.MinKeys <= 0 {
+		slice.MinKeys = 1
+	}
+
+	report := &FlushReport{}
+	keys := c.DirtyKeys()
+	per := max(int(math.Ceil(float64(len(keys))*slice.Fraction)), slice.MinKeys)
+	for len(keys) > 0 {
+		n := min(per, len(keys))
+		c.flushKeys(keys[:n], report)
+		keys = keys[n:]
+		if len(keys) == 0 {
+			break
+		}
+		select {
+		case <-c.clock.After(slice.Tick):
+		case <-ctx.Done():
+			for _, key := range keys {
+				report.record(key, ctx.Err(), false)
+			}
+			return report
+		}
+	}
+	return report
+}
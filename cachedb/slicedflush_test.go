@@ -0,0 +1,105 @@
+package cachedb
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tickClock 立即触发所有定时（block 为 true 时永不触发），并在每次定时时调用 onTick
+type tickClock struct {
+	mu     sync.Mutex
+	onTick func()
+	ticks  []time.Duration
+	block  bool
+}
+
+func (c *tickClock) Now() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) }
+
+func (c *tickClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	c.ticks = append(c.ticks, d)
+	c.mu.Unlock()
+	if c.onTick != nil {
+		c.onTick()
+	}
+	if c.block {
+		return nil
+	}
+	ch := make(chan time.Time, 1)
+	ch <- c.Now()
+	return ch
+}
+
+func TestFlushSliced(t *testing.T) {
+	type Hero struct {
+		ID    uint
+		Level int
+	}
+	db := openTestDB(t, &Hero{})
+	for i := 1; i <= 10; i++ {
+		db.Create(&Hero{ID: uint(i), Level: 1})
+	}
+
+	clock := &tickClock{}
+	var flushed []int64
+	clock.onTick = func() {
+		var n int64
+		db.Model(&Hero{}).Where("level = ?", 2).Count(&n)
+		flushed = append(flushed, n)
+	}
+	c := newTestCache[Hero](t, db, 20, WithClock[Hero](clock), WithBulkFlush[Hero](100))
+	for i := 1; i <= 10; i++ {
+		c.Update(uint(i), func(h *Hero) { h.Level = 2 })
+	}
+
+	report := c.FlushSliced(context.Background(), FlushSlice{Fraction: 0.3, Tick: 50 * time.Millisecond})
+	if err := report.Err(); err != nil || len(report.Flushed) != 10 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	// 每批 3 个，共 4 批，批与批之间等待 3 次
+	if len(flushed) != 3 || flushed[0] != 3 || flushed[1] != 6 || flushed[2] != 9 {
+		t.Errorf("expected 3 keys per tick, got %v", flushed)
+	}
+	if clock.ticks[0] != 50*time.Millisecond {
+		t.Errorf("expected ticks of 50ms, got %v", clock.ticks)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := 1; i <= 10; i++ {
+		c.Update(uint(i), func(h *Hero) { h.Level = 3 })
+	}
+	clock.onTick, clock.block = cancel, true
+	report = c.FlushSliced(ctx, FlushSlice{Fraction: 0.5})
+	if len(report.Flushed) != 5 || len(report.Failed) != 5 || len(c.DirtyKeys()) != 5 {
+		t.Errorf("expected cancellation to leave the remaining keys dirty, got %+v", report)
+	}
+}
+
+func TestCheckpointerTick(t *testing.T) {
+	type Hero struct {
+		ID    uint
+		Level int
+	}
+	db := openTestDB(t, &Hero{})
+	for i := 1; i <= 10; i++ {
+		db.Create(&Hero{ID: uint(i), Level: 1})
+	}
+	c := newTestCache[Hero](t, db, 20)
+	for i := 1; i <= 10; i++ {
+		c.Update(uint(i), func(h *Hero) { h.Level = 2 })
+	}
+
+	clock := &tickClock{}
+	cp := NewCheckpointer(CheckpointConfig{Schedule: Every(time.Hour), Tick: 100 * time.Millisecond, Fraction: 0.2, Clock: clock}, c)
+	if err := cp.RunOnce(); err != nil {
+		t.Fatalf("checkpoint failed: %v", err)
+	}
+	if len(clock.ticks) != 4 || clock.ticks[0] != 100*time.Millisecond {
+		t.Errorf("expected 5 batches separated by 100ms ticks, got %v", clock.ticks)
+	}
+	if len(c.DirtyKeys()) != 0 {
+		t.Errorf("expected all keys to be flushed")
+	}
+}
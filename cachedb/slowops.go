@@ -0,0 +1,34 @@
+package cachedb
+
+import "time"
+
+// WithSlowThresholds 设置慢加载和慢回写的阈值，超过阈值的操作以 LogWarn 级别记录
+// key、耗时和影响的行数，并计入 Stats。0 表示不检查
+func WithSlowThresholds[T any](load, flush time.Duration) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.slowLoad = load
+		c.slowFlush = flush
+	}
+}
+
+// observeLoad 检查一次加载是否超过阈值
+func (c *CacheDB[T]) observeLoad(key interface{}, start time.Time, rows int64) {
+	if c.slowLoad <= 0 {
+		return
+	}
+	if d := time.Since(start); d >= c.slowLoad {
+		c.counters.slowLoads.Add(1)
+		c.log(LogWarn, "Slow load", "key", key, "duration", d, "rows", rows)
+	}
+}
+
+// observeFlush 检查一次回写是否超过阈值，批量回写时 key 为写入的行数描述
+func (c *CacheDB[T]) observeFlush(key interface{}, start time.Time, rows int64) {
+	if c.slowFlush <= 0 {
+		return
+	}
+	if d := time.Since(start); d >= c.slowFlush {
+		c.counters.slowFlushes.Add(1)
+		c.log(LogWarn, "Slow flush", "key", key, "duration", d, "rows", rows)
+	}
+}
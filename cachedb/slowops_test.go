@@ -0,0 +1,71 @@
+package cachedb
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordLogger 记录收到的日志
+type recordLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+type logEntry struct {
+	level  LogLevel
+	msg    string
+	fields []interface{}
+}
+
+func (l *recordLogger) Log(level LogLevel, msg string, fields ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, logEntry{level, msg, fields})
+}
+
+func (l *recordLogger) find(msg string) []logEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []logEntry
+	for _, e := range l.entries {
+		if e.msg == msg {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func TestSlowThresholds(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, Gold: 1})
+
+	logs := &recordLogger{}
+	c := newTestCache[Player](t, db, 10,
+		WithLogger[Player](logs),
+		WithSlowThresholds[Player](time.Nanosecond, time.Hour))
+
+	p, _ := c.Get(1)
+	p.Gold = 2
+	if err := c.Flush(1); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	slow := logs.find("Slow load")
+	if len(slow) != 1 || slow[0].level != LogWarn {
+		t.Fatalf("expected one slow load warning, got %+v", slow)
+	}
+	if fields := slow[0].fields; fields[2] != "key" || fields[3] != uint(1) || fields[7] != int64(1) {
+		t.Errorf("unexpected fields %v", fields)
+	}
+	if len(logs.find("Slow flush")) != 0 {
+		t.Errorf("flush should be under the threshold")
+	}
+	if stats := c.Stats(); stats.SlowLoads != 1 || stats.SlowFlushes != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
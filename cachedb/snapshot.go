@@ -0,0 +1,192 @@
+package cachedb
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// snapshotHeader 是快照文件的第一行
+type snapshotHeader struct {
+	Entity string    `json:"entity"`
+	Since  time.Time `json:"since"` // 所有对象中最早的与数据库一致的时间，对账从这里开始
+}
+
+// snapshotRecord 是快照文件中的一个对象
+type snapshotRecord[T any] struct {
+	Key   string `json:"key"`
+	Value T      `json:"value"`
+}
+
+// SaveSnapshot 把缓存中所有对象与数据库一致的版本（副本）写入 path，供重启后 LoadSnapshot 快速预热。
+// 未落库的修改不会写入快照，停服时应先 FlushAll。文件先写入临时文件再改名，不会留下半个快照
+func (c *CacheDB[T]) SaveSnapshot(path string) (int, error) {
+	c.mu.Lock()
+	header := snapshotHeader{Entity: c.entity, Since: c.clock.Now()}
+	records := make([]snapshotRecord[T], 0, len(c.copies))
+	for key, cpy := range c.copies {
+		m := c.meta[key]
+		if m == nil || c.quarantine[key] != nil {
+			continue
+		}
+		if _, tracked := c.values[key]; !tracked {
+			continue
+		}
+		if m.since.Before(header.Since) {
+			header.Since = m.since
+		}
+		records = append(records, snapshotRecord[T]{Key: fmt.Sprint(key), Value: cpy})
+	}
+	c.mu.Unlock()
+
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create snapshot %s: %w", path, err)
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	err = enc.Encode(header)
+	for i := 0; err == nil && i < len(records); i++ {
+		err = enc.Encode(records[i])
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("failed to write snapshot %s: %w", path, err)
+	}
+	return len(records), nil
+}
+
+// LoadSnapshot 用 SaveSnapshot 写入的文件预热缓存，然后与数据库对账：停服期间被修改的行
+// （模型带有更新时间字段时只重新读取 updated_at 晚于快照的行，否则重新读取全部行）覆盖快照中的版本，
+// 已被删除的行移出缓存。应在缓存开始服务前调用，已缓存的 key 以内存中的版本为准。返回预热的对象数
+func (c *CacheDB[T]) LoadSnapshot(path string) (int, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open snapshot %s: %w", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var header snapshotHeader
+	if !scanner.Scan() || json.Unmarshal(scanner.Bytes(), &header) != nil {
+		return 0, fmt.Errorf("snapshot %s has no valid header", path)
+	}
+	if header.Entity != c.entity {
+		return 0, fmt.Errorf("snapshot %s belongs to %s, not %s", path, header.Entity, c.entity)
+	}
+	var keys []interface{}
+	for scanner.Scan() {
+		var rec snapshotRecord[T]
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return len(keys), fmt.Errorf("failed to decode snapshot %s: %w", path, err)
+		}
+		key, err := c.ParseKey(rec.Key)
+		if err != nil {
+			return len(keys), err
+		}
+		value := rec.Value
+		if _, err := c.adopt(key, &value); err != nil {
+			return len(keys), err
+		}
+		keys = append(keys, key)
+	}
+	if err := scanner.Err(); err != nil {
+		return len(keys), fmt.Errorf("failed to read snapshot %s: %w", path, err)
+	}
+	return len(keys), c.reconcile(keys, header.Since)
+}
+
+// reconcile 按表分批重新读取 keys 中在 since 之后被修改的行，并移除已被删除的 key
+func (c *CacheDB[T]) reconcile(keys []interface{}, since time.Time) error {
+	groups := make(map[Shard][]interface{})
+	var order []Shard
+	for _, key := range keys {
+		s := c.shardFor(key)
+		if _, ok := groups[s]; !ok {
+			order = append(order, s)
+		}
+		groups[s] = append(groups[s], key)
+	}
+
+	keyCol := clause.Column{Table: clause.CurrentTable, Name: c.keyField.DBName}
+	for _, s := range order {
+		pending := groups[s]
+		for len(pending) > 0 {
+			n := min(c.batchSize, len(pending))
+			batch := pending[:n]
+			pending = pending[n:]
+			in := clause.IN{Column: keyCol, Values: batch}
+
+			var existing []interface{}
+			var changed []T
+			release := c.acquireDB()
+			err := s.DB.Table(s.Table).Where(in).Pluck(c.keyField.DBName, &existing).Error
+			if err == nil {
+				q := s.DB.Table(s.Table).Where(in)
+				if c.updatedAt != nil {
+					q = q.Where(clause.Gt{Column: clause.Column{Table: clause.CurrentTable, Name: c.updatedAt.DBName}, Value: since})
+				}
+				err = q.Find(&changed).Error
+			}
+			release()
+			if err != nil {
+				return fmt.Errorf("failed to reconcile snapshot with %s: %w", s.Table, err)
+			}
+
+			alive := make(map[interface{}]bool, len(existing))
+			for _, raw := range existing {
+				if key, err := c.normalizeKey(raw); err == nil {
+					alive[key] = true
+				}
+			}
+			for _, key := range batch {
+				if !alive[key] {
+					c.Invalidate(key)
+				}
+			}
+			for i := range changed {
+				if err := c.replaceClean(c.keyOf(&changed[i]), changed[i]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// replaceClean 用数据库中的新行原地覆盖没有未落库修改的缓存对象
+func (c *CacheDB[T]) replaceClean(key interface{}, fresh T) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[key]
+	if !ok || c.modified(key, value) {
+		return nil
+	}
+	*value = fresh
+	if err := c.rebase(key, value); err != nil {
+		return err
+	}
+	c.bump(key)
+	return nil
+}
@@ -0,0 +1,56 @@
+package cachedb
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshotReconcile(t *testing.T) {
+	type Player struct {
+		ID        uint
+		Gold      int
+		UpdatedAt time.Time
+	}
+	db := openTestDB(t, &Player{})
+	for i := 1; i <= 4; i++ {
+		db.Create(&Player{ID: uint(i), Gold: i})
+	}
+	path := filepath.Join(t.TempDir(), "players.snap")
+
+	before := newTestCache[Player](t, db, 10)
+	for i := 1; i <= 4; i++ {
+		before.Get(i)
+	}
+	if n, err := before.SaveSnapshot(path); err != nil || n != 4 {
+		t.Fatalf("SaveSnapshot = %d %v", n, err)
+	}
+
+	// 停服期间的修改
+	time.Sleep(10 * time.Millisecond)
+	db.Exec("UPDATE players SET gold = 100 WHERE id = 1")      // 未更新 updated_at，对账时视为未修改
+	db.Model(&Player{}).Where("id = ?", 2).Update("gold", 200) // 更新了 updated_at
+	db.Delete(&Player{}, 3)
+
+	after := newTestCache[Player](t, db, 10)
+	n, err := after.LoadSnapshot(path)
+	if err != nil || n != 4 {
+		t.Fatalf("LoadSnapshot = %d %v", n, err)
+	}
+	want := map[uint]int{1: 1, 2: 200, 4: 4}
+	for key, gold := range want {
+		if v, ok := after.values[key]; !ok || v.Gold != gold {
+			t.Errorf("player %d = %+v, want gold %d", key, v, gold)
+		}
+	}
+	if _, ok := after.values[uint(3)]; ok {
+		t.Error("expected the deleted player to be dropped")
+	}
+	if dirty := after.DirtyKeys(); len(dirty) != 0 {
+		t.Errorf("expected a primed cache to be clean, got %v", dirty)
+	}
+
+	if n, err := after.LoadSnapshot(filepath.Join(t.TempDir(), "missing")); err != nil || n != 0 {
+		t.Errorf("expected a missing snapshot to be ignored, got %d %v", n, err)
+	}
+}
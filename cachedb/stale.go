@@ -0,0 +1,26 @@
+package cachedb
+
+import "time"
+
+// RefreshIfStale 在缓存的副本距上次与数据库同步（加载、刷新或落库）超过 maxAge 时从数据库重新加载 key，
+// 适用于偶尔被后台工具直接修改的半静态数据。有未落库修改的对象不会被刷新，查询期间被修改的对象也会保留修改；
+// 未缓存的 key 无需处理。返回是否重新查询了数据库
+func (c *CacheDB[T]) RefreshIfStale(key interface{}, maxAge time.Duration) (bool, error) {
+	key = c.canonicalKey(key)
+	c.mu.Lock()
+	value, tracked := c.values[key]
+	if !tracked {
+		c.mu.Unlock()
+		return false, nil
+	}
+	m := c.meta[key]
+	stale := c.clock.Now().Sub(m.since) > maxAge && !c.modified(key, value)
+	gen := m.gen
+	c.mu.Unlock()
+	if !stale {
+		return false, nil
+	}
+	// 查询期间对象可能被修改，只在仍未修改且代数不变时替换
+	_, err := c.refresh(key, &gen)
+	return true, err
+}
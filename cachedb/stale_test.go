@@ -0,0 +1,86 @@
+package cachedb
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// manualClock 是可以手动推进的时钟
+type manualClock struct {
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time                         { return c.now }
+func (c *manualClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func TestRefreshIfStale(t *testing.T) {
+	type Item struct {
+		ID    uint
+		Price int
+	}
+	db := openTestDB(t, &Item{})
+	db.Create(&Item{ID: 1, Price: 10})
+	clock := &manualClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := newTestCache[Item](t, db, 10, WithClock[Item](clock))
+
+	item, _ := c.Get(1)
+	db.Model(&Item{}).Where("id = ?", 1).Update("price", 20) // 后台工具修改
+
+	clock.now = clock.now.Add(time.Second)
+	if refreshed, err := c.RefreshIfStale(1, time.Minute); refreshed || err != nil || item.Price != 10 {
+		t.Errorf("expected a fresh entry not to be reloaded, got %v %v %+v", refreshed, err, item)
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	c.Cache.Get(uint(1)) // 可能已按缓存自身的有效期过期
+	item, _ = c.Get(1)
+	clock.now = clock.now.Add(2 * time.Minute)
+	db.Model(&Item{}).Where("id = ?", 1).Update("price", 30)
+	item.Price = 11
+	if refreshed, _ := c.RefreshIfStale(1, time.Minute); refreshed || item.Price != 11 {
+		t.Errorf("expected a dirty entry to keep its changes, got %+v", item)
+	}
+	item.Price = 20
+	c.Flush(1)
+	db.Model(&Item{}).Where("id = ?", 1).Update("price", 30)
+	clock.now = clock.now.Add(2 * time.Minute)
+	if refreshed, err := c.RefreshIfStale(1, time.Minute); !refreshed || err != nil || item.Price != 30 {
+		t.Errorf("expected a stale entry to be reloaded in place, got %v %v %+v", refreshed, err, item)
+	}
+}
+
+func TestRefreshIfStaleKeepsConcurrentUpdate(t *testing.T) {
+	type Item struct {
+		ID    uint
+		Price int
+	}
+	db := openTestDB(t, &Item{})
+	db.Create(&Item{ID: 1, Price: 10})
+	clock := &manualClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := newTestCache[Item](t, db, 10, WithClock[Item](clock))
+	c.Get(1)
+
+	// 重新加载的查询期间通过缓存修改对象
+	updated := false
+	db.Callback().Query().Before("gorm:query").Register("update_during_refresh", func(*gorm.DB) {
+		if !updated {
+			updated = true
+			c.Update(1, func(item *Item) { item.Price = 15 })
+		}
+	})
+	defer db.Callback().Query().Remove("update_during_refresh")
+
+	clock.now = clock.now.Add(2 * time.Minute)
+	if _, err := c.RefreshIfStale(1, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if !updated {
+		t.Fatal("expected the refresh to query the database")
+	}
+	item, _ := c.Get(1)
+	if item.Price != 15 || len(c.DirtyKeys()) != 1 {
+		t.Errorf("expected the concurrent update to survive the refresh, got %+v", item)
+	}
+}
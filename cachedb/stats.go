@@ -0,0 +1,117 @@
+package cachedb
+
+import "sync/atomic"
+
+// CacheStats 是 CacheDB 的运行统计
+type CacheStats struct {
+	Entity           string            `json:"entity"`                // 实体标签，见 Entity
+	Size             int               `json:"size"`                  // gcache 中的条目数
+	Tracked          int               `json:"tracked"`               // 正在追踪的对象数，包括被固定和回写失败滞留的对象
+	Dirty            int               `json:"dirty"`                 // 有未落库修改的对象数
+	Pinned           int               `json:"pinned"`                // 被固定的对象数
+	Quarantined      int               `json:"quarantined"`           // 隔离区中的对象数，见 WithQuarantine
+	Hits             uint64            `json:"hits"`                  // 缓存命中次数
+	Misses           uint64            `json:"misses"`                // 缓存未命中次数
+	HitRate          float64           `json:"hit_rate"`              // 命中率
+	SlowLoads        uint64            `json:"slow_loads"`            // 超过慢加载阈值的次数
+	SlowFlushes      uint64            `json:"slow_flushes"`          // 超过慢回写阈值的次数
+	Shedding         bool              `json:"shedding"`              // 是否处于连接池降载状态
+	ShedLoads        uint64            `json:"shed_loads"`            // 降载期间直接使用旧值、未查询数据库的次数
+	ShedFlushes      uint64            `json:"shed_flushes"`          // 降载期间推迟的淘汰回写次数
+	DirtyMarked      uint64            `json:"dirty_marked"`          // 因 Update、Set 的标记而跳过比较的脏检查次数
+	DirtyCompared    uint64            `json:"dirty_compared"`        // 需要与副本比较的脏检查次数
+	UnchangedReloads uint64            `json:"unchanged_reloads"`     // Refresh 按更新时间判断行未修改、未传输整行的次数
+	PresenceSkips    uint64            `json:"presence_skips"`        // 过滤器判断 key 不存在而跳过的数据库查询次数
+	PresenceMisses   uint64            `json:"presence_misses"`       // 过滤器误判：判断可能存在、查询后发现不存在的次数
+	EvictVetoes      uint64            `json:"evict_vetoes"`          // 被 OnBeforeEvict 否决的淘汰次数
+	SecondChances    uint64            `json:"second_chances"`        // 脏对象被淘汰时放回缓存的次数
+	ReplicaReads     uint64            `json:"replica_reads"`         // Get 直接返回只读副本的次数，见 WithReadReplicas
+	StrayMutations   uint64            `json:"stray_mutations"`       // VerifyCopies 发现的未经缓存方法的修改，见 WithCopyVerify
+	CoalescedWrites  uint64            `json:"coalesced_writes"`      // 合并到同一次落库中、未单独写入的修改次数，见 WithCoalesce
+	States           map[string]int    `json:"states"`                // 各生命周期状态的对象数，见 EntryState
+	FlushReasons     map[string]uint64 `json:"flush_reasons"`         // 各原因落库的对象数，见 FlushReason
+	WriteQueue       *FlushStats       `json:"write_queue,omitempty"` // 异步回写协程池的统计，未开启时为 nil
+}
+
+// cacheCounters 是 CacheStats 的原子计数
+type cacheCounters struct {
+	slowLoads     atomic.Uint64
+	slowFlushes   atomic.Uint64
+	shedLoads     atomic.Uint64
+	shedFlushes   atomic.Uint64
+	dirtyMarked   atomic.Uint64
+	dirtyCompared atomic.Uint64
+
+	unchangedReloads atomic.Uint64
+	presenceSkips    atomic.Uint64
+	presenceMisses   atomic.Uint64
+	evictVetoes      atomic.Uint64
+	secondChances    atomic.Uint64
+	replicaReads     atomic.Uint64
+	strayMutations   atomic.Uint64
+}
+
+// modified 判断 key 是否有未落库的修改。通过 Update 或 Set 修改过的对象直接视为脏，
+// 只有可能经指针直接修改的对象才需要与副本比较，调用方需持有 c.mu
+func (c *CacheDB[T]) modified(key interface{}, value *T) bool {
+	if m := c.meta[key]; m != nil && m.marked {
+		c.counters.dirtyMarked.Add(1)
+		return true
+	}
+	c.counters.dirtyCompared.Add(1)
+	return !c.equal(c.copies[key], *value)
+}
+
+// Stats 返回运行统计。Dirty 需要逐个比较副本，不适合在热路径上频繁调用
+func (c *CacheDB[T]) Stats() CacheStats {
+	stats := CacheStats{
+		Entity:           c.entity,
+		Size:             c.Cache.Len(false),
+		Hits:             c.Cache.HitCount(),
+		Misses:           c.Cache.MissCount(),
+		HitRate:          c.Cache.HitRate(),
+		SlowLoads:        c.counters.slowLoads.Load(),
+		SlowFlushes:      c.counters.slowFlushes.Load(),
+		Shedding:         c.Shedding(),
+		ShedLoads:        c.counters.shedLoads.Load(),
+		ShedFlushes:      c.counters.shedFlushes.Load(),
+		DirtyMarked:      c.counters.dirtyMarked.Load(),
+		DirtyCompared:    c.counters.dirtyCompared.Load(),
+		UnchangedReloads: c.counters.unchangedReloads.Load(),
+		PresenceSkips:    c.counters.presenceSkips.Load(),
+		PresenceMisses:   c.counters.presenceMisses.Load(),
+		EvictVetoes:      c.counters.evictVetoes.Load(),
+		SecondChances:    c.counters.secondChances.Load(),
+		ReplicaReads:     c.counters.replicaReads.Load(),
+		StrayMutations:   c.counters.strayMutations.Load(),
+	}
+	if c.coalesce != nil {
+		stats.CoalescedWrites = c.coalesce.merged.Load()
+	}
+	if c.pool != nil {
+		pool := c.pool.Stats()
+		stats.WriteQueue = &pool
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats.Tracked = len(c.values)
+	stats.Pinned = len(c.pins)
+	stats.Quarantined = len(c.quarantine)
+	stats.States = make(map[string]int)
+	stats.FlushReasons = make(map[string]uint64, len(c.flushCounts))
+	for reason, n := range c.flushCounts {
+		stats.FlushReasons[string(reason)] = n
+	}
+	for key := range c.values {
+		state := c.stateLocked(key)
+		if state != StateClean {
+			stats.Dirty++ // Flushing 和 Failed 的对象同样有未落库的修改
+		}
+		stats.States[state.String()]++
+	}
+	if len(c.loading) > 0 {
+		stats.States[StateLoading.String()] = len(c.loading)
+	}
+	return stats
+}
@@ -0,0 +1,91 @@
+package cachedb
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Store 是 CacheDB 加载与回写单行数据使用的持久化接口，默认实现基于 gorm
+type Store[T any] interface {
+	Load(key interface{}) (T, error)         // 按 key 读取一行
+	Save(key interface{}, old, new *T) error // 把 new 相对 old 的修改写回
+	Insert(value *T) error                   // 插入一行，自增主键会回填到 value
+	Delete(key interface{}) error            // 按 key 删除一行
+}
+
+// WithStore 替换 CacheDB 使用的存储。wrap 接收默认的 gorm 存储，
+// 可以返回全新的实现，也可以包装默认实现（例如 ChaosStore）
+func WithStore[T any](wrap func(base Store[T]) Store[T]) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.wrapStore = wrap
+	}
+}
+
+// gormStore 是基于 gorm 的默认存储，按 CacheDB 解析出的 key 字段定位行
+type gormStore[T any] struct {
+	c *CacheDB[T]
+}
+
+func (s gormStore[T]) Load(key interface{}) (T, error) {
+	var entity T
+	start := time.Now()
+	db, done := withTimeout(s.c.preload(s.c.keyDB(key)), s.c.loadTimeout, ErrLoadTimeout)
+	result := db.Where(s.c.keyCondition(key)).First(&entity)
+	s.c.observeLoad(key, start, result.RowsAffected)
+	return entity, done(result.Error)
+}
+
+func (s gormStore[T]) Save(key interface{}, old, new *T) error {
+	model := *old // gorm 会把更新的字段回填到 Model，不能修改调用方的副本
+	start := time.Now()
+	db, done := withTimeout(s.c.keyDB(key), s.c.flushTimeout, ErrFlushTimeout)
+	if len(s.c.collections) > 0 {
+		// 父对象与集合的增删在同一个事务中写入
+		var rows int64
+		err := s.c.flushTransaction(db, func(tx *gorm.DB) error {
+			result := s.c.updateRow(tx, key, &model, new)
+			rows = result.RowsAffected
+			return result.Error
+		})
+		s.c.observeFlush(key, start, rows)
+		return done(err)
+	}
+	result := s.c.updateRow(db, key, &model, new)
+	s.c.observeFlush(key, start, result.RowsAffected)
+	return done(result.Error)
+}
+
+func (s gormStore[T]) Insert(value *T) error {
+	return s.c.keyDB(s.c.keyOf(value)).Create(value).Error
+}
+
+func (s gormStore[T]) Delete(key interface{}) error {
+	return s.c.keyDB(key).Where(s.c.keyCondition(key)).Delete(new(T)).Error
+}
+
+// loadRow 按 key 读取一行，设置了 L2 时优先从 L2 读取
+func (c *CacheDB[T]) loadRow(key interface{}) (T, error) {
+	if c.l2 != nil {
+		if entity, ok := c.loadL2(key); ok {
+			return entity, nil
+		}
+	}
+	return c.loadDB(key)
+}
+
+// loadDB 从数据库读取一行，不经过 L2
+func (c *CacheDB[T]) loadDB(key interface{}) (T, error) {
+	release := c.acquireDB()
+	entity, err := c.store.Load(key)
+	release()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return entity, fmt.Errorf("failed to load from DB: %w: %w", ErrNotFound, err)
+	}
+	if err != nil {
+		return entity, fmt.Errorf("failed to load from DB: %w", err)
+	}
+	return entity, nil
+}
@@ -0,0 +1,132 @@
+package cachedb
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+// ErrTableRouted 表示操作需要扫描整张表，在按 key 分表时不支持
+var ErrTableRouted = errors.New("operation spans tables and is not supported with table routing")
+
+// Shard 是一行数据所在的物理位置
+type Shard struct {
+	DB    *gorm.DB // 为 nil 时使用缓存的默认连接
+	Table string   // 为空时使用默认表名
+}
+
+// ShardStrategy 决定 key 所在的库和表
+type ShardStrategy interface {
+	Shard(key interface{}) Shard
+}
+
+// ShardFunc 把函数适配为 ShardStrategy
+type ShardFunc func(key interface{}) Shard
+
+func (f ShardFunc) Shard(key interface{}) Shard { return f(key) }
+
+// WithTable 指定模型对应的物理表名，替代 gorm 根据模型推导的表名
+func WithTable[T any](name string) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.tableName = name
+	}
+}
+
+// WithSharding 按 key 把行路由到不同的库和表，例如 HashShards("players", 32, dbs...)。
+// 按 key 的加载、回写、批量读取、创建、删除和转账会使用路由后的位置；
+// CachedPage、CachedCount、LoadAll 等整表操作返回 ErrTableRouted
+func WithSharding[T any](s ShardStrategy) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.sharding = s
+	}
+}
+
+// WithTableRouter 只按 key 路由物理表，库使用缓存的默认连接，见 WithSharding
+func WithTableRouter[T any](route func(key interface{}) string) Option[T] {
+	return WithSharding[T](ShardFunc(func(key interface{}) Shard {
+		return Shard{Table: route(key)}
+	}))
+}
+
+// ShardTables 返回把 key 分到 base_00 ~ base_{n-1} 的路由函数：
+// 整数 key 按取模分配，其他 key 按 crc32 分配
+func ShardTables(base string, n int) func(key interface{}) string {
+	return func(key interface{}) string {
+		return fmt.Sprintf("%s_%02d", base, shardIndex(key, n))
+	}
+}
+
+// HashShards 把 key 分到 base_00 ~ base_{tables-1}，第 i 张表位于 dbs[i%len(dbs)]，
+// 未提供 dbs 时所有表都在默认连接上
+func HashShards(base string, tables int, dbs ...*gorm.DB) ShardStrategy {
+	return ShardFunc(func(key interface{}) Shard {
+		i := shardIndex(key, tables)
+		s := Shard{Table: fmt.Sprintf("%s_%02d", base, i)}
+		if len(dbs) > 0 {
+			s.DB = dbs[i%len(dbs)]
+		}
+		return s
+	})
+}
+
+// shardIndex 返回 key 的分片编号
+func shardIndex(key interface{}, n int) int {
+	v := reflect.ValueOf(key)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := v.Int()
+		if i < 0 {
+			i = -i
+		}
+		return int(uint64(i) % uint64(n))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(v.Uint() % uint64(n))
+	default:
+		return int(crc32.ChecksumIEEE([]byte(fmt.Sprint(key))) % uint32(n))
+	}
+}
+
+// shardFor 返回 key 所在的库和表，未分片时为默认连接和表
+func (c *CacheDB[T]) shardFor(key interface{}) Shard {
+	var s Shard
+	if c.sharding != nil {
+		s = c.sharding.Shard(key)
+	}
+	if s.DB == nil {
+		s.DB = c.db
+	}
+	if s.Table == "" {
+		s.Table = c.defaultTable()
+	}
+	return s
+}
+
+// defaultTable 返回未分片时的物理表
+func (c *CacheDB[T]) defaultTable() string {
+	if c.tableName != "" {
+		return c.tableName
+	}
+	return c.schema.Table
+}
+
+// keyDB 返回定位到 key 所在库和表的查询
+func (c *CacheDB[T]) keyDB(key interface{}) *gorm.DB {
+	s := c.shardFor(key)
+	return s.DB.Table(s.Table)
+}
+
+// keyTable 在已有的连接（例如事务）上定位 key 所在的表
+func (c *CacheDB[T]) keyTable(db *gorm.DB, key interface{}) *gorm.DB {
+	return db.Table(c.shardFor(key).Table)
+}
+
+// wholeTable 返回针对整张表的查询，分片时返回 ErrTableRouted
+func (c *CacheDB[T]) wholeTable() (*gorm.DB, error) {
+	if c.sharding != nil {
+		return nil, ErrTableRouted
+	}
+	return c.db.Model(new(T)).Table(c.defaultTable()), nil
+}
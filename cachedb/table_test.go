@@ -0,0 +1,135 @@
+package cachedb
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestWithTable(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t)
+	db.Table("legacy_players").AutoMigrate(&Player{})
+	db.Table("legacy_players").Create(&Player{ID: 1, Gold: 10})
+
+	c := newTestCache[Player](t, db, 10, WithTable[Player]("legacy_players"))
+	p, err := c.Get(1)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	p.Gold = 20
+	if err := c.Flush(1); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	var stored Player
+	db.Table("legacy_players").First(&stored, 1)
+	if stored.Gold != 20 {
+		t.Errorf("expected gold 20, got %d", stored.Gold)
+	}
+	if n, err := c.CachedCount(Query{}); err != nil || n != 1 {
+		t.Errorf("CachedCount = %d, %v", n, err)
+	}
+}
+
+func TestTableRouter(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t)
+	route := ShardTables("players", 4)
+	for i := 0; i < 4; i++ {
+		db.Table(route(i)).AutoMigrate(&Player{})
+	}
+	if route(uint(5)) != "players_01" || route(7) != "players_03" {
+		t.Fatalf("unexpected shard names %s %s", route(uint(5)), route(7))
+	}
+
+	c := newTestCache[Player](t, db, 10, WithTableRouter[Player](route))
+	for i := uint(1); i <= 8; i++ {
+		if _, err := c.Create(Player{ID: i, Gold: int(i)}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+	c.Update(5, func(p *Player) { p.Gold = 50 })
+	if err := c.FlushAll(); err != nil {
+		t.Fatalf("FlushAll failed: %v", err)
+	}
+
+	var count int64
+	db.Table("players_01").Count(&count)
+	if count != 2 {
+		t.Errorf("expected 2 rows in players_01, got %d", count)
+	}
+	var stored Player
+	db.Table("players_01").First(&stored, 5)
+	if stored.Gold != 50 {
+		t.Errorf("expected gold 50 in players_01, got %d", stored.Gold)
+	}
+
+	c.Invalidate(5)
+	if p, err := c.Get(5); err != nil || p.Gold != 50 {
+		t.Errorf("expected reload from shard, got %+v %v", p, err)
+	}
+	if _, err := c.CachedPage(Query{}, 0, 10); !errors.Is(err, ErrTableRouted) {
+		t.Errorf("expected ErrTableRouted, got %v", err)
+	}
+}
+
+func TestHashShardsMGet(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	dbA := openTestDB(t)
+	dbB, err := gorm.Open(sqlite.Open("file:"+t.Name()+"_b?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	sharding := HashShards("players", 4, dbA, dbB)
+	for i := 0; i < 4; i++ {
+		s := sharding.Shard(i)
+		s.DB.Table(s.Table).AutoMigrate(&Player{})
+	}
+
+	c := newTestCache[Player](t, dbA, 10, WithSharding[Player](sharding))
+	for i := uint(1); i <= 8; i++ {
+		if _, err := c.Create(Player{ID: i, Gold: int(i)}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+	var count int64
+	dbB.Table("players_01").Count(&count)
+	if count != 2 {
+		t.Errorf("expected 2 rows in players_01 on the second database, got %d", count)
+	}
+
+	c.Update(3, func(p *Player) { p.Gold = 30 })
+	c.Cache.Purge()
+	var stored Player
+	dbB.Table("players_03").First(&stored, 3)
+	if stored.Gold != 30 {
+		t.Errorf("expected purge to write back to players_03, got %d", stored.Gold)
+	}
+
+	got, err := c.MGet([]interface{}{1, 2, 3, 4, 99})
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Errors) != 1 {
+		t.Fatalf("expected key 99 to be missing, got %v", err)
+	}
+	if len(got) != 4 || got[uint(3)].Gold != 30 {
+		t.Errorf("unexpected MGet result %v", got)
+	}
+	if err := Transfer(c, 1, 2, 1, TransferSpec[Player]{
+		Column:  "gold",
+		Balance: func(p *Player) int64 { return int64(p.Gold) },
+		Set:     func(p *Player, v int64) { p.Gold = int(v) },
+	}); err == nil {
+		t.Errorf("expected transfer across databases to fail")
+	}
+}
@@ -0,0 +1,106 @@
+package cachedb
+
+// tagIndex 按标签索引已缓存的 key，供 FlushByTag 等分组操作使用，由 c.mu 保护
+type tagIndex[T any] struct {
+	fn   func(key interface{}, value *T) []string
+	keys map[string]map[interface{}]struct{} // 标签 -> key
+	tags map[interface{}][]string            // key -> 标签
+}
+
+// WithTags 为缓存对象打上标签（例如 "zone:3"、"guild:42"），之后可以按标签整组落库、淘汰或计数，
+// 无需遍历所有 key。fn 在对象加载、Set 以及每次落库后调用，标签随对象状态更新；
+// fn 在持有内部锁时调用，不能再调用该缓存的方法
+func WithTags[T any](fn func(key interface{}, value *T) []string) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.tags = &tagIndex[T]{
+			fn:   fn,
+			keys: make(map[string]map[interface{}]struct{}),
+			tags: make(map[interface{}][]string),
+		}
+	}
+}
+
+// retag 重新计算 key 的标签，调用方需持有 c.mu
+func (c *CacheDB[T]) retag(key interface{}, value *T) {
+	if c.tags == nil {
+		return
+	}
+	c.untag(key)
+	tags := c.tags.fn(key, value)
+	for _, tag := range tags {
+		set := c.tags.keys[tag]
+		if set == nil {
+			set = make(map[interface{}]struct{})
+			c.tags.keys[tag] = set
+		}
+		set[key] = struct{}{}
+	}
+	if len(tags) > 0 {
+		c.tags.tags[key] = tags
+	}
+}
+
+// untag 从索引中移除 key，调用方需持有 c.mu
+func (c *CacheDB[T]) untag(key interface{}) {
+	if c.tags == nil {
+		return
+	}
+	for _, tag := range c.tags.tags[key] {
+		set := c.tags.keys[tag]
+		delete(set, key)
+		if len(set) == 0 {
+			delete(c.tags.keys, tag)
+		}
+	}
+	delete(c.tags.tags, key)
+}
+
+// tagged 返回带有 tag 的 key 及其中有未落库修改的 key
+func (c *CacheDB[T]) tagged(tag string) (keys, dirty []interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tags == nil {
+		return nil, nil
+	}
+	for key := range c.tags.keys[tag] {
+		keys = append(keys, key)
+		if value, ok := c.values[key]; ok && c.modified(key, value) {
+			dirty = append(dirty, key)
+		}
+	}
+	return keys, dirty
+}
+
+// Tags 返回 key 当前的标签，未缓存或未设置 WithTags 时返回 nil
+func (c *CacheDB[T]) Tags(key interface{}) []string {
+	key = c.canonicalKey(key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tags == nil {
+		return nil
+	}
+	return append([]string(nil), c.tags.tags[key]...)
+}
+
+// CountByTag 返回带有 tag 的已缓存对象数
+func (c *CacheDB[T]) CountByTag(tag string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tags == nil {
+		return 0
+	}
+	return len(c.tags.keys[tag])
+}
+
+// FlushByTag 把带有 tag 的脏对象写回数据库，例如区服交接前落库该区的玩家。有 key 失败时返回 *BatchError
+func (c *CacheDB[T]) FlushByTag(tag string) error {
+	_, dirty := c.tagged(tag)
+	report := &FlushReport{}
+	c.flushKeys(dirty, report)
+	return report.Err()
+}
+
+// EvictByTag 写回并淘汰带有 tag 的对象，例如公会解散后清理其成员，语义同 EvictWhere
+func (c *CacheDB[T]) EvictByTag(tag string) (int, error) {
+	return c.evictKeys(c.tagged(tag))
+}
@@ -0,0 +1,65 @@
+package cachedb
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	type Player struct {
+		ID    uint
+		Zone  int
+		Guild int
+		Gold  int
+	}
+	db := openTestDB(t, &Player{})
+	for i := 1; i <= 4; i++ {
+		db.Create(&Player{ID: uint(i), Zone: i % 2, Guild: 7})
+	}
+	c := newTestCache[Player](t, db, 10, WithTags[Player](func(_ interface{}, p *Player) []string {
+		return []string{fmt.Sprintf("zone:%d", p.Zone), fmt.Sprintf("guild:%d", p.Guild)}
+	}))
+	for i := 1; i <= 4; i++ {
+		p, _ := c.Get(i)
+		p.Gold = 10
+	}
+	if n := c.CountByTag("zone:0"); n != 2 {
+		t.Errorf("CountByTag(zone:0) = %d, want 2", n)
+	}
+	if n := c.CountByTag("guild:7"); n != 4 {
+		t.Errorf("CountByTag(guild:7) = %d, want 4", n)
+	}
+
+	if err := c.FlushByTag("zone:1"); err != nil {
+		t.Fatal(err)
+	}
+	if dirty := c.DirtyKeys(); len(dirty) != 2 {
+		t.Errorf("expected only zone 0 to stay dirty, got %v", dirty)
+	}
+
+	// 标签随落库更新：玩家迁移到 2 区
+	p, _ := c.Get(2)
+	p.Zone = 2
+	if err := c.Flush(2); err != nil {
+		t.Fatal(err)
+	}
+	if tags := c.Tags(2); len(tags) != 2 || tags[0] != "zone:2" {
+		t.Errorf("Tags(2) = %v", tags)
+	}
+	if err := c.Set(5, Player{ID: 5, Zone: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	evicted, err := c.EvictByTag("zone:2")
+	if err != nil || evicted != 2 {
+		t.Fatalf("EvictByTag = %d %v, want 2", evicted, err)
+	}
+	if n := c.CountByTag("zone:2"); n != 0 {
+		t.Errorf("expected zone 2 to be empty after eviction, got %d", n)
+	}
+	var moved Player
+	db.First(&moved, 5)
+	if moved.Zone != 2 {
+		t.Errorf("expected the new player to be written back, got %+v", moved)
+	}
+}
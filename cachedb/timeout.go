@@ -0,0 +1,43 @@
+package cachedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrLoadTimeout 表示从数据库加载超过了 WithTimeouts 设置的时间
+	ErrLoadTimeout = errors.New("load timed out")
+	// ErrFlushTimeout 表示写回数据库超过了 WithTimeouts 设置的时间
+	ErrFlushTimeout = errors.New("flush timed out")
+)
+
+// WithTimeouts 设置每次从数据库加载和每次写回的超时，0 表示不限制。超时通过 context 交给数据库驱动，
+// 驱动会中断执行中的语句，数据库无响应时 Get 和淘汰回调不会一直阻塞。超时的加载返回匹配 ErrLoadTimeout
+// 的错误，写回返回匹配 ErrFlushTimeout 的 WriteError，对象保持为脏。只对默认的 gorm 存储生效
+func WithTimeouts[T any](load, flush time.Duration) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.loadTimeout, c.flushTimeout = load, flush
+	}
+}
+
+// withTimeout 在 d 大于 0 时为 db 设置超时。返回的 done 释放 context，
+// 并在出错时把超时导致的错误包装为 sentinel
+func withTimeout(db *gorm.DB, d time.Duration, sentinel error) (*gorm.DB, func(error) error) {
+	if d <= 0 {
+		return db, func(err error) error { return err }
+	}
+	ctx, cancel := context.WithTimeout(db.Statement.Context, d)
+	return db.WithContext(ctx), func(err error) error {
+		defer cancel()
+		// 驱动中断语句后返回的错误不一定是 context.DeadlineExceeded，例如 MySQL 的 invalid connection
+		if err != nil && (errors.Is(err, context.DeadlineExceeded) || ctx.Err() == context.DeadlineExceeded) {
+			return fmt.Errorf("%w after %v: %w", sentinel, d, err)
+		}
+		return err
+	}
+}
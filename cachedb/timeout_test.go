@@ -0,0 +1,58 @@
+package cachedb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// hang 模拟无响应的数据库：语句一直阻塞到 context 结束
+func hang(tx *gorm.DB) {
+	<-tx.Statement.Context.Done()
+	tx.AddError(tx.Statement.Context.Err())
+}
+
+func TestTimeouts(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	sqlDB, _ := db.DB()
+	keep, _ := sqlDB.Conn(context.Background()) // 中断的连接会被关闭，保留一个连接以免内存库被释放
+	defer keep.Close()
+	db.Create(&Player{ID: 1})
+	db.Create(&Player{ID: 2})
+
+	c := newTestCache[Player](t, db, 10, WithTimeouts[Player](20*time.Millisecond, 20*time.Millisecond))
+	if _, err := c.Get(1); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	c.Update(1, func(p *Player) { p.Gold = 10 })
+
+	db.Callback().Query().Before("gorm:query").Register("hang", hang)
+	db.Callback().Update().Before("gorm:update").Register("hang", hang)
+	start := time.Now()
+	if _, err := c.Get(2); !errors.Is(err, ErrLoadTimeout) || errors.Is(err, ErrFlushTimeout) {
+		t.Errorf("expected ErrLoadTimeout, got %v", err)
+	}
+	err := c.Flush(1)
+	if !errors.Is(err, ErrFlushTimeout) || !errors.Is(err, ErrWriteFailed) {
+		t.Errorf("expected a WriteError matching ErrFlushTimeout, got %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("expected operations to give up quickly, took %v", d)
+	}
+	if keys := c.DirtyKeys(); len(keys) != 1 {
+		t.Errorf("expected the timed-out key to stay dirty, got %v", keys)
+	}
+
+	db.Callback().Query().Remove("hang")
+	db.Callback().Update().Remove("hang")
+	if err := c.Flush(1); err != nil {
+		t.Errorf("expected the flush to succeed once the database recovers, got %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package cachedb
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInsufficientFunds 表示转出方余额不足
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrConflict 表示乐观锁校验失败：数据库中的值已被其他进程修改，或者对象的代数已变化（见 UpdateIfGeneration）
+	ErrConflict = errors.New("concurrent modification conflict")
+)
+
+// LockMode 转账时的加锁方式
+type LockMode int
+
+const (
+	// LockOptimistic 以缓存中的余额作为期望值做条件更新，不匹配时返回 ErrConflict
+	LockOptimistic LockMode = iota
+	// LockPessimistic 在事务内 SELECT ... FOR UPDATE 锁定两行后再更新
+	LockPessimistic
+)
+
+// TransferAudit 是每次转账写入的审计记录，使用前需要 AutoMigrate
+type TransferAudit struct {
+	ID        uint `gorm:"primaryKey"`
+	Table     string
+	Column    string
+	From      string
+	To        string
+	Amount    int64
+	Reason    string
+	CreatedAt time.Time
+}
+
+// TransferSpec 描述要转移的余额字段
+type TransferSpec[T any] struct {
+	Column  string          // 余额列名，例如 "gold"
+	Balance func(*T) int64  // 读取对象中的余额
+	Set     func(*T, int64) // 写入对象中的余额
+	Mode    LockMode        // 加锁方式
+	Reason  string          // 写入审计记录的原因
+}
+
+// Transfer 在一个数据库事务内从 from 扣除 amount 并加到 to 上，同时写入审计记录。
+// 两个对象的其他修改会先落库，事务提交后缓存中的余额与数据库保持一致。
+// 事务期间不持有缓存的锁，两个对象被固定而不会被丢弃，期间对余额的内存修改在提交后仍是未落库的修改；
+// 两行按 key 的固定顺序加锁和更新，方向相反的并发转账不会互相死锁
+func Transfer[T any](c *CacheDB[T], from, to interface{}, amount int64, spec TransferSpec[T]) error {
+	if amount <= 0 {
+		return fmt.Errorf("invalid transfer amount %d", amount)
+	}
+	from, to = c.canonicalKey(from), c.canonicalKey(to)
+	if from == to {
+		return fmt.Errorf("cannot transfer to the same key %v", from)
+	}
+	db := c.shardFor(from).DB
+	if c.shardFor(to).DB != db {
+		return fmt.Errorf("transfer %v -> %v spans databases", from, to)
+	}
+	srcBalance, dstBalance, err := prepareTransfer(c, from, to, spec)
+	if err != nil {
+		return err
+	}
+	defer c.Unpin(from)
+	defer c.Unpin(to)
+
+	pk := c.keyField.DBName
+	type update struct {
+		key     interface{}
+		balance int64
+		delta   int64
+	}
+	updates := []update{{from, srcBalance, -amount}, {to, dstBalance, amount}}
+	if fmt.Sprint(to) < fmt.Sprint(from) {
+		updates[0], updates[1] = updates[1], updates[0]
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if spec.Mode == LockPessimistic {
+			for i := range updates {
+				balance, err := lockBalance[T](c.keyTable(tx, updates[i].key), pk, spec.Column, updates[i].key)
+				if err != nil {
+					return err
+				}
+				updates[i].balance = balance
+			}
+		}
+		for _, u := range updates {
+			if u.key == from && u.balance < amount {
+				return ErrInsufficientFunds
+			}
+		}
+
+		for _, u := range updates {
+			q := c.keyTable(tx, u.key).Model(new(T)).Where(pk+" = ?", u.key)
+			if spec.Mode == LockOptimistic {
+				q = q.Where(spec.Column+" = ?", u.balance)
+			}
+			res := q.Update(spec.Column, u.balance+u.delta)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return ErrConflict
+			}
+		}
+
+		return tx.Create(&TransferAudit{
+			Table:  c.schema.Table,
+			Column: spec.Column,
+			From:   fmt.Sprint(from),
+			To:     fmt.Sprint(to),
+			Amount: amount,
+			Reason: spec.Reason,
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("transfer %v -> %v failed: %w", from, to, err)
+	}
+
+	// 事务已提交，副本与数据库一致；缓存中的值按差额调整，保留事务期间的内存修改
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, u := range updates {
+		value := c.values[u.key]
+		if value == nil {
+			continue
+		}
+		spec.Set(value, spec.Balance(value)+u.delta)
+		copied := c.copies[u.key]
+		spec.Set(&copied, u.balance+u.delta)
+		c.copies[u.key] = copied
+	}
+	// 转账已提交到数据库，复制失败时无法撤销，只把错误返回给调用方
+	for _, key := range []interface{}{from, to} {
+		if value := c.values[key]; value != nil {
+			if err := c.replicateLocked(key, value, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// prepareTransfer 固定两个对象并把它们的修改落库，返回缓存中的余额。
+// 返回 nil 错误时调用方需对两个 key 各调用一次 Unpin
+func prepareTransfer[T any](c *CacheDB[T], from, to interface{}, spec TransferSpec[T]) (int64, int64, error) {
+	if _, err := c.Pin(from); err != nil {
+		return 0, 0, err
+	}
+	if _, err := c.Pin(to); err != nil {
+		c.Unpin(from)
+		return 0, 0, err
+	}
+	c.mu.Lock()
+	balances, err := func() ([]int64, error) {
+		var balances []int64
+		// 先让数据库与缓存一致，后续只需处理余额列
+		for _, key := range []interface{}{from, to} {
+			value := c.values[key]
+			if value == nil {
+				return nil, fmt.Errorf("transfer key %v was removed concurrently", key)
+			}
+			if err := c.saveIfModified(key, value, FlushManual); err != nil {
+				return nil, err
+			}
+			if err := c.rebase(key, value); err != nil {
+				return nil, err
+			}
+			balances = append(balances, spec.Balance(value))
+		}
+		return balances, nil
+	}()
+	c.mu.Unlock()
+	if err != nil {
+		c.Unpin(from)
+		c.Unpin(to)
+		return 0, 0, err
+	}
+	return balances[0], balances[1], nil
+}
+
+// lockBalance 在事务内锁定一行并读取余额
+func lockBalance[T any](tx *gorm.DB, pk, column string, key interface{}) (int64, error) {
+	var balance int64
+	err := lockRow(tx.Model(new(T))).
+		Where(pk+" = ?", key).
+		Select(column).
+		Row().Scan(&balance)
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock key %v: %w", key, err)
+	}
+	return balance, nil
+}
@@ -0,0 +1,116 @@
+package cachedb
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestTransfer(t *testing.T) {
+	type Wallet struct {
+		ID   uint
+		Name string
+		Gold int64
+	}
+	db := openTestDB(t, &Wallet{}, &TransferAudit{})
+	db.Create(&Wallet{ID: 1, Name: "a", Gold: 100})
+	db.Create(&Wallet{ID: 2, Name: "b", Gold: 10})
+
+	c := newTestCache[Wallet](t, db, 10)
+	spec := TransferSpec[Wallet]{
+		Column:  "gold",
+		Balance: func(w *Wallet) int64 { return w.Gold },
+		Set:     func(w *Wallet, v int64) { w.Gold = v },
+		Reason:  "trade",
+	}
+
+	a, _ := c.Get(uint(1))
+	a.Name = "renamed" // 其他未落库的修改应一起写入
+
+	for _, mode := range []LockMode{LockOptimistic, LockPessimistic} {
+		spec.Mode = mode
+		if err := Transfer(c, uint(1), uint(2), 30, spec); err != nil {
+			t.Fatalf("transfer failed (mode %d): %v", mode, err)
+		}
+	}
+
+	var rows []Wallet
+	db.Order("id").Find(&rows)
+	if rows[0].Gold != 40 || rows[1].Gold != 70 || rows[0].Name != "renamed" {
+		t.Fatalf("unexpected db rows %+v", rows)
+	}
+	if a.Gold != 40 {
+		t.Errorf("expected cached balance 40, got %d", a.Gold)
+	}
+	if keys := c.DirtyKeys(); len(keys) != 0 {
+		t.Errorf("expected no dirty keys after transfer, got %v", keys)
+	}
+
+	var audits int64
+	db.Model(&TransferAudit{}).Count(&audits)
+	if audits != 2 {
+		t.Errorf("expected 2 audit records, got %d", audits)
+	}
+
+	if err := Transfer(c, uint(1), uint(2), 1000, spec); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("expected insufficient funds, got %v", err)
+	}
+
+	// 绕过缓存修改数据库，乐观锁应检测到冲突
+	db.Model(&Wallet{}).Where("id = ?", 1).Update("gold", 5)
+	spec.Mode = LockOptimistic
+	if err := Transfer(c, uint(1), uint(2), 10, spec); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected conflict, got %v", err)
+	}
+}
+
+func TestTransferLockOrder(t *testing.T) {
+	type Wallet struct {
+		ID   uint
+		Gold int64
+	}
+	db := openTestDB(t, &Wallet{}, &TransferAudit{})
+	db.Create(&Wallet{ID: 1, Gold: 100})
+	db.Create(&Wallet{ID: 2, Gold: 100})
+
+	c := newTestCache[Wallet](t, db, 10)
+	var locked []interface{}
+	cacheLocked := false
+	db.Callback().Row().After("gorm:row").Register("record_lock", func(tx *gorm.DB) {
+		locked = append(locked, tx.Statement.Vars...)
+		if !c.mu.TryLock() {
+			cacheLocked = true
+			return
+		}
+		c.mu.Unlock()
+	})
+	spec := TransferSpec[Wallet]{
+		Column:  "gold",
+		Balance: func(w *Wallet) int64 { return w.Gold },
+		Set:     func(w *Wallet, v int64) { w.Gold = v },
+		Mode:    LockPessimistic,
+	}
+	if err := Transfer(c, uint(1), uint(2), 10, spec); err != nil {
+		t.Fatal(err)
+	}
+	if err := Transfer(c, uint(2), uint(1), 30, spec); err != nil {
+		t.Fatal(err)
+	}
+	// 方向相反的两次转账都先锁 1 再锁 2
+	if len(locked) != 4 || locked[0] != uint(1) || locked[1] != uint(2) || locked[2] != uint(1) || locked[3] != uint(2) {
+		t.Errorf("expected rows to be locked in key order, got %v", locked)
+	}
+	if cacheLocked {
+		t.Error("expected the cache lock to be released during the transaction")
+	}
+	if a, _ := c.Get(uint(1)); a.Gold != 120 {
+		t.Errorf("expected cached balance 120, got %d", a.Gold)
+	}
+	c.mu.Lock()
+	pins := len(c.pins)
+	c.mu.Unlock()
+	if pins != 0 {
+		t.Errorf("expected transfers to release their pins, got %d", pins)
+	}
+}
@@ -0,0 +1,51 @@
+package cachedb
+
+import (
+	"time"
+
+	"github.com/bluele/gcache"
+)
+
+// WithTTLFunc 按对象状态决定其在缓存中的有效期，例如 VIP 或在线玩家保留更久、离线玩家尽快淘汰。
+// fn 在对象加载或通过 Set 等放入缓存时调用一次，返回值不大于 0 时使用默认有效期；
+// fn 不能再调用该缓存的方法
+func WithTTLFunc[T any](fn func(key interface{}, value *T) time.Duration) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.ttl = fn
+	}
+}
+
+// ttlFunc 是 WithTTLFunc 设置的有效期函数
+type ttlFunc[T any] func(key interface{}, value *T) time.Duration
+
+// ttlOf 返回 value 的有效期，使用默认有效期时返回 nil
+func (c *CacheDB[T]) ttlOf(key interface{}, value *T) *time.Duration {
+	if c.ttl == nil || value == nil {
+		return nil
+	}
+	if ttl := c.ttl(key, value); ttl > 0 {
+		return &ttl
+	}
+	return nil
+}
+
+// loadWithTTL 包装 loadFromDB，为加载的对象设置 WithTTLFunc 计算的有效期
+func (c *CacheDB[T]) loadWithTTL() gcache.LoaderExpireFunc {
+	load := c.loadFromDB()
+	return func(key interface{}) (interface{}, *time.Duration, error) {
+		v, err := load(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		value, _ := v.(*T)
+		return v, c.ttlOf(key, value), nil
+	}
+}
+
+// cacheSet 把 value 放入 gcache，设置了 WithTTLFunc 时使用其计算的有效期
+func (c *CacheDB[T]) cacheSet(key interface{}, value *T) error {
+	if ttl := c.ttlOf(key, value); ttl != nil {
+		return c.Cache.SetWithExpire(key, value, *ttl)
+	}
+	return c.Cache.Set(key, value)
+}
@@ -0,0 +1,35 @@
+package cachedb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLFunc(t *testing.T) {
+	type Player struct {
+		ID  uint
+		VIP bool
+	}
+	db := openTestDB(t, &Player{})
+	db.Create(&Player{ID: 1, VIP: true})
+	db.Create(&Player{ID: 2})
+	// gcache 的 Has 使用系统时间，因此把缓存的时钟拨回一分钟来模拟时间流逝
+	clock := &manualClock{now: time.Now().Add(-time.Minute)}
+	c := newTestCache[Player](t, db, 10, WithClock[Player](clock), WithTTLFunc[Player](func(_ interface{}, p *Player) time.Duration {
+		if p.VIP {
+			return time.Hour
+		}
+		return 0
+	}))
+
+	c.Get(1)
+	c.Get(2)
+	if err := c.Set(3, Player{ID: 3, VIP: true}); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[uint]bool{1: true, 2: false, 3: true} {
+		if got := c.Cache.Has(key); got != want {
+			t.Errorf("key %d cached = %v after a minute, want %v", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package cachedb
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// WithCopier 使用自定义的拷贝函数生成副本，适用于包含 chan、func 等字段的模型。
+// 拷贝结果必须与原值互不共享可变状态
+func WithCopier[T any](copier func(T) (T, error)) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.copier = copier
+		c.customCopier = true
+	}
+}
+
+// validate 在构造时检查模型：必须能确定 key 字段，
+// 使用默认拷贝函数时不能包含无法深拷贝的字段
+func (c *CacheDB[T]) validate() error {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if !c.customCopier {
+		if path, bad := findUncopyable(typ, typ.Name(), map[reflect.Type]bool{}); bad {
+			return fmt.Errorf("model %s: field %s: %w; provide WithCopier", typ.Name(), path, ErrUncopyable)
+		}
+	}
+
+	if c.overflow == OverflowSpill && c.wal == nil {
+		return fmt.Errorf("OverflowSpill requires WithWAL")
+	}
+	if c.partitions != nil {
+		if err := c.partitions.check(); err != nil {
+			return err
+		}
+	}
+	if c.replicas != nil {
+		if err := c.replicas.check(c.safeReads); err != nil {
+			return err
+		}
+	}
+	if c.shed != nil {
+		if err := c.shed.check(c.size); err != nil {
+			return err
+		}
+	}
+	if c.coalesce != nil {
+		if err := c.coalesce.check(); err != nil {
+			return err
+		}
+	}
+	if c.flushTxOpts.StatementTimeout < 0 {
+		return fmt.Errorf("WithFlushTxOptions: negative statement timeout %v", c.flushTxOpts.StatementTimeout)
+	}
+	if err := c.checkGameLoop(); err != nil {
+		return err
+	}
+	if err := c.resolveKeyField(); err != nil {
+		return err
+	}
+	if err := c.resolveCollections(); err != nil {
+		return err
+	}
+	c.redacted = redactedFields(c.schema)
+	c.updatedAt = updatedAtField(c.schema)
+	for _, f := range c.schema.Fields {
+		c.hasJSON = c.hasJSON || (f.DBName != "" && isJSONField(f))
+	}
+	return nil
+}
+
+// findUncopyable 递归查找默认拷贝函数无法处理的字段，返回字段路径
+func findUncopyable(t reflect.Type, path string, seen map[reflect.Type]bool) (string, bool) {
+	if seen[t] {
+		return "", false // 递归类型只检查一次
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return path, true
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return findUncopyable(t.Elem(), path, seen)
+	case reflect.Map:
+		return findUncopyable(t.Elem(), path, seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue // 未导出字段不会被拷贝
+			}
+			if p, bad := findUncopyable(f.Type, path+"."+f.Name, seen); bad {
+				return p, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,38 @@
+package cachedb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateModel(t *testing.T) {
+	type NoKey struct {
+		Name string
+	}
+	type WithFunc struct {
+		ID      uint
+		Handler func() `gorm:"-"`
+	}
+	type Nested struct {
+		ID    uint
+		Inner struct {
+			Events []chan int
+		} `gorm:"-"`
+	}
+	db := openTestDB(t)
+
+	if _, err := NewWithCache[NoKey](db, 10); err == nil {
+		t.Errorf("expected error for model without primary key")
+	}
+	if _, err := NewWithCache[WithFunc](db, 10); !errors.Is(err, ErrUncopyable) {
+		t.Errorf("expected ErrUncopyable for func field, got %v", err)
+	}
+	if _, err := NewWithCache[Nested](db, 10); !errors.Is(err, ErrUncopyable) {
+		t.Errorf("expected ErrUncopyable for nested chan field, got %v", err)
+	}
+
+	copier := func(v WithFunc) (WithFunc, error) { return v, nil }
+	if _, err := NewWithCache[WithFunc](db, 10, WithCopier(copier)); err != nil {
+		t.Errorf("custom copier should allow func fields, got %v", err)
+	}
+}
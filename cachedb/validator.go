@@ -0,0 +1,56 @@
+package cachedb
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidEntity 表示对象未通过落库前的校验
+var ErrInvalidEntity = errors.New("entity failed validation")
+
+// Validator 由需要在落库前自检的模型实现，例如检查货币不为负数、名字不超长
+type Validator interface {
+	Validate() error
+}
+
+// WithValidator 添加落库前的校验函数。未通过校验（包括模型自身实现的 Validator）的对象
+// 不会写入数据库，保持为脏并返回 ErrInvalidEntity，修正后下次落库时再写入。Create 同样会校验
+func WithValidator[T any](fn func(key interface{}, value *T) error) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.validators = append(c.validators, fn)
+	}
+}
+
+// checkValid 依次运行模型的 Validate 和 WithValidator 设置的校验函数
+func (c *CacheDB[T]) checkValid(key interface{}, value *T) error {
+	var err error
+	if v, ok := any(value).(Validator); ok {
+		err = v.Validate()
+	}
+	for _, fn := range c.validators {
+		if err != nil {
+			break
+		}
+		err = fn(key, value)
+	}
+	if err != nil {
+		c.log(LogError, "Rejected invalid entity", "key", key, "err", err)
+		return fmt.Errorf("%w: key %v: %v", ErrInvalidEntity, key, err)
+	}
+	return nil
+}
+
+// checkPersist 在写入追踪的对象前检查大小与合法性，未通过校验的对象在开启隔离时移入隔离区，调用方需持有 c.mu
+func (c *CacheDB[T]) checkPersist(key interface{}, value *T) error {
+	if err := c.checkSize(key, value); err != nil {
+		return err
+	}
+	if m := c.meta[key]; m != nil && m.forced {
+		return nil
+	}
+	err := c.checkValid(key, value)
+	if err != nil {
+		c.quarantineInvalid(key, value, err)
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+package cachedb
+
+import (
+	"errors"
+	"testing"
+)
+
+type validatedPlayer struct {
+	ID   uint
+	Name string
+	Gold int
+}
+
+func (p *validatedPlayer) Validate() error {
+	if p.Gold < 0 {
+		return errors.New("negative gold")
+	}
+	return nil
+}
+
+func TestValidator(t *testing.T) {
+	db := openTestDB(t, &validatedPlayer{})
+	db.Create(&validatedPlayer{ID: 1, Name: "a", Gold: 10})
+	c := newTestCache[validatedPlayer](t, db, 10, WithValidator(func(_ interface{}, p *validatedPlayer) error {
+		if len(p.Name) > 8 {
+			return errors.New("name too long")
+		}
+		return nil
+	}))
+
+	p, _ := c.Get(1)
+	p.Gold = -5
+	if err := c.Flush(1); !errors.Is(err, ErrInvalidEntity) {
+		t.Fatalf("expected ErrInvalidEntity for negative gold, got %v", err)
+	}
+	p.Gold = 5
+	p.Name = "a very long name"
+	if err := c.FlushAll(); !errors.Is(err, ErrInvalidEntity) {
+		t.Fatalf("expected ErrInvalidEntity for long name, got %v", err)
+	}
+	var row validatedPlayer
+	db.First(&row, 1)
+	if row.Gold != 10 || row.Name != "a" {
+		t.Errorf("expected invalid state not to be persisted, got %+v", row)
+	}
+	if len(c.DirtyKeys()) != 1 {
+		t.Errorf("expected rejected entity to stay dirty")
+	}
+
+	p.Name = "b"
+	if err := c.Flush(1); err != nil {
+		t.Fatalf("Flush after fix failed: %v", err)
+	}
+	db.First(&row, 1)
+	if row.Gold != 5 || row.Name != "b" {
+		t.Errorf("expected fixed entity to be persisted, got %+v", row)
+	}
+
+	if _, err := c.Create(validatedPlayer{ID: 2, Gold: -1}); !errors.Is(err, ErrInvalidEntity) {
+		t.Errorf("expected Create to validate, got %v", err)
+	}
+}
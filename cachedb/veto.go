@@ -0,0 +1,52 @@
+package cachedb
+
+// OnBeforeEvict 注册淘汰前的检查，fn 返回 false 时否决淘汰（例如对象正处于战斗中）。
+// 被否决的对象不会回写或丢弃，随后在后台重新放回缓存，腾出容量的代价由下一个最久未访问的对象承担。
+// 容量淘汰、过期和 EvictNow 都会经过检查，Invalidate、Delete 等主动移除不受影响。
+// fn 在持有内部锁时调用，不能再调用该缓存的方法；只应否决少量对象，否则淘汰会在它们之间反复进行
+func (c *CacheDB[T]) OnBeforeEvict(fn func(key interface{}, value *T) bool) {
+	c.beforeEvict = append(c.beforeEvict, fn)
+}
+
+// vetoEvict 在淘汰回调开始时调用，淘汰被否决（包括处于保存点中的对象，见 Begin）
+// 或脏对象获得第二次机会（见 WithDirtySecondChance）时返回 true
+func (c *CacheDB[T]) vetoEvict(key, evicted interface{}) bool {
+	if len(c.beforeEvict) == 0 && !c.secondChance && c.openSavepoints.Load() == 0 {
+		return false
+	}
+	value, ok := evicted.(*T)
+	if !ok {
+		return false
+	}
+	c.mu.Lock()
+	vetoed, spared := false, false
+	if c.current(key, value) {
+		_, vetoed = c.savepoints[key] // 保存点中的投机性修改不能写回
+		for i := 0; !vetoed && i < len(c.beforeEvict); i++ {
+			vetoed = !c.beforeEvict[i](key, value)
+		}
+		if !vetoed {
+			spared = c.spare(key, value)
+		}
+	}
+	c.mu.Unlock()
+	if !vetoed && !spared {
+		return false
+	}
+	if vetoed {
+		c.counters.evictVetoes.Add(1)
+	}
+	// 淘汰回调持有 gcache 的锁，只能在之后重新放回。通过 Get 放回：
+	// 期间对象若已被删除或替换，加载路径会以追踪的版本或数据库为准。单线程模式下交给主循环执行
+	restore := func() {
+		if _, err := c.Cache.Get(key); err != nil {
+			c.log(LogWarn, "Vetoed entry could not be restored", "key", key, "err", err)
+		}
+	}
+	if c.loop != nil {
+		c.loop.post(restore)
+	} else {
+		go restore()
+	}
+	return true
+}
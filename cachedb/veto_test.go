@@ -0,0 +1,58 @@
+package cachedb
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOnBeforeEvict(t *testing.T) {
+	type Player struct {
+		ID   uint
+		Gold int
+	}
+	db := openTestDB(t, &Player{})
+	for id := uint(1); id <= 3; id++ {
+		db.Create(&Player{ID: id})
+	}
+	c := newTestCache[Player](t, db, 2)
+	var inCombat atomic.Bool
+	inCombat.Store(true)
+	c.OnBeforeEvict(func(key interface{}, p *Player) bool {
+		return p.ID != 1 || !inCombat.Load()
+	})
+
+	c.Update(1, func(p *Player) { p.Gold = 100 })
+	c.Get(2)
+	c.Get(3) // 容量已满，最久未访问的 1 被否决
+
+	deadline := time.Now().Add(time.Second)
+	for !c.Cache.Has(uint(1)) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if !c.Cache.Has(uint(1)) {
+		t.Fatal("expected the vetoed entry to be restored")
+	}
+	if c.Cache.Has(uint(2)) {
+		t.Error("expected the next LRU candidate to be evicted instead")
+	}
+	var stored Player
+	db.First(&stored, 1)
+	if stored.Gold != 0 {
+		t.Errorf("expected the vetoed entry not to be written back, got gold %d", stored.Gold)
+	}
+	if p, _ := c.Get(1); p.Gold != 100 {
+		t.Errorf("expected the vetoed entry to keep its changes, got gold %d", p.Gold)
+	}
+	if n := c.Stats().EvictVetoes; n != 1 {
+		t.Errorf("expected 1 veto, got %d", n)
+	}
+
+	// 战斗结束后正常淘汰并回写
+	inCombat.Store(false)
+	c.EvictNow(1)
+	db.First(&stored, 1)
+	if stored.Gold != 100 {
+		t.Errorf("expected the entry to be written back once allowed, got gold %d", stored.Gold)
+	}
+}
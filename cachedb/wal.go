@@ -0,0 +1,153 @@
+package cachedb
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// WriteAheadLog 保存回写队列溢出时尚未落库的修改，进程崩溃后可通过重放恢复。
+// Append 返回时记录必须已持久化；Commit 在 key 成功落库后调用，此后该 key 的旧记录不再需要重放
+type WriteAheadLog[T any] interface {
+	Append(key interface{}, value T) error
+	Commit(key interface{}) error
+}
+
+// WithWAL 设置预写日志，配合 OverflowSpill 使用
+func WithWAL[T any](wal WriteAheadLog[T]) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.wal = wal
+	}
+}
+
+// walRecord 是日志中的一行，Done 表示该 key 已落库
+type walRecord[T any] struct {
+	Key   string `json:"key"`
+	Value T      `json:"value,omitempty"`
+	Done  bool   `json:"done,omitempty"`
+}
+
+// FileWAL 是基于本地文件的预写日志，每条记录一行 JSON，写入后立即 fsync
+type FileWAL[T any] struct {
+	mu      sync.Mutex
+	f       *os.File
+	pending map[string]bool // 已追加但尚未落库的 key
+}
+
+// OpenFileWAL 以追加方式打开日志文件，不存在时创建
+func OpenFileWAL[T any](path string) (*FileWAL[T], error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open WAL %s: %w", path, err)
+	}
+	return &FileWAL[T]{f: f, pending: make(map[string]bool)}, nil
+}
+
+// Append 追加一条修改记录
+func (w *FileWAL[T]) Append(key interface{}, value T) error {
+	k := fmt.Sprint(key)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.write(walRecord[T]{Key: k, Value: value}); err != nil {
+		return err
+	}
+	w.pending[k] = true
+	return nil
+}
+
+// Commit 记录 key 已落库，未追加过的 key 直接忽略
+func (w *FileWAL[T]) Commit(key interface{}) error {
+	k := fmt.Sprint(key)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.pending[k] {
+		return nil
+	}
+	if err := w.write(walRecord[T]{Key: k, Done: true}); err != nil {
+		return err
+	}
+	delete(w.pending, k)
+	return nil
+}
+
+// write 写入一行并 fsync，调用方需持有 w.mu
+func (w *FileWAL[T]) write(rec walRecord[T]) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode WAL record: %w", err)
+	}
+	if _, err := w.f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write WAL: %w", err)
+	}
+	return w.f.Sync()
+}
+
+// Close 关闭日志文件
+func (w *FileWAL[T]) Close() error {
+	return w.f.Close()
+}
+
+// ReplayWAL 在启动时把日志中未落库的修改写回数据库，然后清空日志。
+// 同一个 key 只重放最后一条记录，已标记落库的 key 会被跳过。应在缓存开始服务前调用
+func ReplayWAL[T any](c *CacheDB[T], path string) (int, error) {
+	return replayLog(c, path, nil)
+}
+
+// replayLog 重放日志，跳过 omit 中的列（死信日志中被遮蔽的字段）
+func replayLog[T any](c *CacheDB[T], path string, omit []string) (int, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open WAL %s: %w", path, err)
+	}
+	defer f.Close()
+
+	latest := make(map[string]*walRecord[T])
+	var order []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var rec walRecord[T]
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// 崩溃时最后一行可能不完整，之前的记录仍然有效
+			c.log(LogWarn, "WAL skipped corrupt record", "path", path, "err", err)
+			continue
+		}
+		if _, seen := latest[rec.Key]; !seen {
+			order = append(order, rec.Key)
+		}
+		latest[rec.Key] = &rec
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read WAL %s: %w", path, err)
+	}
+
+	replayed := 0
+	for _, k := range order {
+		rec := latest[k]
+		if rec.Done {
+			continue
+		}
+		key, err := c.ParseKey(rec.Key)
+		if err != nil {
+			return replayed, err
+		}
+		db := c.keyDB(key).Model(new(T)).Where(c.keyCondition(key)).Select("*")
+		if len(omit) > 0 {
+			db = db.Omit(omit...)
+		}
+		if err := db.Updates(&rec.Value).Error; err != nil {
+			return replayed, fmt.Errorf("failed to replay key %v: %w", key, err)
+		}
+		c.Invalidate(key)
+		replayed++
+	}
+	if err := os.Truncate(path, 0); err != nil {
+		return replayed, fmt.Errorf("failed to truncate WAL %s: %w", path, err)
+	}
+	return replayed, nil
+}
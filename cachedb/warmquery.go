@@ -0,0 +1,88 @@
+package cachedb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// WarmConfig 配置 WarmFromQuery
+type WarmConfig struct {
+	BatchSize   int                     // 每条 IN 查询加载的行数，默认使用 WithBatchSize 的值
+	Concurrency int                     // 同时执行的加载查询数，默认 1
+	Limit       int                     // 最多预热的行数，默认为缓存容量，超过容量的预热只会互相淘汰
+	Progress    func(loaded, total int) // 每完成一批后调用，可用于启动日志或进度条
+}
+
+// WarmFromQuery 把满足 scope 的行预先加载进缓存，例如最近 24 小时活跃的玩家或已加载区服的成员。
+// 先只查询满足条件的 key，再按批加载整行；ctx 取消后不再开始新的批次。
+// 返回加载的行数，失败的 key 通过 *BatchError 返回。不支持分表（返回 ErrTableRouted）
+func (c *CacheDB[T]) WarmFromQuery(ctx context.Context, scope func(*gorm.DB) *gorm.DB, cfg WarmConfig) (int, error) {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = c.batchSize
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	if cfg.Limit <= 0 {
+		cfg.Limit = c.size
+	}
+	db, err := c.wholeTable()
+	if err != nil {
+		return 0, err
+	}
+
+	var keys []interface{}
+	release := c.acquireDB()
+	err = db.WithContext(ctx).Scopes(scope).Limit(cfg.Limit).Pluck(c.keyField.DBName, &keys).Error
+	release()
+	if err != nil {
+		return 0, fmt.Errorf("failed to query warm-up keys: %w", err)
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		loaded  int
+		failed  = make(map[interface{}]error)
+		sem     = make(chan struct{}, cfg.Concurrency)
+		stopped error
+	)
+	for start := 0; start < len(keys); start += cfg.BatchSize {
+		if err := ctx.Err(); err != nil {
+			stopped = err
+			break
+		}
+		chunk := keys[start:min(start+cfg.BatchSize, len(keys))]
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			values, err := c.loadMany(chunk)
+			mu.Lock()
+			defer mu.Unlock()
+			loaded += len(values)
+			if batch, ok := err.(*BatchError); ok {
+				for k, e := range batch.Errors {
+					failed[k] = e
+				}
+			} else if err != nil {
+				for _, k := range chunk {
+					failed[k] = err
+				}
+			}
+			if cfg.Progress != nil {
+				cfg.Progress(loaded, len(keys))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return loaded, &BatchError{Errors: failed}
+	}
+	return loaded, stopped
+}
@@ -0,0 +1,49 @@
+package cachedb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestWarmFromQuery(t *testing.T) {
+	type Player struct {
+		ID       uint
+		LastSeen time.Time
+	}
+	db := openTestDB(t, &Player{})
+	now := time.Now()
+	for i := 1; i <= 20; i++ {
+		seen := now.Add(-time.Hour)
+		if i%4 == 0 {
+			seen = now.Add(-48 * time.Hour)
+		}
+		db.Create(&Player{ID: uint(i), LastSeen: seen})
+	}
+	c := newTestCache[Player](t, db, 100)
+
+	var reports [][2]int
+	active := func(tx *gorm.DB) *gorm.DB { return tx.Where("last_seen > ?", now.Add(-24*time.Hour)) }
+	n, err := c.WarmFromQuery(context.Background(), active, WarmConfig{
+		BatchSize:   4,
+		Concurrency: 2,
+		Progress:    func(loaded, total int) { reports = append(reports, [2]int{loaded, total}) },
+	})
+	if err != nil || n != 15 {
+		t.Fatalf("WarmFromQuery = %d %v, want 15", n, err)
+	}
+	if tracked := c.Stats().Tracked; tracked != 15 {
+		t.Errorf("expected 15 warmed players, got %d", tracked)
+	}
+	if len(reports) != 4 || reports[3] != [2]int{15, 15} {
+		t.Errorf("unexpected progress reports %v", reports)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.WarmFromQuery(ctx, active, WarmConfig{}); err == nil {
+		t.Error("expected a cancelled warm-up to fail")
+	}
+}
@@ -0,0 +1,190 @@
+package cachedb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WebhookConfig 描述 Webhook 的发送方式
+type WebhookConfig struct {
+	URL        string
+	Headers    map[string]string // 附加的请求头，例如鉴权
+	BatchSize  int               // 每个请求最多携带的变更数，默认 100
+	Interval   time.Duration     // 未攒满一批时的最长等待，默认 1 秒
+	MaxRetries int               // 失败后的重试次数，默认 3
+	Backoff    time.Duration     // 首次重试前的等待，之后每次翻倍，默认 500ms
+	QueueSize  int               // 等待发送的变更数上限，超出时丢弃并记录，默认 10000
+	Client     *http.Client      // 默认使用超时 10 秒的客户端
+	Logger     Logger            // 发送失败的日志输出，默认打印到标准输出；队列满时由提交变更的缓存记录
+}
+
+// WebhookChange 是 Webhook 请求体（JSON 数组）中的一项
+type WebhookChange struct {
+	Entity  string        `json:"entity"`
+	Key     string        `json:"key"`
+	Time    time.Time     `json:"ts"`
+	Reason  FlushReason   `json:"reason"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// WebhookSink 把落库的变更攒批后以 HTTP POST 发送到配置的地址，非 2xx 响应按指数退避重试，
+// 重试耗尽后丢弃该批并记录日志。可以由多个缓存共享，停服时调用 Close 发送剩余的变更
+type WebhookSink struct {
+	cfg   WebhookConfig
+	queue chan WebhookChange
+
+	stopOnce sync.Once
+	stop     chan struct{} // Close 时关闭
+	done     chan struct{} // 发送协程退出时关闭
+}
+
+// NewWebhookSink 创建 Webhook 发送器并启动后台发送协程
+func NewWebhookSink(cfg WebhookConfig) *WebhookSink {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = 500 * time.Millisecond
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 10000
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = stdLogger{}
+	}
+	s := &WebhookSink{
+		cfg:   cfg,
+		queue: make(chan WebhookChange, cfg.QueueSize),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go s.loop()
+	return s
+}
+
+// WithWebhook 在每次落库成功后把字段修改交给 sink 发送，没有字段变化时不发送。
+// 被 `cache:"redact"` 标记的字段值会被遮蔽
+func WithWebhook[T any](sink *WebhookSink) Option[T] {
+	return func(c *CacheDB[T]) {
+		c.OnFlush(func(key interface{}, old, new T) {
+			changes := c.diff(&old, &new)
+			if len(changes) == 0 {
+				return
+			}
+			c.redactChanges(changes)
+			if !sink.enqueue(WebhookChange{Entity: c.qualifiedEntity(), Key: fmt.Sprint(key), Time: c.clock.Now(), Reason: c.flushReason, Changes: changes}) {
+				c.log(LogWarn, "Webhook queue full, dropped change", "key", key)
+			}
+		})
+	}
+}
+
+// enqueue 把变更放入发送队列，队列已满或已关闭时丢弃。只有队列已满时返回 false
+func (s *WebhookSink) enqueue(ch WebhookChange) bool {
+	select {
+	case <-s.stop:
+		return true
+	default:
+	}
+	select {
+	case s.queue <- ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close 停止接收新的变更，发送队列中剩余的变更后返回
+func (s *WebhookSink) Close() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.done
+}
+
+func (s *WebhookSink) loop() {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	var batch []WebhookChange
+	send := func() {
+		if len(batch) > 0 {
+			s.post(batch)
+			batch = nil
+		}
+	}
+	for {
+		select {
+		case ch := <-s.queue:
+			batch = append(batch, ch)
+			if len(batch) >= s.cfg.BatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case <-s.stop:
+			for len(s.queue) > 0 {
+				batch = append(batch, <-s.queue)
+				if len(batch) >= s.cfg.BatchSize {
+					send()
+				}
+			}
+			send()
+			close(s.done)
+			return
+		}
+	}
+}
+
+// post 发送一批变更，失败时按指数退避重试
+func (s *WebhookSink) post(batch []WebhookChange) {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		s.cfg.Logger.Log(LogError, "Webhook encode failed", "err", err)
+		return
+	}
+	backoff := s.cfg.Backoff
+	for attempt := 0; ; attempt++ {
+		err = s.send(body)
+		if err == nil {
+			return
+		}
+		if attempt >= s.cfg.MaxRetries {
+			s.cfg.Logger.Log(LogError, "Webhook dropped changes", "changes", len(batch), "attempts", attempt+1, "err", err)
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// send 发送一次请求，非 2xx 响应视为失败
+func (s *WebhookSink) send(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package cachedb
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhookSink(t *testing.T) {
+	type Hero struct {
+		ID    uint
+		Level int
+	}
+	db := openTestDB(t, &Hero{})
+	for i := 1; i <= 3; i++ {
+		db.Create(&Hero{ID: uint(i), Level: 1})
+	}
+
+	var mu sync.Mutex
+	var batches [][]WebhookChange
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts == 1 || r.Header.Get("X-Token") != "secret" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var batch []WebhookChange
+		json.NewDecoder(r.Body).Decode(&batch)
+		batches = append(batches, batch)
+	}))
+	defer srv.Close()
+
+	sink := NewWebhookSink(WebhookConfig{
+		URL:       srv.URL,
+		Headers:   map[string]string{"X-Token": "secret"},
+		BatchSize: 2,
+		Interval:  time.Hour,
+		Backoff:   time.Millisecond,
+	})
+	c := newTestCache[Hero](t, db, 10, WithWebhook[Hero](sink))
+	for i := 1; i <= 3; i++ {
+		c.Update(uint(i), func(h *Hero) { h.Level = 2 })
+	}
+	if err := c.FlushAll(); err != nil {
+		t.Fatalf("FlushAll failed: %v", err)
+	}
+	sink.Close() // 发送未攒满的最后一批
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 3 || len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Fatalf("expected a retried batch of 2 and a final batch of 1, got %d attempts %+v", attempts, batches)
+	}
+	if ch := batches[0][0]; ch.Entity != "heros" || ch.Changes[0].Column != "level" {
+		t.Errorf("unexpected change %+v", ch)
+	}
+}
+
+func TestWebhookSinkLogsDroppedBatches(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	logger := &recordLogger{}
+	sink := NewWebhookSink(WebhookConfig{
+		URL:        srv.URL,
+		Interval:   time.Hour,
+		MaxRetries: 1,
+		Backoff:    time.Millisecond,
+		Logger:     logger,
+	})
+	sink.enqueue(WebhookChange{Entity: "heros", Key: "1"})
+	sink.Close()
+	if entries := logger.find("Webhook dropped changes"); len(entries) != 1 || entries[0].level != LogError {
+		t.Errorf("expected the dropped batch to reach the configured logger, got %+v", logger.entries)
+	}
+}